@@ -4,6 +4,8 @@ import (
 	"embed"
 	"fmt"
 	"feedora/models"
+	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,9 +16,14 @@ import (
 	"net/http"
 )
 
+// Version 当前版本号，构建时通过 -ldflags "-X feedora/globals.Version=x.y.z" 注入
+var Version = "dev"
+
 var (
 	DbMap    map[string]models.Feed
 	RssUrls  models.Config
+	// RawConfig 未展开环境变量占位符的原始配置，用于返回给设置界面及写回 config.json
+	RawConfig models.Config
 	Upgrader = websocket.Upgrader{}
 	Lock     sync.RWMutex
 
@@ -48,6 +55,10 @@ var (
 	ItemsCache     map[string][]models.Item
 	ItemsCacheLock sync.RWMutex
 
+	// 卡片展示选项覆盖: map[卡片Link] -> 用户在卡片上切换的展示选项
+	DisplayOverrides     map[string]models.DisplayFlags
+	DisplayOverridesLock sync.RWMutex
+
 	// 下次更新时间
 	NextUpdateTime time.Time
 
@@ -63,6 +74,11 @@ type userAgentTransport struct {
 	base http.RoundTripper
 }
 
+// NewUserAgentTransport 包装 Transport，为未设置 User-Agent 的请求使用浏览器的 User-Agent
+func NewUserAgentTransport(base http.RoundTripper) http.RoundTripper {
+	return &userAgentTransport{base: base}
+}
+
 func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if req.Header.Get("User-Agent") == "" {
 		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
@@ -76,11 +92,13 @@ func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error
 
 // Init 首次初始化，创建所有缓存
 func Init() {
-	conf, err := models.ParseConf()
+	raw, err := models.ParseConf()
 	if err != nil {
 		panic(err)
 	}
-	RssUrls = conf
+	RawConfig = raw
+	RssUrls = expandConfigEnv(raw)
+	logConfigIssues(RssUrls)
 	// 读取 index.html 内容
 	HtmlContent, err = DirStatic.ReadFile("static/index.html")
 	if err != nil {
@@ -91,12 +109,34 @@ func Init() {
 	ClassifyCache = make(map[string]models.ClassifyCacheEntry)
 	ReadState = make(map[string]int64)
 	ItemsCache = make(map[string][]models.Item)
+	DisplayOverrides = make(map[string]models.DisplayFlags)
 	AuthTokens = make(map[string]time.Time)
 
 	// 初始化模板
 	InitTemplate()
 }
 
+// expandConfigEnv 展开配置中的 ${VAR} 环境变量占位符，未定义的变量保持原样并输出警告
+func expandConfigEnv(raw models.Config) models.Config {
+	conf, missing := raw.WithEnvExpanded()
+	if len(missing) > 0 {
+		log.Printf("[配置] 以下环境变量未定义，占位符保持原样: %s", strings.Join(missing, ", "))
+	}
+	return conf
+}
+
+// logConfigIssues 校验配置并输出问题报告（仅警告，不阻止启动）
+func logConfigIssues(conf models.Config) {
+	issues := conf.Validate()
+	if len(issues) == 0 {
+		return
+	}
+	log.Printf("[配置] 校验发现 %d 个问题:", len(issues))
+	for _, issue := range issues {
+		log.Printf("[配置]   %s", issue)
+	}
+}
+
 // InitTemplate 初始化模板缓存
 func InitTemplate() {
 	funcMap := template.FuncMap{
@@ -120,11 +160,14 @@ func InitTemplate() {
 func ReloadConfig() (models.Config, error) {
 	oldConfig := RssUrls
 	
-	conf, err := models.ParseConf()
+	raw, err := models.ParseConf()
 	if err != nil {
 		return oldConfig, fmt.Errorf("解析配置文件失败: %w", err)
 	}
+	conf := expandConfigEnv(raw)
+	RawConfig = raw
 	RssUrls = conf
+	logConfigIssues(conf)
 	// 读取 index.html 内容
 	HtmlContent, err = DirStatic.ReadFile("static/index.html")
 	if err != nil {
@@ -2,7 +2,11 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 	"os"
+	"strings"
+	"time"
 )
 
 func ParseConf() (Config, error) {
@@ -27,6 +31,62 @@ type Category struct {
 	Description string `json:"description,omitempty"`
 	// 类别颜色（用于前端显示）
 	Color string `json:"color,omitempty"`
+	// 父类别ID，为空表示顶级类别；有子类别的类别不会被 AI 直接选择
+	ParentID string `json:"parentId,omitempty"`
+}
+
+// ExpandCategoryIDs 将类别ID列表展开为包含所有子孙类别的列表（选择父类别时自动包含子类别），保持原有顺序
+func ExpandCategoryIDs(categories []Category, ids []string) []string {
+	if len(ids) == 0 {
+		return ids
+	}
+	children := make(map[string][]string)
+	for _, cat := range categories {
+		if cat.ParentID != "" {
+			children[cat.ParentID] = append(children[cat.ParentID], cat.ID)
+		}
+	}
+	if len(children) == 0 {
+		return ids
+	}
+
+	result := make([]string, 0, len(ids))
+	seen := make(map[string]bool)
+	var expand func(id string)
+	expand = func(id string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		result = append(result, id)
+		for _, child := range children[id] {
+			expand(child)
+		}
+	}
+	for _, id := range ids {
+		expand(id)
+	}
+	return result
+}
+
+// LeafCategories 返回没有子类别的类别（AI 分类时只能选择这些类别）
+func LeafCategories(categories []Category) []Category {
+	parents := make(map[string]bool)
+	for _, cat := range categories {
+		if cat.ParentID != "" {
+			parents[cat.ParentID] = true
+		}
+	}
+	if len(parents) == 0 {
+		return categories
+	}
+	leaves := make([]Category, 0, len(categories))
+	for _, cat := range categories {
+		if !parents[cat.ID] {
+			leaves = append(leaves, cat)
+		}
+	}
+	return leaves
 }
 
 // CategoryPackage 分类类别包
@@ -43,13 +103,15 @@ type CategoryPackage struct {
 type AIClassifyConfig struct {
 	// 是否全局启用AI分类
 	Enabled bool `json:"enabled"`
+	// 接口类型: openai（兼容 OpenAI 格式，默认）/ anthropic / gemini / ollama
+	Provider string `json:"provider,omitempty"`
 	// API Key
-	APIKey string `json:"apiKey"`
-	// API Base URL (兼容 OpenAI 格式的 API)
-	APIBase string `json:"apiBase,omitempty"`
+	APIKey string `json:"apiKey" env:"expand"`
+	// API Base URL，未设置时使用所选接口类型的官方地址
+	APIBase string `json:"apiBase,omitempty" env:"expand"`
 	// 模型名称
 	Model string `json:"model,omitempty"`
-	// JSON 输出模式: auto / json_object / prompt_only
+	// JSON 输出模式: auto / json_schema / json_object / prompt_only
 	JSONMode string `json:"jsonMode,omitempty"`
 	// 系统提示词
 	SystemPrompt string `json:"systemPrompt,omitempty"`
@@ -71,28 +133,151 @@ type AIClassifyConfig struct {
 	RetryWait int `json:"retryWait,omitempty"`
 	// 分类类别包列表 (新版)
 	CategoryPackages []CategoryPackage `json:"categoryPackages,omitempty"`
+	// 兴趣描述：设置后批量分类时同时按该描述为每篇文章打 0-100 的相关度分
+	InterestProfile string `json:"interestProfile,omitempty"`
+	// 每篇文章最多的类别数，大于 1 时启用多标签分类，默认 1
+	MaxLabels int `json:"maxLabels,omitempty"`
+	// 最低置信度（0-100）：设置后批量分类时同时返回置信度，低于该值的分类结果按 LowConfidenceAction 处理，0 表示不启用
+	MinConfidence int `json:"minConfidence,omitempty"`
+	// 低置信度处理方式: review（归入 _review 待复核）/ uncategorized（不设类别）/ keyword（仅按关键词规则处理），默认 review
+	LowConfidenceAction string `json:"lowConfidenceAction,omitempty"`
+	// 每日调用预算，超出后当天不再调用 AI
+	Budget LLMBudgetConfig `json:"budget,omitempty"`
+	// 备用接口列表：批量分类在主接口重试后仍失败时按顺序切换
+	Fallbacks []LLMFallbackConfig `json:"fallbacks,omitempty"`
+	// 全局速率限制，所有 AI 调用共享
+	RateLimit LLMRateLimitConfig `json:"rateLimit,omitempty"`
+	// 调试日志：保留最近 N 次 AI 调用的完整提示词与原始响应（仅在内存中，API 密钥脱敏），0 表示不记录，最多 200
+	DebugLog int `json:"debugLog,omitempty"`
+}
+
+// LLMRateLimitConfig AI 调用速率限制（令牌桶），0 表示不限制
+type LLMRateLimitConfig struct {
+	// 每分钟请求数上限
+	RPM int `json:"rpm,omitempty"`
+	// 每分钟 token 数上限（输入 + 输出）
+	TPM int `json:"tpm,omitempty"`
+}
+
+// LLMFallbackConfig 备用接口配置（如云端接口不可用时切换到本地 Ollama）
+type LLMFallbackConfig struct {
+	// 接口类型: openai / anthropic / gemini / ollama
+	Provider string `json:"provider,omitempty"`
+	// API Key（ollama 不需要）
+	APIKey string `json:"apiKey,omitempty" env:"expand"`
+	// API Base URL，未设置时使用所选接口类型的官方地址
+	APIBase string `json:"apiBase,omitempty" env:"expand"`
+	// 模型名称，未设置时使用所选接口类型的默认模型
+	Model string `json:"model,omitempty"`
+	// 请求超时时间（秒），未设置时与主接口相同
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// WithFallback 返回使用备用接口的配置，提示词、类别等其他设置与主配置相同
+func (c AIClassifyConfig) WithFallback(f LLMFallbackConfig) AIClassifyConfig {
+	fallback := c
+	fallback.Provider = f.Provider
+	fallback.APIKey = f.APIKey
+	fallback.APIBase = f.APIBase
+	fallback.Model = f.Model
+	if f.Timeout > 0 {
+		fallback.Timeout = f.Timeout
+	}
+	fallback.Fallbacks = nil
+	return fallback
+}
+
+// GetProvider 获取接口类型，默认为 openai
+func (c AIClassifyConfig) GetProvider() string {
+	switch strings.ToLower(c.Provider) {
+	case "anthropic", "claude":
+		return "anthropic"
+	case "gemini", "google":
+		return "gemini"
+	case "ollama":
+		return "ollama"
+	default:
+		return "openai"
+	}
+}
+
+// HasAPIAccess 是否具备调用接口的条件：本地部署的 Ollama 无需 API Key，其他接口需要配置 API Key
+func (c AIClassifyConfig) HasAPIAccess() bool {
+	return c.APIKey != "" || c.GetProvider() == "ollama"
+}
+
+// GetInterestProfile 获取兴趣描述，为空表示不进行相关度打分
+func (c AIClassifyConfig) GetInterestProfile() string {
+	return strings.TrimSpace(c.InterestProfile)
+}
+
+// GetMaxLabels 获取每篇文章最多的类别数，默认 1（单标签）
+func (c AIClassifyConfig) GetMaxLabels() int {
+	if c.MaxLabels <= 1 {
+		return 1
+	}
+	return c.MaxLabels
+}
+
+// GetLowConfidenceAction 获取低置信度分类结果的处理方式，默认 review
+func (c AIClassifyConfig) GetLowConfidenceAction() string {
+	switch c.LowConfidenceAction {
+	case "uncategorized", "keyword":
+		return c.LowConfidenceAction
+	default:
+		return "review"
+	}
 }
 
-// GetAPIBase 获取 API Base URL，默认为火山引擎
+// GetDebugLog 获取调试日志保留的调用数，默认 0（不记录），最多 200
+func (c AIClassifyConfig) GetDebugLog() int {
+	if c.DebugLog <= 0 {
+		return 0
+	}
+	if c.DebugLog > 200 {
+		return 200
+	}
+	return c.DebugLog
+}
+
+// GetAPIBase 获取 API Base URL，OpenAI 兼容接口默认为火山引擎
 func (c AIClassifyConfig) GetAPIBase() string {
-	if c.APIBase == "" {
+	if c.APIBase != "" {
+		return c.APIBase
+	}
+	switch c.GetProvider() {
+	case "anthropic":
+		return "https://api.anthropic.com/v1"
+	case "gemini":
+		return "https://generativelanguage.googleapis.com/v1beta"
+	case "ollama":
+		return "http://localhost:11434"
+	default:
 		return "https://ark.cn-beijing.volces.com/api/v3"
 	}
-	return c.APIBase
 }
 
-// GetModel 获取模型名称，默认为 doubao-seed-1.8
+// GetModel 获取模型名称，OpenAI 兼容接口默认为 doubao-seed-1.8
 func (c AIClassifyConfig) GetModel() string {
-	if c.Model == "" {
+	if c.Model != "" {
+		return c.Model
+	}
+	switch c.GetProvider() {
+	case "anthropic":
+		return "claude-haiku-4-5"
+	case "gemini":
+		return "gemini-2.5-flash"
+	case "ollama":
+		return "qwen2.5:7b"
+	default:
 		return "doubao-seed-1.8"
 	}
-	return c.Model
 }
 
 // GetJSONMode 获取 JSON 输出模式，默认为 auto
 func (c AIClassifyConfig) GetJSONMode() string {
 	switch c.JSONMode {
-	case "json_object", "prompt_only", "auto":
+	case "json_schema", "json_object", "prompt_only", "auto":
 		return c.JSONMode
 	default:
 		return "auto"
@@ -205,8 +390,12 @@ type ClassifyStrategy struct {
 	WhitelistMode *bool `json:"whitelistMode,omitempty"`
 	// 是否启用脚本规则过滤
 	ScriptFilterEnabled *bool `json:"scriptFilterEnabled,omitempty"`
-	// 脚本规则过滤的脚本内容（Shell 脚本，通过 stdin 接收条目的 JSON 数组）
+	// 脚本规则过滤的脚本内容（bash 模式通过 stdin 接收条目的 JSON 数组；javascript 模式为函数体，通过 items 变量接收条目数组；wasm 模式为插件ID）
 	ScriptFilterContent string `json:"scriptFilterContent,omitempty"`
+	// 脚本规则过滤的语言: javascript（内置 JS 引擎，不依赖外部程序）/ wasm（WASM 插件）/ bash（旧版，调用系统 bash），默认 bash
+	ScriptFilterLang string `json:"scriptFilterLang,omitempty"`
+	// 脚本规则过滤的超时时间（秒），默认 30
+	ScriptTimeout int `json:"scriptTimeout,omitempty"`
 	// 绑定的类别ID列表（发送给AI时仅包含这些类别，为空表示全选）
 	BoundCategories []string `json:"boundCategories,omitempty"`
 	// 类别黑名单（这些类别的文章将被过滤）
@@ -215,6 +404,12 @@ type ClassifyStrategy struct {
 	CategoryWhitelist []string `json:"categoryWhitelist,omitempty"`
 	// 自定义AI提示词（覆盖全局）
 	CustomPrompt string `json:"customPrompt,omitempty"`
+	// 最低相关度（0-100），低于此值的文章将被过滤，需配置 aiClassify.interestProfile
+	MinRelevance int `json:"minRelevance,omitempty"`
+	// 分类示例（标题 → 类别），作为少样本示例注入分类提示词，适用于类别描述难以覆盖的小众源
+	Examples []ClassifyExample `json:"examples,omitempty"`
+	// 注入分类提示词的最近人工修正条数（作为少样本示例），0 表示不注入
+	CorrectionExamples int `json:"correctionExamples,omitempty"`
 }
 
 // IsKeywordEnabled 检查是否启用关键词过滤
@@ -249,24 +444,90 @@ func (f ClassifyStrategy) IsScriptFilterEnabled() bool {
 	return false
 }
 
+// GetScriptTimeout 获取脚本规则过滤的超时时间（秒），默认 30
+func (f ClassifyStrategy) GetScriptTimeout() int {
+	if f.ScriptTimeout <= 0 {
+		return 30
+	}
+	return f.ScriptTimeout
+}
+
+// GetScriptFilterLang 获取脚本规则过滤的语言，默认 bash（兼容旧配置）
+func (f ClassifyStrategy) GetScriptFilterLang() string {
+	switch f.ScriptFilterLang {
+	case "javascript", "js":
+		return "javascript"
+	case "wasm":
+		return "wasm"
+	default:
+		return "bash"
+	}
+}
+
 // PostProcessConfig 后处理配置
 type PostProcessConfig struct {
 	// 是否启用后处理
 	Enabled bool `json:"enabled"`
-	// 处理模式: "ai" 或 "script"
+	// 处理模式: "ai" / "script" / "plugin" / "rules"
 	Mode string `json:"mode,omitempty"`
+	// 规则模式的正则替换规则（按顺序应用）
+	Rules []RewriteRule `json:"rules,omitempty"`
 	// AI模式的提示词
 	Prompt string `json:"prompt,omitempty"`
 	// 脚本模式的脚本路径（二选一）
 	ScriptPath string `json:"scriptPath,omitempty"`
 	// 脚本模式的脚本内容（二选一，优先级高于ScriptPath）
 	ScriptContent string `json:"scriptContent,omitempty"`
+	// 插件模式使用的 WASM 插件ID（见 plugins）
+	Plugin string `json:"plugin,omitempty"`
+	// 脚本/插件模式处理单个条目的超时时间（秒），默认 10
+	ScriptTimeout int `json:"scriptTimeout,omitempty"`
 	// 是否修改标题
 	ModifyTitle bool `json:"modifyTitle,omitempty"`
 	// 是否修改链接
 	ModifyLink bool `json:"modifyLink,omitempty"`
 	// 是否修改发布时间
 	ModifyPubDate bool `json:"modifyPubDate,omitempty"`
+	// 是否修改描述
+	ModifyDescription bool `json:"modifyDescription,omitempty"`
+	// 是否修改类别（须为已配置的类别ID，只影响展示，不参与过滤）
+	ModifyCategory bool `json:"modifyCategory,omitempty"`
+	// 是否允许附加元数据（任意字符串键值，随条目持久化并通过接口返回）
+	ModifyMetadata bool `json:"modifyMetadata,omitempty"`
+}
+
+// PipelineStep 处理流水线中的一个步骤
+type PipelineStep struct {
+	// 步骤类型: keyword / script / ai-classify / regex / ai-rewrite / dedup
+	Type string `json:"type"`
+	// 步骤名称（用于统计与预览中的过滤原因），默认为步骤类型
+	Name string `json:"name,omitempty"`
+	// keyword / script / ai-classify 步骤的配置（与 classify 格式相同，只使用对应部分）
+	Classify *ClassifyStrategy `json:"classify,omitempty"`
+	// regex 步骤的替换规则
+	Rules []RewriteRule `json:"rules,omitempty"`
+	// ai-rewrite 步骤的配置（与 postProcess 格式相同，固定使用 AI 模式）
+	Rewrite *PostProcessConfig `json:"rewrite,omitempty"`
+	// dedup 步骤的去重依据: link（默认）/ title
+	DedupBy string `json:"dedupBy,omitempty"`
+}
+
+// GetName 获取步骤名称，默认为步骤类型
+func (p PipelineStep) GetName() string {
+	if p.Name == "" {
+		return p.Type
+	}
+	return p.Name
+}
+
+// RewriteRule 后处理规则模式的正则替换规则
+type RewriteRule struct {
+	// 替换的字段: "title" / "link"
+	Field string `json:"field"`
+	// 正则表达式（Go RE2 语法）
+	Pattern string `json:"pattern"`
+	// 替换内容，可用 $1 / ${name} 引用分组
+	Replacement string `json:"replacement"`
 }
 
 // GetMode 获取处理模式，默认为ai
@@ -277,10 +538,209 @@ func (p PostProcessConfig) GetMode() string {
 	return p.Mode
 }
 
+// GetScriptTimeout 获取脚本/插件模式处理单个条目的超时时间（秒），默认 10
+func (p PostProcessConfig) GetScriptTimeout() int {
+	if p.ScriptTimeout <= 0 {
+		return 10
+	}
+	return p.ScriptTimeout
+}
+
+// TranslateConfig 订阅源标题翻译配置
+type TranslateConfig struct {
+	// 是否启用
+	Enabled bool `json:"enabled"`
+	// 目标语言（如 zh-CN / zh-TW / en / ja），默认 zh-CN
+	TargetLang string `json:"targetLang,omitempty"`
+}
+
+// GetTargetLang 获取目标语言，默认为 zh-CN
+func (t TranslateConfig) GetTargetLang() string {
+	if t.TargetLang == "" {
+		return "zh-CN"
+	}
+	return t.TargetLang
+}
+
+// JSONSourceConfig JSON API 源配置（字段路径使用 JQ 风格，如 .data.list[] / items[0].title）
+type JSONSourceConfig struct {
+	// 条目数组所在路径，为空表示根节点即为数组
+	ItemsPath string `json:"itemsPath,omitempty"`
+	// 标题字段路径（相对于单个条目）
+	TitlePath string `json:"titlePath"`
+	// 链接字段路径（相对于单个条目，相对链接会基于接口地址补全）
+	LinkPath string `json:"linkPath"`
+	// 链接模板（可选，如 https://example.com/post/{link}，{link} 会被替换为链接字段的值）
+	LinkTemplate string `json:"linkTemplate,omitempty"`
+	// 发布时间字段路径（支持常见时间格式与秒/毫秒时间戳）
+	PubDatePath string `json:"pubDatePath,omitempty"`
+	// 描述字段路径
+	DescriptionPath string `json:"descriptionPath,omitempty"`
+	// 源标题字段路径（相对于根节点）
+	FeedTitlePath string `json:"feedTitlePath,omitempty"`
+	// 自定义请求头（如鉴权 Token）
+	Headers map[string]string `json:"headers,omitempty" env:"expand"`
+}
+
+// ScriptSourceConfig 脚本虚拟源配置
+// 脚本每次刷新时执行，通过 stdout 输出条目 JSON 数组（或 JSON Lines），格式与脚本过滤一致
+type ScriptSourceConfig struct {
+	// 内联脚本内容（优先级高于 ScriptPath）
+	ScriptContent string `json:"scriptContent,omitempty"`
+	// 脚本文件路径
+	ScriptPath string `json:"scriptPath,omitempty"`
+	// 脚本执行的超时时间（秒），默认 60
+	ScriptTimeout int `json:"scriptTimeout,omitempty"`
+}
+
+// GetScriptTimeout 获取脚本执行的超时时间（秒），默认 60
+func (s ScriptSourceConfig) GetScriptTimeout() int {
+	if s.ScriptTimeout <= 0 {
+		return 60
+	}
+	return s.ScriptTimeout
+}
+
+// WebhookSourceConfig 推送源配置
+// 外部系统通过 POST /api/ingest/{id} 推送条目，格式与脚本过滤一致
+type WebhookSourceConfig struct {
+	// 推送ID（为空时使用 url 去掉 webhook:// 前缀后的部分）
+	ID string `json:"id,omitempty"`
+	// 推送令牌（通过 Authorization: Bearer 或 ?token= 传递），为空时使用全局密码或登录 Token，两者都未设置时拒绝推送
+	Token string `json:"token,omitempty" env:"expand"`
+}
+
+// SocialSourceConfig 社交平台源（mastodon / bluesky）的抓取选项
+type SocialSourceConfig struct {
+	// 是否排除回复
+	ExcludeReplies bool `json:"excludeReplies,omitempty"`
+	// 是否排除转发（Mastodon 转嘟 / Bluesky 转发）
+	ExcludeReposts bool `json:"excludeReposts,omitempty"`
+	// 每次请求的条目数，不设置时使用平台默认上限
+	Limit int `json:"limit,omitempty"`
+}
+
+// GetLimit 获取每次请求的条目数，未设置时返回 defaultLimit
+func (c SocialSourceConfig) GetLimit(defaultLimit int) int {
+	if c.Limit <= 0 || c.Limit > defaultLimit {
+		return defaultLimit
+	}
+	return c.Limit
+}
+
+// CommunitySourceConfig 社区聚合源（reddit / hackernews）的抓取选项
+type CommunitySourceConfig struct {
+	// 最低分数（Reddit 分数 / HN points），低于此值的帖子会被忽略
+	MinScore int `json:"minScore,omitempty"`
+	// 每次请求的条目数，不设置时使用平台默认上限
+	Limit int `json:"limit,omitempty"`
+	// 条目链接指向讨论页而非原文链接
+	LinkToComments bool `json:"linkToComments,omitempty"`
+}
+
+// GetLimit 获取每次请求的条目数，未设置时返回 defaultLimit
+func (c CommunitySourceConfig) GetLimit(defaultLimit int) int {
+	if c.Limit <= 0 || c.Limit > defaultLimit {
+		return defaultLimit
+	}
+	return c.Limit
+}
+
+// YouTubeSourceConfig YouTube 频道/播放列表源的抓取选项
+type YouTubeSourceConfig struct {
+	// 是否跳过 Shorts 短视频
+	SkipShorts bool `json:"skipShorts,omitempty"`
+	// YouTube Data API Key（可选，配置后获取视频时长）
+	APIKey string `json:"apiKey,omitempty" env:"expand"`
+}
+
+// SourceTLSConfig 抓取单个源时的 TLS 选项，只作用于该源的抓取请求
+type SourceTLSConfig struct {
+	// 跳过证书校验（不安全，仅用于无法配置 CA 的内网源）
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// 额外信任的 CA 证书：PEM 文件路径或 PEM 内容，与系统证书一起使用
+	CA string `json:"ca,omitempty"`
+}
+
+// IMAPSourceConfig 邮件订阅源配置：从 IMAP 邮箱拉取指定发件人/文件夹的邮件作为条目
+type IMAPSourceConfig struct {
+	// IMAP 服务器地址
+	Host string `json:"host" env:"expand"`
+	// 端口，不设置时 TLS 为 993，明文为 143
+	Port int `json:"port,omitempty"`
+	// 登录用户名
+	Username string `json:"username" env:"expand"`
+	// 登录密码（通常为应用专用密码）
+	Password string `json:"password" env:"expand"`
+	// 邮箱文件夹列表，不设置时为 INBOX
+	Mailboxes []string `json:"mailboxes,omitempty"`
+	// 发件人过滤（匹配 From 中的地址或名称），为空表示不过滤
+	Senders []string `json:"senders,omitempty"`
+	// 只拉取最近多少天的邮件，不设置时为 30 天
+	SinceDays int `json:"sinceDays,omitempty"`
+	// 每个文件夹最多拉取的邮件数，不设置时为 50
+	Limit int `json:"limit,omitempty"`
+	// 不使用 TLS 连接（仅用于本地测试）
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// GetPort 获取 IMAP 端口
+func (c IMAPSourceConfig) GetPort() int {
+	if c.Port > 0 {
+		return c.Port
+	}
+	if c.Insecure {
+		return 143
+	}
+	return 993
+}
+
+// GetMailboxes 获取要拉取的邮箱文件夹
+func (c IMAPSourceConfig) GetMailboxes() []string {
+	if len(c.Mailboxes) == 0 {
+		return []string{"INBOX"}
+	}
+	return c.Mailboxes
+}
+
+// GetSinceDays 获取拉取的时间范围（天）
+func (c IMAPSourceConfig) GetSinceDays() int {
+	if c.SinceDays <= 0 {
+		return 30
+	}
+	return c.SinceDays
+}
+
+// GetLimit 获取每个文件夹最多拉取的邮件数
+func (c IMAPSourceConfig) GetLimit() int {
+	if c.Limit <= 0 {
+		return 50
+	}
+	return c.Limit
+}
+
 // Source 表示单个RSS订阅源
 type Source struct {
 	// RSS源的URL（唯一标识）
-	URL string `json:"url"`
+	URL string `json:"url" env:"expand"`
+	// 源类型: "rss"（默认）/ "json" / "script" / "webhook" / "mastodon" / "bluesky" / "reddit" / "hackernews" / "youtube" / "imap"
+	Type string `json:"type,omitempty"`
+	// JSON API 源配置（type 为 json 时使用）
+	JSON *JSONSourceConfig `json:"json,omitempty"`
+	// 脚本虚拟源配置（type 为 script 时使用）
+	Script *ScriptSourceConfig `json:"script,omitempty"`
+	// 推送源配置（type 为 webhook 时使用）
+	Webhook *WebhookSourceConfig `json:"webhook,omitempty"`
+	// 社交平台源抓取选项（type 为 mastodon / bluesky 时使用）
+	Social *SocialSourceConfig `json:"social,omitempty"`
+	// 社区聚合源抓取选项（type 为 reddit / hackernews 时使用）
+	Community *CommunitySourceConfig `json:"community,omitempty"`
+	// YouTube 源抓取选项（type 为 youtube 时使用）
+	YouTube *YouTubeSourceConfig `json:"youtube,omitempty"`
+	// 邮件订阅源配置（type 为 imap 时使用）
+	IMAP *IMAPSourceConfig `json:"imap,omitempty"`
+	// 抓取该源时的 TLS 选项（内网自签名证书等）
+	TLS *SourceTLSConfig `json:"tls,omitempty"`
 	// 自定义名称
 	Name string `json:"name,omitempty"`
 	// 自定义图标URL
@@ -297,12 +757,116 @@ type Source struct {
 	CacheItems int `json:"cacheItems,omitempty"`
 	// 后处理配置
 	PostProcess *PostProcessConfig `json:"postProcess,omitempty"`
+	// 处理流水线：按顺序执行的处理步骤，设置后替代 classify 与 postProcess 的固定流程
+	Pipeline []PipelineStep `json:"pipeline,omitempty"`
+	// 标题翻译配置
+	Translate *TranslateConfig `json:"translate,omitempty"`
+	// 标题模板（Go text/template，如 "[{{.Category}}] {{.Title}} — {{.Source}}"），在分类、后处理与翻译之后应用
+	TitleTemplate string `json:"titleTemplate,omitempty"`
+	// 自动标记已读的天数，覆盖全局 retention.autoReadDays：0 表示继承，-1 表示该源不自动标记
+	AutoReadDays int `json:"autoReadDays,omitempty"`
+	// 条目保留天数：>0 时早于该天数的条目从卡片与条目缓存中删除（不受 cacheItems 数量影响），
+	// cacheItems 为 0 时缓存保留期内的所有条目
+	RetentionDays int `json:"retentionDays,omitempty"`
+	// 条目没有图片时抓取文章页面的 og:image 作为缩略图
+	OGImage bool `json:"ogImage,omitempty"`
+	// 抓取文章页面前检查站点的 robots.txt，跳过禁止抓取的页面
+	RespectRobots bool `json:"respectRobots,omitempty"`
+	// 描述的 HTML 清洗策略: strip（只保留文本）/ basic（基本格式与链接）/ images（基本格式、链接与图片，默认）
+	Sanitize string `json:"sanitize,omitempty"`
 	// 自定义刷新次数，与时段规则中的基准频率相乘
 	RefreshCount int `json:"refreshCount,omitempty"`
-	// 是否在条目后显示发布时间（如"1小时前"）
-	ShowPubDate bool `json:"showPubDate,omitempty"`
-	// 是否显示分类标签
-	ShowCategory bool `json:"showCategory,omitempty"`
+	// 是否在条目后显示发布时间（如"1小时前"），不设置时继承分组默认值
+	ShowPubDate *bool `json:"showPubDate,omitempty"`
+	// 是否显示分类标签，不设置时继承分组默认值
+	ShowCategory *bool `json:"showCategory,omitempty"`
+	// 卡片排序表达式（如 "unread desc, pubDate desc"），为空时按时间倒序
+	SortBy string `json:"sortBy,omitempty"`
+	// 是否在卡片中显示已读条目，不设置时显示；设为 false 时服务端移除已读条目
+	ShowRead *bool `json:"showRead,omitempty"`
+	// 不发送该源的故障通知（用于长期不稳定的源）
+	MuteErrors bool `json:"muteErrors,omitempty"`
+	// 启用 WebSub 订阅：源支持 Hub 时通过推送即时更新，租约有效期间暂停轮询（需配置 websub.callbackUrl）
+	WebSub bool `json:"websub,omitempty"`
+	// 抓取优先级: low / normal（默认）/ high，超出全局流量预算时依次推迟低优先级源的定时抓取
+	Priority string `json:"priority,omitempty"`
+	// 不遵循订阅源声明的更新提示（全局开启 fetch.updateHints 时）
+	IgnoreUpdateHints bool `json:"ignoreUpdateHints,omitempty"`
+	// 按需抓取：不参与定时抓取，只在客户端请求该源所在的卡片时抓取（距上次抓取超过 fetch.onDemandTTL 时）
+	OnDemand bool `json:"onDemand,omitempty"`
+}
+
+// DisplayFlags 返回该源自身设置的展示选项
+func (s Source) DisplayFlags() DisplayFlags {
+	return DisplayFlags{
+		ShowPubDate:  s.ShowPubDate,
+		ShowCategory: s.ShowCategory,
+	}
+}
+
+// HidesRead 检查源是否设置了不显示已读条目
+func (s Source) HidesRead() bool {
+	return s.ShowRead != nil && !*s.ShowRead
+}
+
+// GetType 获取源类型，默认为 rss
+func (s Source) GetType() string {
+	if s.Type == "" {
+		return "rss"
+	}
+	return s.Type
+}
+
+// GetSanitize 获取描述的 HTML 清洗策略，默认为 images
+func (s Source) GetSanitize() string {
+	switch s.Sanitize {
+	case "strip", "basic":
+		return s.Sanitize
+	default:
+		return "images"
+	}
+}
+
+// GetPriority 获取抓取优先级，默认为 normal
+func (s Source) GetPriority() string {
+	switch s.Priority {
+	case "low", "high":
+		return s.Priority
+	default:
+		return "normal"
+	}
+}
+
+// GetSocialOptions 获取社交平台源抓取选项
+func (s Source) GetSocialOptions() SocialSourceConfig {
+	if s.Social == nil {
+		return SocialSourceConfig{}
+	}
+	return *s.Social
+}
+
+// GetCommunityOptions 获取社区聚合源抓取选项
+func (s Source) GetCommunityOptions() CommunitySourceConfig {
+	if s.Community == nil {
+		return CommunitySourceConfig{}
+	}
+	return *s.Community
+}
+
+// GetYouTubeOptions 获取 YouTube 源抓取选项
+func (s Source) GetYouTubeOptions() YouTubeSourceConfig {
+	if s.YouTube == nil {
+		return YouTubeSourceConfig{}
+	}
+	return *s.YouTube
+}
+
+// GetWebhookID 获取推送源ID，未配置时从 webhook://{id} 形式的 URL 中提取
+func (s Source) GetWebhookID() string {
+	if s.Webhook != nil && s.Webhook.ID != "" {
+		return s.Webhook.ID
+	}
+	return strings.TrimPrefix(s.URL, "webhook://")
 }
 
 // HasAIClassify 判断该源是否启用了AI分类
@@ -316,6 +880,8 @@ type FolderEntry struct {
 	SourceURL string `json:"sourceUrl,omitempty"`
 	// 分类包ID（分类包条目使用，会自动包含该分类包对应的所有订阅源）
 	CategoryPackageId string `json:"categoryPackageId,omitempty"`
+	// 子文件夹ID（子文件夹条目使用，聚合该文件夹展示的全部条目）
+	FolderID string `json:"folderId,omitempty"`
 	// 绑定的类别ID列表 (多选支持)
 	Categories []string `json:"categories,omitempty"`
 	// 是否隐藏源名称（默认显示，true为隐藏）
@@ -332,18 +898,77 @@ type Folder struct {
 	Icon string `json:"icon,omitempty"`
 	// 文件夹绑定的条目列表（订阅源+类别绑定）
 	Entries []FolderEntry `json:"entries,omitempty"`
-	// 是否在条目后显示发布时间
-	ShowPubDate bool `json:"showPubDate,omitempty"`
-	// 是否显示分类标签
-	ShowCategory bool `json:"showCategory,omitempty"`
-	// 是否显示源名称标签
-	ShowSource bool `json:"showSource,omitempty"`
+	// 是否在条目后显示发布时间，不设置时继承分组默认值
+	ShowPubDate *bool `json:"showPubDate,omitempty"`
+	// 是否显示分类标签，不设置时继承分组默认值
+	ShowCategory *bool `json:"showCategory,omitempty"`
+	// 是否显示源名称标签，不设置时继承分组默认值
+	ShowSource *bool `json:"showSource,omitempty"`
+	// 卡片排序：预设（pubDate / fetchTime / source-then-date / ranking）或排序表达式（如 "unread desc, sourcePriority asc, pubDate desc"），为空时按时间倒序
+	SortBy string `json:"sortBy,omitempty"`
+	// 是否升序排列（对空值与预设生效，自定义表达式的方向由表达式指定）
+	SortAscending bool `json:"sortAscending,omitempty"`
+	// 是否生成 AI 一句话概要（显示在卡片标题下方，最多每小时更新一次）
+	Blurb bool `json:"blurb,omitempty"`
+	// 是否将语义相似的报道合并为一条（需启用 embedding）
+	ClusterSimilar bool `json:"clusterSimilar,omitempty"`
+	// 最低相关度（0-100），低于此值的条目不在文件夹中显示，未打分的条目不受影响
+	MinRelevance int `json:"minRelevance,omitempty"`
 	// 总条目限制模式: "count" / "time"
 	LimitMode string `json:"limitMode,omitempty"`
 	// 按条数限制时的总显示条目数
 	LimitCount int `json:"limitCount,omitempty"`
 	// 按时间限制时的时间窗口（小时）
 	LimitHours int `json:"limitHours,omitempty"`
+	// 每个来源（订阅源或子文件夹）最多显示的条目数，0 表示不限制；在排序之后、总条目限制之前应用
+	MaxPerSource int `json:"maxPerSource,omitempty"`
+	// 是否在卡片中显示已读条目，不设置时显示；设为 false 时服务端移除已读条目
+	ShowRead *bool `json:"showRead,omitempty"`
+}
+
+// DisplayFlags 返回该文件夹自身设置的展示选项
+func (f Folder) DisplayFlags() DisplayFlags {
+	return DisplayFlags{
+		ShowPubDate:  f.ShowPubDate,
+		ShowCategory: f.ShowCategory,
+		ShowSource:   f.ShowSource,
+	}
+}
+
+// folderSortPresets 文件夹排序预设对应的排序表达式：[默认方向, sortAscending 时]
+var folderSortPresets = map[string][2]string{
+	// 发布时间
+	"pubDate": {"pubDate desc", "pubDate asc"},
+	// 抓取时间
+	"fetchTime": {"fetchTime desc", "fetchTime asc"},
+	// 按源在文件夹中的顺序分组，源内按发布时间
+	"source-then-date": {"sourcePriority asc, pubDate desc", "sourcePriority asc, pubDate asc"},
+	// 按源在文件夹中的顺序分组，源内保持源自身的顺序（榜单源的排名）
+	"ranking": {"sourcePriority asc, sourceOrder asc", "sourcePriority asc, sourceOrder desc"},
+}
+
+// GetSortExpression 获取文件夹生效的排序表达式：预设展开为对应的表达式，
+// 其他值作为自定义表达式原样返回；为空时按发布时间（sortAscending 时升序）
+func (f Folder) GetSortExpression() string {
+	sortBy := strings.TrimSpace(f.SortBy)
+	if sortBy == "" {
+		if f.SortAscending {
+			return folderSortPresets["pubDate"][1]
+		}
+		return ""
+	}
+	if preset, ok := folderSortPresets[sortBy]; ok {
+		if f.SortAscending {
+			return preset[1]
+		}
+		return preset[0]
+	}
+	return f.SortBy
+}
+
+// HidesRead 检查文件夹是否设置了不显示已读条目
+func (f Folder) HidesRead() bool {
+	return f.ShowRead != nil && !*f.ShowRead
 }
 
 // GetLimitMode 获取文件夹条目限制模式
@@ -372,6 +997,36 @@ func (f Folder) GetLimitHours() int {
 	return f.LimitHours
 }
 
+// DisplayFlags 卡片展示选项，nil 表示未设置（继承上一级）
+// 生效顺序：分组默认值 → 文件夹 → 订阅源 → 用户在卡片上的覆盖
+type DisplayFlags struct {
+	// 是否在条目后显示发布时间
+	ShowPubDate *bool `json:"showPubDate,omitempty"`
+	// 是否显示分类标签
+	ShowCategory *bool `json:"showCategory,omitempty"`
+	// 是否显示源名称标签（仅文件夹有效）
+	ShowSource *bool `json:"showSource,omitempty"`
+}
+
+// Merge 用 override 中已设置的选项覆盖当前选项，返回合并结果
+func (d DisplayFlags) Merge(override DisplayFlags) DisplayFlags {
+	if override.ShowPubDate != nil {
+		d.ShowPubDate = override.ShowPubDate
+	}
+	if override.ShowCategory != nil {
+		d.ShowCategory = override.ShowCategory
+	}
+	if override.ShowSource != nil {
+		d.ShowSource = override.ShowSource
+	}
+	return d
+}
+
+// IsEmpty 判断是否没有设置任何选项
+func (d DisplayFlags) IsEmpty() bool {
+	return d.ShowPubDate == nil && d.ShowCategory == nil && d.ShowSource == nil
+}
+
 // LayoutItem 布局项（可以是订阅源或文件夹）
 type LayoutItem struct {
 	// 类型: "source" 或 "folder"
@@ -390,6 +1045,16 @@ type LayoutGroup struct {
 	Name string `json:"name"`
 	// 分组包含的布局项列表（按显示顺序排列）
 	Items []LayoutItem `json:"items,omitempty"`
+	// 分组内卡片的默认展示选项
+	Display *DisplayFlags `json:"display,omitempty"`
+}
+
+// GetDisplay 获取分组默认展示选项
+func (g LayoutGroup) GetDisplay() DisplayFlags {
+	if g.Display == nil {
+		return DisplayFlags{}
+	}
+	return *g.Display
 }
 
 // Config 主配置结构
@@ -411,7 +1076,7 @@ type Config struct {
 	// 新条目序号加粗高亮颜色
 	BoldColor string `json:"boldColor,omitempty"`
 	// Settings password
-	Password string `json:"password,omitempty"`
+	Password string `json:"password,omitempty" env:"expand"`
 	// Session duration in hours (default: 24)
 	SessionDuration int `json:"sessionDuration,omitempty"`
 	// AI分类配置
@@ -420,6 +1085,761 @@ type Config struct {
 	DefaultGroup string `json:"defaultGroup,omitempty"`
 	// 全局分类类别列表
 	Categories []Category `json:"categories,omitempty"`
+	// 通知配置
+	Notification NotificationConfig `json:"notification,omitempty"`
+	// 版本更新检查配置
+	UpdateCheck UpdateCheckConfig `json:"updateCheck,omitempty"`
+	// WebSub 订阅配置
+	WebSub WebSubConfig `json:"websub,omitempty"`
+	// 更新通知令牌：外部生成器（如自建 RSSHub）调用 /api/ping 通知源有更新时使用，为空表示禁用
+	PingToken string `json:"pingToken,omitempty" env:"expand"`
+	// 数据保留配置
+	Retention RetentionConfig `json:"retention,omitempty"`
+	// 密钥解析配置（以 secret://名称 引用的密钥）
+	Secrets SecretsConfig `json:"secrets,omitempty"`
+	// 语义向量配置（用于跨源相似报道聚合）
+	Embedding EmbeddingConfig `json:"embedding,omitempty"`
+	// 标题翻译引擎配置（各源通过 translate 启用）
+	Translation TranslationConfig `json:"translation,omitempty"`
+	// 每日 AI 简报配置
+	Briefing BriefingConfig `json:"briefing,omitempty"`
+	// 提示词模板：名称 -> 内容，分类策略的 customPrompt 与后处理的 prompt 中以 template://名称 引用
+	PromptTemplates map[string]string `json:"promptTemplates,omitempty"`
+	// WASM 插件列表，过滤（scriptFilterLang 为 wasm）与后处理（mode 为 plugin）中按 ID 引用
+	Plugins []PluginConfig `json:"plugins,omitempty"`
+	// 脚本执行的沙箱与资源限制
+	ScriptSandbox ScriptSandboxConfig `json:"scriptSandbox,omitempty"`
+	// 条目图片代理
+	ImageProxy ImageProxyConfig `json:"imageProxy,omitempty"`
+	// 屏蔽的域名列表：链接属于这些域名（含子域名）的条目在抓取时被丢弃，对所有源生效
+	BlockedDomains []string `json:"blockedDomains,omitempty"`
+	// 失效源检测
+	DeadFeeds DeadFeedConfig `json:"deadFeeds,omitempty"`
+	// 图标与图片代理的访问限制
+	ProxyGuard ProxyGuardConfig `json:"proxyGuard,omitempty"`
+	// 热门话题检测
+	Trending TrendingConfig `json:"trending,omitempty"`
+	// 全局流量预算
+	Bandwidth BandwidthConfig `json:"bandwidth,omitempty"`
+	// 订阅抓取的传输选项
+	Fetch FetchConfig `json:"fetch,omitempty"`
+	// 对外请求的 DNS 解析
+	DNS DNSConfig `json:"dns,omitempty"`
+	// 对外连接的地址族与本地地址
+	Network NetworkConfig `json:"network,omitempty"`
+}
+
+// NetworkConfig 对外连接的地址族偏好与本地地址绑定，作用范围与 DNSConfig 相同
+type NetworkConfig struct {
+	// 地址族: 空（自动）/ prefer-ipv4 / prefer-ipv6（优先尝试）/ ipv4 / ipv6（只使用该地址族）
+	AddressFamily string `json:"addressFamily,omitempty"`
+	// 对外连接绑定的本地 IP 或网卡名称（如 "192.168.1.20"、"eth1"）
+	BindAddress string `json:"bindAddress,omitempty"`
+}
+
+// Enabled 是否设置了地址族或本地地址
+func (c NetworkConfig) Enabled() bool {
+	return c.AddressFamily != "" || c.BindAddress != ""
+}
+
+// DNSConfig 对外请求的 DNS 解析：使用自定义 DNS 服务器或 DNS-over-HTTPS 代替系统解析，并在进程内缓存解析结果
+// 作用于订阅抓取、图标与图片代理、AI 接口、通知等所有对外请求
+type DNSConfig struct {
+	// 自定义 DNS 服务器（host:port，省略端口时为 53），如 "1.1.1.1"
+	Server string `json:"server,omitempty"`
+	// DNS-over-HTTPS 地址（RFC 8484），如 "https://1.1.1.1/dns-query"，同时设置时优先于 server
+	DoH string `json:"doh,omitempty"`
+	// 解析结果的缓存时长（秒）：使用自定义解析时默认 300，使用系统解析时默认不缓存；-1 表示不缓存
+	CacheTTL int `json:"cacheTtl,omitempty"`
+}
+
+// Custom 是否使用自定义 DNS 服务器或 DNS-over-HTTPS
+func (c DNSConfig) Custom() bool {
+	return c.Server != "" || c.DoH != ""
+}
+
+// GetServer 获取自定义 DNS 服务器地址（补全默认端口 53）
+func (c DNSConfig) GetServer() string {
+	if c.Server == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(c.Server); err == nil {
+		return c.Server
+	}
+	return net.JoinHostPort(strings.Trim(c.Server, "[]"), "53")
+}
+
+// GetCacheTTL 获取解析结果的缓存时长，0 表示不缓存
+func (c DNSConfig) GetCacheTTL() time.Duration {
+	switch {
+	case c.CacheTTL < 0:
+		return 0
+	case c.CacheTTL == 0 && c.Custom():
+		return 300 * time.Second
+	default:
+		return time.Duration(c.CacheTTL) * time.Second
+	}
+}
+
+// FetchConfig 订阅抓取的传输选项：请求时声明支持 gzip / br / deflate 压缩，由 feedora 自行解压并限制解压后的大小
+type FetchConfig struct {
+	// 单次响应解压后的大小上限（MB），超出时视为抓取失败（防止压缩炸弹），默认 20
+	MaxSize int `json:"maxSize,omitempty"`
+	// 遵循订阅源声明的更新提示（RSS ttl、sy:updatePeriod、skipHours / skipDays）调整定时抓取间隔
+	UpdateHints bool `json:"updateHints,omitempty"`
+	// 更新提示最多将抓取间隔延长到多少分钟，默认 1440（1 天）
+	MaxHintInterval int `json:"maxHintInterval,omitempty"`
+	// 按需抓取的源在多少分钟内不重复抓取，默认 10
+	OnDemandTTL int `json:"onDemandTTL,omitempty"`
+}
+
+// GetOnDemandTTL 获取按需抓取的源重复抓取的最短间隔
+func (c FetchConfig) GetOnDemandTTL() time.Duration {
+	if c.OnDemandTTL <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.OnDemandTTL) * time.Minute
+}
+
+// GetMaxHintInterval 获取更新提示允许的最长抓取间隔（分钟）
+func (c FetchConfig) GetMaxHintInterval() int {
+	if c.MaxHintInterval <= 0 {
+		return 1440
+	}
+	return c.MaxHintInterval
+}
+
+// GetMaxSize 获取单次响应解压后的大小上限（字节）
+func (c FetchConfig) GetMaxSize() int64 {
+	if c.MaxSize <= 0 {
+		return 20 * 1024 * 1024
+	}
+	return int64(c.MaxSize) * 1024 * 1024
+}
+
+// BandwidthConfig 全局流量预算：限制每小时对外请求数与下载流量（按最近一小时滑动统计），
+// 用量达到上限的 80% 时推迟 low 优先级源的定时抓取，达到上限时只抓取 high 优先级的源，手动刷新不受限制
+type BandwidthConfig struct {
+	// 每小时请求数上限，0 表示不限制
+	MaxRequestsPerHour int `json:"maxRequestsPerHour,omitempty"`
+	// 每小时下载流量上限（MB），0 表示不限制
+	MaxMBPerHour float64 `json:"maxMBPerHour,omitempty"`
+}
+
+// Enabled 是否设置了流量上限
+func (b BandwidthConfig) Enabled() bool {
+	return b.MaxRequestsPerHour > 0 || b.MaxMBPerHour > 0
+}
+
+// MaxBytesPerHour 每小时下载流量上限（字节），0 表示不限制
+func (b BandwidthConfig) MaxBytesPerHour() int64 {
+	if b.MaxMBPerHour <= 0 {
+		return 0
+	}
+	return int64(b.MaxMBPerHour * 1024 * 1024)
+}
+
+// ProxyGuardConfig 图标与图片代理的访问限制：默认禁止访问内网、回环与链路本地地址，防止借代理探测内部服务
+// 条目均可填写域名（含子域名）、IP 或 CIDR
+type ProxyGuardConfig struct {
+	// 允许访问的主机，即使解析到内网地址（如局域网中自建的站点）
+	AllowHosts []string `json:"allowHosts,omitempty"`
+	// 禁止访问的主机，优先于 allowHosts
+	DenyHosts []string `json:"denyHosts,omitempty"`
+}
+
+// DeadFeedConfig 失效源检测：连续抓取失败超过指定天数的源被标记为失效并停止定时抓取，抓取成功后自动恢复
+type DeadFeedConfig struct {
+	// 是否启用失效源检测
+	Enabled bool `json:"enabled"`
+	// 连续失败多少天视为失效，默认 7
+	Days int `json:"days,omitempty"`
+	// 源被标记为失效及恢复时发送通知（使用 notification 中的渠道）
+	Notify bool `json:"notify,omitempty"`
+}
+
+// GetDuration 获取判定为失效的连续失败时长
+func (c DeadFeedConfig) GetDuration() time.Duration {
+	if c.Days <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(c.Days) * 24 * time.Hour
+}
+
+// ImageProxyConfig 条目图片代理：描述中的图片与缩略图经 /api/image 由服务端下载并缓存后提供
+type ImageProxyConfig struct {
+	// 是否将条目中的图片地址改写为代理地址
+	Enabled bool `json:"enabled"`
+	// 单张图片的大小上限（KB），默认 5120
+	MaxSize int `json:"maxSize,omitempty"`
+	// 缓存有效期（小时），默认 168（7 天）
+	CacheHours int `json:"cacheHours,omitempty"`
+	// 缓存总大小上限（MB），超出时删除最早缓存的图片，默认 200
+	MaxCacheSize int `json:"maxCacheSize,omitempty"`
+}
+
+// GetMaxSize 获取单张图片的大小上限（字节）
+func (c ImageProxyConfig) GetMaxSize() int64 {
+	if c.MaxSize <= 0 {
+		return 5120 * 1024
+	}
+	return int64(c.MaxSize) * 1024
+}
+
+// GetCacheTTL 获取缓存有效期
+func (c ImageProxyConfig) GetCacheTTL() time.Duration {
+	if c.CacheHours <= 0 {
+		return 168 * time.Hour
+	}
+	return time.Duration(c.CacheHours) * time.Hour
+}
+
+// GetMaxCacheSize 获取缓存总大小上限（字节）
+func (c ImageProxyConfig) GetMaxCacheSize() int64 {
+	if c.MaxCacheSize <= 0 {
+		return 200 * 1024 * 1024
+	}
+	return int64(c.MaxCacheSize) * 1024 * 1024
+}
+
+// ScriptSandboxConfig 脚本执行的沙箱与资源限制
+// CPU 限制、网络隔离、工作目录与环境变量只作用于外部脚本（bash 过滤/后处理脚本与脚本虚拟源），
+// JavaScript 与 WASM 插件本身无法访问文件系统和网络，受禁用开关、最长执行时间与内存上限约束
+type ScriptSandboxConfig struct {
+	// 禁止执行任何脚本（外部脚本、JavaScript 过滤与 WASM 插件），相关过滤保留原始条目、后处理与脚本源报错
+	Disabled bool `json:"disabled,omitempty"`
+	// 最长执行时间（秒），作为所有脚本超时时间的上限，0 表示不额外限制
+	MaxTime int `json:"maxTime,omitempty"`
+	// CPU 时间上限（秒），0 表示不限制
+	CPUTime int `json:"cpuTime,omitempty"`
+	// 内存上限（MB）：外部脚本为虚拟地址空间（0 表示不限制），JavaScript 为执行期间的堆增长、WASM 插件为线性内存（0 表示 256）
+	MemoryMB int `json:"memoryMb,omitempty"`
+	// 禁止访问网络（仅 Linux，在独立的网络命名空间中执行，需要内核允许非特权用户命名空间）
+	NoNetwork bool `json:"noNetwork,omitempty"`
+	// 工作目录：脚本在该目录中启动，HOME 与 TMPDIR 也指向该目录，不存在时自动创建；为空时使用程序工作目录
+	// 只改变相对路径的起点，不限制脚本访问其他路径
+	WorkDir string `json:"workDir,omitempty"`
+	// 清除环境变量，只保留 PATH 与 LANG，避免脚本读取到 API 密钥等敏感环境变量；默认继承服务的环境变量
+	CleanEnv bool `json:"cleanEnv,omitempty"`
+}
+
+// IsCleanEnv 是否清除脚本的环境变量（需显式开启，已有脚本可能依赖继承的环境变量）
+func (s ScriptSandboxConfig) IsCleanEnv() bool {
+	return s.CleanEnv
+}
+
+// GetEngineMemoryMB 获取内置引擎（JavaScript 过滤与 WASM 插件）的内存上限（MB），未设置时为 256
+// 内置引擎与服务同进程运行，始终需要上限，避免单个脚本耗尽服务内存
+func (s ScriptSandboxConfig) GetEngineMemoryMB() int {
+	if s.MemoryMB <= 0 {
+		return 256
+	}
+	return s.MemoryMB
+}
+
+// LimitTimeout 按最长执行时间限制脚本的超时时间
+func (s ScriptSandboxConfig) LimitTimeout(timeout time.Duration) time.Duration {
+	if s.MaxTime > 0 {
+		if limit := time.Duration(s.MaxTime) * time.Second; timeout <= 0 || timeout > limit {
+			return limit
+		}
+	}
+	return timeout
+}
+
+// PluginConfig WASM 插件：WASI 模块，通过标准输入接收条目 JSON、标准输出返回结果，与过滤/后处理脚本的格式一致
+type PluginConfig struct {
+	// 插件ID（唯一标识）
+	ID string `json:"id"`
+	// .wasm 文件路径（相对路径相对于工作目录）
+	Path string `json:"path"`
+	// 插件说明（显示用）
+	Description string `json:"description,omitempty"`
+}
+
+// GetPlugin 根据ID获取插件配置
+func (c *Config) GetPlugin(id string) *PluginConfig {
+	for i := range c.Plugins {
+		if c.Plugins[i].ID == id {
+			return &c.Plugins[i]
+		}
+	}
+	return nil
+}
+
+// PromptTemplatePrefix 配置中引用提示词模板的前缀: template://名称
+const PromptTemplatePrefix = "template://"
+
+// PromptTemplateName 解析 template://名称 引用，不是模板引用时返回 false
+func PromptTemplateName(prompt string) (string, bool) {
+	prompt = strings.TrimSpace(prompt)
+	if !strings.HasPrefix(prompt, PromptTemplatePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(prompt, PromptTemplatePrefix), true
+}
+
+// ResolvePrompt 将 template://名称 引用替换为模板内容，其他值原样返回；引用的模板不存在时返回错误
+func (c Config) ResolvePrompt(prompt string) (string, error) {
+	name, ok := PromptTemplateName(prompt)
+	if !ok {
+		return prompt, nil
+	}
+	content, exists := c.PromptTemplates[name]
+	if !exists {
+		return "", fmt.Errorf("提示词模板不存在: %s", name)
+	}
+	return content, nil
+}
+
+// SecretsConfig 密钥解析配置：配置中以 secret://名称 引用的密钥在使用时才解析，config.json 中不保存明文
+type SecretsConfig struct {
+	// 未指定解析器时的查找顺序，默认 env、docker、file、vault（vault 仅在配置了地址时参与）
+	Order []string `json:"order,omitempty"`
+	// file 解析器读取的目录，默认为数据目录下的 secrets
+	Dir string `json:"dir,omitempty"`
+	// 解析结果缓存时间（秒），默认 300
+	CacheTTL int `json:"cacheTtl,omitempty"`
+	// HashiCorp Vault（KV v2）
+	Vault *VaultConfig `json:"vault,omitempty"`
+}
+
+// GetOrder 获取解析器查找顺序
+func (c SecretsConfig) GetOrder() []string {
+	if len(c.Order) == 0 {
+		return []string{"env", "docker", "file", "vault"}
+	}
+	return c.Order
+}
+
+// GetCacheTTL 获取解析结果缓存时间（秒），默认为 300
+func (c SecretsConfig) GetCacheTTL() int {
+	if c.CacheTTL <= 0 {
+		return 300
+	}
+	return c.CacheTTL
+}
+
+// VaultConfig HashiCorp Vault 配置
+type VaultConfig struct {
+	// Vault 地址（如 https://vault.example.com:8200）
+	Address string `json:"address" env:"expand"`
+	// 访问令牌，为空时使用环境变量 VAULT_TOKEN
+	Token string `json:"token,omitempty" env:"expand"`
+	// KV v2 引擎挂载路径，默认 secret
+	Mount string `json:"mount,omitempty"`
+	// 读取的字段名，默认 value（可在引用中用 secret://vault/路径#字段 指定）
+	Field string `json:"field,omitempty"`
+}
+
+// GetMount 获取 KV 引擎挂载路径，默认为 secret
+func (c VaultConfig) GetMount() string {
+	if c.Mount == "" {
+		return "secret"
+	}
+	return strings.Trim(c.Mount, "/")
+}
+
+// GetField 获取读取的字段名，默认为 value
+func (c VaultConfig) GetField() string {
+	if c.Field == "" {
+		return "value"
+	}
+	return c.Field
+}
+
+// RetentionConfig 数据保留配置：启用后每个清理周期彻底删除超过保留天数的条目、已读状态、缓存与追踪记录
+type RetentionConfig struct {
+	// 是否启用
+	Enabled bool `json:"enabled"`
+	// 保留天数，默认 30
+	Days int `json:"days,omitempty"`
+	// 自动标记已读：早于该天数的条目自动标记为已读，0 表示不启用（与 enabled 无关，可被源的 autoReadDays 覆盖）
+	AutoReadDays int `json:"autoReadDays,omitempty"`
+}
+
+// GetDays 获取保留天数，默认为 30
+func (r RetentionConfig) GetDays() int {
+	if r.Days <= 0 {
+		return 30
+	}
+	return r.Days
+}
+
+// LLMBudgetConfig AI 调用每日预算：token 数或按单价折算的费用任一超出上限后，当天不再调用 AI
+type LLMBudgetConfig struct {
+	// 每日 token 上限（输入 + 输出），0 表示不限制
+	DailyTokens int `json:"dailyTokens,omitempty"`
+	// 每日费用上限，0 表示不限制（需同时配置单价）
+	DailyCost float64 `json:"dailyCost,omitempty"`
+	// 每百万输入 token 的单价
+	InputPrice float64 `json:"inputPrice,omitempty"`
+	// 每百万输出 token 的单价
+	OutputPrice float64 `json:"outputPrice,omitempty"`
+	// 超出预算时是否发送通知（每天最多一次）
+	Notify bool `json:"notify,omitempty"`
+}
+
+// Enabled 是否设置了预算上限
+func (b LLMBudgetConfig) Enabled() bool {
+	return b.DailyTokens > 0 || b.DailyCost > 0
+}
+
+// Cost 按单价计算费用
+func (b LLMBudgetConfig) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*b.InputPrice + float64(completionTokens)*b.OutputPrice) / 1e6
+}
+
+// Exceeded 检查用量是否达到预算上限
+func (b LLMBudgetConfig) Exceeded(promptTokens, completionTokens int) bool {
+	if b.DailyTokens > 0 && promptTokens+completionTokens >= b.DailyTokens {
+		return true
+	}
+	return b.DailyCost > 0 && b.Cost(promptTokens, completionTokens) >= b.DailyCost
+}
+
+// EmbeddingConfig 语义向量配置：为条目计算向量，文件夹开启 clusterSimilar 后将相似报道合并为一条
+type EmbeddingConfig struct {
+	// 是否启用
+	Enabled bool `json:"enabled"`
+	// 接口类型: openai（兼容 OpenAI 格式的 /embeddings，默认）/ ollama（本地 /api/embed）
+	Provider string `json:"provider,omitempty"`
+	// API Key，未设置时沿用 aiClassify.apiKey（ollama 不需要）
+	APIKey string `json:"apiKey,omitempty" env:"expand"`
+	// API Base URL，未设置时 openai 沿用 aiClassify 的 OpenAI 兼容地址，ollama 为 http://localhost:11434
+	APIBase string `json:"apiBase,omitempty" env:"expand"`
+	// 向量模型名称
+	Model string `json:"model,omitempty"`
+	// 判定为同一报道的余弦相似度阈值，默认 0.88
+	Threshold float64 `json:"threshold,omitempty"`
+	// 每次请求的条目数，默认 32
+	BatchSize int `json:"batchSize,omitempty"`
+	// 请求超时时间（秒），默认 30
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// GetProvider 获取接口类型，默认为 openai
+func (e EmbeddingConfig) GetProvider() string {
+	if strings.ToLower(e.Provider) == "ollama" {
+		return "ollama"
+	}
+	return "openai"
+}
+
+// GetAPIKey 获取 API Key，未设置时沿用 AI 分类配置
+func (e EmbeddingConfig) GetAPIKey(ai AIClassifyConfig) string {
+	if e.APIKey != "" {
+		return e.APIKey
+	}
+	return ai.APIKey
+}
+
+// GetAPIBase 获取 API Base URL
+func (e EmbeddingConfig) GetAPIBase(ai AIClassifyConfig) string {
+	if e.APIBase != "" {
+		return e.APIBase
+	}
+	if e.GetProvider() == "ollama" {
+		return "http://localhost:11434"
+	}
+	if ai.GetProvider() == "openai" && ai.APIBase != "" {
+		return ai.APIBase
+	}
+	return "https://api.openai.com/v1"
+}
+
+// GetModel 获取向量模型名称，默认 openai 为 text-embedding-3-small，ollama 为 nomic-embed-text
+func (e EmbeddingConfig) GetModel() string {
+	if e.Model != "" {
+		return e.Model
+	}
+	if e.GetProvider() == "ollama" {
+		return "nomic-embed-text"
+	}
+	return "text-embedding-3-small"
+}
+
+// HasAPIAccess 是否具备调用接口的条件（ollama 无需 API Key）
+func (e EmbeddingConfig) HasAPIAccess(ai AIClassifyConfig) bool {
+	return e.GetProvider() == "ollama" || e.GetAPIKey(ai) != ""
+}
+
+// GetThreshold 获取相似度阈值，默认为 0.88
+func (e EmbeddingConfig) GetThreshold() float64 {
+	if e.Threshold <= 0 || e.Threshold > 1 {
+		return 0.88
+	}
+	return e.Threshold
+}
+
+// GetBatchSize 获取每次请求的条目数，默认为 32
+func (e EmbeddingConfig) GetBatchSize() int {
+	if e.BatchSize <= 0 {
+		return 32
+	}
+	return e.BatchSize
+}
+
+// GetTimeout 获取请求超时时间，默认为 30 秒
+func (e EmbeddingConfig) GetTimeout() int {
+	if e.Timeout <= 0 {
+		return 30
+	}
+	return e.Timeout
+}
+
+// TranslationConfig 标题翻译引擎配置
+type TranslationConfig struct {
+	// 翻译引擎: llm（使用 aiClassify 的大模型，默认）/ deepl / libretranslate
+	Engine string `json:"engine,omitempty"`
+	// 翻译 API 的密钥（deepl / libretranslate）
+	APIKey string `json:"apiKey,omitempty" env:"expand"`
+	// 翻译 API 地址，默认 deepl 为 https://api-free.deepl.com，libretranslate 为 https://libretranslate.com
+	APIBase string `json:"apiBase,omitempty" env:"expand"`
+	// 每次请求翻译的标题数，默认 20
+	BatchSize int `json:"batchSize,omitempty"`
+}
+
+// GetEngine 获取翻译引擎，默认为 llm
+func (t TranslationConfig) GetEngine() string {
+	switch strings.ToLower(t.Engine) {
+	case "deepl":
+		return "deepl"
+	case "libretranslate":
+		return "libretranslate"
+	default:
+		return "llm"
+	}
+}
+
+// GetAPIBase 获取翻译 API 地址
+func (t TranslationConfig) GetAPIBase() string {
+	if t.APIBase != "" {
+		return t.APIBase
+	}
+	if t.GetEngine() == "libretranslate" {
+		return "https://libretranslate.com"
+	}
+	return "https://api-free.deepl.com"
+}
+
+// GetBatchSize 获取每次请求翻译的标题数，默认为 20
+func (t TranslationConfig) GetBatchSize() int {
+	if t.BatchSize <= 0 {
+		return 20
+	}
+	return t.BatchSize
+}
+
+// BriefingConfig 每日 AI 简报配置：汇总所选文件夹最近 24 小时的未读条目，按主题生成简报
+type BriefingConfig struct {
+	// 是否启用定时生成
+	Enabled bool `json:"enabled"`
+	// 参与汇总的文件夹ID，为空时使用所有文件夹
+	Folders []string `json:"folders,omitempty"`
+	// 每天生成的时间（HH:mm），默认 08:00
+	Time string `json:"time,omitempty"`
+	// 生成后是否推送到通知渠道
+	Notify bool `json:"notify,omitempty"`
+	// 发送给 AI 的最大条目数，默认 100
+	MaxItems int `json:"maxItems,omitempty"`
+	// 自定义提示词（追加在默认提示词之后）
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// GetTime 获取每天生成的时间（小时、分钟），格式错误时使用 08:00
+func (b BriefingConfig) GetTime() (int, int) {
+	t, err := time.Parse("15:04", b.Time)
+	if err != nil {
+		return 8, 0
+	}
+	return t.Hour(), t.Minute()
+}
+
+// GetMaxItems 获取发送给 AI 的最大条目数，默认为 100
+func (b BriefingConfig) GetMaxItems() int {
+	if b.MaxItems <= 0 {
+		return 100
+	}
+	return b.MaxItems
+}
+
+// TrendingConfig 热门话题检测：定时从所有订阅源最近的条目标题中提取关键词，统计跨源出现次数并与之前的时段对比
+type TrendingConfig struct {
+	// 是否启用定时检测
+	Enabled bool `json:"enabled"`
+	// 检测间隔（分钟），默认 30
+	Interval int `json:"interval,omitempty"`
+	// 统计窗口（小时），默认 6
+	Hours int `json:"hours,omitempty"`
+	// 对比窗口（小时，统计窗口之前的时段），默认 24
+	BaselineHours int `json:"baselineHours,omitempty"`
+	// 关键词至少出现在多少个订阅源中，默认 2
+	MinSources int `json:"minSources,omitempty"`
+	// 返回的最大话题数，默认 20
+	MaxTopics int `json:"maxTopics,omitempty"`
+	// 是否调用 AI（使用 aiClassify 的接口配置）提取标题中的实体与话题词，默认按词频在本地提取
+	UseAI bool `json:"useAI,omitempty"`
+	// 每次发送给 AI 的最大条目数（已提取过的条目不重复发送），默认 200
+	MaxItems int `json:"maxItems,omitempty"`
+	// 额外忽略的词（不区分大小写）
+	StopWords []string `json:"stopWords,omitempty"`
+}
+
+// GetInterval 获取检测间隔（分钟），默认为 30
+func (t TrendingConfig) GetInterval() int {
+	if t.Interval <= 0 {
+		return 30
+	}
+	return t.Interval
+}
+
+// GetHours 获取统计窗口（小时），默认为 6
+func (t TrendingConfig) GetHours() int {
+	if t.Hours <= 0 {
+		return 6
+	}
+	return t.Hours
+}
+
+// GetBaselineHours 获取对比窗口（小时），默认为 24
+func (t TrendingConfig) GetBaselineHours() int {
+	if t.BaselineHours <= 0 {
+		return 24
+	}
+	return t.BaselineHours
+}
+
+// GetMinSources 获取关键词至少出现的订阅源数，默认为 2
+func (t TrendingConfig) GetMinSources() int {
+	if t.MinSources <= 0 {
+		return 2
+	}
+	return t.MinSources
+}
+
+// GetMaxTopics 获取返回的最大话题数，默认为 20
+func (t TrendingConfig) GetMaxTopics() int {
+	if t.MaxTopics <= 0 {
+		return 20
+	}
+	return t.MaxTopics
+}
+
+// GetMaxItems 获取每次发送给 AI 的最大条目数，默认为 200
+func (t TrendingConfig) GetMaxItems() int {
+	if t.MaxItems <= 0 {
+		return 200
+	}
+	return t.MaxItems
+}
+
+// WebSubConfig WebSub（PubSubHubbub）订阅配置
+type WebSubConfig struct {
+	// 对外可访问的服务地址（如 https://feedora.example.com），Hub 会回调 {callbackUrl}/api/websub/{id}
+	CallbackURL string `json:"callbackUrl,omitempty" env:"expand"`
+	// 请求的租约时长（秒），默认 864000（10 天）
+	LeaseSeconds int `json:"leaseSeconds,omitempty"`
+}
+
+// GetLeaseSeconds 获取请求的租约时长（秒）
+func (c WebSubConfig) GetLeaseSeconds() int {
+	if c.LeaseSeconds <= 0 {
+		return 864000
+	}
+	return c.LeaseSeconds
+}
+
+// NotifyChannel 通知渠道
+type NotifyChannel struct {
+	// 渠道类型: "webhook"（POST JSON）/ "ntfy" / "telegram"
+	Type string `json:"type"`
+	// 渠道地址（webhook 地址或 ntfy 主题地址，telegram 可留空）
+	URL string `json:"url,omitempty" env:"expand"`
+	// 访问令牌（ntfy 的 Bearer Token 或 telegram 的 Bot Token）
+	Token string `json:"token,omitempty" env:"expand"`
+	// telegram 的 Chat ID
+	ChatID string `json:"chatId,omitempty" env:"expand"`
+}
+
+// NotificationConfig 通知配置
+type NotificationConfig struct {
+	// 是否启用通知
+	Enabled bool `json:"enabled"`
+	// 通知渠道列表
+	Channels []NotifyChannel `json:"channels,omitempty"`
+	// 订阅源故障通知：源由正常变为持续失败时通知一次，恢复时再通知一次
+	SourceErrors bool `json:"sourceErrors,omitempty"`
+	// 连续失败多少次视为故障，默认 3（即一轮抓取的全部重试均失败）
+	SourceErrorThreshold int `json:"sourceErrorThreshold,omitempty"`
+	// 同一源两次故障通知的最小间隔（分钟），默认 60，避免反复波动的源刷屏
+	SourceErrorCooldown int `json:"sourceErrorCooldown,omitempty"`
+	// 新条目通知规则
+	Rules []NotifyRule `json:"rules,omitempty"`
+}
+
+// NotifyRule 新条目通知规则：匹配的新条目在合并窗口内汇总为一条通知
+type NotifyRule struct {
+	// 规则名称（显示在通知标题中）
+	Name string `json:"name,omitempty"`
+	// 限定的订阅源URL，为空表示不限
+	Sources []string `json:"sources,omitempty"`
+	// 限定的文件夹ID（文件夹包含的源），为空表示不限
+	Folders []string `json:"folders,omitempty"`
+	// 限定的类别ID，为空表示不限
+	Categories []string `json:"categories,omitempty"`
+	// 标题需包含的关键词（任意一个），为空表示不限
+	Keywords []string `json:"keywords,omitempty"`
+	// 合并窗口（分钟）：同一源在窗口内到达的新条目合并为一条通知，默认 0 表示仅合并同一次抓取
+	Window int `json:"window,omitempty"`
+	// 汇总通知中列出的条目数，默认 3
+	TopN int `json:"topN,omitempty"`
+	// 匹配的已发布条目标题或描述被修改时也发送通知
+	Updates bool `json:"updates,omitempty"`
+}
+
+// GetTopN 获取汇总通知中列出的条目数，默认为 3
+func (r NotifyRule) GetTopN() int {
+	if r.TopN <= 0 {
+		return 3
+	}
+	return r.TopN
+}
+
+// GetSourceErrorThreshold 获取判定为故障的连续失败次数
+func (c NotificationConfig) GetSourceErrorThreshold() int {
+	if c.SourceErrorThreshold <= 0 {
+		return 3
+	}
+	return c.SourceErrorThreshold
+}
+
+// GetSourceErrorCooldown 获取同一源两次故障通知的最小间隔（分钟），默认为 60
+func (c NotificationConfig) GetSourceErrorCooldown() int {
+	if c.SourceErrorCooldown <= 0 {
+		return 60
+	}
+	return c.SourceErrorCooldown
+}
+
+// UpdateCheckConfig 版本更新检查配置（默认关闭，仅请求静态发布清单，不上报任何信息）
+type UpdateCheckConfig struct {
+	// 是否启用更新检查
+	Enabled bool `json:"enabled"`
+	// 发布清单地址（静态 JSON：{"version":"1.2.0","url":"...","notes":"...","security":true}）
+	ManifestURL string `json:"manifestUrl,omitempty" env:"expand"`
+	// 检查间隔（小时），默认 24
+	IntervalHours int `json:"intervalHours,omitempty"`
+	// 发现新版本时是否发送通知
+	Notify bool `json:"notify,omitempty"`
+}
+
+// GetIntervalHours 获取更新检查间隔（小时），默认为 24
+func (u UpdateCheckConfig) GetIntervalHours() int {
+	if u.IntervalHours <= 0 {
+		return 24
+	}
+	return u.IntervalHours
 }
 
 // GetAllUrls 获取所有RSS源URL
@@ -443,6 +1863,16 @@ func (c Config) GetSourceByURL(url string) *Source {
 	return nil
 }
 
+// GetWebhookSource 根据推送ID获取推送源
+func (c Config) GetWebhookSource(id string) *Source {
+	for i := range c.Sources {
+		if c.Sources[i].GetType() == "webhook" && c.Sources[i].GetWebhookID() == id {
+			return &c.Sources[i]
+		}
+	}
+	return nil
+}
+
 // GetFolderByID 根据ID获取文件夹
 func (c Config) GetFolderByID(id string) *Folder {
 	for i := range c.Folders {
@@ -453,6 +1883,49 @@ func (c Config) GetFolderByID(id string) *Folder {
 	return nil
 }
 
+// FindFolderCycle 查找文件夹嵌套中的循环引用，返回构成循环的文件夹ID路径（首尾相同），没有循环时返回 nil
+func (c Config) FindFolderCycle() []string {
+	// 0: 未访问，1: 在当前路径上，2: 已确认无循环
+	state := make(map[string]int)
+	var path []string
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		switch state[id] {
+		case 1:
+			for i, p := range path {
+				if p == id {
+					return append(append([]string{}, path[i:]...), id)
+				}
+			}
+		case 2:
+			return nil
+		}
+		folder := c.GetFolderByID(id)
+		if folder == nil {
+			return nil
+		}
+		state[id] = 1
+		path = append(path, id)
+		for _, entry := range folder.Entries {
+			if entry.FolderID == "" {
+				continue
+			}
+			if cycle := visit(entry.FolderID); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = 2
+		return nil
+	}
+	for _, folder := range c.Folders {
+		if cycle := visit(folder.ID); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
 // GetLayoutGroupByID 根据ID获取分组布局
 func (c Config) GetLayoutGroupByID(id string) *LayoutGroup {
 	for i := range c.LayoutGroups {
@@ -541,6 +2014,17 @@ func (older Config) GetIncrement(newer Config) []string {
 	return increment
 }
 
+// Clone 返回配置的深拷贝（修改副本不会影响原配置中的切片与指针）
+func (c Config) Clone() (Config, error) {
+	var cloned Config
+	data, err := json.Marshal(c)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &cloned)
+	return cloned, err
+}
+
 // GetSessionDuration 获取会话有效期（小时），默认为 24
 func (c Config) GetSessionDuration() int {
 	if c.SessionDuration <= 0 {
@@ -554,6 +2038,11 @@ func (c Config) GetCategories() []Category {
 	return c.Categories
 }
 
+// ExpandCategoryIDs 将类别ID列表展开为包含所有子孙类别的列表（使用 AI 分类实际生效的类别）
+func (c *Config) ExpandCategoryIDs(ids []string) []string {
+	return ExpandCategoryIDs(c.AIClassify.GetCategories(c), ids)
+}
+
 // GetGroups 获取所有分组名称（按布局顺序）
 func (c Config) GetGroups() []string {
 	groups := make([]string, 0, len(c.LayoutGroups))
@@ -566,6 +2055,19 @@ func (c Config) GetGroups() []string {
 	return groups
 }
 
+// GetDefaultLayoutGroup 获取默认分组（未设置或不存在时为第一个分组），没有分组时返回 nil
+func (c Config) GetDefaultLayoutGroup() *LayoutGroup {
+	if c.DefaultGroup != "" {
+		if lg := c.GetLayoutGroupByID(c.DefaultGroup); lg != nil {
+			return lg
+		}
+	}
+	if len(c.LayoutGroups) > 0 {
+		return &c.LayoutGroups[0]
+	}
+	return nil
+}
+
 // GetDefaultGroupName 获取默认分组名称
 func (c Config) GetDefaultGroupName() string {
 	if c.DefaultGroup != "" {
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+)
+
+// envPlaceholderPattern 匹配 ${VAR} 与 ${VAR:-默认值}
+var envPlaceholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// WithEnvExpanded 返回展开了 ${VAR} 环境变量占位符的配置副本，只展开密钥与地址字段（标记 env:"expand"）（原配置不变，用于保存时保留占位符）
+// 第二个返回值为未定义且没有默认值的环境变量名
+func (c Config) WithEnvExpanded() (Config, []string) {
+	expanded, err := c.Clone()
+	if err != nil {
+		return c, nil
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	expand := func(s string) string {
+		return envPlaceholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+			parts := envPlaceholderPattern.FindStringSubmatch(match)
+			if value, ok := os.LookupEnv(parts[1]); ok {
+				return value
+			}
+			if len(match) > len(parts[1])+3 {
+				// 使用 ${VAR:-默认值} 中的默认值
+				return parts[2]
+			}
+			if !seen[parts[1]] {
+				seen[parts[1]] = true
+				missing = append(missing, parts[1])
+			}
+			return match
+		})
+	}
+	expandEnvValue(reflect.ValueOf(&expanded).Elem(), expand, false)
+	return expanded, missing
+}
+
+// expandEnvValue 递归遍历结构体、切片、映射与指针，只展开标记了 env:"expand" 的字段中的字符串
+// 脚本、提示词等字段不展开，其中的 $1、${HOME} 等内容保持原样
+func expandEnvValue(v reflect.Value, expand func(string) string, enabled bool) {
+	switch v.Kind() {
+	case reflect.String:
+		if enabled && v.CanSet() {
+			v.SetString(expand(v.String()))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			expandEnvValue(v.Elem(), expand, enabled)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.IsExported() {
+				expandEnvValue(v.Field(i), expand, field.Tag.Get("env") == "expand")
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			expandEnvValue(v.Index(i), expand, enabled)
+		}
+	case reflect.Map:
+		// 映射的值不可寻址，复制后展开再写回
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			expandEnvValue(elem, expand, enabled)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
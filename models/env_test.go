@@ -0,0 +1,59 @@
+package models
+
+import "testing"
+
+func TestWithEnvExpandedOnlyExpandsTaggedFields(t *testing.T) {
+	t.Setenv("FEEDORA_TEST_KEY", "sk-test")
+	t.Setenv("HOME", "/home/feedora")
+
+	script := `echo "${1}" "$1" "${HOME}" | jq -r '.title'`
+	prompt := "价格 ${HOME} 与 $5 保持原样"
+	conf := Config{
+		AIClassify: AIClassifyConfig{APIKey: "${FEEDORA_TEST_KEY}", SystemPrompt: prompt},
+		Sources: []Source{{
+			URL:         "https://example.com/feed?key=${FEEDORA_TEST_KEY}",
+			Script:      &ScriptSourceConfig{ScriptContent: script},
+			Classify:    &ClassifyStrategy{ScriptFilterContent: script, CustomPrompt: prompt},
+			PostProcess: &PostProcessConfig{ScriptContent: script, Prompt: prompt},
+			JSON:        &JSONSourceConfig{Headers: map[string]string{"Authorization": "Bearer ${FEEDORA_TEST_KEY}"}},
+		}},
+	}
+
+	expanded, missing := conf.WithEnvExpanded()
+	if len(missing) != 0 {
+		t.Fatalf("missing = %v, want none", missing)
+	}
+	if expanded.AIClassify.APIKey != "sk-test" {
+		t.Errorf("apiKey = %q, want expanded", expanded.AIClassify.APIKey)
+	}
+	source := expanded.Sources[0]
+	if source.URL != "https://example.com/feed?key=sk-test" {
+		t.Errorf("url = %q, want expanded", source.URL)
+	}
+	if got := source.JSON.Headers["Authorization"]; got != "Bearer sk-test" {
+		t.Errorf("header = %q, want expanded", got)
+	}
+
+	for name, got := range map[string]string{
+		"script.scriptContent":         source.Script.ScriptContent,
+		"classify.scriptFilterContent": source.Classify.ScriptFilterContent,
+		"postProcess.scriptContent":    source.PostProcess.ScriptContent,
+	} {
+		if got != script {
+			t.Errorf("%s = %q, want unchanged %q", name, got, script)
+		}
+	}
+	for name, got := range map[string]string{
+		"aiClassify.systemPrompt": expanded.AIClassify.SystemPrompt,
+		"classify.customPrompt":   source.Classify.CustomPrompt,
+		"postProcess.prompt":      source.PostProcess.Prompt,
+	} {
+		if got != prompt {
+			t.Errorf("%s = %q, want unchanged %q", name, got, prompt)
+		}
+	}
+
+	if conf.AIClassify.APIKey != "${FEEDORA_TEST_KEY}" {
+		t.Errorf("original config modified: %q", conf.AIClassify.APIKey)
+	}
+}
@@ -11,30 +11,118 @@ type Feed struct {
 	FilteredCount int      `json:"filteredCount,omitempty"` // 被过滤的文章数量
 	AllItemLinks  []string `json:"-"`                      // 分类前的所有文章链接（不输出到JSON，用于内容变动检测和内部清理）
 	AllItemTitles []string `json:"-"`                      // 分类前的所有文章标题（不输出到JSON，用于内容变动检测）
+	AllItemHashes []string `json:"-"`                      // 分类前的所有文章内容指纹（不输出到JSON，用于检测已发布条目的修改）
 	Group         string   `json:"group,omitempty"`        // 分组名称
 	ShowPubDate   bool              `json:"showPubDate,omitempty"`  // 是否在条目后显示发布时间
 	ShowCategory  bool              `json:"showCategory,omitempty"` // 是否显示分类标签
 	ShowSource    bool              `json:"showSource,omitempty"`   // 是否显示源名称标签
 	RankingMode   bool              `json:"rankingMode,omitempty"`  // 是否为榜单模式
+	OnDemand      bool              `json:"onDemand,omitempty"`     // 是否包含按需抓取的源（请求卡片时抓取）
+	Stats         *FeedStats        `json:"stats,omitempty"`        // 卡片统计信息
+	NextCursor    string            `json:"nextCursor"`             // 下一页条目的游标（没有更多条目时为空）
+}
+
+// FeedStats 卡片统计信息（用于卡片底部的统计栏）
+type FeedStats struct {
+	// 当前缓存（展示）的条目数
+	CachedItems int `json:"cachedItems"`
+	// 未读条目数
+	Unread int `json:"unread"`
+	// 今天被过滤的条目数
+	FilteredToday int `json:"filteredToday"`
+	// 最近 7 天日均条目数
+	AvgPerDay float64 `json:"avgPerDay"`
+	// 最近一次抓取错误（抓取成功后清除）
+	LastError string `json:"lastError,omitempty"`
+	// 最近一次抓取错误的时间
+	LastErrorAt string `json:"lastErrorAt,omitempty"`
 }
 
 type Item struct {
 	Title         string `json:"title"`
+	OriginalTitle string `json:"originalTitle,omitempty"` // 翻译前的原始标题
 	Link          string `json:"link"`
 	OriginalLink  string `json:"originalLink,omitempty"` // 原始链接（后处理前），用于缓存查询
 	Description   string `json:"description"`
 	Source        string `json:"source,omitempty"`   // 来源（用于文件夹内区分不同源）
 	PubDate       string `json:"pubDate,omitempty"`  // 发布时间
 	FetchTime     string `json:"fetchTime,omitempty"` // 抓取时间
-	Category      string `json:"category,omitempty"` // AI分类结果
+	Category      string `json:"category,omitempty"` // AI分类结果（多标签时为主类别）
+	Categories    []string `json:"categories,omitempty"` // 多标签分类的全部类别（含主类别，仅有多个类别时设置）
+	Score         int    `json:"score,omitempty"`    // 热度分数（Reddit/HN 等源）
+	Comments      int    `json:"comments,omitempty"` // 评论数（Reddit/HN 等源）
+	Relevance     *int   `json:"relevance,omitempty"` // 与兴趣描述的相关度（0-100，AI 打分，未打分时为空）
+	Confidence    *int   `json:"confidence,omitempty"` // AI 分类的置信度（0-100，启用最低置信度时返回）
+	Thumbnail     string `json:"thumbnail,omitempty"` // 缩略图（YouTube 等视频源）
+	Duration      int    `json:"duration,omitempty"`  // 视频时长（秒）
+	Bucket        string `json:"bucket,omitempty"`   // 时间分段: today / yesterday / thisWeek / thisMonth / earlier
+	ReadCitations int    `json:"readCitations,omitempty"` // 正文中引用的已读条目数
+	ClusterSize   int      `json:"clusterSize,omitempty"`    // 合并的相似报道数（含自身，文件夹开启 clusterSimilar 时）
+	ClusterSources []string `json:"clusterSources,omitempty"` // 被合并报道的来源
+	ClusterLinks  []string `json:"clusterLinks,omitempty"`   // 被合并报道的链接
+	Metadata      map[string]string `json:"metadata,omitempty"` // 后处理附加的元数据（任意键值）
+	Updated       bool   `json:"updated,omitempty"`     // 发布后标题或描述被修改过
+	LastChanged   string `json:"lastChanged,omitempty"` // 最近一次检测到修改的时间
+	ContentHash   string `json:"-"`                     // 原始标题与描述的指纹（用于检测修改）
 	ForceKeep     bool   `json:"-"`                   // 是否由关键词白名单强制保留
 	OriginalIndex int    `json:"-"`                   // RSS源中的原始索引（用于相同时间戳的次级排序，不输出到JSON）
 }
 
+// CategoryIDs 返回条目的全部类别（多标签时为 Categories，否则为 Category），未分类时为空
+func (i Item) CategoryIDs() []string {
+	if len(i.Categories) > 0 {
+		return i.Categories
+	}
+	if i.Category != "" {
+		return []string{i.Category}
+	}
+	return nil
+}
+
+// HasCategory 判断条目是否带有指定类别（多标签时匹配任一标签）
+func (i Item) HasCategory(id string) bool {
+	for _, cat := range i.CategoryIDs() {
+		if cat == id {
+			return true
+		}
+	}
+	return false
+}
+
 // ClassifyCacheEntry AI分类结果缓存条目
 type ClassifyCacheEntry struct {
 	// 分类类别ID
 	Category string `json:"category"`
+	// 相关度（0-100），未打分时为空
+	Relevance *int `json:"relevance,omitempty"`
+	// 多标签分类的全部类别（含主类别），只有一个类别时为空
+	Categories []string `json:"categories,omitempty"`
+	// 分类置信度（0-100），未返回时为空；缓存保存原始类别，阈值在使用时判断
+	Confidence *int `json:"confidence,omitempty"`
+	// 内容指纹（条目内容、提示词版本与类别集合的哈希），与当前指纹不一致时缓存失效
+	Hash string `json:"hash,omitempty"`
+	// 分类时间（Unix 秒），用于数据保留按时间清理
+	ClassifiedAt int64 `json:"classifiedAt,omitempty"`
+}
+
+// ClassifyCorrection 人工修正的分类结果，优先于 AI 分类与分类缓存
+type ClassifyCorrection struct {
+	// 条目链接（分类时使用的原始链接）
+	Link string `json:"link"`
+	// 所属订阅源
+	RssURL string `json:"rssUrl"`
+	// 条目标题（翻译前的原文），用作分类示例
+	Title string `json:"title,omitempty"`
+	// 修正后的类别ID
+	Category string `json:"category"`
+	// 修正时间（Unix 秒）
+	CreatedAt int64 `json:"createdAt"`
+}
+
+// ClassifyExample 分类示例（标题 → 类别），作为少样本示例注入分类提示词
+type ClassifyExample struct {
+	Title    string `json:"title"`
+	Category string `json:"category"`
 }
 
 // PostProcessCacheEntry 后处理结果缓存条目
@@ -45,6 +133,45 @@ type PostProcessCacheEntry struct {
 	Link string `json:"link,omitempty"`
 	// 处理后的发布时间
 	PubDate string `json:"pubDate,omitempty"`
+	// 处理后的描述
+	Description string `json:"description,omitempty"`
+	// 处理后的类别ID
+	Category string `json:"category,omitempty"`
+	// 附加的元数据
+	Metadata map[string]string `json:"metadata,omitempty"`
 	// 处理时间戳
 	ProcessedAt string `json:"processedAt"`
 }
+
+// Follow 故事追踪：关注某条目后，持续收集各源中与之相关的后续报道
+type Follow struct {
+	// 追踪ID
+	ID string `json:"id"`
+	// 追踪标题（默认为被关注条目的标题）
+	Title string `json:"title"`
+	// 被关注的条目链接
+	SeedLink string `json:"seedLink"`
+	// 匹配关键词
+	Keywords []string `json:"keywords"`
+	// 创建时间（Unix 秒）
+	CreatedAt int64 `json:"createdAt"`
+	// 是否已归档（归档后停止匹配与通知）
+	Archived bool `json:"archived,omitempty"`
+	// 线索条目数
+	ItemCount int `json:"itemCount"`
+	// 追踪线索中的条目（按发布时间倒序）
+	Items []FollowItem `json:"items,omitempty"`
+}
+
+// FollowItem 故事追踪线索中的条目
+type FollowItem struct {
+	Link      string  `json:"link"`
+	Title     string  `json:"title"`
+	Source    string  `json:"source,omitempty"`
+	SourceURL string  `json:"sourceUrl,omitempty"`
+	PubDate   string  `json:"pubDate,omitempty"`
+	// 关键词匹配度（0~1）
+	Score float64 `json:"score"`
+	// 加入线索的时间（Unix 秒）
+	MatchedAt int64 `json:"matchedAt"`
+}
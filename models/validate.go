@@ -0,0 +1,425 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+var (
+	// 已注册抓取器的源类型（由抓取器注册时登记），用于校验 source.type
+	knownSourceTypes     = make(map[string]bool)
+	knownSourceTypesLock sync.RWMutex
+)
+
+// RegisterSourceType 登记可用的源类型，配置中 type 不在登记列表内的源校验为错误
+func RegisterSourceType(sourceType string) {
+	knownSourceTypesLock.Lock()
+	knownSourceTypes[strings.ToLower(sourceType)] = true
+	knownSourceTypesLock.Unlock()
+}
+
+// isKnownSourceType 判断源类型是否已登记
+func isKnownSourceType(sourceType string) bool {
+	knownSourceTypesLock.RLock()
+	defer knownSourceTypesLock.RUnlock()
+	return knownSourceTypes[strings.ToLower(sourceType)]
+}
+
+// ConfigIssue 配置校验发现的问题
+type ConfigIssue struct {
+	// 级别: error（会导致功能异常）/ warning（可能是配置遗漏）
+	Level string `json:"level"`
+	// 问题所在位置（如 sources[3].url）
+	Path string `json:"path"`
+	// 问题描述
+	Message string `json:"message"`
+}
+
+// String 返回便于日志输出的问题描述
+func (i ConfigIssue) String() string {
+	return fmt.Sprintf("[%s] %s: %s", i.Level, i.Path, i.Message)
+}
+
+// Validate 检查配置中的问题：重复的源地址、布局/文件夹引用不存在的文件夹或源、
+// 无效的时间段设置、绑定了未知类别的分类策略、引用了不存在的提示词模板等
+func (c Config) Validate() []ConfigIssue {
+	issues := make([]ConfigIssue, 0)
+	add := func(level, path, format string, args ...interface{}) {
+		issues = append(issues, ConfigIssue{Level: level, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// 订阅源
+	sourceURLs := make(map[string]int)
+	knownCategories := make(map[string]bool)
+	parentOf := make(map[string]string)
+	for _, cat := range c.AIClassify.GetCategories(&c) {
+		knownCategories[cat.ID] = true
+		if cat.ParentID != "" {
+			parentOf[cat.ID] = cat.ParentID
+		}
+	}
+
+	// 类别层级：父类别必须存在且不能成环
+	for _, cat := range c.AIClassify.GetCategories(&c) {
+		if cat.ParentID == "" {
+			continue
+		}
+		path := fmt.Sprintf("categories[%s].parentId", cat.ID)
+		if !knownCategories[cat.ParentID] {
+			add("warning", path, "未知的父类别ID: %s", cat.ParentID)
+			continue
+		}
+		for id, steps := cat.ParentID, 0; id != "" && steps <= len(parentOf); id, steps = parentOf[id], steps+1 {
+			if id == cat.ID {
+				add("error", path, "类别层级存在循环: %s", cat.ID)
+				break
+			}
+		}
+	}
+	for i, source := range c.Sources {
+		path := fmt.Sprintf("sources[%d]", i)
+		if strings.TrimSpace(source.URL) == "" {
+			add("error", path+".url", "订阅源地址为空")
+			continue
+		}
+		if first, exists := sourceURLs[source.URL]; exists {
+			add("error", path+".url", "订阅源地址与 sources[%d] 重复: %s", first, source.URL)
+		} else {
+			sourceURLs[source.URL] = i
+		}
+		if source.Type != "" && !isKnownSourceType(source.Type) {
+			add("error", path+".type", "未知的源类型: %s", source.Type)
+		}
+		if source.GetType() == "webhook" && (source.Webhook == nil || source.Webhook.Token == "") && c.Password == "" {
+			add("warning", path+".webhook.token", "未设置推送令牌且未设置全局密码，推送接口将拒绝所有请求")
+		}
+		if source.Classify != nil {
+			for _, catID := range source.Classify.BoundCategories {
+				if !knownCategories[catID] {
+					add("warning", path+".classify.boundCategories", "未知的类别ID: %s", catID)
+				}
+			}
+			if _, err := c.ResolvePrompt(source.Classify.CustomPrompt); err != nil {
+				add("error", path+".classify.customPrompt", "%v", err)
+			}
+			for j, example := range source.Classify.Examples {
+				examplePath := fmt.Sprintf("%s.classify.examples[%d]", path, j)
+				if strings.TrimSpace(example.Title) == "" {
+					add("warning", examplePath+".title", "分类示例的标题为空")
+				}
+				if !knownCategories[example.Category] {
+					add("warning", examplePath+".category", "未知的类别ID: %s", example.Category)
+				}
+			}
+			switch source.Classify.ScriptFilterLang {
+			case "", "bash", "javascript", "js":
+			case "wasm":
+				if c.GetPlugin(source.Classify.ScriptFilterContent) == nil {
+					add("error", path+".classify.scriptFilterContent", "插件不存在: %s", source.Classify.ScriptFilterContent)
+				}
+			default:
+				add("error", path+".classify.scriptFilterLang", "未知的脚本语言: %s（可选 javascript / wasm / bash）", source.Classify.ScriptFilterLang)
+			}
+		}
+		for j, step := range source.Pipeline {
+			stepPath := fmt.Sprintf("%s.pipeline[%d]", path, j)
+			switch step.Type {
+			case "keyword", "script", "ai-classify":
+				if step.Classify == nil {
+					add("error", stepPath+".classify", "%s 步骤缺少 classify 配置", step.Type)
+				}
+			case "regex":
+				for k, rule := range step.Rules {
+					if _, err := regexp.Compile(rule.Pattern); err != nil {
+						add("error", fmt.Sprintf("%s.rules[%d].pattern", stepPath, k), "无效的正则表达式: %v", err)
+					}
+				}
+			case "ai-rewrite":
+				if step.Rewrite == nil {
+					add("error", stepPath+".rewrite", "ai-rewrite 步骤缺少 rewrite 配置")
+				}
+			case "dedup":
+				if step.DedupBy != "" && step.DedupBy != "link" && step.DedupBy != "title" {
+					add("error", stepPath+".dedupBy", "未知的去重依据: %s（可选 link / title）", step.DedupBy)
+				}
+			default:
+				add("error", stepPath+".type", "未知的步骤类型: %s（可选 keyword / script / ai-classify / regex / ai-rewrite / dedup）", step.Type)
+			}
+		}
+		if len(source.Pipeline) > 0 && (source.Classify != nil || source.PostProcess != nil) {
+			add("warning", path+".pipeline", "已设置处理流水线，classify 与 postProcess 配置将被忽略")
+		}
+		if source.RetentionDays < 0 {
+			add("error", path+".retentionDays", "条目保留天数不能为负数: %d", source.RetentionDays)
+		}
+		if source.TLS != nil {
+			if source.TLS.InsecureSkipVerify {
+				add("warning", path+".tls.insecureSkipVerify", "已跳过证书校验，抓取该源的请求可能被中间人篡改")
+			}
+			if ca := strings.TrimSpace(source.TLS.CA); ca != "" && !strings.HasPrefix(ca, "-----BEGIN") {
+				if _, err := os.Stat(ca); err != nil {
+					add("error", path+".tls.ca", "CA 证书文件无法读取: %v", err)
+				}
+			}
+		}
+		if source.OnDemand && source.GetType() == "webhook" {
+			add("warning", path+".onDemand", "推送源不主动抓取，按需抓取不生效")
+		}
+		switch source.Priority {
+		case "", "low", "normal", "high":
+		default:
+			add("error", path+".priority", "未知的抓取优先级: %s（可选 low / normal / high）", source.Priority)
+		}
+		switch source.Sanitize {
+		case "", "strip", "basic", "images":
+		default:
+			add("error", path+".sanitize", "未知的 HTML 清洗策略: %s（可选 strip / basic / images）", source.Sanitize)
+		}
+		if source.TitleTemplate != "" {
+			if _, err := template.New("title").Parse(source.TitleTemplate); err != nil {
+				add("error", path+".titleTemplate", "标题模板无效: %v", err)
+			}
+		}
+		if source.PostProcess != nil {
+			if _, err := c.ResolvePrompt(source.PostProcess.Prompt); err != nil {
+				add("error", path+".postProcess.prompt", "%v", err)
+			}
+			if source.PostProcess.GetMode() == "plugin" && c.GetPlugin(source.PostProcess.Plugin) == nil {
+				add("error", path+".postProcess.plugin", "插件不存在: %s", source.PostProcess.Plugin)
+			}
+			for j, rule := range source.PostProcess.Rules {
+				rulePath := fmt.Sprintf("%s.postProcess.rules[%d]", path, j)
+				if rule.Field != "title" && rule.Field != "link" {
+					add("error", rulePath+".field", "未知的替换字段: %s（可选 title / link）", rule.Field)
+				}
+				if _, err := regexp.Compile(rule.Pattern); err != nil {
+					add("error", rulePath+".pattern", "无效的正则表达式: %v", err)
+				}
+			}
+		}
+	}
+
+	// 低置信度处理
+	switch c.AIClassify.LowConfidenceAction {
+	case "", "review", "uncategorized", "keyword":
+	default:
+		add("error", "aiClassify.lowConfidenceAction", "未知的低置信度处理方式: %s（可选 review / uncategorized / keyword）", c.AIClassify.LowConfidenceAction)
+	}
+	if c.AIClassify.MinConfidence < 0 || c.AIClassify.MinConfidence > 100 {
+		add("warning", "aiClassify.minConfidence", "最低置信度应在 0-100 之间")
+	}
+	if c.AIClassify.DebugLog > 200 {
+		add("warning", "aiClassify.debugLog", "调试日志最多保留 200 次调用，当前值 %d 将按 200 处理", c.AIClassify.DebugLog)
+	}
+
+	// AI 备用接口
+	for i, fallback := range c.AIClassify.Fallbacks {
+		if !c.AIClassify.WithFallback(fallback).HasAPIAccess() {
+			add("warning", fmt.Sprintf("aiClassify.fallbacks[%d].apiKey", i), "备用接口未配置 API Key，将被跳过")
+		}
+	}
+
+	// WASM 插件
+	pluginIDs := make(map[string]bool)
+	for i, plugin := range c.Plugins {
+		path := fmt.Sprintf("plugins[%d]", i)
+		if plugin.ID == "" {
+			add("error", path+".id", "插件ID为空")
+		} else if pluginIDs[plugin.ID] {
+			add("error", path+".id", "插件ID重复: %s", plugin.ID)
+		}
+		pluginIDs[plugin.ID] = true
+		if strings.TrimSpace(plugin.Path) == "" {
+			add("error", path+".path", "插件「%s」未配置 .wasm 文件路径", plugin.ID)
+		}
+	}
+
+	// 屏蔽域名
+	for i, domain := range c.BlockedDomains {
+		domain = strings.TrimSpace(domain)
+		if domain == "" {
+			add("warning", fmt.Sprintf("blockedDomains[%d]", i), "屏蔽域名为空")
+		} else if strings.ContainsAny(domain, " \t") {
+			add("warning", fmt.Sprintf("blockedDomains[%d]", i), "「%s」不是有效的域名", domain)
+		}
+	}
+
+	// 代理访问限制
+	for _, list := range []struct {
+		name  string
+		hosts []string
+	}{{"allowHosts", c.ProxyGuard.AllowHosts}, {"denyHosts", c.ProxyGuard.DenyHosts}} {
+		for i, host := range list.hosts {
+			host = strings.TrimSpace(host)
+			path := fmt.Sprintf("proxyGuard.%s[%d]", list.name, i)
+			if host == "" {
+				add("warning", path, "主机为空")
+			} else if strings.ContainsAny(host, " \t") {
+				add("warning", path, "「%s」不是有效的域名、IP 或 CIDR", host)
+			}
+		}
+	}
+
+	// 流量预算
+	if c.Bandwidth.MaxRequestsPerHour < 0 {
+		add("error", "bandwidth.maxRequestsPerHour", "每小时请求数上限不能为负数: %d", c.Bandwidth.MaxRequestsPerHour)
+	}
+	if c.Bandwidth.MaxMBPerHour < 0 {
+		add("error", "bandwidth.maxMBPerHour", "每小时流量上限不能为负数: %g", c.Bandwidth.MaxMBPerHour)
+	}
+
+	if c.Fetch.MaxSize < 0 {
+		add("error", "fetch.maxSize", "响应大小上限不能为负数: %d", c.Fetch.MaxSize)
+	}
+	if c.Fetch.MaxHintInterval < 0 {
+		add("error", "fetch.maxHintInterval", "更新提示的最长间隔不能为负数: %d", c.Fetch.MaxHintInterval)
+	}
+	if c.Fetch.OnDemandTTL < 0 {
+		add("error", "fetch.onDemandTTL", "按需抓取的间隔不能为负数: %d", c.Fetch.OnDemandTTL)
+	}
+
+	// DNS 解析
+	if c.DNS.Server != "" {
+		if host, _, err := net.SplitHostPort(c.DNS.GetServer()); err != nil || host == "" {
+			add("error", "dns.server", "DNS 服务器地址无效: %s（应为 host 或 host:port）", c.DNS.Server)
+		}
+	}
+	if c.DNS.DoH != "" && !strings.HasPrefix(c.DNS.DoH, "https://") {
+		add("error", "dns.doh", "DNS-over-HTTPS 地址必须以 https:// 开头: %s", c.DNS.DoH)
+	}
+	if c.DNS.Server != "" && c.DNS.DoH != "" {
+		add("warning", "dns.server", "已设置 dns.doh，server 将被忽略")
+	}
+	switch c.Network.AddressFamily {
+	case "", "prefer-ipv4", "prefer-ipv6", "ipv4", "ipv6":
+	default:
+		add("error", "network.addressFamily", "未知的地址族: %s（可选 prefer-ipv4 / prefer-ipv6 / ipv4 / ipv6）", c.Network.AddressFamily)
+	}
+	if bind := c.Network.BindAddress; bind != "" && net.ParseIP(bind) == nil {
+		if _, err := net.InterfaceByName(bind); err != nil {
+			add("warning", "network.bindAddress", "「%s」不是 IP，也没有找到该名称的网卡", bind)
+		}
+	}
+
+	// 脚本沙箱
+	sandbox := c.ScriptSandbox
+	if sandbox.MaxTime < 0 || sandbox.CPUTime < 0 || sandbox.MemoryMB < 0 {
+		add("warning", "scriptSandbox", "maxTime、cpuTime 与 memoryMb 不能为负数，负数按不限制处理")
+	}
+	if sandbox.MemoryMB > 0 && sandbox.MemoryMB < 16 {
+		add("warning", "scriptSandbox.memoryMb", "内存上限过低（%d MB），bash 可能无法启动", sandbox.MemoryMB)
+	}
+	if sandbox.NoNetwork && runtime.GOOS != "linux" {
+		add("error", "scriptSandbox.noNetwork", "当前系统 (%s) 不支持网络隔离，启用后所有外部脚本都会执行失败", runtime.GOOS)
+	}
+
+	// 文件夹
+	folderIDs := make(map[string]bool)
+	packageIDs := make(map[string]bool)
+	for _, pkg := range c.AIClassify.CategoryPackages {
+		packageIDs[pkg.ID] = true
+	}
+	for i, folder := range c.Folders {
+		path := fmt.Sprintf("folders[%d]", i)
+		if folder.ID == "" {
+			add("error", path+".id", "文件夹ID为空")
+		} else if folderIDs[folder.ID] {
+			add("error", path+".id", "文件夹ID重复: %s", folder.ID)
+		}
+		folderIDs[folder.ID] = true
+		for j, entry := range folder.Entries {
+			entryPath := fmt.Sprintf("%s.entries[%d]", path, j)
+			switch {
+			case entry.FolderID != "":
+				if c.GetFolderByID(entry.FolderID) == nil {
+					add("warning", entryPath+".folderId", "文件夹「%s」引用了不存在的子文件夹: %s", folder.Name, entry.FolderID)
+				}
+			case entry.CategoryPackageId != "":
+				if !packageIDs[entry.CategoryPackageId] {
+					add("warning", entryPath+".categoryPackageId", "文件夹「%s」引用了不存在的分类包: %s", folder.Name, entry.CategoryPackageId)
+				}
+			case entry.SourceURL != "":
+				if _, ok := sourceURLs[entry.SourceURL]; !ok {
+					add("warning", entryPath+".sourceUrl", "文件夹「%s」引用了不存在的订阅源: %s", folder.Name, entry.SourceURL)
+				}
+			default:
+				add("warning", entryPath, "文件夹「%s」中存在未指定订阅源的条目", folder.Name)
+			}
+		}
+	}
+	if cycle := c.FindFolderCycle(); cycle != nil {
+		names := make([]string, len(cycle))
+		for i, id := range cycle {
+			names[i] = c.GetFolderByID(id).Name
+		}
+		add("error", "folders", "文件夹嵌套存在循环引用: %s", strings.Join(names, " → "))
+	}
+
+	// 分组布局
+	groupIDs := make(map[string]bool)
+	for i, group := range c.LayoutGroups {
+		path := fmt.Sprintf("layoutGroups[%d]", i)
+		if group.ID != "" && groupIDs[group.ID] {
+			add("error", path+".id", "分组ID重复: %s", group.ID)
+		}
+		groupIDs[group.ID] = true
+		for j, item := range group.Items {
+			itemPath := fmt.Sprintf("%s.items[%d]", path, j)
+			switch item.Type {
+			case "folder":
+				if !folderIDs[item.FolderID] {
+					add("warning", itemPath+".folderId", "分组「%s」引用了不存在的文件夹: %s", group.Name, item.FolderID)
+				}
+			case "source":
+				if _, ok := sourceURLs[item.SourceURL]; !ok {
+					add("warning", itemPath+".sourceUrl", "分组「%s」引用了不存在的订阅源: %s", group.Name, item.SourceURL)
+				}
+			default:
+				add("error", itemPath+".type", "未知的布局项类型: %s", item.Type)
+			}
+		}
+	}
+	if c.DefaultGroup != "" && !groupIDs[c.DefaultGroup] {
+		add("warning", "defaultGroup", "默认分组不存在: %s", c.DefaultGroup)
+	}
+
+	// 抓取计划
+	for i, schedule := range c.Schedules {
+		path := fmt.Sprintf("schedules[%d]", i)
+		if !isValidClockTime(schedule.StartTime) {
+			add("error", path+".startTime", "无效的开始时间: %q（格式应为 HH:mm:ss）", schedule.StartTime)
+		}
+		if !isValidClockTime(schedule.EndTime) {
+			add("error", path+".endTime", "无效的结束时间: %q（格式应为 HH:mm:ss）", schedule.EndTime)
+		}
+		if schedule.StartTime != "" && schedule.StartTime == schedule.EndTime {
+			add("warning", path, "开始时间与结束时间相同，该规则不会生效")
+		}
+		if schedule.BaseRefresh <= 0 {
+			add("error", path+".baseRefresh", "基准频率必须大于 0")
+		}
+	}
+
+	// 夜间模式时间
+	if c.NightStartTime != "" && !isValidClockTime(c.NightStartTime) {
+		add("error", "nightStartTime", "无效的夜间模式开始时间: %q（格式应为 HH:mm:ss）", c.NightStartTime)
+	}
+	if c.NightEndTime != "" && !isValidClockTime(c.NightEndTime) {
+		add("error", "nightEndTime", "无效的夜间模式结束时间: %q（格式应为 HH:mm:ss）", c.NightEndTime)
+	}
+
+	return issues
+}
+
+// isValidClockTime 判断是否为 HH:mm:ss 格式的时间（时间段按字符串比较，必须补零）
+func isValidClockTime(value string) bool {
+	t, err := time.Parse("15:04:05", value)
+	return err == nil && t.Format("15:04:05") == value
+}
@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestValidateRejectsUnknownSourceType(t *testing.T) {
+	RegisterSourceType("rss")
+	conf := Config{Sources: []Source{
+		{URL: "https://a.example.com/feed", Type: "RSS"},
+		{URL: "https://b.example.com/feed", Type: "rsss"},
+	}}
+
+	var errors []ConfigIssue
+	for _, issue := range conf.Validate() {
+		if issue.Level == "error" {
+			errors = append(errors, issue)
+		}
+	}
+	if len(errors) != 1 || errors[0].Path != "sources[1].type" {
+		t.Fatalf("errors = %v, want one unknown type error for sources[1]", errors)
+	}
+}
+
+func TestFindFolderCycle(t *testing.T) {
+	conf := Config{Folders: []Folder{
+		{ID: "a", Entries: []FolderEntry{{FolderID: "b"}, {FolderID: "c"}}},
+		{ID: "b", Entries: []FolderEntry{{FolderID: "c"}}},
+		{ID: "c", Entries: []FolderEntry{{SourceURL: "https://a.example.com/feed"}}},
+	}}
+	if cycle := conf.FindFolderCycle(); cycle != nil {
+		t.Fatalf("FindFolderCycle() = %v, want nil for shared sub-folder", cycle)
+	}
+
+	conf.Folders[2].Entries = append(conf.Folders[2].Entries, FolderEntry{FolderID: "a"})
+	cycle := conf.FindFolderCycle()
+	if len(cycle) != 4 || cycle[0] != "a" || cycle[len(cycle)-1] != "a" {
+		t.Fatalf("FindFolderCycle() = %v, want a → b → c → a", cycle)
+	}
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 全局流量预算：统计最近一小时订阅抓取（含复用其 Transport 的缩略图等请求）与图标、图片代理的请求数和下载字节数
+// （bandwidthTransport 包装在两者的连接池外层，见 httpclient.go 与 proxyguard.go），
+// 用量接近或达到 bandwidth 配置的上限时推迟低优先级源的定时抓取，便于在按流量计费或共享的网络中运行
+
+// 流量预算的统计窗口，以及开始推迟 low 优先级源的用量比例
+const (
+	bandwidthWindow     = time.Hour
+	bandwidthDeferRatio = 0.8
+)
+
+// 流量预算状态
+const (
+	bandwidthLevelNormal    = "normal"
+	bandwidthLevelThrottled = "throttled"
+	bandwidthLevelExhausted = "exhausted"
+)
+
+// bandwidthBucket 一分钟内的请求数与下载字节数
+type bandwidthBucket struct {
+	minute   int64
+	requests int
+	bytes    int64
+}
+
+var (
+	// 按时间顺序排列的每分钟用量，只保留统计窗口内的记录
+	bandwidthBuckets []bandwidthBucket
+	bandwidthLock    sync.Mutex
+	// 上一次检查时的预算状态，状态变化时记录日志
+	bandwidthLastLevel = bandwidthLevelNormal
+)
+
+// BandwidthStats 最近一小时的流量用量与预算（/api/stats）
+type BandwidthStats struct {
+	Requests           int   `json:"requests"`
+	Bytes              int64 `json:"bytes"`
+	MaxRequestsPerHour int   `json:"maxRequestsPerHour,omitempty"`
+	MaxBytesPerHour    int64 `json:"maxBytesPerHour,omitempty"`
+	// 预算状态: normal / throttled（推迟 low 优先级源）/ exhausted（只抓取 high 优先级源）
+	Level string `json:"level"`
+}
+
+// bandwidthTransport 统计经过的请求数与响应体字节数
+type bandwidthTransport struct {
+	base http.RoundTripper
+}
+
+func (t *bandwidthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	recordBandwidth(time.Now(), 1, 0)
+	if err != nil {
+		return resp, err
+	}
+	resp.Body = &bandwidthCountingBody{ReadCloser: resp.Body}
+	return resp, nil
+}
+
+// bandwidthCountingBody 读取响应体时累计下载字节数
+type bandwidthCountingBody struct {
+	io.ReadCloser
+}
+
+func (b *bandwidthCountingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		recordBandwidth(time.Now(), 0, int64(n))
+	}
+	return n, err
+}
+
+// recordBandwidth 累计用量并丢弃统计窗口外的记录
+func recordBandwidth(now time.Time, requests int, bytes int64) {
+	minute := now.Unix() / 60
+	bandwidthLock.Lock()
+	defer bandwidthLock.Unlock()
+	if n := len(bandwidthBuckets); n > 0 && bandwidthBuckets[n-1].minute == minute {
+		bandwidthBuckets[n-1].requests += requests
+		bandwidthBuckets[n-1].bytes += bytes
+	} else {
+		bandwidthBuckets = append(bandwidthBuckets, bandwidthBucket{minute: minute, requests: requests, bytes: bytes})
+	}
+	pruneBandwidthBuckets(now)
+}
+
+// pruneBandwidthBuckets 丢弃统计窗口外的记录，调用方需持有 bandwidthLock
+func pruneBandwidthBuckets(now time.Time) {
+	oldest := now.Add(-bandwidthWindow).Unix() / 60
+	i := 0
+	for i < len(bandwidthBuckets) && bandwidthBuckets[i].minute <= oldest {
+		i++
+	}
+	if i > 0 {
+		bandwidthBuckets = append(bandwidthBuckets[:0], bandwidthBuckets[i:]...)
+	}
+}
+
+// bandwidthUsage 返回最近一小时的请求数与下载字节数
+func bandwidthUsage(now time.Time) (int, int64) {
+	bandwidthLock.Lock()
+	defer bandwidthLock.Unlock()
+	pruneBandwidthBuckets(now)
+	requests, bytes := 0, int64(0)
+	for _, bucket := range bandwidthBuckets {
+		requests += bucket.requests
+		bytes += bucket.bytes
+	}
+	return requests, bytes
+}
+
+// bandwidthLevel 根据用量与预算计算预算状态
+func bandwidthLevel(budget models.BandwidthConfig, requests int, bytes int64) string {
+	ratio := 0.0
+	if budget.MaxRequestsPerHour > 0 {
+		ratio = float64(requests) / float64(budget.MaxRequestsPerHour)
+	}
+	if maxBytes := budget.MaxBytesPerHour(); maxBytes > 0 {
+		if r := float64(bytes) / float64(maxBytes); r > ratio {
+			ratio = r
+		}
+	}
+	switch {
+	case ratio >= 1:
+		return bandwidthLevelExhausted
+	case ratio >= bandwidthDeferRatio:
+		return bandwidthLevelThrottled
+	default:
+		return bandwidthLevelNormal
+	}
+}
+
+// currentBandwidthLevel 获取当前的预算状态（未设置预算时始终为 normal），状态变化时记录日志
+func currentBandwidthLevel() string {
+	budget := globals.RssUrls.Bandwidth
+	if !budget.Enabled() {
+		return bandwidthLevelNormal
+	}
+	requests, bytes := bandwidthUsage(time.Now())
+	level := bandwidthLevel(budget, requests, bytes)
+
+	bandwidthLock.Lock()
+	changed := level != bandwidthLastLevel
+	bandwidthLastLevel = level
+	bandwidthLock.Unlock()
+	if changed {
+		switch level {
+		case bandwidthLevelThrottled:
+			log.Printf("[流量预算] 最近一小时已请求 %d 次、下载 %.1f MB，接近上限，推迟 low 优先级源的定时抓取", requests, float64(bytes)/1024/1024)
+		case bandwidthLevelExhausted:
+			log.Printf("[流量预算] 最近一小时已请求 %d 次、下载 %.1f MB，达到上限，只抓取 high 优先级的源", requests, float64(bytes)/1024/1024)
+		default:
+			log.Printf("[流量预算] 用量回落，恢复所有源的定时抓取")
+		}
+	}
+	return level
+}
+
+// bandwidthDeferred 检查是否因流量预算推迟该源的定时抓取
+func bandwidthDeferred(source *models.Source) bool {
+	priority := "normal"
+	if source != nil {
+		priority = source.GetPriority()
+	}
+	if priority == "high" {
+		return false
+	}
+	switch currentBandwidthLevel() {
+	case bandwidthLevelExhausted:
+		return true
+	case bandwidthLevelThrottled:
+		return priority == "low"
+	default:
+		return false
+	}
+}
+
+// GetBandwidthStats 获取最近一小时的流量用量与预算
+func GetBandwidthStats() BandwidthStats {
+	budget := globals.RssUrls.Bandwidth
+	requests, bytes := bandwidthUsage(time.Now())
+	stats := BandwidthStats{
+		Requests:           requests,
+		Bytes:              bytes,
+		MaxRequestsPerHour: budget.MaxRequestsPerHour,
+		MaxBytesPerHour:    budget.MaxBytesPerHour(),
+		Level:              bandwidthLevelNormal,
+	}
+	if budget.Enabled() {
+		stats.Level = bandwidthLevel(budget, requests, bytes)
+	}
+	return stats
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"feedora/models"
+	"testing"
+	"time"
+)
+
+func TestBandwidthUsageWindow(t *testing.T) {
+	saved := bandwidthBuckets
+	defer func() { bandwidthBuckets = saved }()
+	bandwidthBuckets = nil
+
+	now := time.Now()
+	recordBandwidth(now.Add(-90*time.Minute), 5, 5000)
+	recordBandwidth(now.Add(-30*time.Minute), 2, 100)
+	recordBandwidth(now.Add(-30*time.Minute), 0, 200)
+	recordBandwidth(now, 1, 50)
+
+	if requests, bytes := bandwidthUsage(now); requests != 3 || bytes != 350 {
+		t.Errorf("bandwidthUsage() = %d, %d, want 3, 350", requests, bytes)
+	}
+}
+
+func TestBandwidthLevel(t *testing.T) {
+	budget := models.BandwidthConfig{MaxRequestsPerHour: 100, MaxMBPerHour: 1}
+	tests := []struct {
+		requests int
+		bytes    int64
+		want     string
+	}{
+		{10, 0, bandwidthLevelNormal},
+		{80, 0, bandwidthLevelThrottled},
+		{10, 900 * 1024, bandwidthLevelThrottled},
+		{100, 0, bandwidthLevelExhausted},
+		{10, 2 * 1024 * 1024, bandwidthLevelExhausted},
+	}
+	for _, tt := range tests {
+		if got := bandwidthLevel(budget, tt.requests, tt.bytes); got != tt.want {
+			t.Errorf("bandwidthLevel(%d, %d) = %s, want %s", tt.requests, tt.bytes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"feedora/globals"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// normalizeBlockedDomain 规范化屏蔽列表中的域名：忽略大小写，允许填写完整地址或 *.example.com 形式
+func normalizeBlockedDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if strings.Contains(domain, "://") {
+		if parsed, err := url.Parse(domain); err == nil {
+			domain = parsed.Hostname()
+		}
+	}
+	domain = strings.TrimPrefix(domain, "*.")
+	domain = strings.TrimPrefix(domain, ".")
+	if i := strings.IndexAny(domain, "/:"); i >= 0 {
+		domain = domain[:i]
+	}
+	return domain
+}
+
+// isBlockedLink 判断链接的主机是否为屏蔽的域名或其子域名
+func isBlockedLink(link string, blocked []string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return false
+	}
+	for _, domain := range blocked {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropBlockedItems 移除链接属于全局屏蔽域名的条目（在分类、缓存合并之前执行，被屏蔽的条目不会进入任何卡片）
+func dropBlockedItems(rssURL string, feed *gofeed.Feed) {
+	blocked := make([]string, 0, len(globals.RssUrls.BlockedDomains))
+	for _, domain := range globals.RssUrls.BlockedDomains {
+		if domain = normalizeBlockedDomain(domain); domain != "" {
+			blocked = append(blocked, domain)
+		}
+	}
+	if len(blocked) == 0 || feed == nil {
+		return
+	}
+
+	kept := feed.Items[:0]
+	dropped := 0
+	for _, item := range feed.Items {
+		if item != nil && isBlockedLink(item.Link, blocked) {
+			dropped++
+			continue
+		}
+		kept = append(kept, item)
+	}
+	feed.Items = kept
+	if dropped > 0 {
+		log.Printf("[域名屏蔽] 源 [%s]: 移除了 %d 个屏蔽域名的条目", rssURL, dropped)
+	}
+}
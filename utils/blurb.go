@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// blurbMinInterval 同一文件夹两次生成概要的最小间隔
+	blurbMinInterval = 1 * time.Hour
+	// blurbItemCount 生成概要时使用的最新条目数
+	blurbItemCount = 15
+	// blurbMaxLength 概要的最大长度（字符）
+	blurbMaxLength = 80
+)
+
+// folderBlurb 文件夹 AI 概要缓存
+type folderBlurb struct {
+	Text        string
+	Signature   string
+	GeneratedAt time.Time
+	Generating  bool
+}
+
+var (
+	// 文件夹概要缓存: map[文件夹ID] -> 概要
+	folderBlurbs     = make(map[string]*folderBlurb)
+	folderBlurbsLock sync.Mutex
+)
+
+// applyFolderBlurb 将文件夹的 AI 概要写入 Feed.Custom["blurb"]
+// 概要在后台生成，最新条目变化且距上次生成超过 1 小时才会重新生成，不阻塞卡片构建
+func applyFolderBlurb(feed *models.Feed, folder models.Folder) {
+	if !folder.Blurb || !globals.RssUrls.AIClassify.HasAPIAccess() || len(feed.Items) == 0 {
+		return
+	}
+
+	items := feed.Items
+	if len(items) > blurbItemCount {
+		items = items[:blurbItemCount]
+	}
+	signature := blurbSignature(items)
+
+	folderBlurbsLock.Lock()
+	blurb, ok := folderBlurbs[folder.ID]
+	if !ok {
+		blurb = &folderBlurb{}
+		folderBlurbs[folder.ID] = blurb
+	}
+	if blurb.Text != "" {
+		feed.Custom["blurb"] = blurb.Text
+	}
+	stale := blurb.Signature != signature && time.Since(blurb.GeneratedAt) >= blurbMinInterval
+	if !stale || blurb.Generating {
+		folderBlurbsLock.Unlock()
+		return
+	}
+	blurb.Generating = true
+	folderBlurbsLock.Unlock()
+
+	titles := make([]string, len(items))
+	for i, item := range items {
+		titles[i] = item.Title
+	}
+	go func(folderID, folderName string) {
+		text, err := generateFolderBlurb(folderName, titles)
+
+		folderBlurbsLock.Lock()
+		defer folderBlurbsLock.Unlock()
+		blurb.Generating = false
+		// 失败时同样记录时间，避免每次构建卡片都重试
+		blurb.GeneratedAt = time.Now()
+		if err != nil {
+			log.Printf("[文件夹概要] 生成失败 | 文件夹: %s | 错误: %v", folderName, err)
+			return
+		}
+		blurb.Text = text
+		blurb.Signature = signature
+		log.Printf("[文件夹概要] 已生成 | 文件夹: %s | 概要: %s", folderName, text)
+	}(folder.ID, folder.Name)
+}
+
+// blurbSignature 根据条目链接计算签名，用于判断最新条目是否变化
+func blurbSignature(items []models.Item) string {
+	h := sha1.New()
+	for _, item := range items {
+		h.Write([]byte(item.Link))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// generateFolderBlurb 调用大模型生成一句话概要
+func generateFolderBlurb(folderName string, titles []string) (string, error) {
+	aiConfig := globals.RssUrls.AIClassify
+
+	prompt := fmt.Sprintf("你是一名资讯编辑。根据「%s」栏目下最新文章的标题，用一句话（不超过 40 个字）概括正在发生什么。"+
+		"只输出这句话本身，不要加引号、前缀或解释。", folderName)
+
+	var content strings.Builder
+	for i, title := range titles {
+		content.WriteString(fmt.Sprintf("%d. %s\n", i+1, title))
+	}
+
+	reqBody := ChatRequest{
+		Model:   aiConfig.GetModel(),
+		Purpose: "blurb",
+		Messages: []ChatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: content.String()},
+		},
+		Temperature: aiConfig.GetTemperature(),
+		MaxTokens:   aiConfig.GetMaxTokens(),
+	}
+
+	client := &http.Client{
+		Timeout: time.Duration(aiConfig.GetTimeout()) * time.Second,
+	}
+	chatResp, err := sendChatCompletion(client, aiConfig, "prompt_only", reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	text := strings.TrimSpace(stripCodeFences(chatResp.Choices[0].Message.Content))
+	text = strings.Trim(text, "\"'“”「」")
+	if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+		text = text[:idx]
+	}
+	if text == "" {
+		return "", fmt.Errorf("模型返回了空内容")
+	}
+	return truncateString(text, blurbMaxLength), nil
+}
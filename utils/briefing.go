@@ -0,0 +1,247 @@
+package utils
+
+import (
+	"encoding/json"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BriefingItem 简报中引用的条目
+type BriefingItem struct {
+	Title  string `json:"title"`
+	Link   string `json:"link"`
+	Source string `json:"source,omitempty"`
+}
+
+// BriefingTopic 简报中的一个主题
+type BriefingTopic struct {
+	Title   string         `json:"title"`
+	Summary string         `json:"summary"`
+	Items   []BriefingItem `json:"items"`
+}
+
+// Briefing 每日 AI 简报
+type Briefing struct {
+	// 简报日期（YYYY-MM-DD）
+	Date        string `json:"date"`
+	GeneratedAt string `json:"generatedAt"`
+	// 参与汇总的未读条目数
+	ItemCount int `json:"itemCount"`
+	// 总体概述
+	Summary string          `json:"summary"`
+	Topics  []BriefingTopic `json:"topics"`
+}
+
+// briefingDescLength 发送给 AI 的每条摘要长度（字符）
+const briefingDescLength = 200
+
+var (
+	latestBriefing     *Briefing
+	latestBriefingLock sync.RWMutex
+	// 串行生成简报，避免定时任务与手动请求同时调用 AI
+	briefingGenerateLock sync.Mutex
+)
+
+// GetLatestBriefing 获取最近一次生成的简报
+func GetLatestBriefing() (*Briefing, bool) {
+	latestBriefingLock.RLock()
+	defer latestBriefingLock.RUnlock()
+	return latestBriefing, latestBriefing != nil
+}
+
+// BriefingLoop 每天在配置的时间生成简报，按配置推送到通知渠道
+func BriefingLoop() {
+	lastDate := ""
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		config := globals.RssUrls.Briefing
+		if !config.Enabled {
+			continue
+		}
+		hour, minute := config.GetTime()
+		today := now.Format("2006-01-02")
+		scheduled := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if lastDate == today || now.Before(scheduled) {
+			continue
+		}
+		lastDate = today
+
+		briefing, err := GenerateBriefing()
+		if err != nil {
+			log.Printf("[每日简报] 生成失败: %v", err)
+			continue
+		}
+		if config.Notify {
+			SendBriefingNotification(briefing)
+		}
+	}
+}
+
+// GenerateBriefing 汇总所选文件夹最近 24 小时的未读条目并调用 AI 生成简报
+func GenerateBriefing() (*Briefing, error) {
+	briefingGenerateLock.Lock()
+	defer briefingGenerateLock.Unlock()
+
+	aiConfig := globals.RssUrls.AIClassify
+	if !aiConfig.HasAPIAccess() {
+		return nil, fmt.Errorf("AI API Key未配置")
+	}
+	config := globals.RssUrls.Briefing
+	now := time.Now()
+	items := collectBriefingItems(config, now)
+	briefing := &Briefing{
+		Date:        now.Format("2006-01-02"),
+		GeneratedAt: now.Format(time.RFC3339),
+		ItemCount:   len(items),
+		Topics:      []BriefingTopic{},
+	}
+	if len(items) == 0 {
+		briefing.Summary = "最近 24 小时没有未读条目"
+	} else if err := requestBriefing(briefing, items, config); err != nil {
+		return nil, err
+	}
+
+	latestBriefingLock.Lock()
+	latestBriefing = briefing
+	latestBriefingLock.Unlock()
+	log.Printf("[每日简报] 已生成 | 条目: %d | 主题: %d", briefing.ItemCount, len(briefing.Topics))
+	return briefing, nil
+}
+
+// collectBriefingItems 收集所选文件夹中最近 24 小时的未读条目（按链接去重，按时间倒序，最多 maxItems 条）
+func collectBriefingItems(config models.BriefingConfig, now time.Time) []models.Item {
+	selected := make(map[string]bool, len(config.Folders))
+	for _, id := range config.Folders {
+		selected["folder:"+id] = true
+	}
+	since := now.Add(-24 * time.Hour)
+
+	seen := make(map[string]bool)
+	var items []models.Item
+	for _, feed := range GetFeeds() {
+		if !feed.IsFolder || (len(selected) > 0 && !selected[feed.Link]) {
+			continue
+		}
+		for _, item := range feed.Items {
+			if item.Link == "" || seen[item.Link] || IsRead(item.Link) || strings.Contains(item.Title, "⚠️") {
+				continue
+			}
+			if t, ok := getItemSortTime(item); !ok || t.Before(since) {
+				continue
+			}
+			seen[item.Link] = true
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return compareItemsByRecency(items[i], items[j]) > 0
+	})
+	if limit := config.GetMaxItems(); len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// requestBriefing 调用 AI 按主题归纳条目，结果写入 briefing
+func requestBriefing(briefing *Briefing, items []models.Item, config models.BriefingConfig) error {
+	aiConfig := globals.RssUrls.AIClassify
+
+	prompt := "你是一名资讯编辑，负责撰写每日简报。用户会给出今天的未读条目（编号、来源、标题、摘要）。" +
+		"请把相关条目归为 3 到 8 个主题，每个主题写一句标题和 2 到 3 句概述，并用一段话概括今天的整体情况。" +
+		"不重要的条目可以不归入任何主题。" +
+		"只输出 JSON：{\"summary\": \"整体概述\", \"topics\": [{\"title\": \"主题\", \"summary\": \"概述\", \"items\": [编号]}]}，不要输出其他内容。"
+	if config.Prompt != "" {
+		prompt += "\n" + config.Prompt
+	}
+
+	var content strings.Builder
+	for i, item := range items {
+		content.WriteString(fmt.Sprintf("%d. [%s] %s", i, item.Source, item.Title))
+		if desc := stripHTML(item.Description); desc != "" {
+			content.WriteString(" —— " + truncateString(desc, briefingDescLength))
+		}
+		content.WriteString("\n")
+	}
+
+	reqBody := ChatRequest{
+		Model:   aiConfig.GetModel(),
+		Purpose: "briefing",
+		Messages: []ChatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: content.String()},
+		},
+		Temperature: aiConfig.GetTemperature(),
+		// 简报输出较长，不受分类用的 maxTokens 限制
+		MaxTokens: 4000,
+	}
+	jsonMode := aiConfig.GetJSONMode()
+	maybeEnableJSONObjectResponseFormat(&reqBody, jsonMode, prompt)
+
+	// 条目较多时生成耗时较长，超时时间放宽到分类配置的 4 倍
+	client := &http.Client{
+		Timeout: 4 * time.Duration(aiConfig.GetTimeout()) * time.Second,
+	}
+	chatResp, err := sendChatCompletion(client, aiConfig, jsonMode, reqBody)
+	if err != nil {
+		return err
+	}
+
+	var parsed struct {
+		Summary string `json:"summary"`
+		Topics  []struct {
+			Title   string            `json:"title"`
+			Summary string            `json:"summary"`
+			Items   []json.RawMessage `json:"items"`
+		} `json:"topics"`
+	}
+	responseContent := extractJSON(stripCodeFences(chatResp.Choices[0].Message.Content))
+	if err := json.Unmarshal([]byte(responseContent), &parsed); err != nil {
+		return fmt.Errorf("解析简报失败: %w", err)
+	}
+
+	briefing.Summary = strings.TrimSpace(parsed.Summary)
+	for _, t := range parsed.Topics {
+		topic := BriefingTopic{Title: strings.TrimSpace(t.Title), Summary: strings.TrimSpace(t.Summary), Items: []BriefingItem{}}
+		for _, raw := range t.Items {
+			// 编号可能以数字或字符串形式返回
+			index, err := strconv.Atoi(strings.Trim(string(raw), `" `))
+			if err != nil || index < 0 || index >= len(items) {
+				continue
+			}
+			item := items[index]
+			topic.Items = append(topic.Items, BriefingItem{Title: item.Title, Link: item.Link, Source: item.Source})
+		}
+		if topic.Title != "" {
+			briefing.Topics = append(briefing.Topics, topic)
+		}
+	}
+	return nil
+}
+
+// SendBriefingNotification 将简报推送到通知渠道
+func SendBriefingNotification(briefing *Briefing) {
+	SendNotification("每日简报 "+briefing.Date, formatBriefingText(briefing))
+}
+
+// formatBriefingText 将简报格式化为纯文本（用于通知推送）
+func formatBriefingText(briefing *Briefing) string {
+	var b strings.Builder
+	b.WriteString(briefing.Summary)
+	for _, topic := range briefing.Topics {
+		b.WriteString("\n\n【" + topic.Title + "】\n" + topic.Summary)
+		for _, item := range topic.Items {
+			b.WriteString("\n- " + item.Title + " " + item.Link)
+		}
+	}
+	return b.String()
+}
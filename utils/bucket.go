@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"feedora/models"
+	"time"
+)
+
+// 时间分段标识（由服务端统一计算，客户端按标识渲染分段标题）
+const (
+	BucketToday     = "today"
+	BucketYesterday = "yesterday"
+	BucketThisWeek  = "thisWeek"
+	BucketThisMonth = "thisMonth"
+	BucketEarlier   = "earlier"
+)
+
+// assignTimeBuckets 根据条目时间（发布时间，缺失时为抓取时间）计算所属时间分段
+// 返回新切片，避免修改 DbMap 中共享的条目
+func assignTimeBuckets(items []models.Item, now time.Time) []models.Item {
+	if len(items) == 0 {
+		return items
+	}
+
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	yesterdayStart := todayStart.AddDate(0, 0, -1)
+	// 以周一作为一周的开始
+	weekday := int(todayStart.Weekday()+6) % 7
+	weekStart := todayStart.AddDate(0, 0, -weekday)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	result := make([]models.Item, len(items))
+	for i, item := range items {
+		result[i] = item
+		t, ok := getItemSortTime(item)
+		if !ok {
+			continue
+		}
+		t = t.In(now.Location())
+		switch {
+		case !t.Before(todayStart):
+			result[i].Bucket = BucketToday
+		case !t.Before(yesterdayStart):
+			result[i].Bucket = BucketYesterday
+		case !t.Before(weekStart):
+			result[i].Bucket = BucketThisWeek
+		case !t.Before(monthStart):
+			result[i].Bucket = BucketThisMonth
+		default:
+			result[i].Bucket = BucketEarlier
+		}
+	}
+	return result
+}
@@ -0,0 +1,355 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// categoryMigrationReloadWindow 类别迁移写入配置后，等待文件监听重新加载配置的最长时间
+const categoryMigrationReloadWindow = 30 * time.Second
+
+var (
+	// 最近一次类别迁移写入配置的时间，对应的配置重载不清除分类缓存
+	categoryMigrationAt   time.Time
+	categoryMigrationLock sync.Mutex
+)
+
+// CategoryMigrationResult 类别迁移的结果统计
+type CategoryMigrationResult struct {
+	From []string `json:"from"`
+	To   string   `json:"to"`
+	// 重命名为目标ID的类别定义数（目标类别原本不存在时）
+	Renamed int `json:"renamed"`
+	// 合并后删除的旧类别定义数
+	Removed int `json:"removed"`
+	// 更新的配置引用数（源的绑定类别/黑白名单/分类示例、文件夹条目、通知规则、父类别）
+	ConfigRefs int `json:"configRefs"`
+	// 更新的分类缓存条目数
+	CacheEntries int `json:"cacheEntries"`
+	// 更新的人工修正条目数
+	Corrections int `json:"corrections"`
+	// 更新的展示条目数
+	Items int `json:"items"`
+}
+
+// markCategoryMigration 记录类别迁移写入配置的时间
+func markCategoryMigration() {
+	categoryMigrationLock.Lock()
+	categoryMigrationAt = time.Now()
+	categoryMigrationLock.Unlock()
+}
+
+// consumeCategoryMigration 配置重载时调用：若该次重载由类别迁移触发，返回 true 并清除标记
+func consumeCategoryMigration() bool {
+	categoryMigrationLock.Lock()
+	defer categoryMigrationLock.Unlock()
+	if categoryMigrationAt.IsZero() {
+		return false
+	}
+	recent := time.Since(categoryMigrationAt) < categoryMigrationReloadWindow
+	categoryMigrationAt = time.Time{}
+	return recent
+}
+
+// categoryMigrator 将旧类别ID替换为新ID
+type categoryMigrator struct {
+	from map[string]bool
+	to   string
+}
+
+// migrateID 返回替换后的ID及是否发生替换
+func (m categoryMigrator) migrateID(id string) (string, bool) {
+	if m.from[id] {
+		return m.to, true
+	}
+	return id, false
+}
+
+// migrateIDs 替换列表中的旧ID并去重（保持原有顺序），返回新列表及是否发生替换
+func (m categoryMigrator) migrateIDs(ids []string) ([]string, bool) {
+	changed := false
+	for _, id := range ids {
+		if m.from[id] {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ids, false
+	}
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id, _ = m.migrateID(id)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result, true
+}
+
+// migrateItemCategories 替换条目（或缓存）的主类别与多标签类别，多标签去重后只剩一个时清空
+func (m categoryMigrator) migrateItemCategories(category string, categories []string) (string, []string, bool) {
+	newCategory, changed := m.migrateID(category)
+	newCategories, labelsChanged := m.migrateIDs(categories)
+	if labelsChanged && len(newCategories) <= 1 {
+		newCategories = nil
+	}
+	return newCategory, newCategories, changed || labelsChanged
+}
+
+// migrateCategoryList 迁移一组类别定义：目标ID已定义时删除旧类别，否则将第一个旧类别重命名为目标ID；
+// 同时更新父类别引用（迁移后指向自身的父类别会被清除）
+func (m categoryMigrator) migrateCategoryList(categories []models.Category, result *CategoryMigrationResult) []models.Category {
+	hasTarget := false
+	for _, cat := range categories {
+		if cat.ID == m.to {
+			hasTarget = true
+			break
+		}
+	}
+
+	migrated := make([]models.Category, 0, len(categories))
+	for _, cat := range categories {
+		if m.from[cat.ID] {
+			if hasTarget {
+				result.Removed++
+				continue
+			}
+			cat.ID = m.to
+			hasTarget = true
+			result.Renamed++
+		}
+		if parent, ok := m.migrateID(cat.ParentID); ok {
+			cat.ParentID = parent
+			result.ConfigRefs++
+		}
+		if cat.ParentID == cat.ID {
+			cat.ParentID = ""
+		}
+		migrated = append(migrated, cat)
+	}
+	return migrated
+}
+
+// affectsSource 迁移是否会改变源的分类上下文（可用类别集合或分类示例），从而使其缓存指纹失效
+// 未绑定类别的源使用全部类别，总是受影响
+func (m categoryMigrator) affectsSource(source models.Source) bool {
+	strategy := source.Classify
+	if strategy == nil || len(strategy.BoundCategories) == 0 {
+		return true
+	}
+	for _, id := range strategy.BoundCategories {
+		if m.from[id] || id == m.to {
+			return true
+		}
+	}
+	for _, example := range strategy.Examples {
+		if m.from[example.Category] {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateConfig 在配置副本上迁移类别定义及所有引用
+func (m categoryMigrator) migrateConfig(conf *models.Config, result *CategoryMigrationResult) {
+	conf.Categories = m.migrateCategoryList(conf.Categories, result)
+	for i := range conf.AIClassify.CategoryPackages {
+		pkg := &conf.AIClassify.CategoryPackages[i]
+		pkg.Categories = m.migrateCategoryList(pkg.Categories, result)
+	}
+
+	migrateRefs := func(ids *[]string) {
+		if migrated, ok := m.migrateIDs(*ids); ok {
+			*ids = migrated
+			result.ConfigRefs++
+		}
+	}
+	for i := range conf.Sources {
+		strategy := conf.Sources[i].Classify
+		if strategy == nil {
+			continue
+		}
+		migrateRefs(&strategy.BoundCategories)
+		migrateRefs(&strategy.CategoryWhitelist)
+		migrateRefs(&strategy.CategoryBlacklist)
+		for j := range strategy.Examples {
+			if category, ok := m.migrateID(strategy.Examples[j].Category); ok {
+				strategy.Examples[j].Category = category
+				result.ConfigRefs++
+			}
+		}
+	}
+	for i := range conf.Folders {
+		for j := range conf.Folders[i].Entries {
+			migrateRefs(&conf.Folders[i].Entries[j].Categories)
+		}
+	}
+	for i := range conf.Notification.Rules {
+		migrateRefs(&conf.Notification.Rules[i].Categories)
+	}
+}
+
+// MigrateCategories 将一个或多个旧类别ID重命名/合并为新ID：
+// 更新配置中的类别定义与全部引用，改写分类缓存、人工修正与展示中的条目，不触发重新分类。
+// 分类缓存与人工修正在同一数据库事务中写入，提交后再写入配置文件，配置写入失败时恢复原始记录
+func MigrateCategories(from []string, to string) (CategoryMigrationResult, error) {
+	result := CategoryMigrationResult{To: to}
+	if to == "" {
+		return result, fmt.Errorf("目标类别ID不能为空")
+	}
+	m := categoryMigrator{from: make(map[string]bool), to: to}
+	for _, id := range from {
+		if id == "" || id == to || m.from[id] {
+			continue
+		}
+		if id == "_keep" || id == "_review" {
+			return result, fmt.Errorf("不能迁移内置类别: %s", id)
+		}
+		m.from[id] = true
+		result.From = append(result.From, id)
+	}
+	if len(result.From) == 0 {
+		return result, fmt.Errorf("未指定需要迁移的旧类别ID")
+	}
+
+	globals.Lock.Lock()
+	defer globals.Lock.Unlock()
+
+	conf, err := globals.RawConfig.Clone()
+	if err != nil {
+		return result, err
+	}
+	m.migrateConfig(&conf, &result)
+	known := false
+	for _, cat := range conf.AIClassify.GetCategories(&conf) {
+		if cat.ID == to {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return result, fmt.Errorf("目标类别不存在，且配置中没有可重命名的旧类别: %s", to)
+	}
+
+	// 类别集合或分类示例变化的源，其条目的缓存指纹会失效；清空这些指纹使现有结果继续有效，
+	// 下次命中时补写新指纹（其他源的缓存指纹不受影响，保持不变）
+	affectedLinks := make(map[string]bool)
+	globals.ItemsCacheLock.RLock()
+	for _, source := range globals.RssUrls.Sources {
+		if !m.affectsSource(source) {
+			continue
+		}
+		for _, item := range globals.DbMap[source.URL].Items {
+			affectedLinks[item.Link] = true
+		}
+		for _, item := range globals.ItemsCache[source.URL] {
+			affectedLinks[item.Link] = true
+		}
+	}
+	globals.ItemsCacheLock.RUnlock()
+
+	// 计算迁移后的分类缓存与人工修正（写入数据库成功后再替换内存中的数据）
+	globals.ClassifyCacheLock.Lock()
+	defer globals.ClassifyCacheLock.Unlock()
+	cacheUpdates := make(map[string]models.ClassifyCacheEntry)
+	cacheOriginals := make(map[string]models.ClassifyCacheEntry)
+	for link, entry := range globals.ClassifyCache {
+		original := entry
+		category, categories, migrated := m.migrateItemCategories(entry.Category, entry.Categories)
+		if !migrated && (!affectedLinks[link] || entry.Hash == "") {
+			continue
+		}
+		if migrated {
+			entry.Category = category
+			entry.Categories = categories
+			result.CacheEntries++
+		}
+		entry.Hash = ""
+		cacheUpdates[link] = entry
+		cacheOriginals[link] = original
+	}
+
+	classifyCorrectionsLock.Lock()
+	defer classifyCorrectionsLock.Unlock()
+	var correctionUpdates, correctionOriginals []models.ClassifyCorrection
+	for _, c := range classifyCorrections {
+		if category, ok := m.migrateID(c.Category); ok {
+			correctionOriginals = append(correctionOriginals, c)
+			c.Category = category
+			correctionUpdates = append(correctionUpdates, c)
+		}
+	}
+
+	// 先提交数据库事务再写入配置（保存配置时会写入配置历史，不能在事务未提交时进行）
+	if err := DBMigrateClassifyCategories(cacheUpdates, correctionUpdates); err != nil {
+		return result, fmt.Errorf("类别迁移失败: %v", err)
+	}
+	// 该次配置重载不清除分类缓存：缓存已迁移到新类别ID
+	markCategoryMigration()
+	if err := SaveConfig(conf); err != nil {
+		consumeCategoryMigration()
+		// 配置写入失败时恢复数据库中的原始记录
+		if rollbackErr := DBMigrateClassifyCategories(cacheOriginals, correctionOriginals); rollbackErr != nil {
+			log.Printf("[类别迁移] 恢复分类缓存失败: %v", rollbackErr)
+		}
+		return result, fmt.Errorf("类别迁移失败: %v", err)
+	}
+
+	for link, entry := range cacheUpdates {
+		globals.ClassifyCache[link] = entry
+	}
+	for _, c := range correctionUpdates {
+		classifyCorrections[c.Link] = c
+	}
+	result.Corrections = len(correctionUpdates)
+
+	// 复制条目列表后再修改，避免影响已取出的旧列表
+	migrateItems := func(items []models.Item) ([]models.Item, int) {
+		var copied []models.Item
+		count := 0
+		for i, item := range items {
+			category, categories, ok := m.migrateItemCategories(item.Category, item.Categories)
+			if !ok {
+				continue
+			}
+			if copied == nil {
+				copied = make([]models.Item, len(items))
+				copy(copied, items)
+			}
+			copied[i].Category = category
+			copied[i].Categories = categories
+			count++
+		}
+		if copied == nil {
+			return items, 0
+		}
+		return copied, count
+	}
+	for url, feed := range globals.DbMap {
+		items, count := migrateItems(feed.Items)
+		if count > 0 {
+			feed.Items = items
+			globals.DbMap[url] = feed
+			result.Items += count
+		}
+	}
+	globals.ItemsCacheLock.Lock()
+	for url, items := range globals.ItemsCache {
+		if migrated, count := migrateItems(items); count > 0 {
+			globals.ItemsCache[url] = migrated
+		}
+	}
+	globals.ItemsCacheLock.Unlock()
+
+	log.Printf("[类别迁移] %v -> %s: 配置引用 %d 处，分类缓存 %d 条，人工修正 %d 条，展示条目 %d 篇",
+		result.From, to, result.ConfigRefs, result.CacheEntries, result.Corrections, result.Items)
+	return result, nil
+}
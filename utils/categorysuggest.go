@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"encoding/json"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// categorySuggestDefaultSample 默认参与类别建议的条目数
+	categorySuggestDefaultSample = 60
+	// categorySuggestMaxSample 参与类别建议的条目数上限
+	categorySuggestMaxSample = 200
+	// categorySuggestDescLength 每个条目发送给 AI 的摘要长度（字符）
+	categorySuggestDescLength = 150
+	// categorySuggestMaxExamples 每个建议类别返回的样本标题数
+	categorySuggestMaxExamples = 5
+)
+
+// categoryIDPattern 建议的类别ID只保留小写字母、数字与连字符
+var categoryIDPattern = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// CategoryProposal AI 建议的类别
+type CategoryProposal struct {
+	models.Category
+	// 样本中属于该类别的条目标题
+	Examples []string `json:"examples,omitempty"`
+	// ID 与现有类别重复（合并时需要改名或跳过）
+	Exists bool `json:"exists,omitempty"`
+}
+
+// CategorySuggestion 类别建议结果
+type CategorySuggestion struct {
+	// 参与建议的条目数
+	SampleSize int `json:"sampleSize"`
+	// 样本中尚未分类（或待复核）的条目数
+	Uncategorized int `json:"uncategorized"`
+	// 建议的类别
+	Categories []CategoryProposal `json:"categories"`
+}
+
+// isUncategorized 条目是否尚未归入任何实际类别
+func isUncategorized(item models.Item) bool {
+	return item.Category == "" || item.Category == "_keep" || item.Category == "_review"
+}
+
+// sampleItemsForCategorySuggestion 抽取最近的条目（rssURL 为空时从所有源抽取），优先选取未分类的条目
+func sampleItemsForCategorySuggestion(rssURL string, size int) ([]models.Item, int) {
+	var uncategorized, categorized []models.Item
+	globals.Lock.RLock()
+	for url, feed := range globals.DbMap {
+		if rssURL != "" && url != rssURL {
+			continue
+		}
+		for _, item := range feed.Items {
+			item.Source = feed.Title
+			if isUncategorized(item) {
+				uncategorized = append(uncategorized, item)
+			} else {
+				categorized = append(categorized, item)
+			}
+		}
+	}
+	globals.Lock.RUnlock()
+
+	byRecency := func(items []models.Item) {
+		sort.SliceStable(items, func(i, j int) bool {
+			return compareItemsByRecency(items[i], items[j]) > 0
+		})
+	}
+	byRecency(uncategorized)
+	byRecency(categorized)
+
+	sample := uncategorized
+	if len(sample) > size {
+		sample = sample[:size]
+	}
+	uncategorizedCount := len(sample)
+	for _, item := range categorized {
+		if len(sample) >= size {
+			break
+		}
+		sample = append(sample, item)
+	}
+	return sample, uncategorizedCount
+}
+
+// SuggestCategories 抽取最近的条目（优先未分类的条目），让 AI 提出新的类别体系（ID、名称、描述），
+// 结果仅供审阅，不会写入配置
+func SuggestCategories(rssURL string, sampleSize int) (*CategorySuggestion, error) {
+	aiConfig := globals.RssUrls.AIClassify
+	if !aiConfig.HasAPIAccess() {
+		return nil, fmt.Errorf("未配置 AI 接口")
+	}
+	if sampleSize <= 0 {
+		sampleSize = categorySuggestDefaultSample
+	} else if sampleSize > categorySuggestMaxSample {
+		sampleSize = categorySuggestMaxSample
+	}
+
+	items, uncategorized := sampleItemsForCategorySuggestion(rssURL, sampleSize)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("没有可用于建议类别的条目")
+	}
+
+	existing := aiConfig.GetCategories(&globals.RssUrls)
+	existingIDs := make(map[string]bool, len(existing))
+	prompt := "你是一名信息架构师，负责为 RSS 阅读器设计文章分类体系。用户会给出一批最近的文章（编号、来源、标题、摘要）。" +
+		"请归纳出 3 到 10 个新的类别，覆盖现有类别无法很好归类的文章；每个类别给出 id（小写英文，单词间用连字符）、简短的中文名称，" +
+		"以及一句用于指导 AI 分类的描述（说明包含和不包含哪些内容），并列出属于该类别的文章编号。不要重复现有类别。" +
+		"只输出 JSON：{\"categories\": [{\"id\": \"类别ID\", \"name\": \"名称\", \"description\": \"描述\", \"items\": [编号]}]}，不要输出其他内容。"
+	if len(existing) > 0 {
+		var b strings.Builder
+		b.WriteString("\n\n现有类别：\n")
+		for _, cat := range existing {
+			existingIDs[cat.ID] = true
+			b.WriteString(fmt.Sprintf("- %s (%s): %s\n", cat.ID, cat.Name, cat.Description))
+		}
+		prompt += b.String()
+	}
+
+	var content strings.Builder
+	for i, item := range items {
+		content.WriteString(fmt.Sprintf("%d. [%s] %s", i, item.Source, item.Title))
+		if desc := stripHTML(item.Description); desc != "" {
+			content.WriteString(" —— " + truncateString(desc, categorySuggestDescLength))
+		}
+		content.WriteString("\n")
+	}
+
+	reqBody := ChatRequest{
+		Model:   aiConfig.GetModel(),
+		Purpose: "category-suggest",
+		Messages: []ChatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: content.String()},
+		},
+		Temperature: aiConfig.GetTemperature(),
+		// 类别体系输出较长，不受分类用的 maxTokens 限制
+		MaxTokens: 4000,
+	}
+	jsonMode := aiConfig.GetJSONMode()
+	maybeEnableJSONObjectResponseFormat(&reqBody, jsonMode, prompt)
+
+	// 样本较多时生成耗时较长，超时时间放宽到分类配置的 4 倍
+	client := &http.Client{
+		Timeout: 4 * time.Duration(aiConfig.GetTimeout()) * time.Second,
+	}
+	chatResp, err := sendChatCompletion(client, aiConfig, jsonMode, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Categories []struct {
+			ID          string            `json:"id"`
+			Name        string            `json:"name"`
+			Description string            `json:"description"`
+			Items       []json.RawMessage `json:"items"`
+		} `json:"categories"`
+	}
+	responseContent := extractJSON(stripCodeFences(chatResp.Choices[0].Message.Content))
+	if err := json.Unmarshal([]byte(responseContent), &parsed); err != nil {
+		return nil, fmt.Errorf("解析类别建议失败: %w", err)
+	}
+
+	suggestion := &CategorySuggestion{
+		SampleSize:    len(items),
+		Uncategorized: uncategorized,
+		Categories:    []CategoryProposal{},
+	}
+	seen := make(map[string]bool)
+	for _, c := range parsed.Categories {
+		id := strings.Trim(categoryIDPattern.ReplaceAllString(strings.ToLower(strings.TrimSpace(c.ID)), "-"), "-")
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		proposal := CategoryProposal{
+			Category: models.Category{
+				ID:          id,
+				Name:        strings.TrimSpace(c.Name),
+				Description: strings.TrimSpace(c.Description),
+			},
+			Exists: existingIDs[id],
+		}
+		if proposal.Name == "" {
+			proposal.Name = id
+		}
+		for _, raw := range c.Items {
+			// 编号可能以数字或字符串形式返回
+			index, err := strconv.Atoi(strings.Trim(string(raw), `" `))
+			if err != nil || index < 0 || index >= len(items) {
+				continue
+			}
+			proposal.Examples = append(proposal.Examples, items[index].Title)
+			if len(proposal.Examples) >= categorySuggestMaxExamples {
+				break
+			}
+		}
+		suggestion.Categories = append(suggestion.Categories, proposal)
+	}
+	return suggestion, nil
+}
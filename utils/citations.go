@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// sourceCitations 单个源的引用索引
+type sourceCitations struct {
+	// 条目链接 -> 正文中的外链（已规范化）
+	Outbound map[string][]string
+	// 规范化链接 -> 条目链接
+	Links map[string]string
+}
+
+// CitationRef 引用关系中的条目
+type CitationRef struct {
+	Link   string `json:"link"`
+	Title  string `json:"title,omitempty"`
+	Source string `json:"source,omitempty"`
+	Read   bool   `json:"read"`
+}
+
+// ItemCitations 条目的引用关系
+type ItemCitations struct {
+	Link string `json:"link"`
+	// 正文中的外链总数
+	OutboundLinks int `json:"outboundLinks"`
+	// 该条目引用的已收录条目
+	Cites []CitationRef `json:"cites"`
+	// 引用了该条目的已收录条目
+	CitedBy []CitationRef `json:"citedBy"`
+}
+
+var (
+	// 引用索引: map[RSS URL] -> 该源的引用索引（每次源更新时整体替换）
+	citationSources = make(map[string]*sourceCitations)
+	// 合并后的索引（由 citationSources 重建）: 条目链接 -> 外链，规范化链接 -> 条目链接
+	citationOutbound    = make(map[string][]string)
+	citationTargets     = make(map[string]string)
+	citationSourcesLock sync.RWMutex
+
+	citationAnchorPattern = regexp.MustCompile(`(?is)<a\b[^>]*>`)
+)
+
+// indexItemCitations 解析源中各条目正文的外链，更新引用索引
+// 条目缓存合并进来的旧条目没有正文，沿用上一次解析的结果
+func indexItemCitations(sourceURL string, items []models.Item) {
+	citationSourcesLock.RLock()
+	previous := citationSources[sourceURL]
+	citationSourcesLock.RUnlock()
+
+	index := &sourceCitations{
+		Outbound: make(map[string][]string),
+		Links:    make(map[string]string, len(items)),
+	}
+	for _, item := range items {
+		self := normalizeCitationURL(item.Link)
+		if self != "" {
+			index.Links[self] = item.Link
+		}
+		if item.Description == "" {
+			if previous != nil {
+				if outbound, ok := previous.Outbound[item.Link]; ok {
+					index.Outbound[item.Link] = outbound
+				}
+			}
+			continue
+		}
+		if outbound := extractOutboundLinks(item.Description, item.Link, self); len(outbound) > 0 {
+			index.Outbound[item.Link] = outbound
+		}
+	}
+
+	citationSourcesLock.Lock()
+	citationSources[sourceURL] = index
+	// 顺带清理已从配置中删除的源，并重建合并索引
+	outbound := make(map[string][]string)
+	targets := make(map[string]string)
+	for u, idx := range citationSources {
+		if globals.RssUrls.GetSourceByURL(u) == nil {
+			delete(citationSources, u)
+			continue
+		}
+		for link, links := range idx.Outbound {
+			outbound[link] = links
+		}
+		for normalized, link := range idx.Links {
+			targets[normalized] = link
+		}
+	}
+	citationOutbound = outbound
+	citationTargets = targets
+	citationSourcesLock.Unlock()
+}
+
+// extractOutboundLinks 提取正文中的 <a href> 外链（相对地址按条目链接解析，去重并排除指向自身的链接）
+func extractOutboundLinks(content, itemLink, self string) []string {
+	base, _ := url.Parse(itemLink)
+	seen := make(map[string]bool)
+	var links []string
+	for _, tag := range citationAnchorPattern.FindAllString(content, -1) {
+		href := strings.TrimSpace(parseHTMLAttrs(tag)["href"])
+		if href == "" || strings.HasPrefix(href, "#") {
+			continue
+		}
+		if base != nil {
+			if resolved, err := base.Parse(href); err == nil {
+				href = resolved.String()
+			}
+		}
+		normalized := normalizeCitationURL(href)
+		if normalized == "" || normalized == self || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		links = append(links, normalized)
+	}
+	return links
+}
+
+// normalizeCitationURL 规范化链接用于比对：忽略协议、www 前缀、锚点、末尾斜杠与 utm_* 跟踪参数
+// 非 http(s) 链接返回空字符串
+func normalizeCitationURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	normalized := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if encoded := query.Encode(); encoded != "" {
+		normalized += "?" + encoded
+	}
+	return normalized
+}
+
+// annotateReadCitations 为条目填写“引用了多少条已读条目”
+// items 需为副本（如 assignTimeBuckets 的返回值），避免修改 DbMap 中共享的条目
+func annotateReadCitations(items []models.Item) {
+	citationSourcesLock.RLock()
+	defer citationSourcesLock.RUnlock()
+
+	for i := range items {
+		count := 0
+		for _, normalized := range citationOutbound[items[i].Link] {
+			if target, ok := citationTargets[normalized]; ok && IsRead(target) {
+				count++
+			}
+		}
+		items[i].ReadCitations = count
+	}
+}
+
+// GetItemCitations 获取条目的引用关系：它引用了哪些已收录条目，以及被哪些已收录条目引用
+func GetItemCitations(link string) (ItemCitations, bool) {
+	result := ItemCitations{Link: link, Cites: []CitationRef{}, CitedBy: []CitationRef{}}
+	self := normalizeCitationURL(link)
+
+	citationSourcesLock.RLock()
+	outbound, hasOutbound := citationOutbound[link]
+	_, indexed := citationTargets[self]
+	var cites, citedBy []string
+	for _, normalized := range outbound {
+		if target, ok := citationTargets[normalized]; ok {
+			cites = append(cites, target)
+		}
+	}
+	if self != "" {
+		for citer, links := range citationOutbound {
+			for _, normalized := range links {
+				if normalized == self {
+					citedBy = append(citedBy, citer)
+					break
+				}
+			}
+		}
+	}
+	citationSourcesLock.RUnlock()
+
+	if !hasOutbound && !indexed {
+		return result, false
+	}
+	result.OutboundLinks = len(outbound)
+	for _, l := range cites {
+		result.Cites = append(result.Cites, buildCitationRef(l))
+	}
+	for _, l := range citedBy {
+		result.CitedBy = append(result.CitedBy, buildCitationRef(l))
+	}
+	return result, true
+}
+
+// buildCitationRef 根据条目链接构建引用条目信息
+func buildCitationRef(link string) CitationRef {
+	ref := CitationRef{Link: link, Read: IsRead(link)}
+	if item, sourceURL, ok := findCachedItem(link); ok {
+		ref.Title = item.Title
+		ref.Source = getSourceDisplayName(sourceURL)
+	}
+	return ref
+}
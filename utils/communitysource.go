@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"feedora/models"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// gofeed.Item.Custom 中保存热度信息使用的键
+const (
+	customKeyScore    = "score"
+	customKeyComments = "comments"
+)
+
+// redditListing Reddit 列表接口响应（仅包含用到的字段）
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data struct {
+				Title        string  `json:"title"`
+				Permalink    string  `json:"permalink"`
+				URL          string  `json:"url"`
+				CreatedUTC   float64 `json:"created_utc"`
+				Score        int     `json:"score"`
+				NumComments  int     `json:"num_comments"`
+				SelftextHTML string  `json:"selftext_html"`
+				Stickied     bool    `json:"stickied"`
+				IsSelf       bool    `json:"is_self"`
+			} `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+// hnSearchResponse HN Algolia 搜索接口响应
+type hnSearchResponse struct {
+	Hits []struct {
+		ObjectID    string `json:"objectID"`
+		Title       string `json:"title"`
+		URL         string `json:"url"`
+		Points      int    `json:"points"`
+		NumComments int    `json:"num_comments"`
+		CreatedAtI  int64  `json:"created_at_i"`
+		StoryText   string `json:"story_text"`
+	} `json:"hits"`
+}
+
+// fetchRedditFeed 通过 Reddit JSON API 抓取版块帖子
+// 源地址形如 https://www.reddit.com/r/golang 或 https://www.reddit.com/r/golang/top?t=day
+func fetchRedditFeed(source models.Source) (*gofeed.Feed, error) {
+	u, err := url.Parse(source.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("无效的 Reddit 地址: %s", source.URL)
+	}
+	options := source.GetCommunityOptions()
+
+	apiURL := *u
+	apiURL.Path = strings.TrimSuffix(u.Path, "/") + ".json"
+	query := apiURL.Query()
+	query.Set("limit", strconv.Itoa(options.GetLimit(100)))
+	query.Set("raw_json", "1")
+	apiURL.RawQuery = query.Encode()
+
+	var listing redditListing
+	if err := fetchJSON(apiURL.String(), nil, &listing); err != nil {
+		return nil, err
+	}
+
+	feed := &gofeed.Feed{
+		Title: strings.Trim(u.Path, "/"),
+		Link:  source.URL,
+		Items: make([]*gofeed.Item, 0, len(listing.Data.Children)),
+	}
+	for _, child := range listing.Data.Children {
+		post := child.Data
+		if post.Stickied || post.Score < options.MinScore {
+			continue
+		}
+
+		commentsURL := "https://www.reddit.com" + post.Permalink
+		link := commentsURL
+		if !options.LinkToComments && !post.IsSelf && post.URL != "" {
+			link = post.URL
+		}
+		description := post.SelftextHTML
+		if !post.IsSelf && post.URL != "" {
+			description = fmt.Sprintf(`<p><a href="%s">%s</a></p>%s`, html.EscapeString(post.URL), html.EscapeString(post.URL), description)
+		}
+
+		feed.Items = append(feed.Items, &gofeed.Item{
+			Title:           post.Title,
+			Link:            link,
+			Description:     description,
+			PublishedParsed: parseUnixTimestamp(int64(post.CreatedUTC)),
+			Custom:          engagementCustom(post.Score, post.NumComments),
+		})
+	}
+	return feed, nil
+}
+
+// fetchHackerNewsFeed 通过 HN Algolia API 抓取 Hacker News 列表
+// 源地址对应 HN 页面：https://news.ycombinator.com/（首页）、/newest、/ask、/show、/jobs
+func fetchHackerNewsFeed(source models.Source) (*gofeed.Feed, error) {
+	u, err := url.Parse(source.URL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 Hacker News 地址: %s", source.URL)
+	}
+	options := source.GetCommunityOptions()
+
+	endpoint := "search"
+	var tags, title string
+	switch strings.Trim(u.Path, "/") {
+	case "", "news", "front":
+		tags, title = "front_page", "Hacker News"
+	case "newest":
+		endpoint, tags, title = "search_by_date", "story", "Hacker News: Newest"
+	case "ask":
+		endpoint, tags, title = "search_by_date", "ask_hn", "Ask HN"
+	case "show":
+		endpoint, tags, title = "search_by_date", "show_hn", "Show HN"
+	case "jobs":
+		endpoint, tags, title = "search_by_date", "job", "HN Jobs"
+	default:
+		return nil, fmt.Errorf("无法识别的 Hacker News 地址: %s", source.URL)
+	}
+
+	query := url.Values{}
+	query.Set("tags", tags)
+	query.Set("hitsPerPage", strconv.Itoa(options.GetLimit(100)))
+	if options.MinScore > 0 {
+		query.Set("numericFilters", fmt.Sprintf("points>=%d", options.MinScore))
+	}
+	apiURL := fmt.Sprintf("https://hn.algolia.com/api/v1/%s?%s", endpoint, query.Encode())
+
+	var response hnSearchResponse
+	if err := fetchJSON(apiURL, nil, &response); err != nil {
+		return nil, err
+	}
+
+	feed := &gofeed.Feed{
+		Title: title,
+		Link:  source.URL,
+		Items: make([]*gofeed.Item, 0, len(response.Hits)),
+	}
+	for _, hit := range response.Hits {
+		if hit.Title == "" || hit.Points < options.MinScore {
+			continue
+		}
+		commentsURL := "https://news.ycombinator.com/item?id=" + hit.ObjectID
+		link := commentsURL
+		if !options.LinkToComments && hit.URL != "" {
+			link = hit.URL
+		}
+		description := hit.StoryText
+		if hit.URL != "" {
+			description = fmt.Sprintf(`<p><a href="%s">评论</a></p>%s`, commentsURL, description)
+		}
+
+		feed.Items = append(feed.Items, &gofeed.Item{
+			Title:           hit.Title,
+			Link:            link,
+			Description:     description,
+			PublishedParsed: parseUnixTimestamp(hit.CreatedAtI),
+			Custom:          engagementCustom(hit.Points, hit.NumComments),
+		})
+	}
+	return feed, nil
+}
+
+// engagementCustom 将热度信息写入 gofeed.Item.Custom，随条目进入处理流程
+func engagementCustom(score, comments int) map[string]string {
+	return map[string]string{
+		customKeyScore:    strconv.Itoa(score),
+		customKeyComments: strconv.Itoa(comments),
+	}
+}
+
+// parseItemEngagement 从 gofeed.Item.Custom 中读取热度分数与评论数
+func parseItemEngagement(item *gofeed.Item) (int, int) {
+	if item == nil || item.Custom == nil {
+		return 0, 0
+	}
+	score, _ := strconv.Atoi(item.Custom[customKeyScore])
+	comments, _ := strconv.Atoi(item.Custom[customKeyComments])
+	return score, comments
+}
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	// configHistoryLimit 保留的配置版本数
+	configHistoryLimit = 100
+	// configDiffContext 差异输出中变更行前后保留的上下文行数
+	configDiffContext = 3
+)
+
+// marshalConfig 按配置文件的格式序列化配置
+func marshalConfig(config models.Config) ([]byte, error) {
+	return json.MarshalIndent(config, "", "    ")
+}
+
+// recordConfigSnapshot 记录配置版本（内容与最新版本相同时跳过）
+// reason: startup（启动时）/ save（通过接口保存）/ reload（文件被修改后重新加载）/ rollback #id（回滚）
+func recordConfigSnapshot(config models.Config, reason string) {
+	if DB == nil {
+		return
+	}
+	data, err := marshalConfig(config)
+	if err != nil {
+		log.Printf("[配置历史] 序列化配置失败: %v", err)
+		return
+	}
+	sum := sha256.Sum256(data)
+	added, err := DBSaveConfigSnapshot(reason, hex.EncodeToString(sum[:]), string(data), configHistoryLimit)
+	if err != nil {
+		log.Printf("[配置历史] 保存配置版本失败: %v", err)
+		return
+	}
+	if added {
+		log.Printf("[配置历史] 已记录配置版本 | 原因: %s", reason)
+	}
+}
+
+// ListConfigVersions 列出所有配置版本（按时间倒序）
+func ListConfigVersions() ([]DBConfigSnapshot, error) {
+	return DBListConfigSnapshots()
+}
+
+// GetConfigVersion 获取指定版本的配置
+func GetConfigVersion(id int64) (models.Config, error) {
+	var config models.Config
+	snapshot, err := DBGetConfigSnapshot(id)
+	if err != nil {
+		return config, fmt.Errorf("配置版本不存在: %d", id)
+	}
+	err = json.Unmarshal([]byte(snapshot.Data), &config)
+	return config, err
+}
+
+// DiffConfigVersions 比较两个配置版本，返回统一差异格式的文本及新增、删除的行数
+// id 为 0 表示当前配置
+func DiffConfigVersions(fromID, toID int64) (string, int, int, error) {
+	from, err := configVersionLines(fromID)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	to, err := configVersionLines(toID)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	diff, added, removed := diffLines(from, to)
+	return diff, added, removed, nil
+}
+
+// RollbackConfig 将配置回滚到指定版本（写入配置文件后由文件监听自动重新加载）
+func RollbackConfig(id int64) error {
+	config, err := GetConfigVersion(id)
+	if err != nil {
+		return err
+	}
+	if err := writeConfigFile(config); err != nil {
+		return err
+	}
+	recordConfigSnapshot(config, fmt.Sprintf("rollback #%d", id))
+	log.Printf("[配置历史] 已回滚到版本 #%d", id)
+	return nil
+}
+
+// configVersionLines 获取配置版本按行拆分的内容
+func configVersionLines(id int64) ([]string, error) {
+	if id == 0 {
+		data, err := marshalConfig(globals.RawConfig)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Split(string(data), "\n"), nil
+	}
+	snapshot, err := DBGetConfigSnapshot(id)
+	if err != nil {
+		return nil, fmt.Errorf("配置版本不存在: %d", id)
+	}
+	return strings.Split(snapshot.Data, "\n"), nil
+}
+
+// diffLines 计算两段文本的行级差异，输出带上下文的统一差异格式
+func diffLines(a, b []string) (string, int, int) {
+	// 去掉相同的首尾，只对中间部分计算最长公共子序列
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type diffLine struct {
+		op   byte
+		text string
+	}
+	lines := make([]diffLine, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	added, removed := 0, 0
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			lines = append(lines, diffLine{' ', midA[i]})
+			i++
+			j++
+		case i < len(midA) && (j == len(midB) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', midA[i]})
+			removed++
+			i++
+		default:
+			lines = append(lines, diffLine{'+', midB[j]})
+			added++
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', line})
+	}
+
+	// 仅输出变更行及其上下文
+	keep := make([]bool, len(lines))
+	for idx, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+		for k := idx - configDiffContext; k <= idx+configDiffContext; k++ {
+			if k >= 0 && k < len(lines) {
+				keep[k] = true
+			}
+		}
+	}
+	var sb strings.Builder
+	lineA, lineB := 1, 1
+	for idx, line := range lines {
+		if keep[idx] {
+			if idx == 0 || !keep[idx-1] {
+				sb.WriteString(fmt.Sprintf("@@ -%d +%d @@\n", lineA, lineB))
+			}
+			sb.WriteByte(line.op)
+			sb.WriteString(line.text)
+			sb.WriteByte('\n')
+		}
+		if line.op != '+' {
+			lineA++
+		}
+		if line.op != '-' {
+			lineB++
+		}
+	}
+	return sb.String(), added, removed
+}
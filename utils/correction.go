@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// 人工修正的分类结果: map[条目原始链接] -> 修正
+	classifyCorrections     = make(map[string]models.ClassifyCorrection)
+	classifyCorrectionsLock sync.RWMutex
+)
+
+// loadClassifyCorrections 加载人工修正的分类结果
+func loadClassifyCorrections() {
+	loaded, err := DBLoadClassifyCorrections()
+	if err != nil {
+		log.Printf("读取分类修正失败: %v", err)
+		return
+	}
+
+	classifyCorrectionsLock.Lock()
+	classifyCorrections = loaded
+	classifyCorrectionsLock.Unlock()
+
+	log.Printf("[数据加载] 分类修正: 已加载 %d 条", len(loaded))
+}
+
+// purgeExpiredCorrections 数据保留：删除修正时间早于截止时间或对应条目已被清理的人工修正，返回删除条数
+func purgeExpiredCorrections(cutoff int64, purgedLinks map[string]bool) int {
+	var links []string
+	classifyCorrectionsLock.Lock()
+	for link, c := range classifyCorrections {
+		if c.CreatedAt < cutoff || purgedLinks[link] {
+			delete(classifyCorrections, link)
+			links = append(links, link)
+		}
+	}
+	classifyCorrectionsLock.Unlock()
+
+	if len(links) > 0 {
+		if err := DBDeleteClassifyCorrectionsBatch(links); err != nil {
+			log.Printf("[数据保留] 删除分类修正失败: %v", err)
+		}
+	}
+	// 数据库中可能残留未加载到内存的记录
+	n, err := DBDeleteClassifyCorrectionsOlderThan(cutoff)
+	if err != nil {
+		log.Printf("[数据保留] 删除分类修正失败: %v", err)
+	}
+	return len(links) + n
+}
+
+// getClassifyCorrection 获取条目的人工修正（link 为分类时使用的原始链接）
+func getClassifyCorrection(link string) (models.ClassifyCorrection, bool) {
+	classifyCorrectionsLock.RLock()
+	defer classifyCorrectionsLock.RUnlock()
+	c, ok := classifyCorrections[link]
+	return c, ok
+}
+
+// GetClassifyCorrections 获取人工修正列表（rssURL 为空时返回全部），按修正时间倒序
+func GetClassifyCorrections(rssURL string) []models.ClassifyCorrection {
+	classifyCorrectionsLock.RLock()
+	result := make([]models.ClassifyCorrection, 0, len(classifyCorrections))
+	for _, c := range classifyCorrections {
+		if rssURL == "" || c.RssURL == rssURL {
+			result = append(result, c)
+		}
+	}
+	classifyCorrectionsLock.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt > result[j].CreatedAt
+	})
+	return result
+}
+
+// recentCorrectionExamples 获取源最近的人工修正，作为少样本示例注入分类提示词
+func recentCorrectionExamples(rssURL string, limit int) []models.ClassifyExample {
+	if limit <= 0 {
+		return nil
+	}
+	corrections := GetClassifyCorrections(rssURL)
+	examples := make([]models.ClassifyExample, 0, limit)
+	for _, c := range corrections {
+		if c.Title == "" {
+			continue
+		}
+		examples = append(examples, models.ClassifyExample{Title: c.Title, Category: c.Category})
+		if len(examples) >= limit {
+			break
+		}
+	}
+	return examples
+}
+
+// CorrectItemCategory 人工修正条目的类别：保存修正记录、覆盖分类缓存，并立即更新展示中的条目
+// 修正后该条目不再交给 AI 分类，类别黑白名单等过滤在下次处理该源时按新类别生效
+func CorrectItemCategory(rssURL, link, category string) (models.ClassifyCorrection, error) {
+	known := false
+	for _, cat := range globals.RssUrls.AIClassify.GetCategories(&globals.RssUrls) {
+		if cat.ID == category {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return models.ClassifyCorrection{}, fmt.Errorf("未知的类别ID: %s", category)
+	}
+
+	// 在展示中的条目里查找，链接可以是后处理前或后处理后的链接
+	globals.Lock.RLock()
+	feed, ok := globals.DbMap[rssURL]
+	var target models.Item
+	found := false
+	if ok {
+		for _, item := range feed.Items {
+			if item.Link == link || item.OriginalLink == link {
+				target = item
+				found = true
+				break
+			}
+		}
+	}
+	globals.Lock.RUnlock()
+	if !found {
+		return models.ClassifyCorrection{}, fmt.Errorf("条目不存在: %s", link)
+	}
+
+	key := reclassifyKey(target)
+	title := target.Title
+	if target.OriginalTitle != "" {
+		title = target.OriginalTitle
+	}
+	correction := models.ClassifyCorrection{
+		Link:      key,
+		RssURL:    rssURL,
+		Title:     title,
+		Category:  category,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := DBSaveClassifyCorrection(correction); err != nil {
+		return correction, fmt.Errorf("保存分类修正失败: %v", err)
+	}
+	classifyCorrectionsLock.Lock()
+	classifyCorrections[key] = correction
+	classifyCorrectionsLock.Unlock()
+
+	// 覆盖分类缓存，保留相关度与内容指纹
+	globals.ClassifyCacheLock.Lock()
+	entry := globals.ClassifyCache[key]
+	entry.Category = category
+	entry.Categories = nil
+	entry.Confidence = nil
+	entry.ClassifiedAt = time.Now().Unix()
+	globals.ClassifyCache[key] = entry
+	globals.ClassifyCacheLock.Unlock()
+	go DBSaveClassifyCache(key, entry)
+
+	// 复制条目列表后再修改，避免影响已取出的旧列表
+	globals.Lock.Lock()
+	if feed, ok := globals.DbMap[rssURL]; ok {
+		items := make([]models.Item, len(feed.Items))
+		copy(items, feed.Items)
+		for i, item := range items {
+			if reclassifyKey(item) == key {
+				items[i].Category = category
+				items[i].Categories = nil
+				items[i].Confidence = nil
+			}
+		}
+		feed.Items = items
+		globals.DbMap[rssURL] = feed
+	}
+	globals.Lock.Unlock()
+
+	log.Printf("[分类修正] 源 [%s]: 文章 [%s] 修正为 %s", rssURL, title, category)
+	return correction, nil
+}
+
+// DeleteClassifyCorrection 删除人工修正，该条目在下次处理时重新交给 AI 分类
+func DeleteClassifyCorrection(link string) error {
+	classifyCorrectionsLock.Lock()
+	_, ok := classifyCorrections[link]
+	delete(classifyCorrections, link)
+	classifyCorrectionsLock.Unlock()
+	if !ok {
+		return fmt.Errorf("分类修正不存在: %s", link)
+	}
+
+	globals.ClassifyCacheLock.Lock()
+	delete(globals.ClassifyCache, link)
+	globals.ClassifyCacheLock.Unlock()
+	go DBDeleteClassifyCache(link)
+
+	return DBDeleteClassifyCorrection(link)
+}
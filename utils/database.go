@@ -2,8 +2,12 @@ package utils
 
 import (
 	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"feedora/models"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"time"
@@ -74,6 +78,19 @@ func createTables() error {
 		return fmt.Errorf("创建 read_state 表失败: %w", err)
 	}
 
+	// 已读状态变化日志表（多端同步用，每个链接只保留最近一次变化，seq 即同步游标）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS read_state_changes (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			link TEXT NOT NULL UNIQUE,
+			read INTEGER NOT NULL,
+			changed_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 read_state_changes 表失败: %w", err)
+	}
+
 	// 后处理缓存表
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS postprocess_cache (
@@ -117,6 +134,214 @@ func createTables() error {
 		return fmt.Errorf("创建 icon_cache 表失败: %w", err)
 	}
 
+	// 条目图片代理缓存表
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS image_cache (
+			url TEXT PRIMARY KEY,
+			data BLOB NOT NULL,
+			mime_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 image_cache 表失败: %w", err)
+	}
+
+	// 文章页面 og:image 缓存表（image 为空表示页面没有图片）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS og_image_cache (
+			link TEXT PRIMARY KEY,
+			image TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 og_image_cache 表失败: %w", err)
+	}
+
+	// 卡片展示选项覆盖表
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS display_overrides (
+			link TEXT PRIMARY KEY,
+			flags TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 display_overrides 表失败: %w", err)
+	}
+
+	// 故事追踪表
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS follows (
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			seed_link TEXT NOT NULL,
+			keywords TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			archived INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 follows 表失败: %w", err)
+	}
+
+	// 故事追踪线索条目表
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS follow_items (
+			follow_id TEXT NOT NULL,
+			link TEXT NOT NULL,
+			title TEXT,
+			source TEXT,
+			source_url TEXT,
+			pub_date TEXT,
+			score REAL,
+			matched_at INTEGER NOT NULL,
+			UNIQUE(follow_id, link)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 follow_items 表失败: %w", err)
+	}
+
+	// 配置历史版本表
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS config_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			hash TEXT NOT NULL,
+			data TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 config_history 表失败: %w", err)
+	}
+
+	// 条目UID表（为条目链接分配稳定的整数ID，供外部同步服务使用）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS item_uids (
+			uid INTEGER PRIMARY KEY AUTOINCREMENT,
+			link TEXT UNIQUE NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 item_uids 表失败: %w", err)
+	}
+
+	// 外部ID映射表（条目UID ↔ 外部服务 ↔ 外部ID，以及最近一次与该服务同步的已读状态）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS item_external_ids (
+			uid INTEGER NOT NULL,
+			service TEXT NOT NULL,
+			external_id TEXT NOT NULL,
+			synced_read INTEGER NOT NULL DEFAULT -1,
+			synced_at INTEGER NOT NULL DEFAULT 0,
+			UNIQUE(service, external_id),
+			UNIQUE(uid, service)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 item_external_ids 表失败: %w", err)
+	}
+
+	// 条目语义向量表（按模型区分，切换模型后旧向量不再使用）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS item_embeddings (
+			link TEXT PRIMARY KEY,
+			model TEXT NOT NULL,
+			vector BLOB NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 item_embeddings 表失败: %w", err)
+	}
+
+	// 标题翻译缓存表（按目标语言与原文的哈希索引）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS translation_cache (
+			hash TEXT PRIMARY KEY,
+			text TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 translation_cache 表失败: %w", err)
+	}
+
+	// AI 调用每日用量表
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS llm_usage (
+			date TEXT PRIMARY KEY,
+			prompt_tokens INTEGER NOT NULL DEFAULT 0,
+			completion_tokens INTEGER NOT NULL DEFAULT 0,
+			requests INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 llm_usage 表失败: %w", err)
+	}
+
+	// 人工修正的分类结果表
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS classify_corrections (
+			link TEXT PRIMARY KEY,
+			rss_url TEXT NOT NULL,
+			title TEXT,
+			category TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 classify_corrections 表失败: %w", err)
+	}
+
+	// 订阅源健康状态表（持续失败中的源，重启后继续累计失败时长）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS source_health (
+			url TEXT PRIMARY KEY,
+			failures INTEGER NOT NULL,
+			failing_since INTEGER NOT NULL,
+			dead_since INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 source_health 表失败: %w", err)
+	}
+
+	// 过滤规则命中记录表（同一条目被同一规则命中只记录一次）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS filter_hits (
+			source_url TEXT NOT NULL,
+			rule_type TEXT NOT NULL,
+			rule TEXT NOT NULL,
+			link TEXT NOT NULL,
+			hit_at INTEGER NOT NULL,
+			PRIMARY KEY (source_url, rule_type, rule, link)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 filter_hits 表失败: %w", err)
+	}
+
+	// 收藏条目表（从其他阅读器导入的收藏）
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS starred_items (
+			link TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			source_url TEXT NOT NULL DEFAULT '',
+			source_title TEXT NOT NULL DEFAULT '',
+			pub_date TEXT NOT NULL DEFAULT '',
+			starred_at INTEGER NOT NULL,
+			origin TEXT NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 starred_items 表失败: %w", err)
+	}
+
 	// 创建索引
 	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_items_cache_rss_url ON items_cache(rss_url)`)
 	if err != nil {
@@ -125,6 +350,36 @@ func createTables() error {
 
 	// 数据库迁移：为 items_cache 添加 fetch_time 列（兼容旧版本）
 	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN fetch_time TEXT`)
+	// 数据库迁移：为 items_cache 添加 score / comments 列（Reddit、HN 等源的热度信息）
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN score INTEGER`)
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN comments INTEGER`)
+	// 数据库迁移：为 items_cache 添加 thumbnail / duration 列（YouTube 等视频源）
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN thumbnail TEXT`)
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN duration INTEGER`)
+	// 数据库迁移：为 items_cache 添加 original_title 列（标题翻译前的原文）
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN original_title TEXT`)
+	// 数据库迁移：为 items_cache 添加 metadata 列（后处理附加的元数据，JSON 对象）
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN metadata TEXT`)
+	// 数据库迁移：为 items_cache 添加 content_hash / updated / last_changed 列（检测已发布条目的修改）
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN content_hash TEXT`)
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN updated INTEGER`)
+	_, _ = DB.Exec(`ALTER TABLE items_cache ADD COLUMN last_changed TEXT`)
+	// 数据库迁移：为 postprocess_cache 添加 description / category / metadata 列（后处理修改的描述、类别与元数据）
+	_, _ = DB.Exec(`ALTER TABLE postprocess_cache ADD COLUMN description TEXT`)
+	_, _ = DB.Exec(`ALTER TABLE postprocess_cache ADD COLUMN category TEXT`)
+	_, _ = DB.Exec(`ALTER TABLE postprocess_cache ADD COLUMN metadata TEXT`)
+	// 数据库迁移：为 classify_cache 添加 relevance 列（兴趣相关度打分）
+	_, _ = DB.Exec(`ALTER TABLE classify_cache ADD COLUMN relevance INTEGER`)
+	// 数据库迁移：为 classify_cache 添加 content_hash 列（内容指纹，用于判断缓存是否失效）
+	_, _ = DB.Exec(`ALTER TABLE classify_cache ADD COLUMN content_hash TEXT`)
+	// 数据库迁移：为 classify_cache 添加 confidence 列（分类置信度）
+	_, _ = DB.Exec(`ALTER TABLE classify_cache ADD COLUMN confidence INTEGER`)
+	// 数据库迁移：为 classify_cache 添加 categories 列（多标签分类的全部类别，JSON 数组）
+	_, _ = DB.Exec(`ALTER TABLE classify_cache ADD COLUMN categories TEXT`)
+	// 数据库迁移：为 classify_cache 添加 classified_at 列（分类时间，数据保留按时间清理），旧记录按升级时间计
+	if _, err := DB.Exec(`ALTER TABLE classify_cache ADD COLUMN classified_at INTEGER`); err == nil {
+		_, _ = DB.Exec(`UPDATE classify_cache SET classified_at = ? WHERE classified_at IS NULL`, time.Now().Unix())
+	}
 
 	return nil
 }
@@ -143,33 +398,68 @@ func CloseDatabase() {
 // ===== 分类缓存操作 =====
 
 // DBLoadClassifyCache 从数据库加载分类缓存到内存
-func DBLoadClassifyCache() (map[string]string, error) {
-	rows, err := DB.Query("SELECT link, category FROM classify_cache")
+func DBLoadClassifyCache() (map[string]models.ClassifyCacheEntry, error) {
+	rows, err := DB.Query("SELECT link, category, relevance, content_hash, confidence, categories, classified_at FROM classify_cache")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	cache := make(map[string]string)
+	cache := make(map[string]models.ClassifyCacheEntry)
 	for rows.Next() {
 		var link, category string
-		if err := rows.Scan(&link, &category); err != nil {
+		var relevance, confidence, classifiedAt sql.NullInt64
+		var hash, categories sql.NullString
+		if err := rows.Scan(&link, &category, &relevance, &hash, &confidence, &categories, &classifiedAt); err != nil {
 			return nil, err
 		}
-		cache[link] = category
+		entry := models.ClassifyCacheEntry{Category: category, Hash: hash.String, ClassifiedAt: classifiedAt.Int64}
+		if relevance.Valid {
+			score := int(relevance.Int64)
+			entry.Relevance = &score
+		}
+		if confidence.Valid {
+			value := int(confidence.Int64)
+			entry.Confidence = &value
+		}
+		if categories.Valid && categories.String != "" {
+			_ = json.Unmarshal([]byte(categories.String), &entry.Categories)
+		}
+		cache[link] = entry
 	}
 	return cache, rows.Err()
 }
 
 // DBSaveClassifyCache 保存分类缓存到数据库
-func DBSaveClassifyCache(link, category string) error {
+func DBSaveClassifyCache(link string, entry models.ClassifyCacheEntry) error {
 	_, err := DB.Exec(
-		"INSERT OR REPLACE INTO classify_cache (link, category) VALUES (?, ?)",
-		link, category,
+		"INSERT OR REPLACE INTO classify_cache (link, category, relevance, content_hash, confidence, categories, classified_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		classifyCacheRow(link, entry)...,
 	)
 	return err
 }
 
+// classifyCacheRow 将分类缓存转换为 classify_cache 表的列值（可空列使用 nil）
+func classifyCacheRow(link string, entry models.ClassifyCacheEntry) []interface{} {
+	var relevance, confidence interface{}
+	if entry.Relevance != nil {
+		relevance = *entry.Relevance
+	}
+	if entry.Confidence != nil {
+		confidence = *entry.Confidence
+	}
+	var categories interface{}
+	if len(entry.Categories) > 0 {
+		data, _ := json.Marshal(entry.Categories)
+		categories = string(data)
+	}
+	var classifiedAt interface{}
+	if entry.ClassifiedAt > 0 {
+		classifiedAt = entry.ClassifiedAt
+	}
+	return []interface{}{link, entry.Category, relevance, entry.Hash, confidence, categories, classifiedAt}
+}
+
 // DBDeleteClassifyCache 删除分类缓存
 func DBDeleteClassifyCache(link string) error {
 	_, err := DB.Exec("DELETE FROM classify_cache WHERE link = ?", link)
@@ -201,12 +491,93 @@ func DBDeleteClassifyCacheBatch(links []string) error {
 	return tx.Commit()
 }
 
+// DBDeleteClassifyCacheOlderThan 删除分类时间早于指定时间的分类缓存（keepLinks 中的链接除外），返回删除条数
+// 没有分类时间的记录（刚升级、尚未写入）不删除
+func DBDeleteClassifyCacheOlderThan(before int64, keepLinks map[string]bool) (int, error) {
+	return deleteLinksOlderThan("classify_cache", "classified_at", before, keepLinks)
+}
+
+// deleteLinksOlderThan 删除表中时间列早于指定时间、且 link 不在 keepLinks 中的记录，返回删除条数
+func deleteLinksOlderThan(table, timeColumn string, before int64, keepLinks map[string]bool) (int, error) {
+	rows, err := DB.Query("SELECT link FROM "+table+" WHERE "+timeColumn+" < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	var toDelete []string
+	for rows.Next() {
+		var link string
+		if err := rows.Scan(&link); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if !keepLinks[link] {
+			toDelete = append(toDelete, link)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(toDelete) == 0 {
+		return 0, nil
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare("DELETE FROM " + table + " WHERE link = ?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, link := range toDelete {
+		if _, err := stmt.Exec(link); err != nil {
+			return 0, err
+		}
+	}
+	return len(toDelete), tx.Commit()
+}
+
 // DBClearClassifyCache 清空分类缓存
 func DBClearClassifyCache() error {
 	_, err := DB.Exec("DELETE FROM classify_cache")
 	return err
 }
 
+// DBMigrateClassifyCategories 在同一事务中写入类别迁移后的分类缓存与人工修正
+func DBMigrateClassifyCategories(entries map[string]models.ClassifyCacheEntry, corrections []models.ClassifyCorrection) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	cacheStmt, err := tx.Prepare("INSERT OR REPLACE INTO classify_cache (link, category, relevance, content_hash, confidence, categories, classified_at) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer cacheStmt.Close()
+	for link, entry := range entries {
+		if _, err := cacheStmt.Exec(classifyCacheRow(link, entry)...); err != nil {
+			return err
+		}
+	}
+
+	correctionStmt, err := tx.Prepare("UPDATE classify_corrections SET category = ? WHERE link = ?")
+	if err != nil {
+		return err
+	}
+	defer correctionStmt.Close()
+	for _, c := range corrections {
+		if _, err := correctionStmt.Exec(c.Category, c.Link); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 // ===== 图标缓存操作 =====
 
 // DBSaveIconCache 保存图标到缓存
@@ -242,6 +613,113 @@ func DBCleanupIconCache(days int) (int64, error) {
 	return res.RowsAffected()
 }
 
+// ===== 图片代理缓存操作 =====
+
+// DBSaveImageCache 保存图片到缓存
+func DBSaveImageCache(url string, data []byte, mimeType string) error {
+	_, err := DB.Exec(
+		"INSERT OR REPLACE INTO image_cache (url, data, mime_type, size, created_at) VALUES (?, ?, ?, ?, ?)",
+		url, data, mimeType, len(data), time.Now().Unix(),
+	)
+	return err
+}
+
+// DBGetImageCache 从缓存获取图片，早于 notBefore（Unix 时间戳）缓存的图片视为过期
+func DBGetImageCache(url string, notBefore int64) ([]byte, string, bool, error) {
+	var data []byte
+	var mimeType string
+	err := DB.QueryRow("SELECT data, mime_type FROM image_cache WHERE url = ? AND created_at >= ?", url, notBefore).Scan(&data, &mimeType)
+	if err == sql.ErrNoRows {
+		return nil, "", false, nil
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+	return data, mimeType, true, nil
+}
+
+// DBDeleteImageCacheOlderThan 删除早于指定时间（Unix 时间戳）缓存的图片，返回删除条数
+func DBDeleteImageCacheOlderThan(before int64) (int64, error) {
+	res, err := DB.Exec("DELETE FROM image_cache WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DBTrimImageCache 缓存总大小超过 maxBytes 时从最早缓存的图片开始删除，返回删除条数
+func DBTrimImageCache(maxBytes int64) (int64, error) {
+	var total int64
+	if err := DB.QueryRow("SELECT COALESCE(SUM(size), 0) FROM image_cache").Scan(&total); err != nil {
+		return 0, err
+	}
+	if total <= maxBytes {
+		return 0, nil
+	}
+
+	rows, err := DB.Query("SELECT url, size FROM image_cache ORDER BY created_at ASC")
+	if err != nil {
+		return 0, err
+	}
+	var expired []string
+	for rows.Next() && total > maxBytes {
+		var url string
+		var size int64
+		if err := rows.Scan(&url, &size); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired = append(expired, url)
+		total -= size
+	}
+	rows.Close()
+
+	var deleted int64
+	for _, url := range expired {
+		res, err := DB.Exec("DELETE FROM image_cache WHERE url = ?", url)
+		if err != nil {
+			return deleted, err
+		}
+		n, _ := res.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// ===== og:image 缓存操作 =====
+
+// DBSaveOGImage 保存文章页面的 og:image
+func DBSaveOGImage(link, image string) error {
+	_, err := DB.Exec(
+		"INSERT OR REPLACE INTO og_image_cache (link, image, created_at) VALUES (?, ?, ?)",
+		link, image, time.Now().Unix(),
+	)
+	return err
+}
+
+// DBGetOGImage 获取缓存的文章页面 og:image
+func DBGetOGImage(link string) (string, bool, error) {
+	var image string
+	err := DB.QueryRow("SELECT image FROM og_image_cache WHERE link = ?", link).Scan(&image)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return image, true, nil
+}
+
+// DBDeleteOGImagesOlderThan 删除早于指定时间（Unix 时间戳）缓存的 og:image，返回删除条数
+func DBDeleteOGImagesOlderThan(before int64) (int, error) {
+	result, err := DB.Exec("DELETE FROM og_image_cache WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
 // ===== 已读状态操作 =====
 
 // DBLoadReadState 从数据库加载已读状态到内存
@@ -371,20 +849,138 @@ func DBDeleteReadStateOlderThan(timestamp int64, excludeLinks map[string]bool) (
 	return len(toDelete), DBDeleteReadStateBatch(toDelete)
 }
 
-// ===== 后处理缓存操作 =====
-
-// DBPostProcessEntry 后处理缓存条目
-type DBPostProcessEntry struct {
-	Link        string
-	Title       string
-	NewLink     string
-	PubDate     string
-	ProcessedAt string
-}
+// ===== 已读状态变化日志操作 =====
 
-// DBLoadPostProcessCache 从数据库加载后处理缓存
+// DBLoadReadStateChangeTimes 加载各链接最近一次已读状态变化的时间（毫秒）
+func DBLoadReadStateChangeTimes() (map[string]int64, error) {
+	rows, err := DB.Query("SELECT link, changed_at FROM read_state_changes")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := make(map[string]int64)
+	for rows.Next() {
+		var link string
+		var changedAt int64
+		if err := rows.Scan(&link, &changedAt); err != nil {
+			return nil, err
+		}
+		times[link] = changedAt
+	}
+	return times, rows.Err()
+}
+
+// DBSaveReadStateChanges 批量记录已读状态变化，每条变化分配新的 seq
+// 只替换更早的记录，异步写入的顺序颠倒时也保留时间最新的变化
+func DBSaveReadStateChanges(changes []ReadStateSyncChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteStmt, err := tx.Prepare("DELETE FROM read_state_changes WHERE link = ? AND changed_at <= ?")
+	if err != nil {
+		return err
+	}
+	defer deleteStmt.Close()
+	insertStmt, err := tx.Prepare("INSERT OR IGNORE INTO read_state_changes (link, read, changed_at) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer insertStmt.Close()
+
+	for _, change := range changes {
+		if _, err := deleteStmt.Exec(change.Link, change.At); err != nil {
+			return err
+		}
+		if _, err := insertStmt.Exec(change.Link, change.Read, change.At); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DBListReadStateChangesSince 获取 seq 大于游标的已读状态变化（按 seq 升序），同时返回当前最大 seq
+func DBListReadStateChangesSince(cursor int64) ([]ReadStateSyncChange, int64, error) {
+	var latest sql.NullInt64
+	if err := DB.QueryRow("SELECT MAX(seq) FROM read_state_changes").Scan(&latest); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := DB.Query("SELECT link, read, changed_at FROM read_state_changes WHERE seq > ? AND seq <= ? ORDER BY seq", cursor, latest.Int64)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	changes := make([]ReadStateSyncChange, 0)
+	for rows.Next() {
+		var change ReadStateSyncChange
+		if err := rows.Scan(&change.Link, &change.Read, &change.At); err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, latest.Int64, rows.Err()
+}
+
+// DBDeleteReadStateChangesBatch 批量删除链接的已读状态变化记录
+func DBDeleteReadStateChangesBatch(links []string) error {
+	if len(links) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM read_state_changes WHERE link = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, link := range links {
+		if _, err := stmt.Exec(link); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DBDeleteReadStateChangesOlderThan 删除早于指定时间（毫秒）的已读状态变化记录，返回删除条数
+func DBDeleteReadStateChangesOlderThan(before int64) (int, error) {
+	result, err := DB.Exec("DELETE FROM read_state_changes WHERE changed_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// ===== 后处理缓存操作 =====
+
+// DBPostProcessEntry 后处理缓存条目
+type DBPostProcessEntry struct {
+	Link        string
+	Title       string
+	NewLink     string
+	PubDate     string
+	Description string
+	Category    string
+	Metadata    map[string]string
+	ProcessedAt string
+}
+
+// DBLoadPostProcessCache 从数据库加载后处理缓存
 func DBLoadPostProcessCache() (map[string]DBPostProcessEntry, error) {
-	rows, err := DB.Query("SELECT link, title, new_link, pub_date, processed_at FROM postprocess_cache")
+	rows, err := DB.Query("SELECT link, title, new_link, pub_date, description, category, metadata, processed_at FROM postprocess_cache")
 	if err != nil {
 		return nil, err
 	}
@@ -393,13 +989,16 @@ func DBLoadPostProcessCache() (map[string]DBPostProcessEntry, error) {
 	cache := make(map[string]DBPostProcessEntry)
 	for rows.Next() {
 		var entry DBPostProcessEntry
-		var title, newLink, pubDate sql.NullString
-		if err := rows.Scan(&entry.Link, &title, &newLink, &pubDate, &entry.ProcessedAt); err != nil {
+		var title, newLink, pubDate, description, category, metadata sql.NullString
+		if err := rows.Scan(&entry.Link, &title, &newLink, &pubDate, &description, &category, &metadata, &entry.ProcessedAt); err != nil {
 			return nil, err
 		}
 		entry.Title = title.String
 		entry.NewLink = newLink.String
 		entry.PubDate = pubDate.String
+		entry.Description = description.String
+		entry.Category = category.String
+		entry.Metadata = decodeMetadata(metadata.String)
 		cache[entry.Link] = entry
 	}
 	return cache, rows.Err()
@@ -408,12 +1007,36 @@ func DBLoadPostProcessCache() (map[string]DBPostProcessEntry, error) {
 // DBSavePostProcessCache 保存后处理缓存到数据库
 func DBSavePostProcessCache(entry DBPostProcessEntry) error {
 	_, err := DB.Exec(
-		"INSERT OR REPLACE INTO postprocess_cache (link, title, new_link, pub_date, processed_at) VALUES (?, ?, ?, ?, ?)",
-		entry.Link, entry.Title, entry.NewLink, entry.PubDate, entry.ProcessedAt,
+		"INSERT OR REPLACE INTO postprocess_cache (link, title, new_link, pub_date, description, category, metadata, processed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.Link, entry.Title, entry.NewLink, entry.PubDate, entry.Description, entry.Category, encodeMetadata(entry.Metadata), entry.ProcessedAt,
 	)
 	return err
 }
 
+// encodeMetadata 将元数据编码为 JSON 字符串保存，没有元数据时保存为 NULL
+func encodeMetadata(metadata map[string]string) interface{} {
+	if len(metadata) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// decodeMetadata 解析数据库中保存的元数据 JSON，为空或无效时返回 nil
+func decodeMetadata(data string) map[string]string {
+	if data == "" {
+		return nil
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		return nil
+	}
+	return metadata
+}
+
 // DBDeletePostProcessCache 删除后处理缓存
 func DBDeletePostProcessCache(link string) error {
 	_, err := DB.Exec("DELETE FROM postprocess_cache WHERE link = ?", link)
@@ -461,11 +1084,23 @@ type DBItemsCacheEntry struct {
 	OriginalLink string
 	PubDate      string
 	FetchTime    string
+	Score        int
+	Comments     int
+	Thumbnail    string
+	Duration     int
+	// 翻译前的原始标题
+	OriginalTitle string
+	// 后处理附加的元数据
+	Metadata map[string]string
+	// 原始标题与描述的指纹，以及内容是否被修改过与最近修改时间
+	ContentHash string
+	Updated     bool
+	LastChanged string
 }
 
 // DBLoadItemsCache 从数据库加载条目缓存
 func DBLoadItemsCache() (map[string][]DBItemsCacheEntry, error) {
-	rows, err := DB.Query("SELECT rss_url, title, link, original_link, pub_date, fetch_time FROM items_cache ORDER BY rss_url, id")
+	rows, err := DB.Query("SELECT rss_url, title, link, original_link, pub_date, fetch_time, score, comments, thumbnail, duration, original_title, metadata, content_hash, updated, last_changed FROM items_cache ORDER BY rss_url, id")
 	if err != nil {
 		return nil, err
 	}
@@ -475,12 +1110,24 @@ func DBLoadItemsCache() (map[string][]DBItemsCacheEntry, error) {
 	for rows.Next() {
 		var entry DBItemsCacheEntry
 		var originalLink, pubDate, fetchTime sql.NullString
-		if err := rows.Scan(&entry.RssURL, &entry.Title, &entry.Link, &originalLink, &pubDate, &fetchTime); err != nil {
+		var thumbnail, originalTitle, metadata sql.NullString
+		var contentHash, lastChanged sql.NullString
+		var score, comments, duration, updated sql.NullInt64
+		if err := rows.Scan(&entry.RssURL, &entry.Title, &entry.Link, &originalLink, &pubDate, &fetchTime, &score, &comments, &thumbnail, &duration, &originalTitle, &metadata, &contentHash, &updated, &lastChanged); err != nil {
 			return nil, err
 		}
 		entry.OriginalLink = originalLink.String
 		entry.PubDate = pubDate.String
 		entry.FetchTime = fetchTime.String
+		entry.Score = int(score.Int64)
+		entry.Comments = int(comments.Int64)
+		entry.Thumbnail = thumbnail.String
+		entry.Duration = int(duration.Int64)
+		entry.OriginalTitle = originalTitle.String
+		entry.Metadata = decodeMetadata(metadata.String)
+		entry.ContentHash = contentHash.String
+		entry.Updated = updated.Int64 != 0
+		entry.LastChanged = lastChanged.String
 		cache[entry.RssURL] = append(cache[entry.RssURL], entry)
 	}
 	return cache, rows.Err()
@@ -488,7 +1135,7 @@ func DBLoadItemsCache() (map[string][]DBItemsCacheEntry, error) {
 
 // DBLoadItemsCacheForURL 从数据库加载指定URL的条目缓存
 func DBLoadItemsCacheForURL(rssURL string) ([]DBItemsCacheEntry, error) {
-	rows, err := DB.Query("SELECT rss_url, title, link, original_link, pub_date, fetch_time FROM items_cache WHERE rss_url = ? ORDER BY id", rssURL)
+	rows, err := DB.Query("SELECT rss_url, title, link, original_link, pub_date, fetch_time, score, comments, thumbnail, duration, original_title, metadata, content_hash, updated, last_changed FROM items_cache WHERE rss_url = ? ORDER BY id", rssURL)
 	if err != nil {
 		return nil, err
 	}
@@ -498,12 +1145,24 @@ func DBLoadItemsCacheForURL(rssURL string) ([]DBItemsCacheEntry, error) {
 	for rows.Next() {
 		var entry DBItemsCacheEntry
 		var originalLink, pubDate, fetchTime sql.NullString
-		if err := rows.Scan(&entry.RssURL, &entry.Title, &entry.Link, &originalLink, &pubDate, &fetchTime); err != nil {
+		var thumbnail, originalTitle, metadata sql.NullString
+		var contentHash, lastChanged sql.NullString
+		var score, comments, duration, updated sql.NullInt64
+		if err := rows.Scan(&entry.RssURL, &entry.Title, &entry.Link, &originalLink, &pubDate, &fetchTime, &score, &comments, &thumbnail, &duration, &originalTitle, &metadata, &contentHash, &updated, &lastChanged); err != nil {
 			return nil, err
 		}
 		entry.OriginalLink = originalLink.String
 		entry.PubDate = pubDate.String
 		entry.FetchTime = fetchTime.String
+		entry.Score = int(score.Int64)
+		entry.Comments = int(comments.Int64)
+		entry.Thumbnail = thumbnail.String
+		entry.Duration = int(duration.Int64)
+		entry.OriginalTitle = originalTitle.String
+		entry.Metadata = decodeMetadata(metadata.String)
+		entry.ContentHash = contentHash.String
+		entry.Updated = updated.Int64 != 0
+		entry.LastChanged = lastChanged.String
 		items = append(items, entry)
 	}
 	return items, rows.Err()
@@ -523,14 +1182,14 @@ func DBSaveItemsCache(rssURL string, items []DBItemsCacheEntry) error {
 	}
 
 	// 插入新缓存
-	stmt, err := tx.Prepare("INSERT OR REPLACE INTO items_cache (rss_url, title, link, original_link, pub_date, fetch_time) VALUES (?, ?, ?, ?, ?, ?)")
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO items_cache (rss_url, title, link, original_link, pub_date, fetch_time, score, comments, thumbnail, duration, original_title, metadata, content_hash, updated, last_changed) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
 	for _, item := range items {
-		if _, err := stmt.Exec(item.RssURL, item.Title, item.Link, item.OriginalLink, item.PubDate, item.FetchTime); err != nil {
+		if _, err := stmt.Exec(item.RssURL, item.Title, item.Link, item.OriginalLink, item.PubDate, item.FetchTime, item.Score, item.Comments, item.Thumbnail, item.Duration, item.OriginalTitle, encodeMetadata(item.Metadata), item.ContentHash, item.Updated, item.LastChanged); err != nil {
 			return err
 		}
 	}
@@ -593,3 +1252,831 @@ func DBGetItemsCacheURLs() ([]string, error) {
 	}
 	return urls, rows.Err()
 }
+
+// DBRenameSourceURL 将按源地址保存的记录（条目缓存、分类修正、追踪线索、卡片展示选项覆盖）迁移到新地址
+func DBRenameSourceURL(oldURL, newURL string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"UPDATE OR REPLACE items_cache SET rss_url = ? WHERE rss_url = ?",
+		"UPDATE classify_corrections SET rss_url = ? WHERE rss_url = ?",
+		"UPDATE follow_items SET source_url = ? WHERE source_url = ?",
+		"UPDATE OR REPLACE display_overrides SET link = ? WHERE link = ?",
+		"UPDATE OR REPLACE filter_hits SET source_url = ? WHERE source_url = ?",
+		"UPDATE starred_items SET source_url = ? WHERE source_url = ?",
+	} {
+		if _, err := tx.Exec(query, newURL, oldURL); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ===== 卡片展示选项覆盖操作 =====
+
+// DBLoadDisplayOverrides 从数据库加载卡片展示选项覆盖
+func DBLoadDisplayOverrides() (map[string]models.DisplayFlags, error) {
+	rows, err := DB.Query("SELECT link, flags FROM display_overrides")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]models.DisplayFlags)
+	for rows.Next() {
+		var link, data string
+		if err := rows.Scan(&link, &data); err != nil {
+			return nil, err
+		}
+		var flags models.DisplayFlags
+		if err := json.Unmarshal([]byte(data), &flags); err != nil {
+			continue
+		}
+		overrides[link] = flags
+	}
+	return overrides, rows.Err()
+}
+
+// DBSaveDisplayOverride 保存单个卡片的展示选项覆盖
+func DBSaveDisplayOverride(link string, flags models.DisplayFlags) error {
+	data, err := json.Marshal(flags)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(
+		"INSERT OR REPLACE INTO display_overrides (link, flags) VALUES (?, ?)",
+		link, string(data),
+	)
+	return err
+}
+
+// DBDeleteDisplayOverride 删除单个卡片的展示选项覆盖
+func DBDeleteDisplayOverride(link string) error {
+	_, err := DB.Exec("DELETE FROM display_overrides WHERE link = ?", link)
+	return err
+}
+
+// ===== 故事追踪操作 =====
+
+// DBLoadFollows 从数据库加载所有故事追踪及其线索条目
+func DBLoadFollows() (map[string]*models.Follow, error) {
+	rows, err := DB.Query("SELECT id, title, seed_link, keywords, created_at, archived FROM follows")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	follows := make(map[string]*models.Follow)
+	for rows.Next() {
+		var f models.Follow
+		var keywords string
+		var archived int
+		if err := rows.Scan(&f.ID, &f.Title, &f.SeedLink, &keywords, &f.CreatedAt, &archived); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(keywords), &f.Keywords)
+		f.Archived = archived != 0
+		follows[f.ID] = &f
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	itemRows, err := DB.Query("SELECT follow_id, link, title, source, source_url, pub_date, score, matched_at FROM follow_items ORDER BY matched_at")
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var followID string
+		var item models.FollowItem
+		var title, source, sourceURL, pubDate sql.NullString
+		var score sql.NullFloat64
+		if err := itemRows.Scan(&followID, &item.Link, &title, &source, &sourceURL, &pubDate, &score, &item.MatchedAt); err != nil {
+			return nil, err
+		}
+		f, ok := follows[followID]
+		if !ok {
+			continue
+		}
+		item.Title = title.String
+		item.Source = source.String
+		item.SourceURL = sourceURL.String
+		item.PubDate = pubDate.String
+		item.Score = score.Float64
+		f.Items = append(f.Items, item)
+	}
+	return follows, itemRows.Err()
+}
+
+// DBSaveFollow 保存故事追踪（不含线索条目）
+func DBSaveFollow(f *models.Follow) error {
+	keywords, err := json.Marshal(f.Keywords)
+	if err != nil {
+		return err
+	}
+	archived := 0
+	if f.Archived {
+		archived = 1
+	}
+	_, err = DB.Exec(
+		"INSERT OR REPLACE INTO follows (id, title, seed_link, keywords, created_at, archived) VALUES (?, ?, ?, ?, ?, ?)",
+		f.ID, f.Title, f.SeedLink, string(keywords), f.CreatedAt, archived,
+	)
+	return err
+}
+
+// DBSaveFollowItems 批量保存故事追踪线索条目
+func DBSaveFollowItems(followID string, items []models.FollowItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO follow_items (follow_id, link, title, source, source_url, pub_date, score, matched_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		if _, err := stmt.Exec(followID, item.Link, item.Title, item.Source, item.SourceURL, item.PubDate, item.Score, item.MatchedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DBDeleteFollow 删除故事追踪及其线索条目
+func DBDeleteFollow(id string) error {
+	if _, err := DB.Exec("DELETE FROM follow_items WHERE follow_id = ?", id); err != nil {
+		return err
+	}
+	_, err := DB.Exec("DELETE FROM follows WHERE id = ?", id)
+	return err
+}
+
+// DBDeleteFollowItemsOlderThan 删除匹配时间早于指定时间的线索条目
+func DBDeleteFollowItemsOlderThan(timestamp int64) error {
+	_, err := DB.Exec("DELETE FROM follow_items WHERE matched_at < ?", timestamp)
+	return err
+}
+
+// ===== 配置历史版本操作 =====
+
+// DBConfigSnapshot 配置历史版本（列表中不含配置内容）
+type DBConfigSnapshot struct {
+	ID        int64  `json:"id"`
+	CreatedAt int64  `json:"createdAt"`
+	Reason    string `json:"reason"`
+	Size      int    `json:"size"`
+	Data      string `json:"-"`
+}
+
+// DBSaveConfigSnapshot 保存配置快照，内容与最新版本相同时跳过，返回是否新增了版本
+// 超过 keep 个版本时删除最旧的版本
+func DBSaveConfigSnapshot(reason, hash, data string, keep int) (bool, error) {
+	var latest string
+	err := DB.QueryRow("SELECT hash FROM config_history ORDER BY id DESC LIMIT 1").Scan(&latest)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	if latest == hash {
+		return false, nil
+	}
+
+	if _, err := DB.Exec(
+		"INSERT INTO config_history (created_at, reason, hash, data) VALUES (?, ?, ?, ?)",
+		time.Now().Unix(), reason, hash, data,
+	); err != nil {
+		return false, err
+	}
+	_, err = DB.Exec("DELETE FROM config_history WHERE id NOT IN (SELECT id FROM config_history ORDER BY id DESC LIMIT ?)", keep)
+	return true, err
+}
+
+// DBDeleteConfigSnapshotsOlderThan 删除创建时间早于指定时间的配置版本（始终保留最新版本），返回删除条数
+func DBDeleteConfigSnapshotsOlderThan(before int64) (int, error) {
+	result, err := DB.Exec(
+		"DELETE FROM config_history WHERE created_at < ? AND id NOT IN (SELECT id FROM config_history ORDER BY id DESC LIMIT 1)",
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// DBListConfigSnapshots 列出所有配置版本（按时间倒序）
+func DBListConfigSnapshots() ([]DBConfigSnapshot, error) {
+	rows, err := DB.Query("SELECT id, created_at, reason, length(data) FROM config_history ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make([]DBConfigSnapshot, 0)
+	for rows.Next() {
+		var s DBConfigSnapshot
+		if err := rows.Scan(&s.ID, &s.CreatedAt, &s.Reason, &s.Size); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+// DBGetConfigSnapshot 获取指定配置版本（含内容）
+func DBGetConfigSnapshot(id int64) (DBConfigSnapshot, error) {
+	var s DBConfigSnapshot
+	err := DB.QueryRow(
+		"SELECT id, created_at, reason, length(data), data FROM config_history WHERE id = ?", id,
+	).Scan(&s.ID, &s.CreatedAt, &s.Reason, &s.Size, &s.Data)
+	return s, err
+}
+
+// ===== 外部ID映射操作 =====
+
+// DBExternalIDMapping 条目在某个外部服务中的ID映射
+type DBExternalIDMapping struct {
+	UID        int64
+	Link       string
+	Service    string
+	ExternalID string
+	// 最近一次与该服务同步的已读状态: -1 未同步 / 0 未读 / 1 已读
+	SyncedRead int
+	SyncedAt   int64
+}
+
+// DBGetOrCreateItemUID 获取条目链接对应的UID，不存在时分配新的UID
+func DBGetOrCreateItemUID(link string) (int64, error) {
+	if _, err := DB.Exec("INSERT OR IGNORE INTO item_uids (link, created_at) VALUES (?, ?)", link, time.Now().Unix()); err != nil {
+		return 0, err
+	}
+	var uid int64
+	err := DB.QueryRow("SELECT uid FROM item_uids WHERE link = ?", link).Scan(&uid)
+	return uid, err
+}
+
+// DBSaveExternalID 保存条目在外部服务中的ID（同一服务中条目或外部ID已有映射时覆盖）
+func DBSaveExternalID(uid int64, service, externalID string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM item_external_ids WHERE service = ? AND (uid = ? OR external_id = ?) AND NOT (uid = ? AND external_id = ?)",
+		service, uid, externalID, uid, externalID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT OR IGNORE INTO item_external_ids (uid, service, external_id) VALUES (?, ?, ?)",
+		uid, service, externalID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DBGetExternalIDMapping 按服务及条目UID或外部ID查询映射（uid 为 0 时按外部ID查询）
+func DBGetExternalIDMapping(service string, uid int64, externalID string) (DBExternalIDMapping, error) {
+	query := `SELECT m.uid, u.link, m.service, m.external_id, m.synced_read, m.synced_at
+		FROM item_external_ids m JOIN item_uids u ON u.uid = m.uid WHERE m.service = ? AND `
+	var row *sql.Row
+	if uid != 0 {
+		row = DB.QueryRow(query+"m.uid = ?", service, uid)
+	} else {
+		row = DB.QueryRow(query+"m.external_id = ?", service, externalID)
+	}
+	var m DBExternalIDMapping
+	err := row.Scan(&m.UID, &m.Link, &m.Service, &m.ExternalID, &m.SyncedRead, &m.SyncedAt)
+	return m, err
+}
+
+// DBListExternalIDMappings 列出外部服务的所有映射
+func DBListExternalIDMappings(service string) ([]DBExternalIDMapping, error) {
+	rows, err := DB.Query(`SELECT m.uid, u.link, m.service, m.external_id, m.synced_read, m.synced_at
+		FROM item_external_ids m JOIN item_uids u ON u.uid = m.uid WHERE m.service = ?`, service)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []DBExternalIDMapping
+	for rows.Next() {
+		var m DBExternalIDMapping
+		if err := rows.Scan(&m.UID, &m.Link, &m.Service, &m.ExternalID, &m.SyncedRead, &m.SyncedAt); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
+
+// DBSetSyncedReadState 记录条目最近一次与外部服务同步的已读状态
+func DBSetSyncedReadState(uid int64, service string, read bool) error {
+	state := 0
+	if read {
+		state = 1
+	}
+	_, err := DB.Exec("UPDATE item_external_ids SET synced_read = ?, synced_at = ? WHERE uid = ? AND service = ?",
+		state, time.Now().Unix(), uid, service)
+	return err
+}
+
+// DBDeleteItemUIDsOlderThan 删除分配时间早于指定时间的条目UID及其外部ID映射（keepLinks 中的链接除外），返回删除的UID数
+func DBDeleteItemUIDsOlderThan(before int64, keepLinks map[string]bool) (int, error) {
+	rows, err := DB.Query("SELECT link FROM item_uids WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	var links []string
+	for rows.Next() {
+		var link string
+		if err := rows.Scan(&link); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if !keepLinks[link] {
+			links = append(links, link)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return len(links), DBDeleteItemUIDsForLinks(links)
+}
+
+// DBDeleteItemUIDsForLinks 删除条目链接的UID及其所有外部ID映射
+func DBDeleteItemUIDsForLinks(links []string) error {
+	if len(links) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, link := range links {
+		if _, err := tx.Exec("DELETE FROM item_external_ids WHERE uid IN (SELECT uid FROM item_uids WHERE link = ?)", link); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM item_uids WHERE link = ?", link); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ===== 语义向量操作 =====
+
+// encodeVector 将向量编码为小端 float32 字节序列
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeVector 解码小端 float32 字节序列
+func decodeVector(data []byte) []float32 {
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector
+}
+
+// DBLoadEmbeddings 加载指定模型的所有条目向量
+func DBLoadEmbeddings(model string) (map[string][]float32, error) {
+	rows, err := DB.Query("SELECT link, vector FROM item_embeddings WHERE model = ?", model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]float32)
+	for rows.Next() {
+		var link string
+		var data []byte
+		if err := rows.Scan(&link, &data); err != nil {
+			return nil, err
+		}
+		result[link] = decodeVector(data)
+	}
+	return result, rows.Err()
+}
+
+// DBSaveEmbeddings 批量保存条目向量
+func DBSaveEmbeddings(model string, vectors map[string][]float32) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO item_embeddings (link, model, vector, created_at) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().Unix()
+	for link, vector := range vectors {
+		if _, err := stmt.Exec(link, model, encodeVector(vector), now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DBDeleteEmbeddingsBatch 批量删除条目向量
+func DBDeleteEmbeddingsBatch(links []string) error {
+	if len(links) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM item_embeddings WHERE link = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, link := range links {
+		if _, err := stmt.Exec(link); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ===== 翻译缓存操作 =====
+
+// DBTranslationEntry 翻译缓存条目
+type DBTranslationEntry struct {
+	Text      string
+	CreatedAt int64
+}
+
+// DBLoadTranslationCache 加载所有翻译缓存
+func DBLoadTranslationCache() (map[string]DBTranslationEntry, error) {
+	rows, err := DB.Query("SELECT hash, text, created_at FROM translation_cache")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cache := make(map[string]DBTranslationEntry)
+	for rows.Next() {
+		var hash string
+		var entry DBTranslationEntry
+		if err := rows.Scan(&hash, &entry.Text, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		cache[hash] = entry
+	}
+	return cache, rows.Err()
+}
+
+// DBSaveTranslations 批量保存翻译结果
+func DBSaveTranslations(entries map[string]DBTranslationEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO translation_cache (hash, text, created_at) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for hash, entry := range entries {
+		if _, err := stmt.Exec(hash, entry.Text, entry.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DBDeleteTranslationsOlderThan 删除早于指定时间（Unix 秒）的翻译缓存，返回删除条数
+func DBDeleteTranslationsOlderThan(before int64) (int, error) {
+	result, err := DB.Exec("DELETE FROM translation_cache WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// ===== AI 用量操作 =====
+
+// DBLLMUsage 某一天的 AI 调用用量
+type DBLLMUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	Requests         int
+}
+
+// DBDeleteLLMUsageBefore 删除指定日期（YYYY-MM-DD）之前的用量记录，返回删除条数
+func DBDeleteLLMUsageBefore(date string) (int, error) {
+	result, err := DB.Exec("DELETE FROM llm_usage WHERE date < ?", date)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// DBLoadLLMUsage 加载指定日期（YYYY-MM-DD）的用量，没有记录时返回零值
+func DBLoadLLMUsage(date string) (DBLLMUsage, error) {
+	var usage DBLLMUsage
+	err := DB.QueryRow(
+		"SELECT prompt_tokens, completion_tokens, requests FROM llm_usage WHERE date = ?", date,
+	).Scan(&usage.PromptTokens, &usage.CompletionTokens, &usage.Requests)
+	if err == sql.ErrNoRows {
+		return usage, nil
+	}
+	return usage, err
+}
+
+// DBSaveLLMUsage 保存指定日期的用量
+func DBSaveLLMUsage(date string, usage DBLLMUsage) error {
+	_, err := DB.Exec(
+		"INSERT OR REPLACE INTO llm_usage (date, prompt_tokens, completion_tokens, requests) VALUES (?, ?, ?, ?)",
+		date, usage.PromptTokens, usage.CompletionTokens, usage.Requests,
+	)
+	return err
+}
+
+// ===== 分类修正操作 =====
+
+// DBLoadClassifyCorrections 加载所有人工修正的分类结果
+func DBLoadClassifyCorrections() (map[string]models.ClassifyCorrection, error) {
+	rows, err := DB.Query("SELECT link, rss_url, title, category, created_at FROM classify_corrections")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	corrections := make(map[string]models.ClassifyCorrection)
+	for rows.Next() {
+		var c models.ClassifyCorrection
+		var title sql.NullString
+		if err := rows.Scan(&c.Link, &c.RssURL, &title, &c.Category, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.Title = title.String
+		corrections[c.Link] = c
+	}
+	return corrections, rows.Err()
+}
+
+// DBSaveClassifyCorrection 保存人工修正的分类结果
+func DBSaveClassifyCorrection(c models.ClassifyCorrection) error {
+	_, err := DB.Exec(
+		"INSERT OR REPLACE INTO classify_corrections (link, rss_url, title, category, created_at) VALUES (?, ?, ?, ?, ?)",
+		c.Link, c.RssURL, c.Title, c.Category, c.CreatedAt,
+	)
+	return err
+}
+
+// DBDeleteClassifyCorrection 删除人工修正的分类结果
+func DBDeleteClassifyCorrection(link string) error {
+	_, err := DB.Exec("DELETE FROM classify_corrections WHERE link = ?", link)
+	return err
+}
+
+// DBDeleteClassifyCorrectionsBatch 批量删除人工修正的分类结果
+func DBDeleteClassifyCorrectionsBatch(links []string) error {
+	if len(links) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM classify_corrections WHERE link = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, link := range links {
+		if _, err := stmt.Exec(link); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DBDeleteClassifyCorrectionsOlderThan 删除修正时间早于指定时间的人工修正，返回删除条数
+func DBDeleteClassifyCorrectionsOlderThan(before int64) (int, error) {
+	result, err := DB.Exec("DELETE FROM classify_corrections WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// ===== 订阅源健康状态操作 =====
+
+// DBLoadSourceHealth 加载持续失败中的订阅源状态
+func DBLoadSourceHealth() (map[string]*sourceHealth, error) {
+	rows, err := DB.Query("SELECT url, failures, failing_since, dead_since FROM source_health")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]*sourceHealth)
+	for rows.Next() {
+		var url string
+		var failures int
+		var failingSince, deadSince int64
+		if err := rows.Scan(&url, &failures, &failingSince, &deadSince); err != nil {
+			return nil, err
+		}
+		health := &sourceHealth{Failures: failures, FailingSince: time.Unix(failingSince, 0)}
+		if deadSince > 0 {
+			health.DeadSince = time.Unix(deadSince, 0)
+		}
+		result[url] = health
+	}
+	return result, rows.Err()
+}
+
+// DBSaveSourceHealth 保存订阅源的失败状态
+func DBSaveSourceHealth(url string, failures int, failingSince, deadSince time.Time) error {
+	var dead int64
+	if !deadSince.IsZero() {
+		dead = deadSince.Unix()
+	}
+	_, err := DB.Exec(
+		"INSERT OR REPLACE INTO source_health (url, failures, failing_since, dead_since) VALUES (?, ?, ?, ?)",
+		url, failures, failingSince.Unix(), dead,
+	)
+	return err
+}
+
+// DBDeleteSourceHealth 删除订阅源的失败状态（抓取恢复或源已删除）
+func DBDeleteSourceHealth(url string) error {
+	_, err := DB.Exec("DELETE FROM source_health WHERE url = ?", url)
+	return err
+}
+
+// ===== 过滤规则命中统计操作 =====
+
+// DBSaveFilterHits 保存规则命中记录，已记录过的条目保持首次命中时间
+func DBSaveFilterHits(rssURL string, hits filterHits, now time.Time) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO filter_hits (source_url, rule_type, rule, link, hit_at) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for rule, links := range hits {
+		for link := range links {
+			if _, err := stmt.Exec(rssURL, rule.Type, rule.Rule, link, now.Unix()); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// DBLoadFilterStats 按源与规则汇总命中记录（rssURL 为空时汇总所有源），recentSince 之后的命中计入 RecentHits
+func DBLoadFilterStats(rssURL string, recentSince time.Time) (map[string]map[filterRule]FilterRuleStats, error) {
+	query := "SELECT source_url, rule_type, rule, COUNT(*), SUM(CASE WHEN hit_at >= ? THEN 1 ELSE 0 END), MAX(hit_at) FROM filter_hits"
+	args := []interface{}{recentSince.Unix()}
+	if rssURL != "" {
+		query += " WHERE source_url = ?"
+		args = append(args, rssURL)
+	}
+	query += " GROUP BY source_url, rule_type, rule"
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]map[filterRule]FilterRuleStats)
+	for rows.Next() {
+		var url string
+		var stat FilterRuleStats
+		var lastHit int64
+		if err := rows.Scan(&url, &stat.Type, &stat.Rule, &stat.Hits, &stat.RecentHits, &lastHit); err != nil {
+			return nil, err
+		}
+		stat.LastHit = time.Unix(lastHit, 0).Format("2006-01-02 15:04:05")
+		if result[url] == nil {
+			result[url] = make(map[filterRule]FilterRuleStats)
+		}
+		result[url][filterRule{Type: stat.Type, Rule: stat.Rule}] = stat
+	}
+	return result, rows.Err()
+}
+
+// DBCleanupFilterHits 删除超过指定天数的命中记录
+func DBCleanupFilterHits(days int) (int64, error) {
+	expirationTime := time.Now().AddDate(0, 0, -days).Unix()
+	res, err := DB.Exec("DELETE FROM filter_hits WHERE hit_at < ?", expirationTime)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ===== 收藏条目操作 =====
+
+// DBSaveStarredItems 保存收藏条目，已收藏的条目保持不变，返回新增的条数
+func DBSaveStarredItems(items []StarredItem) (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO starred_items (link, title, source_url, source_title, pub_date, starred_at, origin) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	added := 0
+	for _, item := range items {
+		res, err := stmt.Exec(item.Link, item.Title, item.SourceURL, item.SourceTitle, item.PubDate, item.starredAt().Unix(), item.Origin)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+	return added, tx.Commit()
+}
+
+// DBLoadStarredItems 加载收藏条目，按收藏时间倒序
+func DBLoadStarredItems() ([]StarredItem, error) {
+	rows, err := DB.Query("SELECT link, title, source_url, source_title, pub_date, starred_at, origin FROM starred_items ORDER BY starred_at DESC, link")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]StarredItem, 0)
+	for rows.Next() {
+		var item StarredItem
+		var starredAt int64
+		if err := rows.Scan(&item.Link, &item.Title, &item.SourceURL, &item.SourceTitle, &item.PubDate, &starredAt, &item.Origin); err != nil {
+			return nil, err
+		}
+		item.StarredAt = time.Unix(starredAt, 0).Format(time.RFC3339)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// DBDeleteStarredItem 删除收藏条目，返回是否存在
+func DBDeleteStarredItem(link string) (bool, error) {
+	res, err := DB.Exec("DELETE FROM starred_items WHERE link = ?", link)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
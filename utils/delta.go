@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"feedora/models"
+	"time"
+)
+
+// FeedDelta 自某时间点以来有变化的卡片，Items 只包含新抓取或被修改的条目
+type FeedDelta struct {
+	models.Feed
+	// 卡片第一页全部条目的链接（按展示顺序），客户端据此移除已不在卡片中的条目并调整顺序
+	Links []string `json:"links"`
+}
+
+// FeedsDelta 增量数据
+type FeedsDelta struct {
+	// 服务器生成本次数据的时间，下次请求时作为 since 传回
+	Now string `json:"now"`
+	// 有变化的卡片
+	Feeds []FeedDelta `json:"feeds"`
+}
+
+// GetFeedsDelta 获取 since 之后有变化的卡片：卡片更新时间晚于 since，或第一页中有 since 之后抓取或修改的条目
+// 比较包含 since 当秒，边界上的条目可能重复返回，客户端按链接去重即可
+func GetFeedsDelta(since time.Time, limit int) FeedsDelta {
+	now := time.Now()
+	delta := FeedsDelta{
+		Now:   now.Format(time.RFC3339),
+		Feeds: make([]FeedDelta, 0),
+	}
+
+	for _, feed := range PaginateFeeds(GetFeeds(), limit) {
+		changed := false
+		if lastUpdate, ok := parseTimestamp(feed.Custom["lastupdate"]); ok && !lastUpdate.Before(since) {
+			changed = true
+		}
+
+		links := make([]string, 0, len(feed.Items))
+		items := make([]models.Item, 0)
+		for _, item := range feed.Items {
+			links = append(links, item.Link)
+			if isItemChangedSince(item, since) {
+				items = append(items, item)
+			}
+		}
+		if !changed && len(items) == 0 {
+			continue
+		}
+
+		feed.Items = items
+		delta.Feeds = append(delta.Feeds, FeedDelta{Feed: feed, Links: links})
+	}
+	return delta
+}
+
+// isItemChangedSince 判断条目是否在 since 之后（含当秒）被抓取或检测到修改
+func isItemChangedSince(item models.Item, since time.Time) bool {
+	for _, value := range []string{item.FetchTime, item.LastChanged} {
+		if t, ok := parseTimestamp(value); ok && !t.Before(since) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bytes"
+	"feedora/globals"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// FeedCandidate 自动发现得到的订阅地址候选
+type FeedCandidate struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	// 订阅格式: rss / atom / json
+	Format string `json:"format,omitempty"`
+}
+
+// discoverFallbackPaths 页面未声明订阅地址时尝试的常见路径
+var discoverFallbackPaths = []string{"/feed", "/rss", "/feed.xml", "/rss.xml", "/atom.xml", "/index.xml"}
+
+var (
+	htmlLinkTagPattern = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	htmlBaseTagPattern = regexp.MustCompile(`(?is)<base\b[^>]*>`)
+	htmlAttrPattern    = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// feedMimeFormats 订阅格式对应的 MIME 类型
+var feedMimeFormats = map[string]string{
+	"application/rss+xml":   "rss",
+	"application/atom+xml":  "atom",
+	"application/feed+json": "json",
+	"application/xml":       "rss",
+	"text/xml":              "rss",
+}
+
+// DiscoverFeeds 从网站地址发现订阅地址
+// 返回的 isFeed 表示地址本身就是订阅地址（无需修正），否则返回页面中声明的候选地址
+func DiscoverFeeds(siteURL string) (bool, []FeedCandidate, error) {
+	u, err := url.Parse(strings.TrimSpace(siteURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false, nil, fmt.Errorf("无效的网址: %s", siteURL)
+	}
+
+	body, contentType, finalURL, err := fetchDiscoverPage(u.String())
+	if err != nil {
+		return false, nil, err
+	}
+
+	// 地址本身就是订阅地址
+	if isFeedContent(body, contentType) {
+		return true, nil, nil
+	}
+
+	candidates := parseFeedLinks(body, finalURL)
+	if len(candidates) > 0 {
+		return false, candidates, nil
+	}
+
+	// 页面未声明时尝试常见路径
+	for _, path := range discoverFallbackPaths {
+		probe := &url.URL{Scheme: finalURL.Scheme, Host: finalURL.Host, Path: path}
+		probeBody, probeType, _, err := fetchDiscoverPage(probe.String())
+		if err != nil || !isFeedContent(probeBody, probeType) {
+			continue
+		}
+		candidates = append(candidates, FeedCandidate{URL: probe.String(), Format: detectFeedFormat(probeBody)})
+		break
+	}
+	return false, candidates, nil
+}
+
+// fetchDiscoverPage 获取页面内容（最多 2MB），返回内容、Content-Type 与重定向后的最终地址
+func fetchDiscoverPage(pageURL string) ([]byte, string, *url.URL, error) {
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/rss+xml,application/atom+xml,application/xml;q=0.9,*/*;q=0.8")
+
+	resp, err := globals.Fp.Client.Do(req)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", nil, fmt.Errorf("请求失败: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return body, resp.Header.Get("Content-Type"), resp.Request.URL, nil
+}
+
+// isFeedContent 判断内容是否为订阅（RSS/Atom/JSON Feed）
+func isFeedContent(body []byte, contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		return false
+	}
+	return detectFeedFormat(body) != ""
+}
+
+// detectFeedFormat 根据内容开头判断订阅格式，不是订阅时返回空字符串
+func detectFeedFormat(body []byte) string {
+	head := body
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	head = bytes.ToLower(bytes.TrimSpace(head))
+	switch {
+	case bytes.Contains(head, []byte("<rss")) || bytes.Contains(head, []byte("<rdf:rdf")):
+		return "rss"
+	case bytes.Contains(head, []byte("<feed")):
+		return "atom"
+	case bytes.HasPrefix(head, []byte("{")) && bytes.Contains(head, []byte("jsonfeed.org/version")):
+		return "json"
+	}
+	return ""
+}
+
+// parseFeedLinks 解析页面中的 <link rel="alternate"> 订阅地址
+func parseFeedLinks(body []byte, pageURL *url.URL) []FeedCandidate {
+	base := pageURL
+	if tag := htmlBaseTagPattern.Find(body); tag != nil {
+		if href := parseHTMLAttrs(string(tag))["href"]; href != "" {
+			if resolved, err := pageURL.Parse(href); err == nil {
+				base = resolved
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var candidates []FeedCandidate
+	for _, tag := range htmlLinkTagPattern.FindAll(body, -1) {
+		attrs := parseHTMLAttrs(string(tag))
+		if !strings.Contains(" "+strings.ToLower(attrs["rel"])+" ", " alternate ") {
+			continue
+		}
+		format, ok := feedMimeFormats[strings.ToLower(strings.TrimSpace(attrs["type"]))]
+		if !ok || attrs["href"] == "" {
+			continue
+		}
+		resolved, err := base.Parse(attrs["href"])
+		if err != nil || seen[resolved.String()] {
+			continue
+		}
+		seen[resolved.String()] = true
+		candidates = append(candidates, FeedCandidate{
+			URL:    resolved.String(),
+			Title:  attrs["title"],
+			Format: format,
+		})
+	}
+	return candidates
+}
+
+// parseHTMLAttrs 解析 HTML 标签中的属性（属性名转为小写，值进行实体解码）
+func parseHTMLAttrs(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range htmlAttrPattern.FindAllStringSubmatch(tag, -1) {
+		name := strings.ToLower(match[1])
+		if _, exists := attrs[name]; exists {
+			continue
+		}
+		attrs[name] = html.UnescapeString(match[2] + match[3] + match[4])
+	}
+	return attrs
+}
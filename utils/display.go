@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+)
+
+// resolveDisplayFlags 按 分组默认值 → 文件夹/订阅源 → 卡片覆盖 的顺序合并展示选项，写入 Feed
+// 未设置的选项均视为关闭
+func resolveDisplayFlags(feed *models.Feed, layers ...models.DisplayFlags) {
+	var effective models.DisplayFlags
+	for _, layer := range layers {
+		effective = effective.Merge(layer)
+	}
+	effective = effective.Merge(GetDisplayOverride(feed.Link))
+
+	feed.ShowPubDate = effective.ShowPubDate != nil && *effective.ShowPubDate
+	feed.ShowCategory = effective.ShowCategory != nil && *effective.ShowCategory
+	feed.ShowSource = effective.ShowSource != nil && *effective.ShowSource
+}
+
+// GetDisplayOverride 获取指定卡片的展示选项覆盖
+func GetDisplayOverride(link string) models.DisplayFlags {
+	globals.DisplayOverridesLock.RLock()
+	defer globals.DisplayOverridesLock.RUnlock()
+	return globals.DisplayOverrides[link]
+}
+
+// GetDisplayOverrides 获取所有卡片的展示选项覆盖（副本）
+func GetDisplayOverrides() map[string]models.DisplayFlags {
+	globals.DisplayOverridesLock.RLock()
+	defer globals.DisplayOverridesLock.RUnlock()
+	result := make(map[string]models.DisplayFlags, len(globals.DisplayOverrides))
+	for link, flags := range globals.DisplayOverrides {
+		result[link] = flags
+	}
+	return result
+}
+
+// SetDisplayOverride 设置卡片的单个展示选项覆盖，value 为 nil 表示清除覆盖（恢复继承）
+func SetDisplayOverride(link, key string, value *bool) error {
+	globals.DisplayOverridesLock.Lock()
+	flags := globals.DisplayOverrides[link]
+	switch key {
+	case "showPubDate":
+		flags.ShowPubDate = value
+	case "showCategory":
+		flags.ShowCategory = value
+	case "showSource":
+		flags.ShowSource = value
+	default:
+		globals.DisplayOverridesLock.Unlock()
+		return fmt.Errorf("不支持的展示选项: %s", key)
+	}
+	if flags.IsEmpty() {
+		delete(globals.DisplayOverrides, link)
+	} else {
+		globals.DisplayOverrides[link] = flags
+	}
+	globals.DisplayOverridesLock.Unlock()
+
+	// 异步写入数据库
+	go func() {
+		var err error
+		if flags.IsEmpty() {
+			err = DBDeleteDisplayOverride(link)
+		} else {
+			err = DBSaveDisplayOverride(link, flags)
+		}
+		if err != nil {
+			log.Printf("保存展示选项覆盖失败 [%s]: %v", link, err)
+		}
+	}()
+	return nil
+}
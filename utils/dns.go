@@ -0,0 +1,340 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"feedora/globals"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// 自定义 DNS 解析：按 dns 配置使用自定义 DNS 服务器或 DNS-over-HTTPS（RFC 8484）解析主机，结果在进程内缓存。
+// 解析通过替换 http.DefaultTransport 与代理访问限制 Transport 的拨号函数生效，未指定 Transport 的 HTTP 客户端
+// （AI 接口、通知等）与复用订阅抓取 Transport 的请求都经过这里。自定义解析失败或没有结果时回退到系统解析，
+// 使 localhost、局域网主机名等只存在于本机或内网 DNS 中的名称仍可访问。
+// 同一拨号函数还按 network 配置选择地址族（IPv4 / IPv6）并绑定本地地址
+
+// dnsMessageType DNS-over-HTTPS 请求与响应的内容类型
+const dnsMessageType = "application/dns-message"
+
+// dohClient 发送 DNS-over-HTTPS 请求的客户端，使用系统解析 DoH 服务器自身的地址（避免循环解析）
+var dohClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	Timeout: 10 * time.Second,
+}
+
+// dnsCacheEntry 缓存的解析结果
+type dnsCacheEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+var (
+	// 解析结果缓存: map[解析方式|主机] -> 结果
+	dnsCache     = make(map[string]dnsCacheEntry)
+	dnsCacheLock sync.Mutex
+)
+
+func init() {
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport.DialContext = resolvingDialContext(&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second})
+	}
+}
+
+// resolvingDialContext 包装 Dialer：配置了自定义解析、缓存、地址族或本地地址时先解析主机，
+// 再按地址族偏好依次连接解析到的地址
+func resolvingDialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		config, netConfig := globals.RssUrls.DNS, globals.RssUrls.Network
+		if !config.Custom() && config.GetCacheTTL() == 0 && !netConfig.Enabled() {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		ips := []net.IP{net.ParseIP(host)}
+		if ips[0] == nil {
+			if ips, err = lookupHostIPs(ctx, host); err != nil {
+				return nil, err
+			}
+		}
+		ips = orderByAddressFamily(ips, netConfig.AddressFamily)
+
+		var lastErr error
+		for _, ip := range ips {
+			if (network == "tcp4" && ip.To4() == nil) || (network == "tcp6" && ip.To4() != nil) {
+				continue
+			}
+			d := *dialer
+			if d.LocalAddr, err = bindLocalAddr(netConfig.BindAddress, ip); err != nil {
+				lastErr = err
+				continue
+			}
+			conn, err := d.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("%s 没有可用的 %s 地址", host, network)
+		}
+		return nil, lastErr
+	}
+}
+
+// orderByAddressFamily 按地址族偏好排列地址：prefer-* 将该地址族排在前面，ipv4 / ipv6 只保留该地址族
+func orderByAddressFamily(ips []net.IP, family string) []net.IP {
+	if family == "" {
+		return ips
+	}
+	var v4, v6 []net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	switch family {
+	case "ipv4":
+		return v4
+	case "ipv6":
+		return v6
+	case "prefer-ipv6":
+		return append(v6, v4...)
+	default:
+		return append(v4, v6...)
+	}
+}
+
+// bindLocalAddr 返回连接目标地址时绑定的本地地址：bind 为 IP 时直接使用（地址族需与目标相同），
+// 为网卡名称时使用该网卡上与目标同一地址族的地址；未设置时返回 nil
+func bindLocalAddr(bind string, target net.IP) (net.Addr, error) {
+	if bind == "" {
+		return nil, nil
+	}
+	targetV4 := target.To4() != nil
+	if ip := net.ParseIP(bind); ip != nil {
+		if (ip.To4() != nil) != targetV4 {
+			return nil, fmt.Errorf("本地地址 %s 与目标 %s 的地址族不同", bind, target)
+		}
+		return &net.TCPAddr{IP: ip}, nil
+	}
+	iface, err := net.InterfaceByName(bind)
+	if err != nil {
+		return nil, fmt.Errorf("网卡 %s 不存在: %w", bind, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || (ipNet.IP.To4() != nil) != targetV4 || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		return &net.TCPAddr{IP: ipNet.IP}, nil
+	}
+	return nil, fmt.Errorf("网卡 %s 没有可连接 %s 的地址", bind, target)
+}
+
+// lookupHostIPs 按 dns 配置解析主机的 IP 地址，结果按配置的时长缓存
+func lookupHostIPs(ctx context.Context, host string) ([]net.IP, error) {
+	config := globals.RssUrls.DNS
+	key := config.DoH + "|" + config.GetServer() + "|" + strings.ToLower(host)
+	ttl := config.GetCacheTTL()
+	if ttl > 0 {
+		dnsCacheLock.Lock()
+		entry, ok := dnsCache[key]
+		dnsCacheLock.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.ips, nil
+		}
+	}
+
+	var ips []net.IP
+	var err error
+	switch {
+	case config.DoH != "":
+		ips, err = lookupDoH(ctx, config.DoH, host)
+	case config.Server != "":
+		ips, err = lookupWithServer(ctx, config.GetServer(), host)
+	}
+	if config.Custom() && (err != nil || len(ips) == 0) {
+		if err != nil {
+			log.Printf("[DNS] 自定义解析 %s 失败，回退到系统解析: %v", host, err)
+		}
+		ips, err = nil, nil
+	}
+	if len(ips) == 0 && err == nil {
+		ips, err = lookupWithSystem(ctx, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 {
+		dnsCacheLock.Lock()
+		pruneDNSCache()
+		dnsCache[key] = dnsCacheEntry{ips: ips, expires: time.Now().Add(ttl)}
+		dnsCacheLock.Unlock()
+	}
+	return ips, nil
+}
+
+// pruneDNSCache 删除过期的解析结果，调用方需持有 dnsCacheLock
+func pruneDNSCache() {
+	now := time.Now()
+	for key, entry := range dnsCache {
+		if now.After(entry.expires) {
+			delete(dnsCache, key)
+		}
+	}
+}
+
+// lookupWithSystem 使用系统解析
+func lookupWithSystem(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// lookupWithServer 向指定的 DNS 服务器查询（仍会先读取 hosts 文件）
+func lookupWithServer(ctx context.Context, server, host string) ([]net.IP, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// lookupDoH 通过 DNS-over-HTTPS 查询主机的 A 与 AAAA 记录
+func lookupDoH(ctx context.Context, endpoint, host string) ([]net.IP, error) {
+	var ips []net.IP
+	var lastErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		found, err := queryDoH(ctx, endpoint, host, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ips = append(ips, found...)
+	}
+	if len(ips) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return ips, nil
+}
+
+// queryDoH 发送一次 DNS-over-HTTPS 查询（POST），返回应答中的地址
+func queryDoH(ctx context.Context, endpoint, host string, qtype dnsmessage.Type) ([]net.IP, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+	// RFC 8484 建议 DoH 请求的 ID 为 0，便于 HTTP 缓存
+	query, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dnsMessageType)
+	req.Header.Set("Accept", dnsMessageType)
+	resp, err := dohClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH 服务器返回 %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	return parseDNSAnswer(body)
+}
+
+// parseDNSAnswer 读取 DNS 应答中的 A / AAAA 记录（CNAME 链由服务器展开）
+func parseDNSAnswer(data []byte) ([]net.IP, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("DNS 应答错误: %s", header.RCode)
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for {
+		answer, err := parser.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch answer.Type {
+		case dnsmessage.TypeA:
+			record, err := parser.AResource()
+			if err != nil {
+				return nil, err
+			}
+			ips = append(ips, net.IP(record.A[:]))
+		case dnsmessage.TypeAAAA:
+			record, err := parser.AAAAResource()
+			if err != nil {
+				return nil, err
+			}
+			ips = append(ips, net.IP(record.AAAA[:]))
+		default:
+			if err := parser.SkipAnswer(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ips, nil
+}
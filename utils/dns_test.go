@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestParseDNSAnswer(t *testing.T) {
+	name := dnsmessage.MustNewName("feeds.example.com.")
+	target := dnsmessage.MustNewName("cdn.example.net.")
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{Response: true, RCode: dnsmessage.RCodeSuccess},
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+		Answers: []dnsmessage.Resource{
+			{Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 60}, Body: &dnsmessage.CNAMEResource{CNAME: target}},
+			{Header: dnsmessage.ResourceHeader{Name: target, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60}, Body: &dnsmessage.AResource{A: [4]byte{203, 0, 113, 7}}},
+			{Header: dnsmessage.ResourceHeader{Name: target, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60}, Body: &dnsmessage.AResource{A: [4]byte{203, 0, 113, 8}}},
+		},
+	}
+	data, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ips, err := parseDNSAnswer(data)
+	if err != nil {
+		t.Fatalf("parseDNSAnswer() error: %v", err)
+	}
+	want := []net.IP{net.IPv4(203, 0, 113, 7).To4(), net.IPv4(203, 0, 113, 8).To4()}
+	if !reflect.DeepEqual(ips, want) {
+		t.Errorf("parseDNSAnswer() = %v, want %v", ips, want)
+	}
+
+	msg.Header.RCode = dnsmessage.RCodeNameError
+	msg.Answers = nil
+	data, _ = msg.Pack()
+	if _, err := parseDNSAnswer(data); err == nil {
+		t.Error("parseDNSAnswer() with NXDOMAIN returned no error")
+	}
+}
+
+func TestOrderByAddressFamily(t *testing.T) {
+	v4, v6 := net.ParseIP("203.0.113.7"), net.ParseIP("2001:db8::7")
+	ips := []net.IP{v6, v4}
+	tests := map[string][]net.IP{
+		"":            {v6, v4},
+		"prefer-ipv4": {v4, v6},
+		"prefer-ipv6": {v6, v4},
+		"ipv4":        {v4},
+		"ipv6":        {v6},
+	}
+	for family, want := range tests {
+		if got := orderByAddressFamily(ips, family); !reflect.DeepEqual(got, want) {
+			t.Errorf("orderByAddressFamily(%q) = %v, want %v", family, got, want)
+		}
+	}
+
+	if _, err := bindLocalAddr("192.0.2.1", v6); err == nil {
+		t.Error("bindLocalAddr() with mismatched family returned no error")
+	}
+	if addr, err := bindLocalAddr("192.0.2.1", v4); err != nil || addr.String() != "192.0.2.1:0" {
+		t.Errorf("bindLocalAddr() = %v, %v", addr, err)
+	}
+}
@@ -0,0 +1,303 @@
+package utils
+
+import (
+	"encoding/json"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// embeddingTextLimit 计算向量时使用的描述长度（字符）
+const embeddingTextLimit = 500
+
+var (
+	// 条目向量缓存: map[链接] -> 向量（仅包含当前模型的向量）
+	itemEmbeddings     = make(map[string][]float32)
+	itemEmbeddingsLock sync.RWMutex
+	// 向量缓存对应的模型，配置中的模型变化后重新加载
+	embeddingsModel string
+
+	// 正在计算向量的条目，避免重复请求
+	embeddingPending     = make(map[string]bool)
+	embeddingPendingLock sync.Mutex
+	// 串行发送向量请求，避免多个源同时更新时打满接口
+	embeddingRequestLock sync.Mutex
+)
+
+// embeddingEnabled 是否启用了语义向量
+func embeddingEnabled() bool {
+	config := globals.RssUrls.Embedding
+	return config.Enabled && config.HasAPIAccess(globals.RssUrls.AIClassify)
+}
+
+// ensureEmbeddingsLoaded 确保向量缓存与当前模型一致，模型变化时从数据库重新加载
+func ensureEmbeddingsLoaded(model string) {
+	itemEmbeddingsLock.RLock()
+	loaded := embeddingsModel == model
+	itemEmbeddingsLock.RUnlock()
+	if loaded {
+		return
+	}
+
+	vectors, err := DBLoadEmbeddings(model)
+	if err != nil {
+		log.Printf("[语义向量] 加载失败: %v", err)
+		vectors = make(map[string][]float32)
+	}
+	itemEmbeddingsLock.Lock()
+	if embeddingsModel != model {
+		itemEmbeddings = vectors
+		embeddingsModel = model
+		log.Printf("[语义向量] 已加载 %d 条向量 (模型: %s)", len(vectors), model)
+	}
+	itemEmbeddingsLock.Unlock()
+}
+
+// getItemEmbedding 获取条目向量
+func getItemEmbedding(link string) ([]float32, bool) {
+	itemEmbeddingsLock.RLock()
+	defer itemEmbeddingsLock.RUnlock()
+	vector, ok := itemEmbeddings[link]
+	return vector, ok
+}
+
+// embeddingText 构建计算向量的文本：标题 + 截断的纯文本描述
+func embeddingText(item models.Item) string {
+	text := strings.TrimSpace(item.Title)
+	if desc := stripHTML(item.Description); desc != "" {
+		text += "\n" + truncateString(desc, embeddingTextLimit)
+	}
+	return text
+}
+
+// embedItems 为尚无向量的条目计算向量并保存
+func embedItems(items []models.Item) {
+	if !embeddingEnabled() || len(items) == 0 {
+		return
+	}
+	config := globals.RssUrls.Embedding
+	model := config.GetModel()
+	ensureEmbeddingsLoaded(model)
+
+	// 筛选需要计算的条目（跳过已有向量、正在计算以及加载失败的提示项）
+	var pending []models.Item
+	embeddingPendingLock.Lock()
+	for _, item := range items {
+		if item.Link == "" || strings.TrimSpace(item.Title) == "" || embeddingPending[item.Link] {
+			continue
+		}
+		if _, ok := getItemEmbedding(item.Link); ok {
+			continue
+		}
+		embeddingPending[item.Link] = true
+		pending = append(pending, item)
+	}
+	embeddingPendingLock.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	defer func() {
+		embeddingPendingLock.Lock()
+		for _, item := range pending {
+			delete(embeddingPending, item.Link)
+		}
+		embeddingPendingLock.Unlock()
+	}()
+
+	embeddingRequestLock.Lock()
+	defer embeddingRequestLock.Unlock()
+
+	batchSize := config.GetBatchSize()
+	saved := 0
+	for start := 0; start < len(pending); start += batchSize {
+		end := start + batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+		texts := make([]string, len(batch))
+		for i, item := range batch {
+			texts[i] = embeddingText(item)
+		}
+
+		vectors, err := requestEmbeddings(config, texts)
+		if err != nil {
+			log.Printf("[语义向量] 计算失败 (%d 条): %v", len(batch), err)
+			return
+		}
+
+		result := make(map[string][]float32, len(batch))
+		for i, item := range batch {
+			result[item.Link] = vectors[i]
+		}
+		if err := DBSaveEmbeddings(model, result); err != nil {
+			log.Printf("[语义向量] 保存失败: %v", err)
+		}
+		itemEmbeddingsLock.Lock()
+		if embeddingsModel == model {
+			for link, vector := range result {
+				itemEmbeddings[link] = vector
+			}
+		}
+		itemEmbeddingsLock.Unlock()
+		saved += len(result)
+	}
+	log.Printf("[语义向量] 已计算 %d 条向量 (模型: %s)", saved, model)
+}
+
+// requestEmbeddings 调用向量接口，返回与输入顺序一致的向量
+func requestEmbeddings(config models.EmbeddingConfig, texts []string) ([][]float32, error) {
+	aiConfig := globals.RssUrls.AIClassify
+	apiKey, err := ResolveSecret(config.GetAPIKey(aiConfig))
+	if err != nil {
+		return nil, err
+	}
+	apiBase := strings.TrimSuffix(config.GetAPIBase(aiConfig), "/")
+	client := &http.Client{Timeout: time.Duration(config.GetTimeout()) * time.Second}
+	headers := map[string]string{}
+	if apiKey != "" {
+		headers["Authorization"] = "Bearer " + apiKey
+	}
+	payload := map[string]interface{}{
+		"model": config.GetModel(),
+		"input": texts,
+	}
+
+	var vectors [][]float32
+	if config.GetProvider() == "ollama" {
+		body, err := postLLMJSON(client, apiBase+"/api/embed", headers, payload)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Embeddings [][]float32 `json:"embeddings"`
+			Error      string      `json:"error,omitempty"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w (Body: %s)", err, truncateString(string(body), 200))
+		}
+		if result.Error != "" {
+			return nil, fmt.Errorf("API错误: %s", result.Error)
+		}
+		vectors = result.Embeddings
+	} else {
+		body, err := postLLMJSON(client, apiBase+"/embeddings", headers, payload)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+			Error *ChatError `json:"error,omitempty"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w (Body: %s)", err, truncateString(string(body), 200))
+		}
+		if result.Error != nil {
+			return nil, fmt.Errorf("API错误: %s", result.Error.Message)
+		}
+		sort.Slice(result.Data, func(i, j int) bool { return result.Data[i].Index < result.Data[j].Index })
+		for _, d := range result.Data {
+			vectors = append(vectors, d.Embedding)
+		}
+	}
+
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("返回的向量数 %d 与请求的条目数 %d 不一致", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// vectorCosine 计算两个向量的余弦相似度（维度不同时返回 0）
+func vectorCosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// clusterSimilarItems 将语义相似的报道合并为一条：按当前顺序，每条与已有的代表条目比较，
+// 相似度达到阈值时并入该代表条目（记录来源与链接），否则成为新的代表条目
+// 尚无向量的条目原样保留，并在后台计算向量，下次构建卡片时参与合并
+func clusterSimilarItems(items []models.Item) []models.Item {
+	if !embeddingEnabled() || len(items) < 2 {
+		return items
+	}
+	ensureEmbeddingsLoaded(globals.RssUrls.Embedding.GetModel())
+	threshold := globals.RssUrls.Embedding.GetThreshold()
+
+	type cluster struct {
+		index  int
+		vector []float32
+	}
+	var clusters []cluster
+	var missing []models.Item
+	result := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		vector, ok := getItemEmbedding(item.Link)
+		if !ok {
+			if !strings.Contains(item.Title, "⚠️") {
+				missing = append(missing, item)
+			}
+			result = append(result, item)
+			continue
+		}
+
+		merged := false
+		for _, c := range clusters {
+			if vectorCosine(vector, c.vector) < threshold {
+				continue
+			}
+			rep := &result[c.index]
+			if rep.ClusterSize == 0 {
+				rep.ClusterSize = 1
+			}
+			rep.ClusterSize++
+			if item.Source != "" {
+				rep.ClusterSources = append(rep.ClusterSources, item.Source)
+			}
+			rep.ClusterLinks = append(rep.ClusterLinks, item.Link)
+			merged = true
+			break
+		}
+		if !merged {
+			clusters = append(clusters, cluster{index: len(result), vector: vector})
+			result = append(result, item)
+		}
+	}
+
+	if len(missing) > 0 {
+		go embedItems(missing)
+	}
+	return result
+}
+
+// purgeEmbeddings 删除已清理条目的向量
+func purgeEmbeddings(links []string) {
+	itemEmbeddingsLock.Lock()
+	for _, link := range links {
+		delete(itemEmbeddings, link)
+	}
+	itemEmbeddingsLock.Unlock()
+	if err := DBDeleteEmbeddingsBatch(links); err != nil {
+		log.Printf("[数据保留] 删除语义向量失败: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 条目导出：按订阅源、文件夹、类别、时间范围与已读状态筛选已抓取的条目，逐条输出为 JSON Lines 或 CSV，
+// 用于离线分析阅读记录或导入其他工具。条目来自各源当前的条目（含缓存合并的历史条目）
+
+// ItemExportFilter 条目导出的筛选条件（零值表示不限制）
+type ItemExportFilter struct {
+	// 只导出这些订阅源的条目，为空时导出所有源
+	Sources []string
+	// 只导出文件夹包含的订阅源（含分类包与子文件夹展开），与 Sources 同时设置时取交集
+	Folder string
+	// 只导出属于该类别（含子类别）的条目
+	Category string
+	// 发布时间（无发布时间时按抓取时间）范围，设置后没有时间的条目不导出
+	Since time.Time
+	Until time.Time
+	// 已读状态: "read" / "unread"，为空时不限
+	Read string
+}
+
+// ExportItem 导出的条目
+type ExportItem struct {
+	Source      string   `json:"source"`
+	SourceName  string   `json:"sourceName,omitempty"`
+	Title       string   `json:"title"`
+	Link        string   `json:"link"`
+	PubDate     string   `json:"pubDate,omitempty"`
+	FetchTime   string   `json:"fetchTime,omitempty"`
+	Categories  []string `json:"categories,omitempty"`
+	Score       int      `json:"score,omitempty"`
+	Comments    int      `json:"comments,omitempty"`
+	Relevance   *int     `json:"relevance,omitempty"`
+	Read        bool     `json:"read"`
+	ReadAt      string   `json:"readAt,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// ExportCSVHeader CSV 导出的表头，与 ExportItem.CSVRecord 的列一一对应
+var ExportCSVHeader = []string{
+	"source", "sourceName", "title", "link", "pubDate", "fetchTime", "categories",
+	"score", "comments", "relevance", "read", "readAt", "description",
+}
+
+// CSVRecord 返回条目的 CSV 行（多个类别以分号分隔）
+func (e ExportItem) CSVRecord() []string {
+	relevance := ""
+	if e.Relevance != nil {
+		relevance = strconv.Itoa(*e.Relevance)
+	}
+	return []string{
+		e.Source, e.SourceName, e.Title, e.Link, e.PubDate, e.FetchTime, strings.Join(e.Categories, ";"),
+		strconv.Itoa(e.Score), strconv.Itoa(e.Comments), relevance, strconv.FormatBool(e.Read), e.ReadAt, e.Description,
+	}
+}
+
+// ParseItemExportFilter 解析导出接口的查询参数：source（可重复）、folder、category、since、until、read
+func ParseItemExportFilter(query url.Values) (ItemExportFilter, error) {
+	filter := ItemExportFilter{
+		Folder:   query.Get("folder"),
+		Category: query.Get("category"),
+		Read:     query.Get("read"),
+	}
+	for _, source := range query["source"] {
+		if source == "" {
+			continue
+		}
+		if globals.RssUrls.GetSourceByURL(source) == nil {
+			return filter, fmt.Errorf("订阅源不存在: %s", source)
+		}
+		filter.Sources = append(filter.Sources, source)
+	}
+	if filter.Folder != "" && globals.RssUrls.GetFolderByID(filter.Folder) == nil {
+		return filter, fmt.Errorf("文件夹不存在: %s", filter.Folder)
+	}
+	switch filter.Read {
+	case "", "read", "unread":
+	default:
+		return filter, fmt.Errorf("未知的已读状态: %s（可选 read / unread）", filter.Read)
+	}
+
+	var err error
+	if filter.Since, err = parseExportTime(query.Get("since"), false); err != nil {
+		return filter, fmt.Errorf("since 无效: %w", err)
+	}
+	if filter.Until, err = parseExportTime(query.Get("until"), true); err != nil {
+		return filter, fmt.Errorf("until 无效: %w", err)
+	}
+	return filter, nil
+}
+
+// parseExportTime 解析时间参数：RFC3339、Unix 秒或日期（YYYY-MM-DD，本地时区；作为结束时间时包含当天）
+func parseExportTime(raw string, endOfDay bool) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("应为 RFC3339、Unix 秒或 YYYY-MM-DD: %s", raw)
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return day, nil
+}
+
+// ExportItems 按筛选条件逐条输出条目（按订阅源的配置顺序，源内保持卡片中的顺序），emit 返回错误时停止
+func ExportItems(filter ItemExportFilter, emit func(ExportItem) error) error {
+	var categories map[string]bool
+	if filter.Category != "" {
+		categories = make(map[string]bool)
+		for _, id := range globals.RssUrls.ExpandCategoryIDs([]string{filter.Category}) {
+			categories[id] = true
+		}
+	}
+	readState := GetReadState()
+
+	for _, sourceURL := range exportSourceURLs(filter) {
+		globals.Lock.RLock()
+		feed, ok := globals.DbMap[sourceURL]
+		items := append([]models.Item(nil), feed.Items...)
+		globals.Lock.RUnlock()
+		if !ok {
+			continue
+		}
+		name := feed.Title
+		if source := globals.RssUrls.GetSourceByURL(sourceURL); source != nil && source.Name != "" {
+			name = source.Name
+		}
+
+		for _, item := range items {
+			if item.Link == "" || (categories != nil && !hasAnyCategory(item, categories)) {
+				continue
+			}
+			if !filter.Since.IsZero() || !filter.Until.IsZero() {
+				t, ok := getItemSortTime(item)
+				if !ok || (!filter.Since.IsZero() && t.Before(filter.Since)) || (!filter.Until.IsZero() && t.After(filter.Until)) {
+					continue
+				}
+			}
+			readAt, read := readState[item.Link]
+			if (filter.Read == "read" && !read) || (filter.Read == "unread" && read) {
+				continue
+			}
+
+			exported := ExportItem{
+				Source:      sourceURL,
+				SourceName:  name,
+				Title:       item.Title,
+				Link:        item.Link,
+				PubDate:     item.PubDate,
+				FetchTime:   item.FetchTime,
+				Categories:  item.CategoryIDs(),
+				Score:       item.Score,
+				Comments:    item.Comments,
+				Relevance:   item.Relevance,
+				Read:        read,
+				Description: stripHTML(item.Description),
+			}
+			if read {
+				exported.ReadAt = time.Unix(readAt, 0).Format(time.RFC3339)
+			}
+			if err := emit(exported); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportSourceURLs 按筛选条件确定要导出的订阅源（配置顺序）
+func exportSourceURLs(filter ItemExportFilter) []string {
+	var allowed map[string]bool
+	if filter.Folder != "" {
+		allowed = make(map[string]bool)
+		if folder := globals.RssUrls.GetFolderByID(filter.Folder); folder != nil {
+			for _, u := range getFolderSourceURLs(*folder) {
+				allowed[u] = true
+			}
+		}
+	}
+	selected := make(map[string]bool, len(filter.Sources))
+	for _, u := range filter.Sources {
+		selected[u] = true
+	}
+
+	var urls []string
+	for _, source := range globals.RssUrls.Sources {
+		if source.URL == "" || (allowed != nil && !allowed[source.URL]) || (len(selected) > 0 && !selected[source.URL]) {
+			continue
+		}
+		urls = append(urls, source.URL)
+	}
+	return urls
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportItems(t *testing.T) {
+	savedConfig, savedDbMap, savedReadState := globals.RssUrls, globals.DbMap, globals.ReadState
+	defer func() { globals.RssUrls, globals.DbMap, globals.ReadState = savedConfig, savedDbMap, savedReadState }()
+
+	globals.RssUrls = models.Config{
+		Sources: []models.Source{{URL: "https://a.example.com/feed", Name: "A"}, {URL: "https://b.example.com/feed"}},
+		Folders: []models.Folder{{ID: "f1", Entries: []models.FolderEntry{{SourceURL: "https://b.example.com/feed"}}}},
+	}
+	globals.DbMap = map[string]models.Feed{
+		"https://a.example.com/feed": {Title: "Feed A", Items: []models.Item{
+			{Link: "a1", Title: "A1", PubDate: "2026-01-02T08:00:00Z", Category: "tech"},
+			{Link: "a2", Title: "A2", PubDate: "2025-12-01T08:00:00Z"},
+		}},
+		"https://b.example.com/feed": {Title: "Feed B", Items: []models.Item{
+			{Link: "b1", Title: "B1", Description: "<p>hello</p>"},
+		}},
+	}
+	globals.ReadState = map[string]int64{"a1": time.Now().Unix()}
+
+	export := func(query string) []string {
+		values, _ := url.ParseQuery(query)
+		filter, err := ParseItemExportFilter(values)
+		if err != nil {
+			t.Fatalf("ParseItemExportFilter(%q) error: %v", query, err)
+		}
+		var links []string
+		ExportItems(filter, func(item ExportItem) error {
+			links = append(links, item.Link)
+			return nil
+		})
+		return links
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"a1", "a2", "b1"}},
+		{"source=https://a.example.com/feed", []string{"a1", "a2"}},
+		{"folder=f1", []string{"b1"}},
+		{"since=2026-01-01", []string{"a1"}},
+		{"until=2025-12-01", []string{"a2"}},
+		{"read=unread", []string{"a2", "b1"}},
+		{"category=tech", []string{"a1"}},
+	}
+	for _, tt := range tests {
+		if got := export(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("export(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"source=https://missing.example.com/", "folder=missing", "read=maybe", "since=yesterday"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := ParseItemExportFilter(values); err == nil {
+			t.Errorf("ParseItemExportFilter(%q) accepted invalid filter", query)
+		}
+	}
+}
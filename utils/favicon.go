@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// 站点图标解析：不依赖第三方 favicon 服务，由服务端读取站点首页中声明的图标
+// （<link rel="icon">、apple-touch-icon、Web App Manifest），都不可用时回退到 /favicon.ico。
+// 卡片中的图标地址为 favicon:<站点地址> 形式的占位地址，经 /api/icon 首次请求时解析，结果以占位地址为键存入图标缓存
+
+// siteFaviconPrefix 站点图标占位地址的前缀
+const siteFaviconPrefix = "favicon:"
+
+// 站点首页与单个图标的读取上限，以及解析失败后再次尝试的间隔
+const (
+	faviconMaxPageSize = 512 * 1024
+	faviconMaxIconSize = 1024 * 1024
+	faviconRetryAfter  = 6 * time.Hour
+)
+
+var (
+	// 最近解析失败的站点: map[站点地址] -> 失败时间，避免每次加载页面都重新请求
+	faviconMisses     = make(map[string]time.Time)
+	faviconMissesLock sync.Mutex
+)
+
+// siteFaviconURL 返回站点图标的占位地址
+func siteFaviconURL(origin string) string {
+	return siteFaviconPrefix + origin
+}
+
+// IsSiteFaviconURL 判断图标地址是否为待解析的站点图标占位地址（不能直接由浏览器加载）
+func IsSiteFaviconURL(iconURL string) bool {
+	return strings.HasPrefix(iconURL, siteFaviconPrefix)
+}
+
+// fetchSiteFavicon 解析并下载站点图标，结果以占位地址为键缓存
+func fetchSiteFavicon(iconURL string) ([]byte, string, error) {
+	origin := strings.TrimPrefix(iconURL, siteFaviconPrefix)
+	site, err := url.Parse(origin)
+	if err != nil || (site.Scheme != "http" && site.Scheme != "https") || site.Host == "" {
+		return nil, "", fmt.Errorf("无效的站点地址: %s", origin)
+	}
+
+	faviconMissesLock.Lock()
+	missedAt, missed := faviconMisses[origin]
+	faviconMissesLock.Unlock()
+	if missed && time.Since(missedAt) < faviconRetryAfter {
+		return nil, "", errors.New("站点没有可用的图标")
+	}
+
+	client := newGuardedClient(10 * time.Second)
+	for _, candidate := range faviconCandidates(client, site) {
+		data, mimeType, err := downloadFavicon(client, candidate)
+		if err != nil {
+			continue
+		}
+		if err := DBSaveIconCache(iconURL, data, mimeType); err != nil {
+			log.Printf("[图标] 缓存站点图标失败 [%s]: %v", origin, err)
+		}
+		return data, mimeType, nil
+	}
+
+	faviconMissesLock.Lock()
+	faviconMisses[origin] = time.Now()
+	faviconMissesLock.Unlock()
+	return nil, "", errors.New("站点没有可用的图标")
+}
+
+// faviconCandidates 按优先级列出站点的候选图标：<link rel="icon">、apple-touch-icon、Manifest 中的图标、/favicon.ico
+func faviconCandidates(client *http.Client, site *url.URL) []string {
+	root := &url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/"}
+	var icons, touchIcons, manifestIcons []string
+
+	resp, err := guardedGet(client, root.String())
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			base := resp.Request.URL
+			page := io.LimitReader(resp.Body, faviconMaxPageSize)
+			var manifest string
+			icons, touchIcons, manifest = parseIconLinks(page, base)
+			if manifest != "" {
+				manifestIcons = fetchManifestIcons(client, manifest)
+			}
+		}
+	}
+
+	candidates := make([]string, 0, len(icons)+len(touchIcons)+len(manifestIcons)+1)
+	seen := make(map[string]bool)
+	for _, group := range [][]string{icons, touchIcons, manifestIcons, {root.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()}} {
+		for _, candidate := range group {
+			if !seen[candidate] {
+				seen[candidate] = true
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	return candidates
+}
+
+// parseIconLinks 读取页面 <head> 中声明的图标与 Manifest 地址（相对地址按页面地址解析）
+func parseIconLinks(page io.Reader, base *url.URL) (icons, touchIcons []string, manifest string) {
+	tokenizer := html.NewTokenizer(page)
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			return
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := tokenizer.TagName()
+		switch string(name) {
+		case "body":
+			// 图标只在 <head> 中声明
+			return
+		case "link":
+			if !hasAttr {
+				continue
+			}
+			var rel, href string
+			for {
+				key, value, more := tokenizer.TagAttr()
+				switch string(key) {
+				case "rel":
+					rel = strings.ToLower(string(value))
+				case "href":
+					href = strings.TrimSpace(string(value))
+				}
+				if !more {
+					break
+				}
+			}
+			link := resolveIconHref(base, href)
+			if link == "" {
+				continue
+			}
+			for _, token := range strings.Fields(rel) {
+				switch token {
+				case "icon":
+					icons = append(icons, link)
+				case "apple-touch-icon", "apple-touch-icon-precomposed":
+					touchIcons = append(touchIcons, link)
+				case "manifest":
+					if manifest == "" {
+						manifest = link
+					}
+				}
+			}
+		}
+	}
+}
+
+// fetchManifestIcons 读取 Web App Manifest 中声明的图标
+func fetchManifestIcons(client *http.Client, manifestURL string) []string {
+	resp, err := guardedGet(client, manifestURL)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	var manifest struct {
+		Icons []struct {
+			Src string `json:"src"`
+		} `json:"icons"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, faviconMaxPageSize)).Decode(&manifest); err != nil {
+		return nil
+	}
+	icons := make([]string, 0, len(manifest.Icons))
+	for _, icon := range manifest.Icons {
+		if link := resolveIconHref(resp.Request.URL, icon.Src); link != "" {
+			icons = append(icons, link)
+		}
+	}
+	return icons
+}
+
+// resolveIconHref 将图标地址解析为绝对地址，只接受 http(s) 地址
+func resolveIconHref(base *url.URL, href string) string {
+	if href == "" {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	link := base.ResolveReference(ref)
+	if link.Scheme != "http" && link.Scheme != "https" {
+		return ""
+	}
+	return link.String()
+}
+
+// downloadFavicon 下载图标，只接受非空的图片内容（未声明类型时按内容判断）
+func downloadFavicon(client *http.Client, iconURL string) ([]byte, string, error) {
+	resp, err := guardedGet(client, iconURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fetch icon failed: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, faviconMaxIconSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 || len(data) > faviconMaxIconSize {
+		return nil, "", fmt.Errorf("图标大小无效: %d 字节", len(data))
+	}
+
+	mimeType := strings.ToLower(strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0]))
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+		if !strings.HasPrefix(mimeType, "image/") {
+			return nil, "", fmt.Errorf("不是图片: %s", mimeType)
+		}
+	}
+	return data, mimeType, nil
+}
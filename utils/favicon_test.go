@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseIconLinks(t *testing.T) {
+	page := `<!DOCTYPE html><html><head>
+<link rel="stylesheet" href="/style.css">
+<link rel="shortcut icon" href="/static/icon.png">
+<link rel="apple-touch-icon" sizes="180x180" href="https://cdn.example.com/touch.png">
+<link rel="manifest" href="site.webmanifest">
+<link rel="icon" href="data:image/png;base64,AAAA">
+</head><body><link rel="icon" href="/late.png"></body></html>`
+	base, _ := url.Parse("https://example.com/blog/")
+
+	icons, touchIcons, manifest := parseIconLinks(strings.NewReader(page), base)
+	if want := []string{"https://example.com/static/icon.png"}; !reflect.DeepEqual(icons, want) {
+		t.Errorf("icons = %v, want %v", icons, want)
+	}
+	if want := []string{"https://cdn.example.com/touch.png"}; !reflect.DeepEqual(touchIcons, want) {
+		t.Errorf("touchIcons = %v, want %v", touchIcons, want)
+	}
+	if want := "https://example.com/blog/site.webmanifest"; manifest != want {
+		t.Errorf("manifest = %q, want %q", manifest, want)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"feedora/globals"
 	"feedora/models"
 	"log"
+	"math"
 	"net/url"
+	"reflect"
 	"sort"
 	"strings"
 	"time"
@@ -49,7 +51,12 @@ func getEffectiveInterval(rssURL string, sourceRefreshCount int) (int, string) {
 				count = sourceRefreshCount
 			}
 			interval := s.BaseRefresh * count
-			return interval, fmt.Sprintf("时段规则 (%s-%s, 基频:%d, 次数:%d)", s.StartTime, s.EndTime, s.BaseRefresh, count)
+			desc := fmt.Sprintf("时段规则 (%s-%s, 基频:%d, 次数:%d)", s.StartTime, s.EndTime, s.BaseRefresh, count)
+			// 开启 fetch.updateHints 时按源声明的更新提示调整
+			if hinted, note := applyUpdateHints(rssURL, interval, time.Now()); note != "" {
+				return hinted, desc + ", " + note
+			}
+			return interval, desc
 		}
 	}
 
@@ -58,16 +65,23 @@ func getEffectiveInterval(rssURL string, sourceRefreshCount int) (int, string) {
 }
 
 func UpdateFeeds() {
+	warmStart := true
 	for {
 		now := time.Now()
 		formattedTime := now.Format(time.RFC3339)
 
 		var nextGlobalUpdate time.Time
 
+		// 重启后的第一轮先抓取默认分组中的源，完成后再调度其余的源
+		if warmStart {
+			warmStartDefaultGroup(formattedTime, now, &nextGlobalUpdate)
+			warmStart = false
+		}
+
 		// 获取当前所有URL的刷新需求
 		for _, source := range globals.RssUrls.Sources {
-			if source.URL != "" {
-				processFeedUpdate(source.URL, source.RefreshCount, formattedTime, now, &nextGlobalUpdate)
+			if source.URL != "" && source.GetType() != "webhook" {
+				processFeedUpdate(source.URL, source.RefreshCount, formattedTime, now, &nextGlobalUpdate, nil)
 			}
 		}
 
@@ -80,13 +94,28 @@ func UpdateFeeds() {
 	}
 }
 
-func processFeedUpdate(urlBack string, sourceRefreshCount int, formattedTime string, now time.Time, nextGlobalUpdate *time.Time) {
+// processFeedUpdate 到达抓取间隔时在后台更新源，done 不为空时在更新结束后调用 done.Done()
+func processFeedUpdate(urlBack string, sourceRefreshCount int, formattedTime string, now time.Time, nextGlobalUpdate *time.Time, done *sync.WaitGroup) {
+	// 按需抓取的源不参与定时抓取，请求卡片时再抓取
+	if isOnDemandSource(urlBack) {
+		return
+	}
+
 	interval, _ := getEffectiveInterval(urlBack, sourceRefreshCount)
 
 	if interval <= 0 {
 		return
 	}
 
+	// WebSub 租约有效期间由 Hub 推送触发更新，暂停轮询
+	if IsWebSubActive(urlBack) {
+		return
+	}
+	// 已失效的源停止定时抓取，手动刷新成功后恢复
+	if IsSourceDead(urlBack) {
+		return
+	}
+
 	lutLock.Lock()
 	lastUpdate, ok := lastUpdateTimes[urlBack]
 	lutLock.Unlock()
@@ -94,8 +123,18 @@ func processFeedUpdate(urlBack string, sourceRefreshCount int, formattedTime str
 	intervalDuration := time.Duration(interval) * time.Minute
 
 	if !ok || now.Sub(lastUpdate) >= intervalDuration {
+		// 超出流量预算时推迟低优先级源，预算恢复后的下一轮调度再抓取
+		if bandwidthDeferred(globals.RssUrls.GetSourceByURL(urlBack)) {
+			return
+		}
 		// 执行更新（带重试机制）
+		if done != nil {
+			done.Add(1)
+		}
 		go func(url, formattedTime string) {
+			if done != nil {
+				defer done.Done()
+			}
 			const maxRetries = 3
 			const retryDelay = 1 * time.Second
 
@@ -141,9 +180,13 @@ func GetFaviconURL(rssURL string) string {
 	if err != nil {
 		return ""
 	}
-	// 使用 Google 的 favicon 服务
+	// 虚拟源（如 script://）没有对应站点
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return ""
+	}
+	// 站点图标由 /api/icon 解析站点首页后提供，见 favicon.go
 	if parsedURL.Host != "" {
-		return "https://www.google.com/s2/favicons?domain=" + parsedURL.Host + "&sz=64"
+		return siteFaviconURL(parsedURL.Scheme + "://" + parsedURL.Host)
 	}
 	return ""
 }
@@ -294,6 +337,29 @@ func compareItemsByRecency(left, right models.Item) int {
 	return 0
 }
 
+// applyMaxPerSource 按排序结果保留每个来源（订阅源或子文件夹）排在最前的 maxPerSource 条，防止高产的源占满文件夹
+func applyMaxPerSource(items []models.Item, maxPerSource int, origins map[string]string) []models.Item {
+	if maxPerSource <= 0 {
+		return items
+	}
+	counts := make(map[string]int)
+	kept := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		origin, ok := origins[item.Link]
+		if !ok {
+			// 来源未知的条目（如源加载失败的提示项）不受限制
+			kept = append(kept, item)
+			continue
+		}
+		if counts[origin] >= maxPerSource {
+			continue
+		}
+		counts[origin]++
+		kept = append(kept, item)
+	}
+	return kept
+}
+
 func applyFolderItemLimit(folder models.Folder, items []models.Item) []models.Item {
 	switch folder.GetLimitMode() {
 	case "count":
@@ -329,12 +395,12 @@ func FetchAndCacheIcon(iconURL string) ([]byte, string, error) {
 	if err == nil && ok {
 		return data, mimeType, nil
 	}
-
-	// 从网络获取
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	if IsSiteFaviconURL(iconURL) {
+		return fetchSiteFavicon(iconURL)
 	}
-	resp, err := client.Get(iconURL)
+
+	// 从网络获取（禁止访问内网地址，见 proxyguard.go）
+	resp, err := guardedGet(newGuardedClient(10*time.Second), iconURL)
 	if err != nil {
 		return nil, "", err
 	}
@@ -344,10 +410,13 @@ func FetchAndCacheIcon(iconURL string) ([]byte, string, error) {
 		return nil, "", fmt.Errorf("fetch icon failed: %s", resp.Status)
 	}
 
-	data, err = io.ReadAll(resp.Body)
+	data, err = io.ReadAll(io.LimitReader(resp.Body, faviconMaxIconSize+1))
 	if err != nil {
 		return nil, "", err
 	}
+	if len(data) > faviconMaxIconSize {
+		return nil, "", fmt.Errorf("icon too large: more than %d bytes", faviconMaxIconSize)
+	}
 
 	mimeType = resp.Header.Get("Content-Type")
 	if mimeType == "" {
@@ -360,12 +429,31 @@ func FetchAndCacheIcon(iconURL string) ([]byte, string, error) {
 	return data, mimeType, nil
 }
 
+// fetchFeed 根据订阅源类型抓取内容，统一转换为 gofeed.Feed 供后续流程处理
+func fetchFeed(rssURL string) (*gofeed.Feed, error) {
+	if source := globals.RssUrls.GetSourceByURL(rssURL); source != nil {
+		return fetchSourceFeed(*source)
+	}
+	return fetchSourceFeed(models.Source{URL: rssURL})
+}
+
+// fetchSourceFeed 按订阅源配置抓取内容（源可以尚未加入配置，如添加前的试抓取）
+// 具体的抓取方式由源类型或 URL 协议对应的 Fetcher 决定，见 fetcher.go
+func fetchSourceFeed(source models.Source) (*gofeed.Feed, error) {
+	return resolveFetcher(source).Fetch(source)
+}
+
 func UpdateFeed(url, formattedTime string, isManual bool) error {
 	return UpdateFeedWithOptions(url, formattedTime, isManual, false)
 }
 
 // UpdateFeedWithOptions 更新Feed，支持强制重新处理选项
 func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReprocess bool) error {
+	// 推送源（webhook）没有可抓取的地址，仅通过 /api/ingest 写入条目
+	if source := globals.RssUrls.GetSourceByURL(url); source != nil && source.GetType() == "webhook" {
+		return nil
+	}
+
 	// 获取并发锁，限制同时进行的抓取任务数量
 	feedUpdateSemaphore <- struct{}{}
 	defer func() { <-feedUpdateSemaphore }()
@@ -378,18 +466,42 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 		prefix = "[强制重处理]"
 	}
 
-	result, err := globals.Fp.ParseURL(url)
+	result, err := fetchFeed(url)
 	if err != nil {
 		errStr := err.Error()
 		if strings.HasSuffix(errStr, "EOF") {
 			errStr += " (服务器拒绝访问请求)"
 		}
 		log.Printf("%s [抓取失败] 地址: %s | 详情: %v", prefix, url, errStr)
+		recordSourceError(url, err)
 		return err
 	}
+	clearSourceError(url)
+	ensureWebSubSubscription(url)
+	rememberUpdateHints(url, result)
+
+	// 移除链接属于屏蔽域名的条目
+	dropBlockedItems(url, result)
+	// 清洗描述中的 HTML，缓存与返回的内容不携带脚本和跟踪像素
+	sanitizeSourceFeed(url, result)
 
 	log.Printf("%s [抓取成功] 源: %s | 条目数: %d", prefix, result.Title, len(result.Items))
 
+	if err := processFeedResult(url, result, formattedTime, prefix, isManual, forceReprocess); err != nil {
+		return err
+	}
+	// 源地址已永久重定向时，更新配置中的地址并迁移缓存
+	if target, ok := takePermanentRedirect(url); ok {
+		migrateSourceURL(url, target)
+	}
+	return nil
+}
+
+// processFeedResult 对抓取（或推送）得到的 Feed 执行分类、排序、后处理、缓存合并并写入 DbMap
+func processFeedResult(url string, result *gofeed.Feed, formattedTime, prefix string, isManual bool, forceReprocess bool) error {
+	// 保存原始内容，供规则预览重放处理流程
+	rememberFetchedFeed(url, result)
+
 	// 如果源名称为空，则使用抓取到的标题
 	func(u string, title string) {
 		if title == "" {
@@ -401,13 +513,17 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 		for i := range globals.RssUrls.Sources {
 			if globals.RssUrls.Sources[i].URL == u && globals.RssUrls.Sources[i].Name == "" {
 				globals.RssUrls.Sources[i].Name = title
+				// 原始配置与展开后的配置源顺序一致，同步写入以保留环境变量占位符
+				if i < len(globals.RawConfig.Sources) {
+					globals.RawConfig.Sources[i].Name = title
+				}
 				changed = true
 				break
 			}
 		}
 		if changed {
 			// 保存配置
-			if err := SaveConfig(globals.RssUrls); err != nil {
+			if err := SaveConfig(globals.RawConfig); err != nil {
 				log.Printf("[配置] 自动更新源名称失败: %v", err)
 			} else {
 				log.Printf("[配置] 已自动为源 %s 设置名称: %s", u, title)
@@ -464,6 +580,16 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 			}
 		}
 
+		// 链接与标题都未变化时，检查描述是否被修改（没有旧指纹的条目不比较）
+		if !isChanged && len(cache.AllItemHashes) == len(checkItems) {
+			for i, item := range checkItems {
+				if cache.AllItemHashes[i] != "" && cache.AllItemHashes[i] != itemContentHash(item.Title, item.Description) {
+					isChanged = true
+					break
+				}
+			}
+		}
+
 		if !isChanged {
 			if isManual {
 				log.Printf("%s [无新内容] 源: %s | 内容与顺序均未发生变化", prefix, result.Title)
@@ -526,6 +652,10 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 		}
 	}
 
+	// 上次抓取时各条目的内容指纹，用于检测已发布条目的修改
+	previousStates := previousContentStates(url)
+	changedLinks := make(map[string]bool)
+
 	// 先构建所有Items
 	allItems := make([]models.Item, 0, len(result.Items))
 	rankingBaseTime := time.Now()
@@ -567,15 +697,29 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 			fetchTime = formattedTime
 		}
 
-		allItems = append(allItems, models.Item{
+		score, comments := parseItemEngagement(v)
+		thumbnail, duration := parseItemMedia(v)
+
+		newItem := models.Item{
 			Link:          v.Link,
 			Title:         v.Title,
 			Description:   v.Description,
 			Source:        result.Title,
 			PubDate:       pubDate,
 			FetchTime:     fetchTime,
+			Score:         score,
+			Comments:      comments,
+			Thumbnail:     thumbnail,
+			Duration:      duration,
 			OriginalIndex: idx, // 记录在RSS源中的原始索引
-		})
+		}
+		if applyContentChange(&newItem, previousStates, formattedTime) {
+			changedLinks[newItem.Link] = true
+		}
+		allItems = append(allItems, newItem)
+	}
+	if len(changedLinks) > 0 {
+		log.Printf("%s [内容修改] 源: %s | 标题或描述被修改的条目: %d", prefix, result.Title, len(changedLinks))
 	}
 
 	// 应用最大条目数限制
@@ -584,26 +728,55 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 		allItems = allItems[:maxItems]
 	}
 
+	// 数据保留模式与源的 retentionDays：丢弃超过保留天数的条目
+	allItems = dropExpiredItems(url, allItems)
+
 	// 应用AI分类和过滤
 	originalCount := len(allItems)
 	filteredItems := allItems
 	passedLinks := make(map[string]bool)
 
-	if ShouldFilter(url) {
+	usePipeline := HasPipeline(url)
+	if usePipeline {
+		// 配置了处理流水线时按步骤顺序处理，取代分类与后处理
+		log.Printf("%s [开始流水线] 源: %s | 待处理条目: %d", prefix, result.Title, originalCount)
+		filteredItems = RunSourcePipeline(allItems, url)
+		// 正则或 AI 改写可能修改链接，按原始链接对应回 allItems
+		classifiedMap := make(map[string]models.Item)
+		for _, item := range filteredItems {
+			key := item.Link
+			if item.OriginalLink != "" {
+				key = item.OriginalLink
+			}
+			passedLinks[key] = true
+			classifiedMap[key] = item
+		}
+		for i := range allItems {
+			if classified, ok := classifiedMap[allItems[i].Link]; ok {
+				allItems[i].Category = classified.Category
+				allItems[i].Categories = classified.Categories
+				allItems[i].Relevance = classified.Relevance
+				allItems[i].Confidence = classified.Confidence
+			}
+		}
+	} else if ShouldFilter(url) {
 		log.Printf("%s [开始分类] 源: %s | 待处理条目: %d", prefix, result.Title, originalCount)
 		// 使用新的分类函数，它会同时处理分类和过滤
 		filteredItems = ClassifyItems(allItems, url)
 		for _, item := range filteredItems {
 			passedLinks[item.Link] = true
 		}
-		// 更新allItems中的分类信息
-		categoryMap := make(map[string]string)
+		// 更新allItems中的分类信息（类别、多标签、相关度与置信度）
+		classifiedMap := make(map[string]models.Item)
 		for _, item := range filteredItems {
-			categoryMap[item.Link] = item.Category
+			classifiedMap[item.Link] = item
 		}
 		for i := range allItems {
-			if cat, ok := categoryMap[allItems[i].Link]; ok {
-				allItems[i].Category = cat
+			if classified, ok := classifiedMap[allItems[i].Link]; ok {
+				allItems[i].Category = classified.Category
+				allItems[i].Categories = classified.Categories
+				allItems[i].Relevance = classified.Relevance
+				allItems[i].Confidence = classified.Confidence
 			}
 		}
 	} else {
@@ -613,6 +786,17 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 		}
 	}
 
+	// 记录今天被过滤的条目，用于卡片统计
+	if len(passedLinks) < len(allItems) {
+		filteredLinks := make([]string, 0, len(allItems)-len(passedLinks))
+		for _, item := range allItems {
+			if !passedLinks[item.Link] {
+				filteredLinks = append(filteredLinks, item.Link)
+			}
+		}
+		recordFilteredLinks(url, filteredLinks)
+	}
+
 	// 按时间戳降序排序（确保所有条目按时间排列，新条目自然排在最前）
 	// 当时间戳相同时，按原始索引升序排列，保持RSS源中的原始顺序
 	sort.SliceStable(allItems, func(i, j int) bool {
@@ -626,7 +810,15 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 	})
 
 	// 重新构建过滤后的列表，以反映排序变化
-	if len(passedLinks) < len(allItems) {
+	if usePipeline {
+		// 流水线的输出可能已被改写，直接对其排序
+		sort.SliceStable(filteredItems, func(i, j int) bool {
+			if cmp := compareItemsByRecency(filteredItems[i], filteredItems[j]); cmp != 0 {
+				return cmp > 0
+			}
+			return filteredItems[i].OriginalIndex < filteredItems[j].OriginalIndex
+		})
+	} else if len(passedLinks) < len(allItems) {
 		newFilteredItems := make([]models.Item, 0, len(filteredItems))
 		for _, item := range allItems {
 			if passedLinks[item.Link] {
@@ -638,19 +830,73 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 		filteredItems = allItems
 	}
 
-	// 应用后处理
-	if ShouldPostProcess(url) {
+	// 应用后处理（流水线中的改写步骤已处理过）
+	if !usePipeline && ShouldPostProcess(url) {
 		beforePostCount := len(filteredItems)
 		filteredItems = PostProcessItems(filteredItems, url)
 		log.Printf("%s [后处理完成] 源: %s | 处理条目: %d", prefix, result.Title, beforePostCount)
 	}
 
+	// 为没有缩略图的条目抓取文章页面的 og:image
+	if ShouldFetchOGImage(url) {
+		filteredItems = fillOGImageThumbnails(filteredItems, url)
+	}
+
+	// 翻译标题
+	if ShouldTranslate(url) {
+		filteredItems = TranslateItems(filteredItems, url)
+	}
+
+	// 按标题模板格式化标题
+	if ShouldFormatTitles(url) {
+		filteredItems = FormatTitles(filteredItems, url)
+	}
+
+	// 找出本次检测到修改的条目（用于修改通知）
+	var updatedItems []models.Item
+	for _, item := range filteredItems {
+		link := item.Link
+		if item.OriginalLink != "" {
+			link = item.OriginalLink
+		}
+		if changedLinks[link] {
+			updatedItems = append(updatedItems, item)
+		}
+	}
+
+	// 找出本次新出现的条目（用于新条目通知），没有旧数据的源（首次抓取）不通知
+	var newItems []models.Item
+	if ok {
+		knownLinks := make(map[string]bool, len(cache.AllItemLinks)+len(cache.Items))
+		for _, link := range cache.AllItemLinks {
+			knownLinks[link] = true
+		}
+		for _, item := range cache.Items {
+			knownLinks[item.Link] = true
+			if item.OriginalLink != "" {
+				knownLinks[item.OriginalLink] = true
+			}
+		}
+		for _, item := range filteredItems {
+			link := item.Link
+			if item.OriginalLink != "" {
+				link = item.OriginalLink
+			}
+			if !knownLinks[link] {
+				newItems = append(newItems, item)
+			}
+		}
+	}
+
 	// 应用条目缓存逻辑：将旧条目与新条目合并
 	// cacheItems: -1表示禁用缓存，0表示自动缓存所有过滤后的条目，>0表示缓存指定数量
 	cacheItems := GetCacheItems(url)
 	if cacheItems == 0 {
-		// 0表示自动缓存所有过滤后的条目
+		// 0表示自动缓存所有过滤后的条目；源设置了 retentionDays 时缓存保留期内的所有条目，数量只受保留天数限制
 		cacheItems = len(filteredItems)
+		if sourceRetentionDays(url) > 0 {
+			cacheItems = math.MaxInt32
+		}
 	}
 	if cacheItems > 0 {
 		beforeMergeCount := len(filteredItems)
@@ -661,9 +907,11 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 	// 记录过滤前的所有文章链接和标题，用于清理和变动检测
 	allItemLinks := make([]string, 0, len(allItems))
 	allItemTitles := make([]string, 0, len(allItems))
+	allItemHashes := make([]string, 0, len(allItems))
 	for _, item := range allItems {
 		allItemLinks = append(allItemLinks, item.Link)
 		allItemTitles = append(allItemTitles, item.Title)
+		allItemHashes = append(allItemHashes, item.ContentHash)
 	}
 
 	// 即时清理该源已不存在的文章缓存（AI过滤缓存、后处理缓存、榜单时间戳等）
@@ -740,8 +988,20 @@ func UpdateFeedWithOptions(url, formattedTime string, isManual bool, forceReproc
 		FilteredCount: originalCount - len(filteredItems),
 		AllItemLinks:  allItemLinks,
 		AllItemTitles: allItemTitles,
+		AllItemHashes: allItemHashes,
 	}
 
+	// 更新引用索引
+	indexItemCitations(url, filteredItems)
+
+	// 将新条目与故事追踪匹配
+	go matchFollowItems(url, filteredItems, true)
+	// 按通知规则发送新条目通知
+	go notifyNewItems(url, newItems)
+	go notifyUpdatedItems(url, updatedItems)
+	// 计算新条目的语义向量（用于文件夹合并相似报道）
+	go embedItems(filteredItems)
+
 	globals.Lock.Lock()
 	defer globals.Lock.Unlock()
 	globals.DbMap[url] = customFeed
@@ -774,7 +1034,7 @@ func cleanupPostProcessCacheForSource(oldLinks []string, newLinks map[string]boo
 	}
 }
 
-// mergeWithCachedItems 将新条目与缓存的旧条目合并，保持总数达到 cacheItems
+// mergeWithCachedItems 将新条目与缓存的旧条目合并，保持总数达到 cacheItems（超过保留天数的旧条目不再合并）
 func mergeWithCachedItems(url string, newItems []models.Item, cacheItems int) []models.Item {
 	// 构建链接集合用于去重，并首先对新条目内部去重
 	uniqueNewItems := make([]models.Item, 0, len(newItems))
@@ -789,13 +1049,17 @@ func mergeWithCachedItems(url string, newItems []models.Item, cacheItems int) []
 
 	// 从缓存中获取旧条目
 	cachedItems, hasCached := GetItemsCache(url)
+	cutoff, hasCutoff := itemsCutoff(url)
 
 	// 合并条目：新条目 + 不在新条目中的旧条目
-	mergedItems := make([]models.Item, 0, cacheItems)
+	mergedItems := make([]models.Item, 0, len(newItems)+len(cachedItems))
 	mergedItems = append(mergedItems, newItems...)
 
 	if hasCached {
 		for _, item := range cachedItems {
+			if hasCutoff && isItemExpired(item, cutoff) {
+				continue
+			}
 			// 只添加不在新列表中的旧条目，且旧条目本身也要去重（以防万一）
 			if item.Link != "" && !newLinks[item.Link] {
 				newLinks[item.Link] = true
@@ -817,12 +1081,21 @@ func mergeWithCachedItems(url string, newItems []models.Item, cacheItems int) []
 	cachedItemsToSave := make([]models.Item, len(mergedItems))
 	for i, item := range mergedItems {
 		cachedItemsToSave[i] = models.Item{
-			Title:        item.Title,
-			Link:         item.Link,
-			OriginalLink: item.OriginalLink, // 保留原始链接用于后处理缓存查询
-			PubDate:      item.PubDate,
-			FetchTime:    item.FetchTime, // 保留抓取时间
-			Category:     item.Category,  // 保留分类信息
+			Title:         item.Title,
+			Link:          item.Link,
+			OriginalLink:  item.OriginalLink, // 保留原始链接用于后处理缓存查询
+			PubDate:       item.PubDate,
+			FetchTime:     item.FetchTime, // 保留抓取时间
+			Category:      item.Category,  // 保留分类信息
+			Categories:    item.Categories,
+			Score:         item.Score,
+			Comments:      item.Comments,
+			Thumbnail:     item.Thumbnail,
+			Duration:      item.Duration,
+			OriginalTitle: item.OriginalTitle, // 保留翻译前的原始标题
+			ContentHash:   item.ContentHash,   // 保留内容指纹与修改状态，重启后仍能检测修改
+			Updated:       item.Updated,
+			LastChanged:   item.LastChanged,
 			// Description 和 Source 字段不保存到缓存
 		}
 	}
@@ -879,26 +1152,14 @@ func GetIconForFeed(rssURL string, feed interface{}) string {
 // GetFeeds 获取feeds列表，根据布局分组返回
 func GetFeeds() []models.Feed {
 	feeds := make([]models.Feed, 0)
+	now := time.Now()
 
 	// 遍历所有分组布局
 	for _, layoutGroup := range globals.RssUrls.LayoutGroups {
 		// 遍历该分组中的所有布局项
 		for _, item := range layoutGroup.Items {
-			if item.Type == "source" && item.SourceURL != "" {
-				// 单个源
-				feed := buildSourceFeed(item.SourceURL, layoutGroup.Name)
-				if feed != nil {
-					feeds = append(feeds, *feed)
-				}
-			} else if item.Type == "folder" && item.FolderID != "" {
-				// 文件夹
-				folder := globals.RssUrls.GetFolderByID(item.FolderID)
-				if folder != nil {
-					feed := buildFolderFeed(*folder, layoutGroup.Name)
-					if feed != nil {
-						feeds = append(feeds, *feed)
-					}
-				}
+			if feed := buildLayoutItemFeed(item, layoutGroup, now); feed != nil {
+				feeds = append(feeds, *feed)
 			}
 		}
 	}
@@ -906,8 +1167,120 @@ func GetFeeds() []models.Feed {
 	return feeds
 }
 
-// buildSourceFeed 构建单个源的Feed
-func buildSourceFeed(sourceURL string, groupName string) *models.Feed {
+// GetFeed 只构建一张卡片（源 URL 或 folder:{id}），结果与 GetFeeds 中该卡片相同
+// 卡片出现在多个分组中时取第一个分组；卡片不在布局中时返回 nil
+func GetFeed(cardLink string) *models.Feed {
+	now := time.Now()
+	for _, layoutGroup := range globals.RssUrls.LayoutGroups {
+		for _, item := range layoutGroup.Items {
+			if layoutItemLink(item) != cardLink {
+				continue
+			}
+			if feed := buildLayoutItemFeed(item, layoutGroup, now); feed != nil {
+				return feed
+			}
+		}
+	}
+	return nil
+}
+
+// layoutItemLink 布局项对应的卡片链接（源 URL 或 folder:{id}），无效布局项返回空
+func layoutItemLink(item models.LayoutItem) string {
+	if item.Type == "source" && item.SourceURL != "" {
+		return item.SourceURL
+	}
+	if item.Type == "folder" && item.FolderID != "" {
+		return "folder:" + item.FolderID
+	}
+	return ""
+}
+
+// buildLayoutItemFeed 构建布局项对应的卡片（含时间分组、图片代理、引用标注、统计与 AI 概要）
+func buildLayoutItemFeed(item models.LayoutItem, layoutGroup models.LayoutGroup, now time.Time) *models.Feed {
+	if item.Type == "source" && item.SourceURL != "" {
+		// 单个源
+		feed := buildSourceFeed(item.SourceURL, layoutGroup.Name, layoutGroup.GetDisplay())
+		if feed == nil {
+			return nil
+		}
+		feed.Items = assignTimeBuckets(proxyItemImages(feed.Items), now)
+		annotateReadCitations(feed.Items)
+		if feed.Stats == nil {
+			feed.Stats = buildFeedStats(feed.Items, []string{item.SourceURL}, now)
+		}
+		feed.OnDemand = isOnDemandSource(item.SourceURL)
+		return feed
+	}
+	if item.Type == "folder" && item.FolderID != "" {
+		// 文件夹
+		folder := globals.RssUrls.GetFolderByID(item.FolderID)
+		if folder == nil {
+			return nil
+		}
+		feed := buildFolderFeed(*folder, layoutGroup.Name, layoutGroup.GetDisplay())
+		if feed == nil {
+			return nil
+		}
+		feed.Items = assignTimeBuckets(proxyItemImages(feed.Items), now)
+		annotateReadCitations(feed.Items)
+		if feed.Stats == nil {
+			feed.Stats = buildFeedStats(feed.Items, getFolderSourceURLs(*folder), now)
+		}
+		feed.OnDemand = hasOnDemandSource(getFolderSourceURLs(*folder))
+		applyFolderBlurb(feed, *folder)
+		return feed
+	}
+	return nil
+}
+
+// CatchUpFeed 将卡片中指定条目及所有不晚于它的条目（按发布时间，与卡片的排序方向无关）标记为已读
+// cardLink 为卡片链接（源 URL 或 folder:{id}），返回新标记为已读的条目数
+func CatchUpFeed(cardLink, itemLink string) (int, error) {
+	card := GetFeed(cardLink)
+	if card == nil {
+		return 0, fmt.Errorf("卡片不存在: %s", cardLink)
+	}
+
+	index := -1
+	for i, item := range card.Items {
+		if item.Link == itemLink {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return 0, fmt.Errorf("条目不在卡片中: %s", itemLink)
+	}
+
+	links := catchUpLinks(card.Items, index)
+	if len(links) > 0 {
+		MarkReadBatch(links)
+	}
+	return len(links), nil
+}
+
+// catchUpLinks 返回锚点条目及发布时间不晚于它的未读条目链接
+// 没有时间的条目无法比较：锚点有时间时跳过，锚点也没有时间时按展示顺序取其后的条目
+func catchUpLinks(items []models.Item, index int) []string {
+	anchorTime, anchorOK := getItemSortTime(items[index])
+	links := make([]string, 0)
+	for i, item := range items {
+		itemTime, ok := getItemSortTime(item)
+		older := i == index
+		if anchorOK && ok {
+			older = older || !itemTime.After(anchorTime)
+		} else if !anchorOK && !ok {
+			older = older || i > index
+		}
+		if older && !IsRead(item.Link) {
+			links = append(links, item.Link)
+		}
+	}
+	return links
+}
+
+// buildSourceFeed 构建单个源的Feed，groupDisplay 为所在分组的默认展示选项
+func buildSourceFeed(sourceURL string, groupName string, groupDisplay models.DisplayFlags) *models.Feed {
 	source := globals.RssUrls.GetSourceByURL(sourceURL)
 	if source == nil {
 		return nil
@@ -923,7 +1296,7 @@ func buildSourceFeed(sourceURL string, groupName string) *models.Feed {
 		if source.Name != "" {
 			title = source.Name
 		}
-		return &models.Feed{
+		feed := &models.Feed{
 			Title:  title,
 			Link:   source.URL,
 			Icon:   source.Icon,
@@ -931,6 +1304,8 @@ func buildSourceFeed(sourceURL string, groupName string) *models.Feed {
 			Items:  []models.Item{},
 			Group:  groupName,
 		}
+		resolveDisplayFlags(feed, groupDisplay, source.DisplayFlags())
+		return feed
 	}
 
 	// 复制缓存以避免修改原始数据
@@ -945,18 +1320,32 @@ func buildSourceFeed(sourceURL string, groupName string) *models.Feed {
 		result.Icon = ProxyIconURL(source.Icon)
 	}
 	result.Group = groupName
-	// 设置是否显示发布时间
-	result.ShowPubDate = source.ShowPubDate
-	// 设置是否显示分类标签
-	result.ShowCategory = source.ShowCategory
+	// 计算生效的展示选项（分组默认值 → 订阅源 → 卡片覆盖）
+	resolveDisplayFlags(&result, groupDisplay, source.DisplayFlags())
+	// 不显示已读条目（卡片统计仍按全部条目计算，否则未读数总等于条目数）
+	if source.HidesRead() {
+		result.Stats = buildFeedStats(result.Items, []string{source.URL}, time.Now())
+		result.Items = excludeReadItems(result.Items)
+	}
+	// 应用自定义排序表达式
+	result.Items = applySortExpression(result.Items, source.SortBy, nil, nil)
 	// 设置是否为榜单模式
 	result.RankingMode = source.RankingMode
 
 	return &result
 }
 
-// buildFolderFeed 构建文件夹Feed，聚合多个源的内容
-func buildFolderFeed(folder models.Folder, groupName string) *models.Feed {
+// buildFolderFeed 构建文件夹Feed，聚合多个源的内容，groupDisplay 为所在分组的默认展示选项
+func buildFolderFeed(folder models.Folder, groupName string, groupDisplay models.DisplayFlags) *models.Feed {
+	return buildNestedFolderFeed(folder, groupName, groupDisplay, make(map[string]bool))
+}
+
+// buildNestedFolderFeed 构建文件夹Feed，子文件夹递归聚合；ancestors 为正在构建的上层文件夹，
+// 引用上层文件夹的子文件夹条目（循环引用，配置校验会报告）被跳过
+func buildNestedFolderFeed(folder models.Folder, groupName string, groupDisplay models.DisplayFlags, ancestors map[string]bool) *models.Feed {
+	ancestors[folder.ID] = true
+	defer delete(ancestors, folder.ID)
+
 	icon := folder.Icon
 	if icon != "" {
 		icon = ProxyIconURL(icon)
@@ -978,28 +1367,50 @@ func buildFolderFeed(folder models.Folder, groupName string) *models.Feed {
 				}
 			}
 		}
-		// 如果第一个是分类包 (CategoryPackageId != "")，使用默认逻辑 (即保持为空，由前端或后续逻辑处理)
+		// 如果第一个是分类包或子文件夹，使用默认逻辑 (即保持为空，由前端或后续逻辑处理)
 	}
 
 	folderFeed := &models.Feed{
-		Title:        folder.Name,
-		Link:         "folder:" + folder.ID,
-		Icon:         icon,
-		IsFolder:     true,
-		Custom:       map[string]string{"lastupdate": "加载中"},
-		Items:        make([]models.Item, 0),
-		ShowPubDate:  folder.ShowPubDate,
-		ShowCategory: folder.ShowCategory,
-		ShowSource:   folder.ShowSource,
-		Group:        groupName,
+		Title:    folder.Name,
+		Link:     "folder:" + folder.ID,
+		Icon:     icon,
+		IsFolder: true,
+		Custom:   map[string]string{"lastupdate": "加载中"},
+		Items:    make([]models.Item, 0),
+		Group:    groupName,
+	}
+	// 计算生效的展示选项（分组默认值 → 文件夹 → 卡片覆盖）
+	resolveDisplayFlags(folderFeed, groupDisplay, folder.DisplayFlags())
+
+	// 记录条目所属源在文件夹中的顺序及条目在源中的位置，供 sourcePriority / sourceOrder 排序使用
+	sourcePriority := make(map[string]int)
+	sourceOrder := make(map[string]int)
+	recordPriority := func(priority int, from int) {
+		for i, item := range folderFeed.Items[from:] {
+			if _, exists := sourcePriority[item.Link]; !exists {
+				sourcePriority[item.Link] = priority
+				sourceOrder[item.Link] = i
+			}
+		}
+	}
+
+	// 记录条目的来源（订阅源 URL，子文件夹为 folder:{id}），供每源条目数限制使用
+	itemOrigins := make(map[string]string)
+	recordOrigin := func(origin string, from int) {
+		for _, item := range folderFeed.Items[from:] {
+			if _, exists := itemOrigins[item.Link]; !exists {
+				itemOrigins[item.Link] = origin
+			}
+		}
 	}
 
 	// 遍历文件夹条目
-	for _, entry := range folder.Entries {
-		// 确定要过滤的类别列表
+	for priority, entry := range folder.Entries {
+		before := len(folderFeed.Items)
+		// 确定要过滤的类别列表（选择父类别时自动包含其所有子类别）
 		var categories []string
 		if len(entry.Categories) > 0 {
-			categories = entry.Categories
+			categories = globals.RssUrls.ExpandCategoryIDs(entry.Categories)
 		}
 
 		// 确定是否隐藏源名称
@@ -1009,7 +1420,9 @@ func buildFolderFeed(folder models.Folder, groupName string) *models.Feed {
 			// 分类包条目 - 添加该分类包对应的所有订阅源
 			packageSources := globals.RssUrls.GetSourcesByPackageId(entry.CategoryPackageId)
 			for _, pkgSource := range packageSources {
+				from := len(folderFeed.Items)
 				addSourceItemsToFolder(folderFeed, pkgSource.URL, pkgSource.Name, categories, hideSource)
+				recordOrigin(pkgSource.URL, from)
 			}
 		} else if entry.SourceURL != "" {
 			// 普通订阅源条目
@@ -1019,13 +1432,32 @@ func buildFolderFeed(folder models.Folder, groupName string) *models.Feed {
 				sourceName = source.Name
 			}
 			addSourceItemsToFolder(folderFeed, entry.SourceURL, sourceName, categories, hideSource)
+			recordOrigin(entry.SourceURL, before)
+		} else if entry.FolderID != "" && !ancestors[entry.FolderID] {
+			// 子文件夹条目 - 聚合子文件夹展示的条目（已按子文件夹自身的过滤、排序与条目数限制处理）
+			if sub := globals.RssUrls.GetFolderByID(entry.FolderID); sub != nil {
+				subFeed := buildNestedFolderFeed(*sub, groupName, groupDisplay, ancestors)
+				addFolderItemsToFolder(folderFeed, subFeed.Items, categories, hideSource)
+				recordOrigin("folder:"+sub.ID, before)
+			}
 		}
+		recordPriority(priority, before)
+	}
+
+	// 过滤相关度低于文件夹阈值的条目
+	folderFeed.Items, _ = filterItemsByRelevance(folderFeed.Items, folder.MinRelevance)
+	// 不显示已读条目（在条目数限制之前移除，限制只作用于未读条目；卡片统计仍按全部条目计算）
+	if folder.HidesRead() {
+		folderFeed.Stats = buildFeedStats(folderFeed.Items, getFolderSourceURLs(folder), time.Now())
+		folderFeed.Items = excludeReadItems(folderFeed.Items)
 	}
 
 	// 按发布时间倒序排列
 	sort.SliceStable(folderFeed.Items, func(i, j int) bool {
 		return compareItemsByRecency(folderFeed.Items[i], folderFeed.Items[j]) > 0
 	})
+	// 应用自定义排序表达式（在时间倒序基础上稳定排序）
+	folderFeed.Items = applySortExpression(folderFeed.Items, folder.GetSortExpression(), sourcePriority, sourceOrder)
 
 	// 根据标题去重
 	seenTitles := make(map[string]bool)
@@ -1042,6 +1474,11 @@ func buildFolderFeed(folder models.Folder, groupName string) *models.Feed {
 		}
 	}
 	folderFeed.Items = uniqueItems
+	// 合并语义相似的报道（跨源的同一事件只保留排在最前的一条）
+	if folder.ClusterSimilar {
+		folderFeed.Items = clusterSimilarItems(folderFeed.Items)
+	}
+	folderFeed.Items = applyMaxPerSource(folderFeed.Items, folder.MaxPerSource, itemOrigins)
 	folderFeed.Items = applyFolderItemLimit(folder, folderFeed.Items)
 
 	// 确定文件夹的最后更新时间（取所有条目中最新的抓取时间）
@@ -1108,7 +1545,7 @@ func addSourceItemsToFolder(folderFeed *models.Feed, sourceURL string, sourceNam
 		if len(categoryFilters) > 0 {
 			match := false
 			for _, filter := range categoryFilters {
-				if item.Category == filter {
+				if item.HasCategory(filter) {
 					match = true
 					break
 				}
@@ -1129,6 +1566,28 @@ func addSourceItemsToFolder(folderFeed *models.Feed, sourceURL string, sourceNam
 	}
 }
 
+// addFolderItemsToFolder 将子文件夹的条目添加到文件夹（保留子文件夹中的源名称，hideSource 时隐藏）
+func addFolderItemsToFolder(folderFeed *models.Feed, items []models.Item, categoryFilters []string, hideSource bool) {
+	for _, item := range items {
+		if len(categoryFilters) > 0 {
+			match := false
+			for _, filter := range categoryFilters {
+				if item.HasCategory(filter) {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+		}
+		if hideSource {
+			item.Source = ""
+		}
+		folderFeed.Items = append(folderFeed.Items, item)
+	}
+}
+
 func WatchConfigFileChanges(filePath string) {
 	// 创建一个新的监控器
 	watcher, err := fsnotify.NewWatcher()
@@ -1172,6 +1631,9 @@ func WatchConfigFileChanges(filePath string) {
 
 			log.Println("配置重载成功")
 
+			// 记录手动修改配置文件产生的版本（与最新版本相同时跳过）
+			recordConfigSnapshot(globals.RawConfig, "reload")
+
 			// 1. 立即清理后处理缓存
 			CleanupPostProcessCacheOnConfigChange()
 
@@ -1181,13 +1643,29 @@ func WatchConfigFileChanges(filePath string) {
 			// 3. 立即清理已读状态（清理已删除源的数据）
 			CleanupReadStateOnConfigChange()
 
-			// 收集受影响的源（配置发生变化的源）
-			affectedUrls := collectAffectedUrls(oldConfig, globals.RssUrls)
+			// 根据配置变化决定需要执行的操作（重新抓取 / 重新分类 / 重新调度）
+			plan := planConfigChange(oldConfig, globals.RssUrls)
+			if consumeCategoryMigration() && len(plan.Reclassify) > 0 {
+				// 类别迁移已改写分类缓存与展示条目，无需重新分类
+				log.Printf("配置更新：类别迁移，跳过 %d 个源的重新分类", len(plan.Reclassify))
+				plan.Reclassify = make(map[string]bool)
+			}
+			if plan.Reschedule {
+				// 更新循环每次都按当前配置计算刷新间隔，无需立即抓取
+				log.Println("配置更新：抓取计划已变化，按新计划调度")
+			}
+			for url := range plan.Reclassify {
+				ClearClassifyCacheForSource(url)
+			}
 
+			affectedUrls := plan.affectedUrls()
 			if len(affectedUrls) == 0 {
 				log.Println("配置更新：无源受影响，跳过更新")
 				return
 			}
+			if len(plan.Reclassify) > 0 {
+				log.Printf("配置更新：AI 分类配置或类别变化，%d 个启用 AI 分类的源将重新分类", len(plan.Reclassify))
+			}
 
 			log.Printf("配置更新：%d 个源受影响，开始更新", len(affectedUrls))
 			formattedTime := time.Now().Format(time.RFC3339)
@@ -1256,18 +1734,8 @@ func RefreshSingleFeed(link string) error {
 
 		log.Printf("[手动刷新] 刷新文件夹 [%s] 中的所有源", folder.Name)
 
-		// 收集需要刷新的源URL
-		urlsToRefresh := make([]string, 0)
-		for _, entry := range folder.Entries {
-			if entry.CategoryPackageId != "" {
-				// 分类包条目 - 添加该分类包对应的所有订阅源
-				for _, src := range globals.RssUrls.GetSourcesByPackageId(entry.CategoryPackageId) {
-					urlsToRefresh = append(urlsToRefresh, src.URL)
-				}
-			} else if entry.SourceURL != "" {
-				urlsToRefresh = append(urlsToRefresh, entry.SourceURL)
-			}
-		}
+		// 收集需要刷新的源URL（含分类包与子文件夹展开）
+		urlsToRefresh := getFolderSourceURLs(*folder)
 
 		// 并发刷新所有源
 		var wg sync.WaitGroup
@@ -1363,6 +1831,131 @@ func ClearFeedCacheForPostProcessSources() {
 	}
 }
 
+// configChangePlan 配置变更后需要执行的操作
+type configChangePlan struct {
+	// 源自身配置变化，需要重新抓取并处理的源
+	Refetch map[string]bool
+	// AI 分类配置或全局类别变化，需要清除 AI 分类缓存后重新处理的源（仅启用 AI 分类的源）
+	Reclassify map[string]bool
+	// 抓取计划（时间段规则或源的刷新次数）是否变化，只需按新计划调度，不需要重新抓取
+	Reschedule bool
+}
+
+// affectedUrls 返回需要重新处理的所有源
+func (p configChangePlan) affectedUrls() map[string]bool {
+	urls := make(map[string]bool, len(p.Refetch)+len(p.Reclassify))
+	for url := range p.Refetch {
+		urls[url] = true
+	}
+	for url := range p.Reclassify {
+		urls[url] = true
+	}
+	return urls
+}
+
+// planConfigChange 比较新旧配置，决定需要重新抓取、重新分类及重新调度的范围
+func planConfigChange(oldConfig, newConfig models.Config) configChangePlan {
+	plan := configChangePlan{
+		Refetch:    collectAffectedUrls(oldConfig, newConfig),
+		Reclassify: make(map[string]bool),
+	}
+
+	// AI 分类配置或类别变化：只影响启用了 AI 分类的源（变更前后任一时刻使用 AI 分类）
+	if aiClassifyChanged(oldConfig, newConfig) {
+		oldAI := make(map[string]bool)
+		for _, source := range oldConfig.Sources {
+			if usesAIClassify(oldConfig, source) {
+				oldAI[source.URL] = true
+			}
+		}
+		for _, source := range newConfig.Sources {
+			if source.URL == "" || plan.Refetch[source.URL] {
+				continue
+			}
+			if oldAI[source.URL] || usesAIClassify(newConfig, source) {
+				plan.Reclassify[source.URL] = true
+			}
+		}
+	}
+
+	// 提示词模板内容变化：引用了该模板的源重新抓取处理
+	for _, source := range newConfig.Sources {
+		if source.URL != "" && usesChangedPromptTemplate(oldConfig, newConfig, source) {
+			plan.Refetch[source.URL] = true
+			delete(plan.Reclassify, source.URL)
+		}
+	}
+
+	// 置信度阈值或处理方式变化：缓存中保存的是原始类别与置信度，重新处理即可按新规则过滤，无需清除缓存
+	if oldConfig.AIClassify.MinConfidence != newConfig.AIClassify.MinConfidence ||
+		oldConfig.AIClassify.GetLowConfidenceAction() != newConfig.AIClassify.GetLowConfidenceAction() {
+		for _, source := range newConfig.Sources {
+			if source.URL != "" && !plan.Reclassify[source.URL] && usesAIClassify(newConfig, source) {
+				plan.Refetch[source.URL] = true
+			}
+		}
+	}
+
+	// 抓取计划变化
+	if !reflect.DeepEqual(oldConfig.Schedules, newConfig.Schedules) {
+		plan.Reschedule = true
+	} else {
+		oldCounts := make(map[string]int, len(oldConfig.Sources))
+		for _, source := range oldConfig.Sources {
+			oldCounts[source.URL] = source.RefreshCount
+		}
+		for _, source := range newConfig.Sources {
+			if count, ok := oldCounts[source.URL]; ok && count != source.RefreshCount {
+				plan.Reschedule = true
+				break
+			}
+		}
+	}
+
+	return plan
+}
+
+// usesChangedPromptTemplate 判断源的分类或后处理提示词引用的模板内容是否变化
+func usesChangedPromptTemplate(oldConfig, newConfig models.Config, source models.Source) bool {
+	var prompts []string
+	if source.Classify != nil {
+		prompts = append(prompts, source.Classify.CustomPrompt)
+	}
+	if source.PostProcess != nil {
+		prompts = append(prompts, source.PostProcess.Prompt)
+	}
+	for _, prompt := range prompts {
+		if name, ok := models.PromptTemplateName(prompt); ok && oldConfig.PromptTemplates[name] != newConfig.PromptTemplates[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// usesAIClassify 判断在指定配置下该源是否使用 AI 分类
+func usesAIClassify(config models.Config, source models.Source) bool {
+	return config.AIClassify.Enabled && config.AIClassify.HasAPIAccess() && source.HasAIClassify()
+}
+
+// aiClassifyChanged 检查影响分类结果的 AI 分类配置或类别是否变化
+// 密钥、超时、并发、批量大小、重试等仅影响调用方式的配置不计入
+func aiClassifyChanged(oldConfig, newConfig models.Config) bool {
+	old, new := oldConfig.AIClassify, newConfig.AIClassify
+	if old.Enabled != new.Enabled ||
+		old.HasAPIAccess() != new.HasAPIAccess() ||
+		old.GetProvider() != new.GetProvider() ||
+		old.GetAPIBase() != new.GetAPIBase() ||
+		old.GetModel() != new.GetModel() ||
+		old.GetJSONMode() != new.GetJSONMode() ||
+		old.GetSystemPrompt() != new.GetSystemPrompt() ||
+		old.GetInterestProfile() != new.GetInterestProfile() ||
+		old.GetTemperature() != new.GetTemperature() ||
+		old.MaxDescLength != new.MaxDescLength {
+		return true
+	}
+	return !reflect.DeepEqual(old.GetCategories(&oldConfig), new.GetCategories(&newConfig))
+}
+
 // collectAffectedUrls 比较新旧配置，收集受影响的源URL
 func collectAffectedUrls(oldConfig, newConfig models.Config) map[string]bool {
 	affectedUrls := make(map[string]bool)
@@ -1395,8 +1988,27 @@ func sourceChanged(old, new *models.Source) bool {
 	// 检查影响数据获取或处理的关键配置
 	if old.MaxItems != new.MaxItems ||
 		old.CacheItems != new.CacheItems ||
+		old.RetentionDays != new.RetentionDays ||
 		old.IgnoreOriginalPubDate != new.IgnoreOriginalPubDate ||
-		old.RankingMode != new.RankingMode {
+		old.RankingMode != new.RankingMode ||
+		old.Type != new.Type ||
+		old.TitleTemplate != new.TitleTemplate ||
+		old.Sanitize != new.Sanitize ||
+		old.OGImage != new.OGImage {
+		return true
+	}
+
+	// 检查 JSON 源字段映射、脚本源及推送源配置是否变化
+	if !reflect.DeepEqual(old.JSON, new.JSON) || !reflect.DeepEqual(old.Script, new.Script) || !reflect.DeepEqual(old.Webhook, new.Webhook) ||
+		!reflect.DeepEqual(old.Social, new.Social) ||
+		!reflect.DeepEqual(old.Community, new.Community) ||
+		!reflect.DeepEqual(old.YouTube, new.YouTube) ||
+		!reflect.DeepEqual(old.IMAP, new.IMAP) {
+		return true
+	}
+
+	// 检查处理流水线是否变化
+	if !reflect.DeepEqual(old.Pipeline, new.Pipeline) {
 		return true
 	}
 
@@ -1458,6 +2070,19 @@ func classifyChanged(old, new *models.ClassifyStrategy) bool {
 	if old.ScriptFilterContent != new.ScriptFilterContent {
 		return true
 	}
+	if old.GetScriptFilterLang() != new.GetScriptFilterLang() || old.GetScriptTimeout() != new.GetScriptTimeout() {
+		return true
+	}
+
+	// 比较 MinRelevance 字段
+	if old.MinRelevance != new.MinRelevance {
+		return true
+	}
+
+	// 比较分类示例
+	if !reflect.DeepEqual(old.Examples, new.Examples) {
+		return true
+	}
 
 	// 检查关键词列表
 	if len(old.FilterKeywords) != len(new.FilterKeywords) || len(old.KeepKeywords) != len(new.KeepKeywords) {
@@ -1482,9 +2107,19 @@ func postProcessChanged(old, new *models.PostProcessConfig) bool {
 		old.Prompt != new.Prompt ||
 		old.ScriptPath != new.ScriptPath ||
 		old.ScriptContent != new.ScriptContent ||
+		old.Plugin != new.Plugin ||
+		old.GetScriptTimeout() != new.GetScriptTimeout() ||
 		old.ModifyTitle != new.ModifyTitle ||
 		old.ModifyLink != new.ModifyLink ||
-		old.ModifyPubDate != new.ModifyPubDate {
+		old.ModifyPubDate != new.ModifyPubDate ||
+		old.ModifyDescription != new.ModifyDescription ||
+		old.ModifyCategory != new.ModifyCategory ||
+		old.ModifyMetadata != new.ModifyMetadata {
+		return true
+	}
+
+	// 规则模式的替换规则变化
+	if !reflect.DeepEqual(old.Rules, new.Rules) {
 		return true
 	}
 
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"reflect"
+	"testing"
+)
+
+func TestPlanConfigChange(t *testing.T) {
+	aiOn := true
+	aiSource := models.Source{URL: "https://ai.example.com/feed", Classify: &models.ClassifyStrategy{AIEnabled: &aiOn}}
+	plainSource := models.Source{URL: "https://plain.example.com/feed"}
+	base := models.Config{
+		AIClassify: models.AIClassifyConfig{Enabled: true, APIKey: "sk-test", Model: "model-a"},
+		Categories: []models.Category{{ID: "tech", Name: "科技"}},
+		Sources:    []models.Source{aiSource, plainSource},
+	}
+	with := func(modify func(c *models.Config)) models.Config {
+		c := base
+		c.Sources = append([]models.Source(nil), base.Sources...)
+		c.Categories = append([]models.Category(nil), base.Categories...)
+		modify(&c)
+		return c
+	}
+
+	tests := []struct {
+		name           string
+		newConfig      models.Config
+		wantRefetch    []string
+		wantReclassify []string
+	}{
+		{
+			name:      "no-op",
+			newConfig: with(func(c *models.Config) {}),
+		},
+		{
+			name: "source added",
+			newConfig: with(func(c *models.Config) {
+				c.Sources = append(c.Sources, models.Source{URL: "https://new.example.com/feed"})
+			}),
+			wantRefetch: []string{"https://new.example.com/feed"},
+		},
+		{
+			name: "source removed",
+			newConfig: with(func(c *models.Config) {
+				c.Sources = c.Sources[:1]
+			}),
+		},
+		{
+			name: "ai classify model changed",
+			newConfig: with(func(c *models.Config) {
+				c.AIClassify.Model = "model-b"
+			}),
+			wantReclassify: []string{aiSource.URL},
+		},
+		{
+			name: "categories changed",
+			newConfig: with(func(c *models.Config) {
+				c.Categories = append(c.Categories, models.Category{ID: "news", Name: "新闻"})
+			}),
+			wantReclassify: []string{aiSource.URL},
+		},
+		{
+			name: "api key rotated",
+			newConfig: with(func(c *models.Config) {
+				c.AIClassify.APIKey = "sk-rotated"
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planConfigChange(base, tt.newConfig)
+			if !reflect.DeepEqual(plan.Refetch, toSet(tt.wantRefetch)) {
+				t.Errorf("Refetch = %v, want %v", plan.Refetch, tt.wantRefetch)
+			}
+			if !reflect.DeepEqual(plan.Reclassify, toSet(tt.wantReclassify)) {
+				t.Errorf("Reclassify = %v, want %v", plan.Reclassify, tt.wantReclassify)
+			}
+			if plan.Reschedule {
+				t.Errorf("Reschedule = true, want false")
+			}
+		})
+	}
+}
+
+func TestUsesAIClassify(t *testing.T) {
+	aiOn := true
+	aiSource := models.Source{URL: "https://ai.example.com/feed", Classify: &models.ClassifyStrategy{AIEnabled: &aiOn}}
+
+	tests := []struct {
+		name   string
+		config models.AIClassifyConfig
+		source models.Source
+		want   bool
+	}{
+		{"enabled with key", models.AIClassifyConfig{Enabled: true, APIKey: "sk-test"}, aiSource, true},
+		{"ollama without key", models.AIClassifyConfig{Enabled: true, Provider: "ollama"}, aiSource, true},
+		{"globally disabled", models.AIClassifyConfig{APIKey: "sk-test"}, aiSource, false},
+		{"missing key", models.AIClassifyConfig{Enabled: true}, aiSource, false},
+		{"source without ai", models.AIClassifyConfig{Enabled: true, APIKey: "sk-test"}, models.Source{URL: "https://plain.example.com/feed"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usesAIClassify(models.Config{AIClassify: tt.config}, tt.source); got != tt.want {
+				t.Errorf("usesAIClassify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func TestCatchUpLinksAscendingFolder(t *testing.T) {
+	savedConfig, savedDb, savedRead := globals.RssUrls, globals.DbMap, globals.ReadState
+	defer func() { globals.RssUrls, globals.DbMap, globals.ReadState = savedConfig, savedDb, savedRead }()
+
+	globals.RssUrls = models.Config{
+		Sources: []models.Source{{URL: "https://a.example/feed"}, {URL: "https://b.example/feed"}},
+		Folders: []models.Folder{{ID: "f1", SortAscending: true, Entries: []models.FolderEntry{{SourceURL: "https://a.example/feed"}, {SourceURL: "https://b.example/feed"}}}},
+	}
+	globals.DbMap = map[string]models.Feed{
+		"https://a.example/feed": {Link: "https://a.example/feed", Items: []models.Item{
+			{Title: "a3", Link: "https://a.example/3", PubDate: "2024-01-03 08:00:00"},
+			{Title: "a1", Link: "https://a.example/1", PubDate: "2024-01-01 08:00:00"},
+		}},
+		"https://b.example/feed": {Link: "https://b.example/feed", Items: []models.Item{
+			{Title: "b2", Link: "https://b.example/2", PubDate: "2024-01-02 08:00:00"},
+		}},
+	}
+	globals.ReadState = map[string]int64{}
+
+	card := buildFolderFeed(globals.RssUrls.Folders[0], "", models.DisplayFlags{})
+	var order []string
+	for _, item := range card.Items {
+		order = append(order, item.Title)
+	}
+	if want := []string{"a1", "b2", "a3"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("ascending folder order = %v, want %v", order, want)
+	}
+
+	// 升序卡片中，不晚于锚点的条目排在锚点之前
+	if got, want := catchUpLinks(card.Items, 1), []string{"https://a.example/1", "https://b.example/2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("catchUpLinks = %v, want %v", got, want)
+	}
+
+	globals.ReadState["https://a.example/1"] = 1
+	if got, want := catchUpLinks(card.Items, 2), []string{"https://b.example/2", "https://a.example/3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("catchUpLinks skipping read = %v, want %v", got, want)
+	}
+}
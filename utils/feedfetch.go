@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"feedora/globals"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/mmcdole/gofeed"
+)
+
+// 订阅抓取的传输层：请求时显式声明支持 gzip / br / deflate，由 feedora 自行解压，
+// 解压后的大小超过 fetch.maxSize 时中止读取（防止压缩炸弹），并统计压缩前后的字节数
+
+// feedAcceptEncoding 订阅抓取请求声明支持的压缩格式
+const feedAcceptEncoding = "gzip, br, deflate"
+
+// fetchLargestLimit /api/stats 中列出的解压后最大的订阅源数量
+const fetchLargestLimit = 10
+
+// errFeedTooLarge 响应解压后超过大小上限
+var errFeedTooLarge = errors.New("响应超过大小上限")
+
+// FetchTransfer 一次订阅抓取的传输统计
+type FetchTransfer struct {
+	// 响应的压缩格式: gzip / br / deflate / identity（未压缩）
+	Encoding string `json:"encoding"`
+	// 实际传输的字节数
+	CompressedBytes int64 `json:"compressedBytes"`
+	// 解压后的字节数
+	Bytes int64 `json:"bytes"`
+}
+
+// SourceFetchTransfer 订阅地址最近一次抓取的传输统计
+type SourceFetchTransfer struct {
+	URL string `json:"url"`
+	FetchTransfer
+	FetchedAt string `json:"fetchedAt"`
+}
+
+// FetchStats 启动以来订阅抓取的传输统计（/api/stats）
+type FetchStats struct {
+	Fetches         int            `json:"fetches"`
+	CompressedBytes int64          `json:"compressedBytes"`
+	Bytes           int64          `json:"bytes"`
+	Encodings       map[string]int `json:"encodings"`
+	// 最近一次抓取解压后最大的订阅地址，按解压后字节数降序
+	Largest []SourceFetchTransfer `json:"largest"`
+}
+
+var (
+	fetchStats = FetchStats{Encodings: make(map[string]int)}
+	// 各订阅地址最近一次抓取的传输统计: map[抓取地址] -> 统计
+	fetchTransfers     = make(map[string]SourceFetchTransfer)
+	fetchTransfersLock sync.Mutex
+)
+
+// fetchFeedURL 抓取并解析订阅地址，替代 gofeed 自带的 ParseURL，以便控制压缩与响应大小
+func fetchFeedURL(ctx context.Context, feedURL string) (*gofeed.Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", globals.Fp.UserAgent)
+	// 显式设置后 Transport 不再自动解压，由 readFeedBody 按 Content-Encoding 处理
+	req.Header.Set("Accept-Encoding", feedAcceptEncoding)
+
+	resp, err := globals.Fp.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, transfer, err := readFeedBody(resp, globals.RssUrls.Fetch.GetMaxSize())
+	recordFetchTransfer(feedURL, transfer)
+	if err != nil {
+		return nil, err
+	}
+	return globals.Fp.Parse(bytes.NewReader(body))
+}
+
+// readFeedBody 按 Content-Encoding 解压响应体，解压后超过 maxSize 时返回 errFeedTooLarge
+func readFeedBody(resp *http.Response, maxSize int64) ([]byte, FetchTransfer, error) {
+	raw := &countingReader{reader: resp.Body}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	transfer := FetchTransfer{Encoding: encoding}
+
+	var reader io.Reader
+	switch encoding {
+	case "", "identity":
+		transfer.Encoding = "identity"
+		reader = raw
+	case "gzip", "x-gzip":
+		transfer.Encoding = "gzip"
+		gz, err := gzip.NewReader(raw)
+		if err != nil {
+			transfer.CompressedBytes = raw.n
+			return nil, transfer, fmt.Errorf("gzip 解压失败: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	case "br":
+		reader = brotli.NewReader(raw)
+	case "deflate":
+		zr, err := zlib.NewReader(raw)
+		if err != nil {
+			transfer.CompressedBytes = raw.n
+			return nil, transfer, fmt.Errorf("deflate 解压失败: %w", err)
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		return nil, transfer, fmt.Errorf("不支持的压缩格式: %s", encoding)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	transfer.CompressedBytes = raw.n
+	transfer.Bytes = int64(len(body))
+	if err != nil {
+		return nil, transfer, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if transfer.Bytes > maxSize {
+		return nil, transfer, fmt.Errorf("%w（%d MB）", errFeedTooLarge, maxSize/1024/1024)
+	}
+	return body, transfer, nil
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// recordFetchTransfer 累计一次抓取的传输统计
+func recordFetchTransfer(feedURL string, transfer FetchTransfer) {
+	fetchTransfersLock.Lock()
+	defer fetchTransfersLock.Unlock()
+	fetchStats.Fetches++
+	fetchStats.CompressedBytes += transfer.CompressedBytes
+	fetchStats.Bytes += transfer.Bytes
+	fetchStats.Encodings[transfer.Encoding]++
+	fetchTransfers[feedURL] = SourceFetchTransfer{
+		URL:           feedURL,
+		FetchTransfer: transfer,
+		FetchedAt:     time.Now().Format(time.RFC3339),
+	}
+}
+
+// GetFetchStats 获取启动以来订阅抓取的传输统计
+func GetFetchStats() FetchStats {
+	fetchTransfersLock.Lock()
+	defer fetchTransfersLock.Unlock()
+	stats := fetchStats
+	stats.Encodings = make(map[string]int, len(fetchStats.Encodings))
+	for encoding, count := range fetchStats.Encodings {
+		stats.Encodings[encoding] = count
+	}
+	stats.Largest = make([]SourceFetchTransfer, 0, len(fetchTransfers))
+	for _, transfer := range fetchTransfers {
+		stats.Largest = append(stats.Largest, transfer)
+	}
+	sort.Slice(stats.Largest, func(i, j int) bool {
+		if stats.Largest[i].Bytes != stats.Largest[j].Bytes {
+			return stats.Largest[i].Bytes > stats.Largest[j].Bytes
+		}
+		return stats.Largest[i].URL < stats.Largest[j].URL
+	})
+	if len(stats.Largest) > fetchLargestLimit {
+		stats.Largest = stats.Largest[:fetchLargestLimit]
+	}
+	return stats
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestReadFeedBody(t *testing.T) {
+	content := strings.Repeat("<item><title>feedora</title></item>", 200)
+	var gz, br bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(content))
+	gw.Close()
+	bw := brotli.NewWriter(&br)
+	bw.Write([]byte(content))
+	bw.Close()
+
+	tests := []struct {
+		encoding   string
+		body       []byte
+		compressed int64
+	}{
+		{"", []byte(content), int64(len(content))},
+		{"gzip", gz.Bytes(), int64(gz.Len())},
+		{"br", br.Bytes(), int64(br.Len())},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(tt.body))}
+		resp.Header.Set("Content-Encoding", tt.encoding)
+		body, transfer, err := readFeedBody(resp, 1<<20)
+		if err != nil {
+			t.Fatalf("readFeedBody(%q) error: %v", tt.encoding, err)
+		}
+		if string(body) != content {
+			t.Errorf("readFeedBody(%q) returned unexpected body", tt.encoding)
+		}
+		if transfer.CompressedBytes != tt.compressed || transfer.Bytes != int64(len(content)) {
+			t.Errorf("readFeedBody(%q) transfer = %+v, want compressed %d, bytes %d", tt.encoding, transfer, tt.compressed, len(content))
+		}
+	}
+
+	// 解压后超过上限时中止
+	resp := &http.Response{Header: http.Header{"Content-Encoding": {"gzip"}}, Body: io.NopCloser(bytes.NewReader(gz.Bytes()))}
+	if _, _, err := readFeedBody(resp, 1024); !errors.Is(err, errFeedTooLarge) {
+		t.Errorf("readFeedBody() over limit error = %v, want errFeedTooLarge", err)
+	}
+}
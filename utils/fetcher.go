@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"feedora/models"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// Fetcher 订阅源抓取器：抓取源的内容并转换为统一的 feed 结构，之后的分类、过滤、缓存等处理与源类型无关
+type Fetcher interface {
+	Fetch(source models.Source) (*gofeed.Feed, error)
+}
+
+// FetcherFunc 将普通函数适配为 Fetcher
+type FetcherFunc func(source models.Source) (*gofeed.Feed, error)
+
+// Fetch 调用函数本身
+func (f FetcherFunc) Fetch(source models.Source) (*gofeed.Feed, error) {
+	return f(source)
+}
+
+var (
+	// 按源类型（source.type）注册的抓取器
+	typeFetchers = make(map[string]Fetcher)
+	// 按 URL 协议注册的抓取器（源未指定 type 时使用）
+	schemeFetchers = make(map[string]Fetcher)
+	fetchersLock   sync.RWMutex
+)
+
+func init() {
+	httpFetcher := FetcherFunc(fetchHTTPFeed)
+	RegisterFetcher("rss", httpFetcher)
+	RegisterFetcher("json", FetcherFunc(fetchJSONFeed))
+	RegisterFetcher("script", FetcherFunc(fetchScriptFeed))
+	RegisterFetcher("mastodon", FetcherFunc(fetchMastodonFeed))
+	RegisterFetcher("bluesky", FetcherFunc(fetchBlueskyFeed))
+	RegisterFetcher("reddit", FetcherFunc(fetchRedditFeed))
+	RegisterFetcher("hackernews", FetcherFunc(fetchHackerNewsFeed))
+	RegisterFetcher("youtube", FetcherFunc(fetchYouTubeFeed))
+	RegisterFetcher("imap", FetcherFunc(fetchIMAPFeed))
+	RegisterFetcher("webhook", FetcherFunc(fetchWebhookFeed))
+
+	RegisterSchemeFetcher("http", httpFetcher)
+	RegisterSchemeFetcher("https", httpFetcher)
+}
+
+// RegisterFetcher 注册源类型对应的抓取器（已存在时覆盖）
+// 自行构建时可在 init 中注册新的接入类型，配置中 type 为该名称的源即使用该抓取器
+func RegisterFetcher(sourceType string, fetcher Fetcher) {
+	fetchersLock.Lock()
+	typeFetchers[strings.ToLower(sourceType)] = fetcher
+	fetchersLock.Unlock()
+	models.RegisterSourceType(sourceType)
+}
+
+// RegisterSchemeFetcher 注册 URL 协议对应的抓取器（已存在时覆盖），用于未指定 type 的源（如 telegram://）
+func RegisterSchemeFetcher(scheme string, fetcher Fetcher) {
+	fetchersLock.Lock()
+	schemeFetchers[strings.ToLower(scheme)] = fetcher
+	fetchersLock.Unlock()
+}
+
+// resolveFetcher 选择源的抓取器：优先按 type，未指定 type 时按 URL 协议，都未匹配时按普通 RSS/Atom 抓取
+func resolveFetcher(source models.Source) Fetcher {
+	fetchersLock.RLock()
+	defer fetchersLock.RUnlock()
+
+	if source.Type != "" {
+		if fetcher, ok := typeFetchers[strings.ToLower(source.Type)]; ok {
+			return fetcher
+		}
+	} else if u, err := url.Parse(source.URL); err == nil && u.Scheme != "" {
+		if fetcher, ok := schemeFetchers[strings.ToLower(u.Scheme)]; ok {
+			return fetcher
+		}
+	}
+	return typeFetchers["rss"]
+}
@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 过滤规则命中统计：记录每条规则（过滤/保留关键词、白名单模式、类别黑白名单、最低相关度、脚本）过滤或保留的条目，
+// 同一条目被同一规则重复处理（每次抓取都会重新过滤）只计一次。统计保存在数据库中，用于找出从不命中或过滤过多的规则
+
+// 命中记录的保留天数，以及「最近命中」的统计天数
+const (
+	filterHitsRetentionDays = 90
+	filterHitsRecentDays    = 7
+)
+
+// 规则类型
+const (
+	filterRuleKeyword           = "filterKeyword"
+	filterRuleKeep              = "keepKeyword"
+	filterRuleWhitelistMode     = "whitelistMode"
+	filterRuleCategoryBlacklist = "categoryBlacklist"
+	filterRuleCategoryWhitelist = "categoryWhitelist"
+	filterRuleRelevance         = "minRelevance"
+	filterRuleScript            = "script"
+)
+
+// filterRule 一条过滤规则：类型与规则内容（关键词、类别ID、相关度阈值或流水线脚本步骤名称）
+type filterRule struct {
+	Type string
+	Rule string
+}
+
+// filterHits 一次处理中各规则命中的条目链接，为 nil 时不记录（预览）
+type filterHits map[filterRule]map[string]bool
+
+// add 记录条目命中规则
+func (h filterHits) add(ruleType, rule, link string) {
+	if h == nil || link == "" {
+		return
+	}
+	key := filterRule{Type: ruleType, Rule: rule}
+	if h[key] == nil {
+		h[key] = make(map[string]bool)
+	}
+	h[key][link] = true
+}
+
+// addRemoved 将 before 中存在而 after 中不存在的条目记为命中规则（与 recordFilterReasons 一致，按原始链接记录）
+func (h filterHits) addRemoved(before, after []models.Item, ruleType, rule string) {
+	if h == nil || len(before) == len(after) {
+		return
+	}
+	kept := make(map[string]bool, len(after))
+	for _, item := range after {
+		kept[item.Link] = true
+	}
+	for _, item := range before {
+		if !kept[item.Link] {
+			link := item.Link
+			if item.OriginalLink != "" {
+				link = item.OriginalLink
+			}
+			h.add(ruleType, rule, link)
+		}
+	}
+}
+
+// addKeywordResult 记录关键词过滤结果（_filtered / _keep）对应的规则
+func (h filterHits) addKeywordResult(item models.Item, strategy *models.ClassifyStrategy, category string) {
+	if h == nil || strategy == nil {
+		return
+	}
+	switch category {
+	case "_keep":
+		if keyword := matchedKeyword(item, strategy.KeepKeywords); keyword != "" {
+			h.add(filterRuleKeep, keyword, item.Link)
+		}
+	case "_filtered":
+		if strategy.IsWhitelistMode() {
+			h.add(filterRuleWhitelistMode, "", item.Link)
+		} else if keyword := matchedKeyword(item, strategy.FilterKeywords); keyword != "" {
+			h.add(filterRuleKeyword, keyword, item.Link)
+		}
+	}
+}
+
+// addCategoryRemoved 记录被类别黑白名单过滤的条目：黑名单按命中的配置项记录，白名单整体作为一条规则
+func (h filterHits) addCategoryRemoved(before, after []models.Item, strategy *models.ClassifyStrategy) {
+	if h == nil || len(before) == len(after) {
+		return
+	}
+	if len(strategy.CategoryWhitelist) > 0 {
+		h.addRemoved(before, after, filterRuleCategoryWhitelist, "")
+		return
+	}
+	kept := make(map[string]bool, len(after))
+	for _, item := range after {
+		kept[item.Link] = true
+	}
+	for _, entry := range strategy.CategoryBlacklist {
+		set := make(map[string]bool)
+		for _, id := range globals.RssUrls.ExpandCategoryIDs([]string{entry}) {
+			set[id] = true
+		}
+		for _, item := range before {
+			if !kept[item.Link] && hasAnyCategory(item, set) {
+				h.add(filterRuleCategoryBlacklist, entry, item.Link)
+			}
+		}
+	}
+}
+
+// matchedKeyword 返回条目标题或描述中第一个命中的关键词
+func matchedKeyword(item models.Item, keywords []string) string {
+	for _, keyword := range keywords {
+		if containsKeyword(item.Title, keyword) || containsKeyword(item.Description, keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// saveFilterHits 保存一次处理中的规则命中记录
+func saveFilterHits(rssURL string, hits filterHits) {
+	if DB == nil || len(hits) == 0 {
+		return
+	}
+	if err := DBSaveFilterHits(rssURL, hits, time.Now()); err != nil {
+		log.Printf("[过滤统计] 保存源 [%s] 的规则命中记录失败: %v", rssURL, err)
+	}
+}
+
+// FilterRuleStats 单条过滤规则的命中统计
+type FilterRuleStats struct {
+	Type string `json:"type"`
+	Rule string `json:"rule"`
+	// 保留期内（90 天）命中的条目数，保留关键词为被其保留的条目数
+	Hits int `json:"hits"`
+	// 最近 7 天命中的条目数
+	RecentHits int `json:"recentHits"`
+	// 最近一次命中的时间
+	LastHit string `json:"lastHit,omitempty"`
+	// 规则是否仍在当前配置中（已删除的规则保留历史统计）
+	Configured bool `json:"configured"`
+}
+
+// SourceFilterStats 订阅源的过滤规则统计
+type SourceFilterStats struct {
+	URL   string            `json:"url"`
+	Title string            `json:"title,omitempty"`
+	Rules []FilterRuleStats `json:"rules"`
+}
+
+// GetFilterStats 获取各订阅源（rssURL 不为空时只获取该源）的过滤规则命中统计：
+// 当前配置的规则按配置顺序在前（从未命中的规则计数为 0），已从配置中删除但仍有命中记录的规则在后
+func GetFilterStats(rssURL string) ([]SourceFilterStats, error) {
+	now := time.Now()
+	stored, err := DBLoadFilterStats(rssURL, now.AddDate(0, 0, -filterHitsRecentDays))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SourceFilterStats, 0)
+	for _, source := range globals.RssUrls.Sources {
+		if rssURL != "" && source.URL != rssURL {
+			continue
+		}
+		rules := stored[source.URL]
+		stats := SourceFilterStats{URL: source.URL, Rules: make([]FilterRuleStats, 0)}
+		globals.Lock.RLock()
+		if feed, ok := globals.DbMap[source.URL]; ok {
+			stats.Title = feed.Title
+		}
+		globals.Lock.RUnlock()
+
+		seen := make(map[filterRule]bool)
+		for _, rule := range configuredFilterRules(source) {
+			if seen[rule] {
+				continue
+			}
+			seen[rule] = true
+			stat, ok := rules[rule]
+			if !ok {
+				stat = FilterRuleStats{Type: rule.Type, Rule: rule.Rule}
+			}
+			stat.Configured = true
+			stats.Rules = append(stats.Rules, stat)
+		}
+		removed := make([]FilterRuleStats, 0)
+		for rule, stat := range rules {
+			if !seen[rule] {
+				removed = append(removed, stat)
+			}
+		}
+		sort.Slice(removed, func(i, j int) bool {
+			if removed[i].Type != removed[j].Type {
+				return removed[i].Type < removed[j].Type
+			}
+			return removed[i].Rule < removed[j].Rule
+		})
+		stats.Rules = append(stats.Rules, removed...)
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// configuredFilterRules 列出源当前配置的过滤规则（配置了处理流水线时按步骤列出）
+func configuredFilterRules(source models.Source) []filterRule {
+	var rules []filterRule
+	if len(source.Pipeline) > 0 {
+		for _, step := range source.Pipeline {
+			if step.Classify == nil {
+				continue
+			}
+			switch step.Type {
+			case "keyword":
+				rules = append(rules, keywordFilterRules(step.Classify)...)
+			case "script":
+				rules = append(rules, filterRule{Type: filterRuleScript, Rule: step.GetName()})
+			case "ai-classify":
+				rules = append(rules, classifyFilterRules(step.Classify)...)
+			}
+		}
+		return rules
+	}
+
+	strategy := source.Classify
+	if strategy == nil {
+		return nil
+	}
+	if strategy.IsKeywordEnabled() || strategy.IsWhitelistMode() {
+		rules = append(rules, keywordFilterRules(strategy)...)
+	}
+	rules = append(rules, classifyFilterRules(strategy)...)
+	if strategy.IsScriptFilterEnabled() && strategy.ScriptFilterContent != "" {
+		rules = append(rules, filterRule{Type: filterRuleScript})
+	}
+	return rules
+}
+
+// keywordFilterRules 列出关键词过滤的规则
+func keywordFilterRules(strategy *models.ClassifyStrategy) []filterRule {
+	var rules []filterRule
+	for _, keyword := range strategy.KeepKeywords {
+		rules = append(rules, filterRule{Type: filterRuleKeep, Rule: keyword})
+	}
+	if strategy.IsWhitelistMode() {
+		return append(rules, filterRule{Type: filterRuleWhitelistMode})
+	}
+	for _, keyword := range strategy.FilterKeywords {
+		rules = append(rules, filterRule{Type: filterRuleKeyword, Rule: keyword})
+	}
+	return rules
+}
+
+// classifyFilterRules 列出分类后的过滤规则：类别黑白名单与最低相关度
+func classifyFilterRules(strategy *models.ClassifyStrategy) []filterRule {
+	var rules []filterRule
+	if len(strategy.CategoryWhitelist) > 0 {
+		rules = append(rules, filterRule{Type: filterRuleCategoryWhitelist})
+	} else {
+		for _, category := range strategy.CategoryBlacklist {
+			rules = append(rules, filterRule{Type: filterRuleCategoryBlacklist, Rule: category})
+		}
+	}
+	if strategy.MinRelevance > 0 {
+		rules = append(rules, filterRule{Type: filterRuleRelevance, Rule: strconv.Itoa(strategy.MinRelevance)})
+	}
+	return rules
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"feedora/models"
+	"reflect"
+	"testing"
+)
+
+func TestFilterHitsAttribution(t *testing.T) {
+	whitelist := true
+	items := []models.Item{
+		{Title: "限时广告", Link: "https://a.example.com/1"},
+		{Title: "Go 1.22 发布", Link: "https://a.example.com/2"},
+		{Title: "娱乐新闻", Link: "https://a.example.com/3", Category: "fun", OriginalLink: "https://a.example.com/orig3"},
+	}
+
+	hits := make(filterHits)
+	strategy := &models.ClassifyStrategy{FilterKeywords: []string{"抽奖", "广告"}, KeepKeywords: []string{"go"}}
+	hits.addKeywordResult(items[0], strategy, "_filtered")
+	hits.addKeywordResult(items[1], strategy, "_keep")
+	hits.addKeywordResult(items[2], &models.ClassifyStrategy{WhitelistMode: &whitelist}, "_filtered")
+	hits.addCategoryRemoved(items, items[:2], &models.ClassifyStrategy{CategoryBlacklist: []string{"news", "fun"}})
+	hits.addRemoved(items, items[:2], filterRuleScript, "")
+
+	want := filterHits{
+		{filterRuleKeyword, "广告"}:            {"https://a.example.com/1": true},
+		{filterRuleKeep, "go"}:               {"https://a.example.com/2": true},
+		{filterRuleWhitelistMode, ""}:        {"https://a.example.com/3": true},
+		{filterRuleCategoryBlacklist, "fun"}: {"https://a.example.com/3": true},
+		{filterRuleScript, ""}:               {"https://a.example.com/orig3": true},
+	}
+	if !reflect.DeepEqual(hits, want) {
+		t.Errorf("hits = %v, want %v", hits, want)
+	}
+
+	var none filterHits
+	none.addKeywordResult(items[0], strategy, "_filtered")
+	none.addRemoved(items, nil, filterRuleScript, "")
+}
@@ -0,0 +1,399 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// followMaxKeywords 自动提取的关键词上限
+	followMaxKeywords = 12
+	// followMatchThreshold 条目加入线索所需的最低关键词匹配度
+	followMatchThreshold = 0.5
+	// followNotifyThreshold 新条目触发通知所需的最低匹配度（视为重要进展）
+	followNotifyThreshold = 0.6
+	// followMatchTextLimit 参与匹配的正文长度上限（字符）
+	followMatchTextLimit = 500
+)
+
+var (
+	// 故事追踪: map[追踪ID] -> 追踪
+	follows     = make(map[string]*models.Follow)
+	followsLock sync.Mutex
+
+	// followStopwords 提取关键词时忽略的常见英文词
+	followStopwords = map[string]bool{
+		"the": true, "and": true, "for": true, "with": true, "from": true, "that": true, "this": true,
+		"are": true, "was": true, "were": true, "has": true, "have": true, "had": true, "will": true,
+		"into": true, "over": true, "after": true, "about": true, "more": true, "than": true, "its": true,
+		"new": true, "how": true, "why": true, "what": true, "who": true, "when": true, "says": true,
+		"not": true, "but": true, "you": true, "your": true, "our": true, "can": true, "just": true,
+	}
+)
+
+// followID 根据被关注条目链接生成追踪ID
+func followID(seedLink string) string {
+	sum := sha1.Sum([]byte(seedLink))
+	return hex.EncodeToString(sum[:6])
+}
+
+// loadFollows 加载故事追踪
+func loadFollows() {
+	loaded, err := DBLoadFollows()
+	if err != nil {
+		log.Printf("读取故事追踪失败: %v", err)
+		return
+	}
+
+	followsLock.Lock()
+	follows = loaded
+	followsLock.Unlock()
+
+	log.Printf("[数据加载] 故事追踪: 已加载 %d 条", len(loaded))
+}
+
+// GetFollows 获取所有故事追踪（不含线索条目，按创建时间倒序）
+func GetFollows() []models.Follow {
+	followsLock.Lock()
+	defer followsLock.Unlock()
+
+	result := make([]models.Follow, 0, len(follows))
+	for _, f := range follows {
+		copied := *f
+		copied.ItemCount = len(f.Items)
+		copied.Items = nil
+		result = append(result, copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt > result[j].CreatedAt
+	})
+	return result
+}
+
+// GetFollow 获取单个故事追踪及其线索条目
+func GetFollow(id string) (models.Follow, bool) {
+	followsLock.Lock()
+	defer followsLock.Unlock()
+
+	f, ok := follows[id]
+	if !ok {
+		return models.Follow{}, false
+	}
+	copied := *f
+	copied.ItemCount = len(f.Items)
+	copied.Items = append([]models.FollowItem(nil), f.Items...)
+	sort.SliceStable(copied.Items, func(i, j int) bool {
+		return compareTimestampStrings(copied.Items[i].PubDate, copied.Items[j].PubDate) > 0
+	})
+	return copied, true
+}
+
+// FollowStory 关注条目：从当前已抓取的条目中查找 link，提取关键词并回填已有的相关条目
+// keywords 为空时从标题自动提取；重复关注同一条目会更新关键词并取消归档
+func FollowStory(link string, keywords []string) (models.Follow, error) {
+	seed, sourceURL, ok := findCachedItem(link)
+	if !ok {
+		return models.Follow{}, fmt.Errorf("未找到条目: %s", link)
+	}
+
+	keywords = normalizeFollowKeywords(keywords)
+	if len(keywords) == 0 {
+		keywords = extractFollowKeywords(seed.Title)
+	}
+	if len(keywords) == 0 {
+		return models.Follow{}, fmt.Errorf("无法从标题中提取关键词，请手动指定")
+	}
+
+	id := followID(link)
+	now := time.Now().Unix()
+	followsLock.Lock()
+	f, exists := follows[id]
+	if !exists {
+		f = &models.Follow{
+			ID:        id,
+			Title:     seed.Title,
+			SeedLink:  link,
+			CreatedAt: now,
+			Items: []models.FollowItem{{
+				Link:      seed.Link,
+				Title:     seed.Title,
+				Source:    seed.Source,
+				SourceURL: sourceURL,
+				PubDate:   seed.PubDate,
+				Score:     1,
+				MatchedAt: now,
+			}},
+		}
+		follows[id] = f
+	}
+	f.Keywords = keywords
+	f.Archived = false
+	saved := *f
+	followsLock.Unlock()
+
+	if err := DBSaveFollow(&saved); err != nil {
+		return models.Follow{}, err
+	}
+	if !exists {
+		if err := DBSaveFollowItems(id, saved.Items); err != nil {
+			log.Printf("[故事追踪] 保存线索条目失败: %v", err)
+		}
+	}
+
+	// 回填当前已抓取的相关条目（不发送通知）
+	globals.Lock.RLock()
+	snapshot := make(map[string][]models.Item, len(globals.DbMap))
+	for url, feed := range globals.DbMap {
+		snapshot[url] = feed.Items
+	}
+	globals.Lock.RUnlock()
+	for url, items := range snapshot {
+		matchFollowItems(url, items, false)
+	}
+
+	log.Printf("[故事追踪] 已关注: %s | 关键词: %s", seed.Title, strings.Join(keywords, ", "))
+	result, _ := GetFollow(id)
+	return result, nil
+}
+
+// SetFollowArchived 归档或恢复故事追踪
+func SetFollowArchived(id string, archived bool) error {
+	followsLock.Lock()
+	f, ok := follows[id]
+	if !ok {
+		followsLock.Unlock()
+		return fmt.Errorf("未找到故事追踪: %s", id)
+	}
+	f.Archived = archived
+	saved := *f
+	followsLock.Unlock()
+	return DBSaveFollow(&saved)
+}
+
+// DeleteFollow 删除故事追踪
+func DeleteFollow(id string) error {
+	followsLock.Lock()
+	if _, ok := follows[id]; !ok {
+		followsLock.Unlock()
+		return fmt.Errorf("未找到故事追踪: %s", id)
+	}
+	delete(follows, id)
+	followsLock.Unlock()
+	return DBDeleteFollow(id)
+}
+
+// purgeExpiredFollows 删除匹配时间早于 cutoff 的线索条目，以及创建时间早于 cutoff 的已归档追踪
+// 返回删除的线索条目数与追踪数
+func purgeExpiredFollows(cutoff int64) (int, int) {
+	var removedIDs []string
+	removedItems := 0
+
+	followsLock.Lock()
+	for id, f := range follows {
+		if f.Archived && f.CreatedAt < cutoff {
+			removedItems += len(f.Items)
+			delete(follows, id)
+			removedIDs = append(removedIDs, id)
+			continue
+		}
+		kept := f.Items[:0]
+		for _, item := range f.Items {
+			if item.MatchedAt >= cutoff {
+				kept = append(kept, item)
+			}
+		}
+		removedItems += len(f.Items) - len(kept)
+		f.Items = kept
+	}
+	followsLock.Unlock()
+
+	for _, id := range removedIDs {
+		if err := DBDeleteFollow(id); err != nil {
+			log.Printf("[数据保留] 删除故事追踪失败 [%s]: %v", id, err)
+		}
+	}
+	if err := DBDeleteFollowItemsOlderThan(cutoff); err != nil {
+		log.Printf("[数据保留] 删除追踪线索失败: %v", err)
+	}
+	return removedItems, len(removedIDs)
+}
+
+// matchFollowItems 将源的条目与所有未归档的故事追踪匹配，新加入线索的条目按需发送通知
+func matchFollowItems(sourceURL string, items []models.Item, notify bool) {
+	if len(items) == 0 {
+		return
+	}
+
+	type followMatch struct {
+		follow models.Follow
+		items  []models.FollowItem
+	}
+	var matches []followMatch
+	now := time.Now().Unix()
+
+	followsLock.Lock()
+	for _, f := range follows {
+		if f.Archived || len(f.Keywords) == 0 {
+			continue
+		}
+		existing := make(map[string]bool, len(f.Items))
+		for _, item := range f.Items {
+			existing[item.Link] = true
+		}
+		var added []models.FollowItem
+		for _, item := range items {
+			if existing[item.Link] {
+				continue
+			}
+			score := followMatchScore(f.Keywords, item)
+			if score < followMatchThreshold {
+				continue
+			}
+			existing[item.Link] = true
+			added = append(added, models.FollowItem{
+				Link:      item.Link,
+				Title:     item.Title,
+				Source:    item.Source,
+				SourceURL: sourceURL,
+				PubDate:   item.PubDate,
+				Score:     score,
+				MatchedAt: now,
+			})
+		}
+		if len(added) > 0 {
+			f.Items = append(f.Items, added...)
+			matches = append(matches, followMatch{follow: *f, items: added})
+		}
+	}
+	followsLock.Unlock()
+
+	for _, m := range matches {
+		if err := DBSaveFollowItems(m.follow.ID, m.items); err != nil {
+			log.Printf("[故事追踪] 保存线索条目失败: %v", err)
+		}
+		log.Printf("[故事追踪] %s | 新增相关条目 %d 条 | 源: %s", m.follow.Title, len(m.items), sourceURL)
+		if notify {
+			notifyFollowUpdate(m.follow, m.items)
+		}
+	}
+}
+
+// notifyFollowUpdate 为匹配度较高的新条目发送一次合并通知
+func notifyFollowUpdate(f models.Follow, items []models.FollowItem) {
+	var lines []string
+	for _, item := range items {
+		if item.Score < followNotifyThreshold {
+			continue
+		}
+		line := "• " + item.Title
+		if item.Source != "" {
+			line += "（" + item.Source + "）"
+		}
+		lines = append(lines, line+"\n"+item.Link)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	SendNotification("追踪进展: "+truncateString(f.Title, 60), strings.Join(lines, "\n"))
+}
+
+// followMatchScore 计算条目与关键词的匹配度：命中关键词数 / 关键词总数
+// 关键词不少于 2 个时至少需命中 2 个，避免单个常见词误匹配
+func followMatchScore(keywords []string, item models.Item) float64 {
+	text := item.Title + " " + truncateString(stripHTML(item.Description), followMatchTextLimit)
+	hits := 0
+	for _, keyword := range keywords {
+		if containsKeyword(text, keyword) {
+			hits++
+		}
+	}
+	if hits == 0 || (len(keywords) >= 2 && hits < 2) {
+		return 0
+	}
+	return float64(hits) / float64(len(keywords))
+}
+
+// extractFollowKeywords 从标题中提取关键词：英文等按单词切分（忽略常见词），中日韩文字按双字切分
+func extractFollowKeywords(title string) []string {
+	var keywords []string
+	seen := make(map[string]bool)
+	add := func(word string) {
+		if word == "" || seen[word] || len(keywords) >= followMaxKeywords {
+			return
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		w := string(word)
+		word = word[:0]
+		if len([]rune(w)) < 3 || followStopwords[w] {
+			return
+		}
+		add(w)
+	}
+	flushCJK := func() {
+		for i := 0; i+1 < len(cjk); i++ {
+			add(string(cjk[i : i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return keywords
+}
+
+// normalizeFollowKeywords 清理用户指定的关键词（去空白、去重）
+func normalizeFollowKeywords(keywords []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" || seen[keyword] {
+			continue
+		}
+		seen[keyword] = true
+		result = append(result, keyword)
+	}
+	return result
+}
+
+// findCachedItem 在已抓取的条目中查找链接，返回条目及所属源地址
+func findCachedItem(link string) (models.Item, string, bool) {
+	globals.Lock.RLock()
+	defer globals.Lock.RUnlock()
+	for url, feed := range globals.DbMap {
+		for _, item := range feed.Items {
+			if item.Link == link {
+				return item, url, true
+			}
+		}
+	}
+	return models.Item{}, "", false
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"context"
+	"feedora/globals"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 共享的 HTTP 连接池：订阅抓取（含 JSON 接口、缩略图页面、robots.txt）共用 feed 连接池，
+// 站点图标与图片代理共用 proxy 连接池（连接时需检查目标地址，不能与订阅抓取混用），
+// 两者使用相同的连接池参数，保持长连接并在服务器支持时使用 HTTP/2，连接与复用情况见 /api/stats
+
+// 连接池参数：同一主机最多保留的空闲连接较默认值（2）更多，便于同一站点的多个源与图片复用连接
+const (
+	httpMaxIdleConns        = 256
+	httpMaxIdleConnsPerHost = 16
+	httpIdleConnTimeout     = 90 * time.Second
+	httpKeepAlive           = 30 * time.Second
+	httpDialTimeout         = 30 * time.Second
+)
+
+// httpPoolCounters 连接池的累计统计
+type httpPoolCounters struct {
+	requests  int64
+	newConns  int64
+	reused    int64
+	openConns int64
+	http2     int64
+}
+
+// pooledTransport 带统计的连接池
+type pooledTransport struct {
+	name      string
+	transport *http.Transport
+	counters  *httpPoolCounters
+}
+
+// HTTPPoolStats 连接池统计（/api/stats）
+type HTTPPoolStats struct {
+	Name     string `json:"name"`
+	Requests int64  `json:"requests"`
+	// 新建的连接数
+	NewConns int64 `json:"newConns"`
+	// 复用空闲连接的请求数
+	ReusedConns int64 `json:"reusedConns"`
+	// 当前打开的连接数
+	OpenConns int64 `json:"openConns"`
+	// 使用 HTTP/2 的请求数
+	HTTP2 int64 `json:"http2"`
+}
+
+// HTTPStats 共享连接池的参数与统计
+type HTTPStats struct {
+	MaxIdleConnsPerHost int             `json:"maxIdleConnsPerHost"`
+	IdleConnTimeout     int             `json:"idleConnTimeout"`
+	Pools               []HTTPPoolStats `json:"pools"`
+}
+
+var (
+	// 已创建的连接池（按创建顺序）
+	httpPools     []*pooledTransport
+	httpPoolsLock sync.Mutex
+
+	// feedPool 订阅抓取使用的连接池
+	feedPool = newPooledTransport("feed", resolvingDialContext(&net.Dialer{Timeout: httpDialTimeout, KeepAlive: httpKeepAlive}))
+)
+
+func init() {
+	// 源设置了 TLS 选项时改用对应的连接（见 sourcetls.go），两种连接的流量都计入预算
+	globals.Fp.Client.Transport = &bandwidthTransport{base: &sourceTLSTransport{base: globals.NewUserAgentTransport(feedPool)}}
+}
+
+// newPooledTransport 创建使用统一参数的连接池，dial 负责建立连接（解析与地址检查）
+func newPooledTransport(name string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) *pooledTransport {
+	counters := &httpPoolCounters{}
+	pool := &pooledTransport{
+		name: name,
+		transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := dial(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				atomic.AddInt64(&counters.newConns, 1)
+				atomic.AddInt64(&counters.openConns, 1)
+				return &trackedConn{Conn: conn, counters: counters}, nil
+			},
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          httpMaxIdleConns,
+			MaxIdleConnsPerHost:   httpMaxIdleConnsPerHost,
+			IdleConnTimeout:       httpIdleConnTimeout,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		counters: counters,
+	}
+	httpPoolsLock.Lock()
+	httpPools = append(httpPools, pool)
+	httpPoolsLock.Unlock()
+	return pool
+}
+
+// withTransport 返回使用另一 Transport（如不同的 TLS 选项）但统计计入同一连接池的副本
+func (p *pooledTransport) withTransport(transport *http.Transport) *pooledTransport {
+	return &pooledTransport{name: p.name, transport: transport, counters: p.counters}
+}
+
+func (p *pooledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&p.counters.requests, 1)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddInt64(&p.counters.reused, 1)
+			}
+		},
+	}
+	resp, err := p.transport.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err == nil && resp.ProtoMajor == 2 {
+		atomic.AddInt64(&p.counters.http2, 1)
+	}
+	return resp, err
+}
+
+// trackedConn 关闭时更新连接池的打开连接数
+type trackedConn struct {
+	net.Conn
+	counters  *httpPoolCounters
+	closeOnce sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(func() { atomic.AddInt64(&c.counters.openConns, -1) })
+	return c.Conn.Close()
+}
+
+// GetHTTPStats 获取共享连接池的参数与统计
+func GetHTTPStats() HTTPStats {
+	stats := HTTPStats{
+		MaxIdleConnsPerHost: httpMaxIdleConnsPerHost,
+		IdleConnTimeout:     int(httpIdleConnTimeout.Seconds()),
+		Pools:               []HTTPPoolStats{},
+	}
+	httpPoolsLock.Lock()
+	defer httpPoolsLock.Unlock()
+	for _, pool := range httpPools {
+		stats.Pools = append(stats.Pools, HTTPPoolStats{
+			Name:        pool.name,
+			Requests:    atomic.LoadInt64(&pool.counters.requests),
+			NewConns:    atomic.LoadInt64(&pool.counters.newConns),
+			ReusedConns: atomic.LoadInt64(&pool.counters.reused),
+			OpenConns:   atomic.LoadInt64(&pool.counters.openConns),
+			HTTP2:       atomic.LoadInt64(&pool.counters.http2),
+		})
+	}
+	return stats
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPooledTransportReusesConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var dialer net.Dialer
+	pool := newPooledTransport("test", func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	})
+	client := &http.Client{Transport: pool}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if pool.counters.requests != 3 || pool.counters.newConns != 1 || pool.counters.reused != 2 || pool.counters.openConns != 1 {
+		t.Errorf("counters = %+v, want 3 requests over 1 reused connection", *pool.counters)
+	}
+	pool.transport.CloseIdleConnections()
+	if pool.counters.openConns != 0 {
+		t.Errorf("openConns after closing idle connections = %d, want 0", pool.counters.openConns)
+	}
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"database/sql"
+	"log"
+)
+
+// 外部ID映射：为条目分配稳定的整数UID，并记录条目在各外部服务（Miniflux、Fever 客户端、稍后读应用等）中的ID
+// 及最近一次与该服务同步的已读状态。同步模块通过 POST /api/external-sync 调用 SyncExternalService，据此双向合并状态而不会产生循环：
+//   - 从服务 A 拉取到的变化通过 ApplyExternalReadState 应用到本地，同时记为已与 A 同步，不会再推送回 A
+//   - 推送给服务 B 时通过 PendingReadStateChanges 获取本地状态与 B 不一致的条目，推送成功后调用 ConfirmReadStateSynced
+
+// ReadStateChange 需要推送给外部服务的已读状态变化
+type ReadStateChange struct {
+	UID        int64  `json:"uid"`
+	Link       string `json:"link"`
+	ExternalID string `json:"externalId"`
+	Read       bool   `json:"read"`
+}
+
+// ExternalIDPair 条目链接与外部服务中的ID
+type ExternalIDPair struct {
+	Link       string `json:"link"`
+	ExternalID string `json:"externalId"`
+}
+
+// ExternalReadState 外部服务中条目的已读状态
+type ExternalReadState struct {
+	ExternalID string `json:"externalId"`
+	Read       bool   `json:"read"`
+}
+
+// ExternalSyncResult 一轮同步的结果
+type ExternalSyncResult struct {
+	// 新记录的ID映射数
+	Mapped int `json:"mapped"`
+	// 应用到本地且改变了本地状态的外部变化数
+	Applied int `json:"applied"`
+	// 未找到映射而忽略的外部变化数
+	Unknown int `json:"unknown"`
+	// 需要推送给该服务的本地变化，推送成功后在下一轮的 confirmed 中回传
+	Pending []ReadStateChange `json:"pending"`
+}
+
+// MapExternalID 记录条目在外部服务中的ID，返回条目UID
+func MapExternalID(service, externalID, link string) (int64, error) {
+	uid, err := DBGetOrCreateItemUID(link)
+	if err != nil {
+		return 0, err
+	}
+	return uid, DBSaveExternalID(uid, service, externalID)
+}
+
+// SyncExternalService 同步模块的一轮双向合并：记录新的ID映射，确认上一轮已推送的变化，
+// 应用服务端的已读状态变化，最后返回仍需推送给该服务的本地变化
+// 先确认再应用，保证服务端刚推来的变化不会被作为待推送变化再发回服务端
+func SyncExternalService(service string, mappings []ExternalIDPair, confirmed []ReadStateChange, remote []ExternalReadState) (ExternalSyncResult, error) {
+	var result ExternalSyncResult
+	for _, m := range mappings {
+		if m.Link == "" || m.ExternalID == "" {
+			continue
+		}
+		if _, err := MapExternalID(service, m.ExternalID, m.Link); err != nil {
+			return result, err
+		}
+		result.Mapped++
+	}
+	for _, c := range confirmed {
+		if err := ConfirmReadStateSynced(service, c.UID, c.Read); err != nil {
+			return result, err
+		}
+	}
+	for _, state := range remote {
+		changed, err := ApplyExternalReadState(service, state.ExternalID, state.Read)
+		if err == sql.ErrNoRows {
+			result.Unknown++
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if changed {
+			result.Applied++
+		}
+	}
+
+	pending, err := PendingReadStateChanges(service)
+	if err != nil {
+		return result, err
+	}
+	result.Pending = pending
+	return result, nil
+}
+
+// ApplyExternalReadState 将外部服务中的已读状态变化应用到本地，返回本地状态是否发生变化
+// 变化同时记为已与该服务同步，避免再推送回同一服务
+func ApplyExternalReadState(service, externalID string, read bool) (bool, error) {
+	m, err := DBGetExternalIDMapping(service, 0, externalID)
+	if err != nil {
+		return false, err
+	}
+	if err := DBSetSyncedReadState(m.UID, service, read); err != nil {
+		return false, err
+	}
+	if IsRead(m.Link) == read {
+		return false, nil
+	}
+	if read {
+		MarkRead(m.Link)
+	} else {
+		MarkUnread(m.Link)
+	}
+	log.Printf("[外部同步] 已应用 %s 的已读状态 | 条目: %s | 已读: %v", service, m.Link, read)
+	return true, nil
+}
+
+// PendingReadStateChanges 获取本地已读状态与外部服务不一致、需要推送的条目
+// 从未同步过的条目仅推送已读状态（外部服务中的状态未知时不覆盖为未读）
+func PendingReadStateChanges(service string) ([]ReadStateChange, error) {
+	mappings, err := DBListExternalIDMappings(service)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]ReadStateChange, 0)
+	for _, m := range mappings {
+		read := IsRead(m.Link)
+		switch {
+		case m.SyncedRead < 0 && !read:
+			continue
+		case m.SyncedRead == 1 && read, m.SyncedRead == 0 && !read:
+			continue
+		}
+		changes = append(changes, ReadStateChange{UID: m.UID, Link: m.Link, ExternalID: m.ExternalID, Read: read})
+	}
+	return changes, nil
+}
+
+// ConfirmReadStateSynced 推送成功后记录条目已与外部服务同步
+func ConfirmReadStateSynced(service string, uid int64, read bool) error {
+	return DBSetSyncedReadState(uid, service, read)
+}
@@ -0,0 +1,347 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"feedora/models"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+const (
+	// imapTimeout 单次 IMAP 会话的超时时间
+	imapTimeout = 2 * time.Minute
+	// imapMaxMessageSize 单封邮件的最大字节数，超过的邮件会被跳过
+	imapMaxMessageSize = 20 << 20
+)
+
+var (
+	imapLiteralPattern = regexp.MustCompile(`\{(\d+)\}$`)
+	imapUIDPattern     = regexp.MustCompile(`UID (\d+)`)
+)
+
+// imapResponse IMAP 服务器的一条响应，Literals 为响应中携带的字面量数据
+type imapResponse struct {
+	Line     string
+	Literals [][]byte
+}
+
+// imapClient 极简 IMAP 客户端，仅实现拉取邮件所需的命令
+type imapClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// fetchIMAPFeed 从 IMAP 邮箱拉取邮件，转换为 gofeed.Feed（主题 → 标题，HTML 正文 → 描述）
+func fetchIMAPFeed(source models.Source) (*gofeed.Feed, error) {
+	if source.IMAP == nil || source.IMAP.Host == "" {
+		return nil, fmt.Errorf("邮件订阅源未配置 IMAP 服务器")
+	}
+	cfg := *source.IMAP
+
+	client, err := dialIMAP(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("连接 IMAP 服务器失败: %w", err)
+	}
+	defer client.close()
+
+	if _, err := client.command("LOGIN %s %s", imapQuote(cfg.Username), imapQuote(cfg.Password)); err != nil {
+		return nil, fmt.Errorf("IMAP 登录失败: %w", err)
+	}
+
+	title := source.Name
+	if title == "" {
+		title = "邮件订阅"
+	}
+	feed := &gofeed.Feed{
+		Title: title,
+		Link:  source.URL,
+	}
+	for _, mailbox := range cfg.GetMailboxes() {
+		items, err := client.fetchMailbox(cfg, mailbox)
+		if err != nil {
+			return nil, fmt.Errorf("拉取邮箱文件夹 %s 失败: %w", mailbox, err)
+		}
+		feed.Items = append(feed.Items, items...)
+	}
+
+	// 按邮件时间倒序排列
+	sort.SliceStable(feed.Items, func(i, j int) bool {
+		a, b := feed.Items[i].PublishedParsed, feed.Items[j].PublishedParsed
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.After(*b)
+	})
+	return feed, nil
+}
+
+// dialIMAP 建立 IMAP 连接并读取服务器问候
+func dialIMAP(cfg models.IMAPSourceConfig) (*imapClient, error) {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.GetPort()))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if cfg.Insecure {
+		conn, err = dialer.Dial("tcp", addr)
+	} else {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: cfg.Host})
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(imapTimeout))
+
+	client := &imapClient{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := client.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting.Line, "* OK") && !strings.HasPrefix(greeting.Line, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("服务器拒绝连接: %s", greeting.Line)
+	}
+	return client, nil
+}
+
+// fetchMailbox 拉取单个邮箱文件夹中符合条件的邮件
+func (c *imapClient) fetchMailbox(cfg models.IMAPSourceConfig, mailbox string) ([]*gofeed.Item, error) {
+	// 以只读方式打开，避免改变邮件的已读状态
+	if _, err := c.command("EXAMINE %s", imapQuote(mailbox)); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -cfg.GetSinceDays()).Format("2-Jan-2006")
+	criteria := "SINCE " + since
+	if len(cfg.Senders) > 0 {
+		criteria += " " + imapSenderCriteria(cfg.Senders)
+	}
+	responses, err := c.command("UID SEARCH %s", criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []string
+	for _, resp := range responses {
+		if strings.HasPrefix(resp.Line, "* SEARCH") {
+			uids = append(uids, strings.Fields(strings.TrimPrefix(resp.Line, "* SEARCH"))...)
+		}
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	// UID 递增，只保留最新的 limit 封
+	if limit := cfg.GetLimit(); len(uids) > limit {
+		uids = uids[len(uids)-limit:]
+	}
+
+	responses, err = c.command("UID FETCH %s (UID BODY.PEEK[])", strings.Join(uids, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*gofeed.Item, 0, len(responses))
+	for _, resp := range responses {
+		if !strings.Contains(resp.Line, "FETCH") || len(resp.Literals) == 0 {
+			continue
+		}
+		uid := ""
+		if match := imapUIDPattern.FindStringSubmatch(resp.Line); match != nil {
+			uid = match[1]
+		}
+		item, err := parseNewsletterMessage(resp.Literals[0])
+		if err != nil {
+			log.Printf("[邮件订阅] 解析邮件失败 | 文件夹: %s | UID: %s | 错误: %v", mailbox, uid, err)
+			continue
+		}
+		if item.Link == "" {
+			// 没有 Message-ID 时使用 IMAP URL（RFC 5092）作为唯一标识
+			item.Link = fmt.Sprintf("imap://%s@%s/%s;UID=%s", cfg.Username, cfg.Host, mailbox, uid)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// command 发送命令并读取响应，返回所有未标记（untagged）响应
+func (c *imapClient) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("A%03d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var responses []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(resp.Line, tag+" ") {
+			status := strings.TrimPrefix(resp.Line, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("%s", status)
+			}
+			return responses, nil
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// readResponse 读取一条完整响应（包含其中的字面量）
+func (c *imapClient) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	var line strings.Builder
+	for {
+		part, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, err
+		}
+		part = strings.TrimRight(part, "\r\n")
+		line.WriteString(part)
+
+		match := imapLiteralPattern.FindStringSubmatch(part)
+		if match == nil {
+			break
+		}
+		size, _ := strconv.Atoi(match[1])
+		if size > imapMaxMessageSize {
+			return resp, fmt.Errorf("邮件过大（%d 字节）", size)
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return resp, err
+		}
+		resp.Literals = append(resp.Literals, literal)
+	}
+	resp.Line = line.String()
+	return resp, nil
+}
+
+// close 退出登录并关闭连接
+func (c *imapClient) close() {
+	_, _ = c.command("LOGOUT")
+	c.conn.Close()
+}
+
+// imapQuote 将字符串转换为 IMAP 带引号字符串
+func imapQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// imapSenderCriteria 构造发件人搜索条件，多个发件人使用 OR 组合
+func imapSenderCriteria(senders []string) string {
+	if len(senders) == 1 {
+		return "FROM " + imapQuote(senders[0])
+	}
+	return "OR FROM " + imapQuote(senders[0]) + " " + imapSenderCriteria(senders[1:])
+}
+
+// parseNewsletterMessage 解析原始邮件为条目
+func parseNewsletterMessage(raw []byte) (*gofeed.Item, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		subject = "(无主题)"
+	}
+
+	htmlBody, textBody := extractMessageBody(textproto.MIMEHeader(msg.Header), msg.Body)
+	description := htmlBody
+	if description == "" && textBody != "" {
+		description = strings.ReplaceAll(html.EscapeString(strings.TrimSpace(textBody)), "\n", "<br>")
+	}
+
+	item := &gofeed.Item{
+		Title:       subject,
+		Description: description,
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		item.PublishedParsed = &date
+	}
+	if messageID := strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>"); messageID != "" {
+		// 使用 mid: URI（RFC 2392）作为条目链接，保证唯一
+		item.Link = "mid:" + messageID
+	}
+	return item, nil
+}
+
+// extractMessageBody 递归解析邮件正文，返回第一个 HTML 正文和第一个纯文本正文
+func extractMessageBody(header textproto.MIMEHeader, body io.Reader) (string, string) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(header.Get("Content-Disposition"), "attachment") {
+		return "", ""
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var htmlBody, textBody string
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			h, t := extractMessageBody(part.Header, part)
+			if htmlBody == "" {
+				htmlBody = h
+			}
+			if textBody == "" {
+				textBody = t
+			}
+		}
+		return htmlBody, textBody
+	}
+
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return "", ""
+	}
+
+	// multipart.Reader 会自动处理子部分的 quoted-printable，这里处理顶层正文及 base64
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, imapMaxMessageSize))
+	if err != nil && len(data) == 0 {
+		return "", ""
+	}
+	if mediaType == "text/html" {
+		return string(data), ""
+	}
+	return "", string(data)
+}
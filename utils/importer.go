@@ -0,0 +1,738 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 从其他阅读器迁移：读取 Miniflux、FreshRSS、Tiny Tiny RSS 的导出文件（OPML、FreshRSS 的 Google Reader JSON、
+// Tiny Tiny RSS 的条目 XML），或直接调用它们的接口，将订阅源加入配置（类别对应为同名文件夹）、
+// 收藏条目写入收藏表、已读条目写入已读状态
+
+// 每种条目（收藏、已读）最多读取的数量
+const (
+	importDefaultMaxEntries = 1000
+	importMaxEntries        = 10000
+)
+
+// ImportRequest 导入请求：设置 baseUrl 时调用对应阅读器的接口，也可以直接提供导出文件的内容，两者可同时使用
+type ImportRequest struct {
+	// 阅读器类型: miniflux / freshrss / ttrss
+	Service string `json:"service"`
+	// 接口地址：Miniflux 为站点地址，FreshRSS 为 Google Reader 接口地址（.../api/greader.php），Tiny Tiny RSS 为站点地址
+	BaseURL string `json:"baseUrl"`
+	// Miniflux 的 API 令牌
+	APIToken string `json:"apiToken"`
+	// FreshRSS / Tiny Tiny RSS 的用户名与（API）密码
+	Username    string `json:"username"`
+	APIPassword string `json:"apiPassword"`
+	// OPML 导出文件的内容
+	OPML string `json:"opml"`
+	// 条目导出文件的内容：FreshRSS 的收藏导出（Google Reader JSON）或 Tiny Tiny RSS 的条目导出（XML）
+	Articles string `json:"articles"`
+	// 收藏与已读条目各自最多读取的数量，默认 1000，最多 10000
+	MaxEntries int `json:"maxEntries"`
+	// 新建的文件夹与未分类的订阅源加入的分组ID，为空时不加入分组
+	Group string `json:"group"`
+	// 只返回将要导入的内容，不修改配置与数据
+	DryRun bool `json:"dryRun"`
+}
+
+// ImportedFeed 导入的订阅源
+type ImportedFeed struct {
+	URL      string `json:"url"`
+	Title    string `json:"title,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// ImportData 从其他阅读器读取到的订阅源、收藏条目与已读条目链接
+type ImportData struct {
+	Feeds   []ImportedFeed
+	Starred []StarredItem
+	Read    []string
+}
+
+// add 合并另一份数据（订阅源按地址、收藏与已读按链接去重）
+func (d *ImportData) add(other ImportData) {
+	feeds := make(map[string]bool, len(d.Feeds))
+	for _, feed := range d.Feeds {
+		feeds[feed.URL] = true
+	}
+	for _, feed := range other.Feeds {
+		if feed.URL != "" && !feeds[feed.URL] {
+			feeds[feed.URL] = true
+			d.Feeds = append(d.Feeds, feed)
+		}
+	}
+	starred := make(map[string]bool, len(d.Starred))
+	for _, item := range d.Starred {
+		starred[item.Link] = true
+	}
+	for _, item := range other.Starred {
+		if item.Link != "" && !starred[item.Link] {
+			starred[item.Link] = true
+			d.Starred = append(d.Starred, item)
+		}
+	}
+	read := make(map[string]bool, len(d.Read))
+	for _, link := range d.Read {
+		read[link] = true
+	}
+	for _, link := range other.Read {
+		if link != "" && !read[link] {
+			read[link] = true
+			d.Read = append(d.Read, link)
+		}
+	}
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	DryRun bool `json:"dryRun"`
+	// 读取到的订阅源
+	Feeds []ImportedFeed `json:"feeds"`
+	// 新增的订阅源数，以及配置中已存在（保持不变）的订阅源数
+	SourcesAdded    int `json:"sourcesAdded"`
+	SourcesExisting int `json:"sourcesExisting"`
+	// 新建的文件夹名称
+	FoldersCreated []string `json:"foldersCreated"`
+	// 读取到的收藏条目数与新增的收藏数
+	Starred      int `json:"starred"`
+	StarredAdded int `json:"starredAdded"`
+	// 读取到的已读条目数与新标记为已读的条目数
+	Read      int `json:"read"`
+	ReadAdded int `json:"readAdded"`
+}
+
+// ImportFromReader 读取导出文件或调用阅读器接口，并将订阅源、收藏与已读状态导入
+func ImportFromReader(req ImportRequest) (ImportResult, error) {
+	result := ImportResult{DryRun: req.DryRun, FoldersCreated: []string{}}
+	if req.Group != "" && globals.RssUrls.GetLayoutGroupByID(req.Group) == nil {
+		return result, fmt.Errorf("分组不存在: %s", req.Group)
+	}
+	data, err := fetchImportData(req)
+	if err != nil {
+		return result, err
+	}
+	result.Feeds = data.Feeds
+	if result.Feeds == nil {
+		result.Feeds = []ImportedFeed{}
+	}
+	result.Starred = len(data.Starred)
+	result.Read = len(data.Read)
+
+	if req.DryRun {
+		globals.Lock.RLock()
+		conf, err := globals.RawConfig.Clone()
+		globals.Lock.RUnlock()
+		if err != nil {
+			return result, err
+		}
+		applyImportedFeeds(&conf, data.Feeds, req.Group, &result)
+		return result, nil
+	}
+
+	if len(data.Feeds) > 0 {
+		if err := updateConfig(func(conf *models.Config) error {
+			applyImportedFeeds(conf, data.Feeds, req.Group, &result)
+			return nil
+		}); err != nil {
+			return result, err
+		}
+	}
+	if result.StarredAdded, err = SaveStarredItems(data.Starred); err != nil {
+		return result, fmt.Errorf("保存收藏条目失败: %w", err)
+	}
+	var unread []string
+	for _, link := range data.Read {
+		if !IsRead(link) {
+			unread = append(unread, link)
+		}
+	}
+	if len(unread) > 0 {
+		MarkReadBatch(unread)
+	}
+	result.ReadAdded = len(unread)
+
+	log.Printf("[导入] %s: 新增订阅源 %d 个（已存在 %d 个），新建文件夹 %d 个，新增收藏 %d 条，标记已读 %d 条",
+		req.Service, result.SourcesAdded, result.SourcesExisting, len(result.FoldersCreated), result.StarredAdded, result.ReadAdded)
+	return result, nil
+}
+
+// applyImportedFeeds 将订阅源加入配置：已存在的源保持不变，新源按类别加入同名文件夹（不存在时新建），
+// 指定分组时新建的文件夹与未分类的新源加入该分组
+func applyImportedFeeds(conf *models.Config, feeds []ImportedFeed, groupID string, result *ImportResult) {
+	groupIdx := -1
+	if groupID != "" {
+		groupIdx = findLayoutGroupIndex(conf, groupID)
+	}
+	folderByName := make(map[string]int)
+	for i, folder := range conf.Folders {
+		if _, ok := folderByName[folder.Name]; !ok {
+			folderByName[folder.Name] = i
+		}
+	}
+
+	for _, feed := range feeds {
+		if hasConfigSource(conf, feed.URL) {
+			result.SourcesExisting++
+			continue
+		}
+		conf.Sources = append(conf.Sources, models.Source{URL: feed.URL, Name: feed.Title})
+		result.SourcesAdded++
+
+		if feed.Category == "" {
+			if groupIdx >= 0 {
+				conf.LayoutGroups[groupIdx].Items = append(conf.LayoutGroups[groupIdx].Items, models.LayoutItem{Type: "source", SourceURL: feed.URL})
+			}
+			continue
+		}
+		idx, ok := folderByName[feed.Category]
+		if !ok {
+			folder := models.Folder{Name: feed.Category}
+			folder.ID = generateConfigID("folder", func(id string) bool {
+				return findFolderIndex(conf, id) >= 0
+			})
+			conf.Folders = append(conf.Folders, folder)
+			idx = len(conf.Folders) - 1
+			folderByName[feed.Category] = idx
+			result.FoldersCreated = append(result.FoldersCreated, feed.Category)
+			if groupIdx >= 0 {
+				conf.LayoutGroups[groupIdx].Items = append(conf.LayoutGroups[groupIdx].Items, models.LayoutItem{Type: "folder", FolderID: folder.ID})
+			}
+		}
+		conf.Folders[idx].Entries = append(conf.Folders[idx].Entries, models.FolderEntry{SourceURL: feed.URL})
+	}
+}
+
+// fetchImportData 读取导出文件与阅读器接口中的数据
+func fetchImportData(req ImportRequest) (ImportData, error) {
+	limit := req.MaxEntries
+	if limit <= 0 || limit > importMaxEntries {
+		limit = importDefaultMaxEntries
+	}
+
+	var data ImportData
+	if strings.TrimSpace(req.OPML) != "" {
+		feeds, err := parseOPMLFeeds([]byte(req.OPML))
+		if err != nil {
+			return data, fmt.Errorf("解析 OPML 失败: %w", err)
+		}
+		data.add(ImportData{Feeds: feeds})
+	}
+	if articles := strings.TrimSpace(req.Articles); articles != "" {
+		var parsed ImportData
+		var err error
+		if strings.HasPrefix(articles, "<") {
+			parsed, err = parseTTRSSArticles([]byte(articles))
+		} else {
+			parsed, err = parseGReaderItems([]byte(articles), nil, "freshrss")
+		}
+		if err != nil {
+			return data, fmt.Errorf("解析条目导出文件失败: %w", err)
+		}
+		data.add(parsed)
+	}
+
+	if req.BaseURL != "" {
+		client := &http.Client{Timeout: 30 * time.Second}
+		base := strings.TrimRight(strings.TrimSpace(req.BaseURL), "/")
+		var fetched ImportData
+		var err error
+		switch req.Service {
+		case "miniflux":
+			fetched, err = fetchMinifluxData(client, base, req.APIToken, limit)
+		case "freshrss":
+			fetched, err = fetchFreshRSSData(client, base, req.Username, req.APIPassword, limit)
+		case "ttrss":
+			fetched, err = fetchTTRSSData(client, base, req.Username, req.APIPassword, limit)
+		default:
+			return data, fmt.Errorf("未知的阅读器类型: %s（可选 miniflux / freshrss / ttrss）", req.Service)
+		}
+		if err != nil {
+			return data, fmt.Errorf("读取 %s 数据失败: %w", req.Service, err)
+		}
+		data.add(fetched)
+	}
+
+	if len(data.Feeds) == 0 && len(data.Starred) == 0 && len(data.Read) == 0 {
+		return data, errors.New("没有可导入的内容（需提供 baseUrl、opml 或 articles）")
+	}
+	if len(data.Starred) > limit {
+		data.Starred = data.Starred[:limit]
+	}
+	if len(data.Read) > limit {
+		data.Read = data.Read[:limit]
+	}
+	return data, nil
+}
+
+// ===== OPML =====
+
+// opmlOutline OPML 中的订阅源或类别
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr"`
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	Category string        `xml:"category,attr"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// parseOPMLFeeds 读取 OPML 中的订阅源，所在的类别（最内层的父级 outline，或 category 属性的第一项）作为类别
+func parseOPMLFeeds(data []byte) ([]ImportedFeed, error) {
+	var doc struct {
+		Body struct {
+			Outlines []opmlOutline `xml:"outline"`
+		} `xml:"body"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var feeds []ImportedFeed
+	var walk func(outlines []opmlOutline, category string)
+	walk = func(outlines []opmlOutline, category string) {
+		for _, outline := range outlines {
+			name := strings.TrimSpace(outline.Title)
+			if name == "" {
+				name = strings.TrimSpace(outline.Text)
+			}
+			if outline.XMLURL == "" {
+				walk(outline.Outlines, name)
+				continue
+			}
+			feedCategory := category
+			if feedCategory == "" && outline.Category != "" {
+				feedCategory = strings.Trim(strings.TrimSpace(strings.Split(outline.Category, ",")[0]), "/")
+			}
+			feeds = append(feeds, ImportedFeed{URL: strings.TrimSpace(outline.XMLURL), Title: name, Category: feedCategory})
+		}
+	}
+	walk(doc.Body.Outlines, "")
+	return feeds, nil
+}
+
+// ===== Google Reader JSON（FreshRSS） =====
+
+// greaderLink Google Reader 格式中的链接
+type greaderLink struct {
+	Href string `json:"href"`
+}
+
+// greaderItem Google Reader 格式的条目
+type greaderItem struct {
+	Title      string        `json:"title"`
+	Published  int64         `json:"published"`
+	Canonical  []greaderLink `json:"canonical"`
+	Alternate  []greaderLink `json:"alternate"`
+	Categories []string      `json:"categories"`
+	// 收藏时间（微秒，FreshRSS 的收藏导出中为收藏/修改时间）
+	TimestampUsec string `json:"timestampUsec"`
+	Origin        struct {
+		StreamID string `json:"streamId"`
+		Title    string `json:"title"`
+		FeedURL  string `json:"feedUrl"`
+	} `json:"origin"`
+}
+
+// link 返回条目链接
+func (i greaderItem) link() string {
+	for _, links := range [][]greaderLink{i.Canonical, i.Alternate} {
+		if len(links) > 0 && links[0].Href != "" {
+			return links[0].Href
+		}
+	}
+	return ""
+}
+
+// hasState 判断条目是否带有指定状态（如 com.google/starred）
+func (i greaderItem) hasState(state string) bool {
+	for _, category := range i.Categories {
+		if strings.HasSuffix(category, "/state/"+state) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGReaderItems 读取 Google Reader 格式的条目列表：收藏流中的条目或带收藏状态的条目计为收藏，带已读状态的条目计为已读
+// feedURLs 为订阅ID到订阅地址的映射（接口返回的 origin.streamId 为 feed/<ID> 时使用）
+func parseGReaderItems(data []byte, feedURLs map[string]string, origin string) (ImportData, error) {
+	var stream struct {
+		ID    string        `json:"id"`
+		Items []greaderItem `json:"items"`
+	}
+	if err := json.Unmarshal(data, &stream); err != nil {
+		return ImportData{}, err
+	}
+	starredStream := strings.HasSuffix(stream.ID, "/state/com.google/starred")
+	readStream := strings.HasSuffix(stream.ID, "/state/com.google/read")
+
+	var result ImportData
+	for _, item := range stream.Items {
+		link := item.link()
+		if link == "" {
+			continue
+		}
+		if starredStream || item.hasState("com.google/starred") {
+			feedURL := item.Origin.FeedURL
+			if feedURL == "" {
+				feedURL = feedURLs[item.Origin.StreamID]
+			}
+			if feedURL == "" && strings.HasPrefix(item.Origin.StreamID, "feed/http") {
+				feedURL = strings.TrimPrefix(item.Origin.StreamID, "feed/")
+			}
+			starred := StarredItem{Link: link, Title: item.Title, SourceURL: feedURL, SourceTitle: item.Origin.Title, Origin: origin}
+			if item.Published > 0 {
+				starred.PubDate = time.Unix(item.Published, 0).Format(time.RFC3339)
+			}
+			if usec, err := strconv.ParseInt(item.TimestampUsec, 10, 64); err == nil && usec > 0 {
+				starred.StarredAt = time.UnixMicro(usec).Format(time.RFC3339)
+			}
+			result.Starred = append(result.Starred, starred)
+		}
+		if readStream || item.hasState("com.google/read") {
+			result.Read = append(result.Read, link)
+		}
+	}
+	return result, nil
+}
+
+// ===== Tiny Tiny RSS 条目导出 =====
+
+// parseTTRSSArticles 读取 Tiny Tiny RSS 条目导出（import_export 插件）中的收藏条目（marked 为 1）
+func parseTTRSSArticles(data []byte) (ImportData, error) {
+	var doc struct {
+		Articles []struct {
+			Title     string `xml:"title"`
+			Link      string `xml:"link"`
+			Marked    string `xml:"marked"`
+			Updated   string `xml:"updated"`
+			FeedTitle string `xml:"feed_title"`
+			FeedURL   string `xml:"feed_url"`
+		} `xml:"article"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return ImportData{}, err
+	}
+	var result ImportData
+	for _, article := range doc.Articles {
+		link := strings.TrimSpace(article.Link)
+		if link == "" || strings.TrimSpace(article.Marked) != "1" {
+			continue
+		}
+		starred := StarredItem{Link: link, Title: strings.TrimSpace(article.Title), SourceURL: strings.TrimSpace(article.FeedURL),
+			SourceTitle: strings.TrimSpace(article.FeedTitle), Origin: "ttrss"}
+		if t, ok := parseTimestamp(strings.TrimSpace(article.Updated)); ok {
+			starred.PubDate = t.Format(time.RFC3339)
+		}
+		result.Starred = append(result.Starred, starred)
+	}
+	return result, nil
+}
+
+// ===== 阅读器接口 =====
+
+// importRequestJSON 发送请求并解析 JSON 响应
+func importRequestJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// fetchMinifluxData 通过 Miniflux 接口（/v1）读取订阅源、收藏条目与已读条目
+func fetchMinifluxData(client *http.Client, base, token string, limit int) (ImportData, error) {
+	get := func(path string, v interface{}) error {
+		req, err := http.NewRequest(http.MethodGet, base+path, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Auth-Token", token)
+		return importRequestJSON(client, req, v)
+	}
+
+	var data ImportData
+	var feeds []struct {
+		FeedURL  string `json:"feed_url"`
+		Title    string `json:"title"`
+		Category struct {
+			Title string `json:"title"`
+		} `json:"category"`
+	}
+	if err := get("/v1/feeds", &feeds); err != nil {
+		return data, err
+	}
+	for _, feed := range feeds {
+		category := feed.Category.Title
+		if category == "All" {
+			// Miniflux 的默认类别
+			category = ""
+		}
+		data.Feeds = append(data.Feeds, ImportedFeed{URL: feed.FeedURL, Title: feed.Title, Category: category})
+	}
+
+	type entries struct {
+		Entries []struct {
+			URL         string `json:"url"`
+			Title       string `json:"title"`
+			PublishedAt string `json:"published_at"`
+			ChangedAt   string `json:"changed_at"`
+			Feed        struct {
+				FeedURL string `json:"feed_url"`
+				Title   string `json:"title"`
+			} `json:"feed"`
+		} `json:"entries"`
+	}
+	var starred entries
+	if err := get(fmt.Sprintf("/v1/entries?starred=true&order=changed_at&direction=desc&limit=%d", limit), &starred); err != nil {
+		return data, err
+	}
+	for _, entry := range starred.Entries {
+		data.Starred = append(data.Starred, StarredItem{Link: entry.URL, Title: entry.Title, SourceURL: entry.Feed.FeedURL,
+			SourceTitle: entry.Feed.Title, PubDate: entry.PublishedAt, StarredAt: entry.ChangedAt, Origin: "miniflux"})
+	}
+	var read entries
+	if err := get(fmt.Sprintf("/v1/entries?status=read&order=changed_at&direction=desc&limit=%d", limit), &read); err != nil {
+		return data, err
+	}
+	for _, entry := range read.Entries {
+		data.Read = append(data.Read, entry.URL)
+	}
+	return data, nil
+}
+
+// fetchFreshRSSData 通过 FreshRSS 的 Google Reader 接口读取订阅源、收藏条目与已读条目
+func fetchFreshRSSData(client *http.Client, base, username, password string, limit int) (ImportData, error) {
+	var data ImportData
+	form := url.Values{"Email": {username}, "Passwd": {password}}
+	resp, err := client.PostForm(base+"/accounts/ClientLogin", form)
+	if err != nil {
+		return data, err
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return data, fmt.Errorf("登录失败: %s", resp.Status)
+	}
+	auth := ""
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.HasPrefix(line, "Auth=") {
+			auth = strings.TrimSpace(strings.TrimPrefix(line, "Auth="))
+		}
+	}
+	if auth == "" {
+		return data, errors.New("登录失败: 响应中没有 Auth 令牌")
+	}
+	get := func(path string, v interface{}) error {
+		req, err := http.NewRequest(http.MethodGet, base+path, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "GoogleLogin auth="+auth)
+		return importRequestJSON(client, req, v)
+	}
+
+	var subscriptions struct {
+		Subscriptions []struct {
+			ID         string `json:"id"`
+			Title      string `json:"title"`
+			URL        string `json:"url"`
+			Categories []struct {
+				Label string `json:"label"`
+			} `json:"categories"`
+		} `json:"subscriptions"`
+	}
+	if err := get("/reader/api/0/subscription/list?output=json", &subscriptions); err != nil {
+		return data, err
+	}
+	feedURLs := make(map[string]string)
+	for _, sub := range subscriptions.Subscriptions {
+		feedURL := sub.URL
+		if feedURL == "" {
+			feedURL = strings.TrimPrefix(sub.ID, "feed/")
+		}
+		feedURLs[sub.ID] = feedURL
+		category := ""
+		if len(sub.Categories) > 0 {
+			category = sub.Categories[0].Label
+		}
+		data.Feeds = append(data.Feeds, ImportedFeed{URL: feedURL, Title: sub.Title, Category: category})
+	}
+
+	for _, state := range []string{"starred", "read"} {
+		continuation := ""
+		count := 0
+		for count < limit {
+			n := limit - count
+			if n > 1000 {
+				n = 1000
+			}
+			path := fmt.Sprintf("/reader/api/0/stream/contents/user/-/state/com.google/%s?output=json&n=%d", state, n)
+			if continuation != "" {
+				path += "&c=" + url.QueryEscape(continuation)
+			}
+			var raw json.RawMessage
+			if err := get(path, &raw); err != nil {
+				return data, err
+			}
+			page, err := parseGReaderItems(raw, feedURLs, "freshrss")
+			if err != nil {
+				return data, err
+			}
+			data.add(page)
+			var meta struct {
+				Items        []json.RawMessage `json:"items"`
+				Continuation string            `json:"continuation"`
+			}
+			json.Unmarshal(raw, &meta)
+			count += len(meta.Items)
+			if meta.Continuation == "" || len(meta.Items) == 0 {
+				break
+			}
+			continuation = meta.Continuation
+		}
+	}
+	return data, nil
+}
+
+// ttrssID Tiny Tiny RSS 接口中的ID（不同版本中为数字或字符串）
+type ttrssID string
+
+// UnmarshalJSON 同时接受数字与字符串
+func (id *ttrssID) UnmarshalJSON(data []byte) error {
+	*id = ttrssID(strings.Trim(string(data), `"`))
+	return nil
+}
+
+// ttrssPageSize Tiny Tiny RSS 每次最多返回的条目数
+const ttrssPageSize = 200
+
+// fetchTTRSSData 通过 Tiny Tiny RSS 接口（/api/）读取订阅源、收藏条目与已读条目
+func fetchTTRSSData(client *http.Client, base, username, password string, limit int) (ImportData, error) {
+	var data ImportData
+	sessionID := ""
+	call := func(op string, params map[string]interface{}, content interface{}) error {
+		body := map[string]interface{}{"op": op}
+		for key, value := range params {
+			body[key] = value
+		}
+		if sessionID != "" {
+			body["sid"] = sessionID
+		}
+		payload, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, base+"/api/", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		var resp struct {
+			Status  int             `json:"status"`
+			Content json.RawMessage `json:"content"`
+		}
+		if err := importRequestJSON(client, req, &resp); err != nil {
+			return err
+		}
+		if resp.Status != 0 {
+			var apiErr struct {
+				Error string `json:"error"`
+			}
+			json.Unmarshal(resp.Content, &apiErr)
+			return fmt.Errorf("%s 失败: %s", op, apiErr.Error)
+		}
+		return json.Unmarshal(resp.Content, content)
+	}
+
+	var login struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := call("login", map[string]interface{}{"user": username, "password": password}, &login); err != nil {
+		return data, err
+	}
+	sessionID = login.SessionID
+
+	var categories []struct {
+		ID    ttrssID `json:"id"`
+		Title string  `json:"title"`
+	}
+	if err := call("getCategories", nil, &categories); err != nil {
+		return data, err
+	}
+	categoryNames := make(map[ttrssID]string)
+	for _, category := range categories {
+		// 0 为“未分类”
+		if category.ID != "0" {
+			categoryNames[category.ID] = category.Title
+		}
+	}
+	var feeds []struct {
+		ID      ttrssID `json:"id"`
+		FeedURL string  `json:"feed_url"`
+		Title   string  `json:"title"`
+		CatID   ttrssID `json:"cat_id"`
+	}
+	if err := call("getFeeds", map[string]interface{}{"cat_id": -3}, &feeds); err != nil {
+		return data, err
+	}
+	feedByID := make(map[ttrssID]ImportedFeed)
+	for _, feed := range feeds {
+		imported := ImportedFeed{URL: feed.FeedURL, Title: feed.Title, Category: categoryNames[feed.CatID]}
+		feedByID[feed.ID] = imported
+		data.Feeds = append(data.Feeds, imported)
+	}
+
+	type headline struct {
+		Link    string  `json:"link"`
+		Title   string  `json:"title"`
+		Updated int64   `json:"updated"`
+		FeedID  ttrssID `json:"feed_id"`
+		Unread  bool    `json:"unread"`
+		Marked  bool    `json:"marked"`
+	}
+	// 收藏（-1）中的条目，以及全部条目（-4）中的已读条目
+	for _, feedID := range []int{-1, -4} {
+		for skip := 0; skip < limit; skip += ttrssPageSize {
+			var headlines []headline
+			if err := call("getHeadlines", map[string]interface{}{"feed_id": feedID, "limit": ttrssPageSize, "skip": skip, "view_mode": "all_articles"}, &headlines); err != nil {
+				return data, err
+			}
+			for _, h := range headlines {
+				if h.Link == "" {
+					continue
+				}
+				if feedID == -1 {
+					feed := feedByID[h.FeedID]
+					starred := StarredItem{Link: h.Link, Title: h.Title, SourceURL: feed.URL, SourceTitle: feed.Title, Origin: "ttrss"}
+					if h.Updated > 0 {
+						starred.PubDate = time.Unix(h.Updated, 0).Format(time.RFC3339)
+					}
+					data.Starred = append(data.Starred, starred)
+				} else if !h.Unread {
+					data.Read = append(data.Read, h.Link)
+				}
+			}
+			if len(headlines) < ttrssPageSize {
+				break
+			}
+		}
+	}
+	return data, nil
+}
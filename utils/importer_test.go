@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"feedora/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseOPMLFeeds(t *testing.T) {
+	opml := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0"><body>
+  <outline text="Tech">
+    <outline text="Blog A" title="Blog A" type="rss" xmlUrl="https://a.example.com/feed"/>
+  </outline>
+  <outline text="Loose" type="rss" xmlUrl="https://b.example.com/feed"/>
+  <outline text="Tagged" type="rss" xmlUrl="https://c.example.com/feed" category="/News,/World"/>
+</body></opml>`
+	feeds, err := parseOPMLFeeds([]byte(opml))
+	if err != nil {
+		t.Fatalf("parseOPMLFeeds() error: %v", err)
+	}
+	want := []ImportedFeed{
+		{URL: "https://a.example.com/feed", Title: "Blog A", Category: "Tech"},
+		{URL: "https://b.example.com/feed", Title: "Loose"},
+		{URL: "https://c.example.com/feed", Title: "Tagged", Category: "News"},
+	}
+	if !reflect.DeepEqual(feeds, want) {
+		t.Errorf("parseOPMLFeeds() = %+v, want %+v", feeds, want)
+	}
+}
+
+func TestParseGReaderItems(t *testing.T) {
+	data := `{"id":"user/-/state/com.google/starred","items":[
+		{"title":"Post","published":1700000000,"alternate":[{"href":"https://a.example.com/post"}],
+		 "categories":["user/-/state/com.google/read"],"origin":{"streamId":"feed/12","title":"Blog A"}}
+	]}`
+	parsed, err := parseGReaderItems([]byte(data), map[string]string{"feed/12": "https://a.example.com/feed"}, "freshrss")
+	if err != nil {
+		t.Fatalf("parseGReaderItems() error: %v", err)
+	}
+	if len(parsed.Starred) != 1 || parsed.Starred[0].SourceURL != "https://a.example.com/feed" || parsed.Starred[0].Link != "https://a.example.com/post" {
+		t.Errorf("parseGReaderItems() starred = %+v", parsed.Starred)
+	}
+	if !reflect.DeepEqual(parsed.Read, []string{"https://a.example.com/post"}) {
+		t.Errorf("parseGReaderItems() read = %v", parsed.Read)
+	}
+}
+
+func TestApplyImportedFeeds(t *testing.T) {
+	conf := models.Config{
+		Sources:      []models.Source{{URL: "https://a.example.com/feed"}},
+		Folders:      []models.Folder{{ID: "folder_tech", Name: "Tech"}},
+		LayoutGroups: []models.LayoutGroup{{ID: "group_main", Name: "Main"}},
+	}
+	feeds := []ImportedFeed{
+		{URL: "https://a.example.com/feed", Category: "Tech"},
+		{URL: "https://b.example.com/feed", Title: "B", Category: "Tech"},
+		{URL: "https://c.example.com/feed", Category: "News"},
+		{URL: "https://d.example.com/feed"},
+	}
+	var result ImportResult
+	applyImportedFeeds(&conf, feeds, "group_main", &result)
+
+	if result.SourcesAdded != 3 || result.SourcesExisting != 1 || !reflect.DeepEqual(result.FoldersCreated, []string{"News"}) {
+		t.Errorf("applyImportedFeeds() result = %+v", result)
+	}
+	if got := conf.Folders[0].Entries; len(got) != 1 || got[0].SourceURL != "https://b.example.com/feed" {
+		t.Errorf("existing folder entries = %+v", got)
+	}
+	if len(conf.Folders) != 2 || conf.Folders[1].Name != "News" || len(conf.Folders[1].Entries) != 1 {
+		t.Errorf("new folder = %+v", conf.Folders)
+	}
+	items := conf.LayoutGroups[0].Items
+	if len(items) != 2 || items[0].FolderID != conf.Folders[1].ID || items[1].SourceURL != "https://d.example.com/feed" {
+		t.Errorf("group items = %+v", items)
+	}
+}
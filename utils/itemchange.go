@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"feedora/globals"
+	"feedora/models"
+)
+
+// itemContentState 条目上次抓取时的内容指纹与修改状态
+type itemContentState struct {
+	Hash        string
+	Updated     bool
+	LastChanged string
+}
+
+// itemContentHash 计算条目原始标题与描述的指纹（后处理、翻译前），用于检测已发布条目的修改
+// 描述只取文本，图片地址中的跟踪参数或清洗策略的变化不视为修改
+func itemContentHash(title, description string) string {
+	sum := sha1.Sum([]byte(title + "\n" + sanitizeHTML("strip", description)))
+	return hex.EncodeToString(sum[:])
+}
+
+// previousContentStates 收集源上次抓取时各条目（按原始链接）的内容指纹与修改状态
+func previousContentStates(rssURL string) map[string]itemContentState {
+	states := make(map[string]itemContentState)
+	record := func(item models.Item) {
+		link := item.Link
+		if item.OriginalLink != "" {
+			link = item.OriginalLink
+		}
+		if _, exists := states[link]; !exists && item.ContentHash != "" {
+			states[link] = itemContentState{Hash: item.ContentHash, Updated: item.Updated, LastChanged: item.LastChanged}
+		}
+	}
+
+	globals.Lock.RLock()
+	if cache, ok := globals.DbMap[rssURL]; ok {
+		for _, item := range cache.Items {
+			record(item)
+		}
+		// 被过滤的条目不在展示列表中，只有指纹
+		if len(cache.AllItemHashes) == len(cache.AllItemLinks) {
+			for i, link := range cache.AllItemLinks {
+				if _, exists := states[link]; !exists && cache.AllItemHashes[i] != "" {
+					states[link] = itemContentState{Hash: cache.AllItemHashes[i]}
+				}
+			}
+		}
+	}
+	globals.Lock.RUnlock()
+
+	if cachedItems, ok := GetItemsCache(rssURL); ok {
+		for _, item := range cachedItems {
+			record(item)
+		}
+	}
+	return states
+}
+
+// applyContentChange 设置条目的内容指纹；与上次抓取的指纹不同时标记为已修改并记录修改时间，返回本次是否检测到修改
+// 没有旧指纹（新条目或升级前缓存的条目）时不视为修改
+func applyContentChange(item *models.Item, previous map[string]itemContentState, now string) bool {
+	item.ContentHash = itemContentHash(item.Title, item.Description)
+	prev, ok := previous[item.Link]
+	if !ok {
+		return false
+	}
+	if prev.Hash != item.ContentHash {
+		item.Updated = true
+		item.LastChanged = now
+		return true
+	}
+	item.Updated = prev.Updated
+	item.LastChanged = prev.LastChanged
+	return false
+}
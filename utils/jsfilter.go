@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"feedora/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// applyJSScriptFilter 使用内置 JavaScript 引擎执行过滤脚本，不依赖外部程序
+// 脚本内容为函数体：通过 items 变量接收条目数组（字段与 bash 模式的 JSON 一致），return 过滤后的条目数组，例如
+// return items.filter(item => !item.title.includes("广告"))
+func applyJSScriptFilter(items []models.Item, scriptContent string, rssURL string, timeout time.Duration) ([]models.Item, error) {
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		return items, fmt.Errorf("序列化条目失败: %w", err)
+	}
+
+	vm := goja.New()
+	console := vm.NewObject()
+	_ = console.Set("log", func(call goja.FunctionCall) goja.Value {
+		args := make([]string, 0, len(call.Arguments))
+		for _, arg := range call.Arguments {
+			args = append(args, arg.String())
+		}
+		log.Printf("[脚本规则过滤] 源 [%s]: %s", rssURL, strings.Join(args, " "))
+		return goja.Undefined()
+	})
+	_ = vm.Set("console", console)
+	_ = vm.Set("__itemsJSON", string(itemsJSON))
+
+	// 超时后中断脚本执行（如死循环）
+	timer := time.AfterFunc(timeout, func() {
+		vm.Interrupt("timeout")
+	})
+	defer timer.Stop()
+	// 堆增长超过内存上限时中断脚本（如无限增长的数组）
+	memoryLimit := engineMemoryLimit()
+	stopWatch := watchHeapGrowth(memoryLimit, func() {
+		vm.Interrupt("memory")
+	})
+	defer stopWatch()
+
+	// 条目通过 JSON 传入传出，脚本看到的字段名与 bash 模式一致
+	src := "JSON.stringify((function (items) {\n" + scriptContent + "\n})(JSON.parse(__itemsJSON)))"
+	value, err := vm.RunScript("scriptFilter.js", src)
+	if err != nil {
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			if interrupted.Value() == "memory" {
+				return items, fmt.Errorf("脚本内存超出上限（%d MB）", memoryLimit>>20)
+			}
+			return items, fmt.Errorf("脚本执行超时（超过 %v）", timeout)
+		}
+		return items, fmt.Errorf("脚本执行失败: %v", err)
+	}
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return items, fmt.Errorf("脚本未返回条目数组（需要 return 过滤后的 items）")
+	}
+
+	return parseScriptItemsOutput([]byte(value.String()))
+}
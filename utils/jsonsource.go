@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// fetchJSONFeed 抓取 JSON API 源并按字段映射转换为 gofeed.Feed
+func fetchJSONFeed(source models.Source) (*gofeed.Feed, error) {
+	if source.JSON == nil {
+		return nil, fmt.Errorf("JSON 源未配置字段映射")
+	}
+	mapping := source.JSON
+	if mapping.TitlePath == "" || mapping.LinkPath == "" {
+		return nil, fmt.Errorf("JSON 源必须配置 titlePath 和 linkPath")
+	}
+
+	var root interface{}
+	if err := fetchJSONContext(withSourceTLS(context.Background(), source), source.URL, mapping.Headers, &root); err != nil {
+		return nil, err
+	}
+
+	return mapJSONToFeed(root, source.URL, mapping)
+}
+
+// fetchJSON 请求 JSON 接口并解析到 out
+func fetchJSON(apiURL string, headers map[string]string, out interface{}) error {
+	return fetchJSONContext(context.Background(), apiURL, headers, out)
+}
+
+// fetchJSONContext 与 fetchJSON 相同，请求使用指定的 context（如携带源的 TLS 选项）
+func fetchJSONContext(ctx context.Context, apiURL string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := globals.Fp.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 JSON 接口失败: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析 JSON 失败: %w", err)
+	}
+	return nil
+}
+
+// mapJSONToFeed 根据字段映射将 JSON 数据转换为 gofeed.Feed
+func mapJSONToFeed(root interface{}, baseURL string, mapping *models.JSONSourceConfig) (*gofeed.Feed, error) {
+	node, ok := lookupJSONPath(root, mapping.ItemsPath)
+	if !ok {
+		return nil, fmt.Errorf("未找到条目路径: %s", mapping.ItemsPath)
+	}
+	list, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("条目路径 %s 对应的不是数组", mapping.ItemsPath)
+	}
+
+	feed := &gofeed.Feed{
+		Link:  baseURL,
+		Items: make([]*gofeed.Item, 0, len(list)),
+	}
+	if mapping.FeedTitlePath != "" {
+		feed.Title = jsonPathString(root, mapping.FeedTitlePath)
+	}
+
+	base, _ := url.Parse(baseURL)
+	for _, entry := range list {
+		title := strings.TrimSpace(jsonPathString(entry, mapping.TitlePath))
+		link := strings.TrimSpace(jsonPathString(entry, mapping.LinkPath))
+		if title == "" || link == "" {
+			continue
+		}
+		if mapping.LinkTemplate != "" {
+			link = strings.ReplaceAll(mapping.LinkTemplate, "{link}", link)
+		}
+		// 相对链接基于接口地址补全
+		if base != nil {
+			if ref, err := url.Parse(link); err == nil && !ref.IsAbs() {
+				link = base.ResolveReference(ref).String()
+			}
+		}
+
+		item := &gofeed.Item{
+			Title: title,
+			Link:  link,
+		}
+		if mapping.DescriptionPath != "" {
+			item.Description = jsonPathString(entry, mapping.DescriptionPath)
+		}
+		if mapping.PubDatePath != "" {
+			if value, ok := lookupJSONPath(entry, mapping.PubDatePath); ok {
+				item.PublishedParsed = parseJSONTime(value)
+			}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, nil
+}
+
+// lookupJSONPath 按 JQ 风格路径取值，支持 .a.b、a.b、items[0]、.list[] 等写法
+func lookupJSONPath(node interface{}, path string) (interface{}, bool) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, ".")
+	// 末尾的 [] 表示迭代数组，这里直接返回数组本身
+	path = strings.TrimSuffix(path, "[]")
+	if path == "" {
+		return node, true
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			continue
+		}
+		key := segment
+		indexes := make([]int, 0)
+		if pos := strings.Index(segment, "["); pos >= 0 {
+			key = segment[:pos]
+			rest := segment[pos:]
+			for strings.HasPrefix(rest, "[") {
+				end := strings.Index(rest, "]")
+				if end < 0 {
+					return nil, false
+				}
+				idx, err := strconv.Atoi(rest[1:end])
+				if err != nil {
+					return nil, false
+				}
+				indexes = append(indexes, idx)
+				rest = rest[end+1:]
+			}
+		}
+
+		if key != "" {
+			obj, ok := node.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			node, ok = obj[key]
+			if !ok {
+				return nil, false
+			}
+		}
+
+		for _, idx := range indexes {
+			arr, ok := node.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			if idx < 0 {
+				idx += len(arr)
+			}
+			if idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			node = arr[idx]
+		}
+	}
+
+	return node, true
+}
+
+// jsonPathString 按路径取值并转换为字符串
+func jsonPathString(node interface{}, path string) string {
+	value, ok := lookupJSONPath(node, path)
+	if !ok || value == nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// parseJSONTime 解析 JSON 中的时间值，支持字符串与秒/毫秒时间戳
+func parseJSONTime(value interface{}) *time.Time {
+	switch v := value.(type) {
+	case float64:
+		return parseUnixTimestamp(int64(v))
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil
+		}
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return parseUnixTimestamp(n)
+		}
+		layouts := []string{
+			time.RFC3339Nano,
+			time.RFC3339,
+			time.RFC1123Z,
+			time.RFC1123,
+			"2006-01-02 15:04:05",
+			"2006-01-02T15:04:05",
+			"2006-01-02",
+		}
+		for _, layout := range layouts {
+			if parsed, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return &parsed
+			}
+		}
+	}
+	return nil
+}
+
+// parseUnixTimestamp 将秒或毫秒时间戳转换为时间
+func parseUnixTimestamp(n int64) *time.Time {
+	if n <= 0 {
+		return nil
+	}
+	var t time.Time
+	if n > 1e12 {
+		t = time.UnixMilli(n)
+	} else {
+		t = time.Unix(n, 0)
+	}
+	return &t
+}
@@ -0,0 +1,412 @@
+package utils
+
+import (
+	"crypto/rand"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// updateConfig 在当前配置的副本上执行修改并保存到配置文件（随后由文件监听自动重新加载）
+func updateConfig(modify func(conf *models.Config) error) error {
+	globals.Lock.Lock()
+	defer globals.Lock.Unlock()
+
+	conf, err := globals.RawConfig.Clone()
+	if err != nil {
+		return err
+	}
+	if err := modify(&conf); err != nil {
+		return err
+	}
+	if cycle := conf.FindFolderCycle(); cycle != nil {
+		return fmt.Errorf("文件夹嵌套形成循环引用: %s", strings.Join(cycle, " → "))
+	}
+	return SaveConfig(conf)
+}
+
+// generateConfigID 生成与设置界面格式一致的ID（前缀_6位随机字符），保证不与已有ID重复
+func generateConfigID(prefix string, exists func(id string) bool) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	buf := make([]byte, 6)
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			panic(err)
+		}
+		for i := range buf {
+			buf[i] = alphabet[int(buf[i])%len(alphabet)]
+		}
+		id := prefix + "_" + string(buf)
+		if !exists(id) {
+			return id
+		}
+	}
+}
+
+// findFolderIndex 查找文件夹在配置中的位置
+func findFolderIndex(conf *models.Config, id string) int {
+	for i := range conf.Folders {
+		if conf.Folders[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// findLayoutGroupIndex 查找分组在配置中的位置
+func findLayoutGroupIndex(conf *models.Config, id string) int {
+	for i := range conf.LayoutGroups {
+		if conf.LayoutGroups[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasConfigSource 判断配置中是否存在该订阅源
+func hasConfigSource(conf *models.Config, url string) bool {
+	for _, source := range conf.Sources {
+		if source.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFolderEntry 检查文件夹条目引用的订阅源、分类包或子文件夹是否存在
+func checkFolderEntry(conf *models.Config, entry models.FolderEntry) error {
+	specified := 0
+	for _, ref := range []string{entry.SourceURL, entry.CategoryPackageId, entry.FolderID} {
+		if ref != "" {
+			specified++
+		}
+	}
+	switch {
+	case specified > 1:
+		return fmt.Errorf("文件夹条目只能指定订阅源、分类包或子文件夹之一")
+	case entry.FolderID != "":
+		if findFolderIndex(conf, entry.FolderID) < 0 {
+			return fmt.Errorf("子文件夹不存在: %s", entry.FolderID)
+		}
+		return nil
+	case entry.CategoryPackageId != "":
+		for _, pkg := range conf.AIClassify.CategoryPackages {
+			if pkg.ID == entry.CategoryPackageId {
+				return nil
+			}
+		}
+		return fmt.Errorf("分类包不存在: %s", entry.CategoryPackageId)
+	case entry.SourceURL != "":
+		if !hasConfigSource(conf, entry.SourceURL) {
+			return fmt.Errorf("订阅源不存在: %s", entry.SourceURL)
+		}
+		return nil
+	default:
+		return fmt.Errorf("文件夹条目未指定订阅源、分类包或子文件夹")
+	}
+}
+
+// checkLayoutItem 检查布局项引用的订阅源或文件夹是否存在
+func checkLayoutItem(conf *models.Config, item models.LayoutItem) error {
+	switch item.Type {
+	case "source":
+		if !hasConfigSource(conf, item.SourceURL) {
+			return fmt.Errorf("订阅源不存在: %s", item.SourceURL)
+		}
+	case "folder":
+		if findFolderIndex(conf, item.FolderID) < 0 {
+			return fmt.Errorf("文件夹不存在: %s", item.FolderID)
+		}
+	default:
+		return fmt.Errorf("未知的布局项类型: %s", item.Type)
+	}
+	return nil
+}
+
+// checkIndex 检查条目位置是否有效
+func checkIndex(index, length int) error {
+	if index < 0 || index >= length {
+		return fmt.Errorf("位置超出范围: %d", index)
+	}
+	return nil
+}
+
+// insertAt 计算插入位置：index 为负数或超出范围时追加到末尾
+func insertAt(index, length int) int {
+	if index < 0 || index > length {
+		return length
+	}
+	return index
+}
+
+// ===== 文件夹 =====
+
+// CreateFolder 创建文件夹（ID 由服务端生成），返回创建后的文件夹
+func CreateFolder(folder models.Folder) (models.Folder, error) {
+	if strings.TrimSpace(folder.Name) == "" {
+		return folder, fmt.Errorf("文件夹名称为空")
+	}
+	err := updateConfig(func(conf *models.Config) error {
+		for _, entry := range folder.Entries {
+			if err := checkFolderEntry(conf, entry); err != nil {
+				return err
+			}
+		}
+		folder.ID = generateConfigID("folder", func(id string) bool {
+			return findFolderIndex(conf, id) >= 0
+		})
+		conf.Folders = append(conf.Folders, folder)
+		return nil
+	})
+	if err == nil {
+		log.Printf("[配置] 已创建文件夹: %s (%s)", folder.Name, folder.ID)
+	}
+	return folder, err
+}
+
+// UpdateFolder 更新文件夹（ID 保持不变）
+func UpdateFolder(id string, folder models.Folder) error {
+	if strings.TrimSpace(folder.Name) == "" {
+		return fmt.Errorf("文件夹名称为空")
+	}
+	return updateConfig(func(conf *models.Config) error {
+		idx := findFolderIndex(conf, id)
+		if idx < 0 {
+			return fmt.Errorf("文件夹不存在: %s", id)
+		}
+		for _, entry := range folder.Entries {
+			if err := checkFolderEntry(conf, entry); err != nil {
+				return err
+			}
+		}
+		folder.ID = id
+		conf.Folders[idx] = folder
+		return nil
+	})
+}
+
+// DeleteFolder 删除文件夹
+// 文件夹仍被分组或其他文件夹引用时，cascade 为 true 则一并移除这些布局项与子文件夹条目，否则拒绝删除
+func DeleteFolder(id string, cascade bool) error {
+	return updateConfig(func(conf *models.Config) error {
+		idx := findFolderIndex(conf, id)
+		if idx < 0 {
+			return fmt.Errorf("文件夹不存在: %s", id)
+		}
+
+		var referencedBy []string
+		for g := range conf.LayoutGroups {
+			group := &conf.LayoutGroups[g]
+			items := make([]models.LayoutItem, 0, len(group.Items))
+			for _, item := range group.Items {
+				if item.Type == "folder" && item.FolderID == id {
+					if len(referencedBy) == 0 || referencedBy[len(referencedBy)-1] != group.Name {
+						referencedBy = append(referencedBy, group.Name)
+					}
+					continue
+				}
+				items = append(items, item)
+			}
+			group.Items = items
+		}
+		for f := range conf.Folders {
+			parent := &conf.Folders[f]
+			entries := make([]models.FolderEntry, 0, len(parent.Entries))
+			for _, entry := range parent.Entries {
+				if entry.FolderID == id {
+					referencedBy = append(referencedBy, parent.Name)
+					continue
+				}
+				entries = append(entries, entry)
+			}
+			parent.Entries = entries
+		}
+		if len(referencedBy) > 0 && !cascade {
+			return fmt.Errorf("文件夹仍被分组或其他文件夹引用: %s", strings.Join(referencedBy, "、"))
+		}
+
+		conf.Folders = append(conf.Folders[:idx], conf.Folders[idx+1:]...)
+		return nil
+	})
+}
+
+// AddFolderEntry 向文件夹添加条目，index 为插入位置（负数表示追加到末尾）
+func AddFolderEntry(folderID string, entry models.FolderEntry, index int) error {
+	return updateConfig(func(conf *models.Config) error {
+		idx := findFolderIndex(conf, folderID)
+		if idx < 0 {
+			return fmt.Errorf("文件夹不存在: %s", folderID)
+		}
+		if err := checkFolderEntry(conf, entry); err != nil {
+			return err
+		}
+		folder := &conf.Folders[idx]
+		for _, existing := range folder.Entries {
+			if existing.SourceURL == entry.SourceURL && existing.CategoryPackageId == entry.CategoryPackageId && existing.FolderID == entry.FolderID {
+				return fmt.Errorf("文件夹中已存在该条目")
+			}
+		}
+		pos := insertAt(index, len(folder.Entries))
+		folder.Entries = append(folder.Entries, models.FolderEntry{})
+		copy(folder.Entries[pos+1:], folder.Entries[pos:])
+		folder.Entries[pos] = entry
+		return nil
+	})
+}
+
+// UpdateFolderEntry 替换文件夹中指定位置的条目
+func UpdateFolderEntry(folderID string, index int, entry models.FolderEntry) error {
+	return updateConfig(func(conf *models.Config) error {
+		idx := findFolderIndex(conf, folderID)
+		if idx < 0 {
+			return fmt.Errorf("文件夹不存在: %s", folderID)
+		}
+		folder := &conf.Folders[idx]
+		if err := checkIndex(index, len(folder.Entries)); err != nil {
+			return err
+		}
+		if err := checkFolderEntry(conf, entry); err != nil {
+			return err
+		}
+		folder.Entries[index] = entry
+		return nil
+	})
+}
+
+// DeleteFolderEntry 删除文件夹中指定位置的条目
+func DeleteFolderEntry(folderID string, index int) error {
+	return updateConfig(func(conf *models.Config) error {
+		idx := findFolderIndex(conf, folderID)
+		if idx < 0 {
+			return fmt.Errorf("文件夹不存在: %s", folderID)
+		}
+		folder := &conf.Folders[idx]
+		if err := checkIndex(index, len(folder.Entries)); err != nil {
+			return err
+		}
+		folder.Entries = append(folder.Entries[:index], folder.Entries[index+1:]...)
+		return nil
+	})
+}
+
+// ===== 分组布局 =====
+
+// CreateLayoutGroup 创建分组（ID 由服务端生成），返回创建后的分组
+func CreateLayoutGroup(group models.LayoutGroup) (models.LayoutGroup, error) {
+	if strings.TrimSpace(group.Name) == "" {
+		return group, fmt.Errorf("分组名称为空")
+	}
+	err := updateConfig(func(conf *models.Config) error {
+		for _, item := range group.Items {
+			if err := checkLayoutItem(conf, item); err != nil {
+				return err
+			}
+		}
+		group.ID = generateConfigID("group", func(id string) bool {
+			return findLayoutGroupIndex(conf, id) >= 0
+		})
+		conf.LayoutGroups = append(conf.LayoutGroups, group)
+		return nil
+	})
+	if err == nil {
+		log.Printf("[配置] 已创建分组: %s (%s)", group.Name, group.ID)
+	}
+	return group, err
+}
+
+// UpdateLayoutGroup 更新分组（ID 保持不变）
+func UpdateLayoutGroup(id string, group models.LayoutGroup) error {
+	if strings.TrimSpace(group.Name) == "" {
+		return fmt.Errorf("分组名称为空")
+	}
+	return updateConfig(func(conf *models.Config) error {
+		idx := findLayoutGroupIndex(conf, id)
+		if idx < 0 {
+			return fmt.Errorf("分组不存在: %s", id)
+		}
+		for _, item := range group.Items {
+			if err := checkLayoutItem(conf, item); err != nil {
+				return err
+			}
+		}
+		group.ID = id
+		conf.LayoutGroups[idx] = group
+		return nil
+	})
+}
+
+// DeleteLayoutGroup 删除分组（若为默认分组则同时清除默认分组设置）
+func DeleteLayoutGroup(id string) error {
+	return updateConfig(func(conf *models.Config) error {
+		idx := findLayoutGroupIndex(conf, id)
+		if idx < 0 {
+			return fmt.Errorf("分组不存在: %s", id)
+		}
+		conf.LayoutGroups = append(conf.LayoutGroups[:idx], conf.LayoutGroups[idx+1:]...)
+		if conf.DefaultGroup == id {
+			conf.DefaultGroup = ""
+		}
+		return nil
+	})
+}
+
+// AddLayoutItem 向分组添加布局项，index 为插入位置（负数表示追加到末尾）
+func AddLayoutItem(groupID string, item models.LayoutItem, index int) error {
+	return updateConfig(func(conf *models.Config) error {
+		idx := findLayoutGroupIndex(conf, groupID)
+		if idx < 0 {
+			return fmt.Errorf("分组不存在: %s", groupID)
+		}
+		if err := checkLayoutItem(conf, item); err != nil {
+			return err
+		}
+		group := &conf.LayoutGroups[idx]
+		for _, existing := range group.Items {
+			if existing.Type == item.Type && existing.SourceURL == item.SourceURL && existing.FolderID == item.FolderID {
+				return fmt.Errorf("分组中已存在该布局项")
+			}
+		}
+		pos := insertAt(index, len(group.Items))
+		group.Items = append(group.Items, models.LayoutItem{})
+		copy(group.Items[pos+1:], group.Items[pos:])
+		group.Items[pos] = item
+		return nil
+	})
+}
+
+// UpdateLayoutItem 替换分组中指定位置的布局项
+func UpdateLayoutItem(groupID string, index int, item models.LayoutItem) error {
+	return updateConfig(func(conf *models.Config) error {
+		idx := findLayoutGroupIndex(conf, groupID)
+		if idx < 0 {
+			return fmt.Errorf("分组不存在: %s", groupID)
+		}
+		group := &conf.LayoutGroups[idx]
+		if err := checkIndex(index, len(group.Items)); err != nil {
+			return err
+		}
+		if err := checkLayoutItem(conf, item); err != nil {
+			return err
+		}
+		group.Items[index] = item
+		return nil
+	})
+}
+
+// DeleteLayoutItem 删除分组中指定位置的布局项
+func DeleteLayoutItem(groupID string, index int) error {
+	return updateConfig(func(conf *models.Config) error {
+		idx := findLayoutGroupIndex(conf, groupID)
+		if idx < 0 {
+			return fmt.Errorf("分组不存在: %s", groupID)
+		}
+		group := &conf.LayoutGroups[idx]
+		if err := checkIndex(index, len(group.Items)); err != nil {
+			return err
+		}
+		group.Items = append(group.Items[:index], group.Items[index+1:]...)
+		return nil
+	})
+}
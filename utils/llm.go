@@ -3,16 +3,21 @@ package utils
 import (
 	"bytes"
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"feedora/globals"
 	"feedora/models"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"os/exec"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +37,12 @@ type CheckBatchResponse struct {
 // BatchClassifyResponse 批量AI分类响应结构
 type BatchClassifyResponse struct {
 	Results map[string]string `json:"results"`
+	// 相关度打分 (Map: index -> 0-100)，仅在配置了兴趣描述时返回
+	Scores map[string]int `json:"-"`
+	// 分类置信度 (Map: index -> 0-100)，仅在配置了最低置信度时返回
+	Confidence map[string]int `json:"-"`
+	// 多标签分类的全部类别 (Map: index -> 类别ID列表，第一个为主类别)，仅返回了多个类别的文章
+	Labels map[string][]string `json:"-"`
 }
 
 // LLMClient 大模型客户端
@@ -63,49 +74,114 @@ type ChatRequest struct {
 	Temperature    float64         `json:"temperature,omitempty"`
 	MaxTokens      int             `json:"max_tokens,omitempty"`
 	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// 调用用途（classify / postprocess / translate 等），仅用于调试日志，不发送给接口
+	Purpose string `json:"-"`
 }
 
 // ResponseFormat 响应格式
 type ResponseFormat struct {
 	Type string `json:"type"`
+	// Type 为 json_schema 时的输出结构
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat 结构化输出的 JSON Schema
+type JSONSchemaFormat struct {
+	Name   string                 `json:"name"`
+	Strict bool                   `json:"strict"`
+	Schema map[string]interface{} `json:"schema"`
 }
 
 // ChatResponse 聊天响应结构
 type ChatResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index   int `json:"index"`
-		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"message"`
-		FinishReason string `json:"finish_reason"`
-	} `json:"choices"`
-	Error *struct {
-		Message string `json:"message"`
-		Type    string `json:"type"`
-		Code    string `json:"code"`
-	} `json:"error,omitempty"`
-}
-
-func sendChatCompletion(client *http.Client, apiBase, apiKey, jsonMode string, reqBody ChatRequest) (*ChatResponse, error) {
-	chatResp, err := doChatCompletionRequest(client, apiBase, apiKey, reqBody)
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+	Usage   *ChatUsage   `json:"usage,omitempty"`
+	Error   *ChatError   `json:"error,omitempty"`
+	// HTTP 状态码，不参与序列化
+	StatusCode int `json:"-"`
+}
+
+// ChatUsage 聊天响应中的 token 用量
+type ChatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatChoice 聊天响应中的候选结果
+type ChatChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// ChatError 接口返回的错误信息
+type ChatError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+}
+
+// sendChatCompletion 按配置的接口类型发送聊天请求，返回统一的 OpenAI 格式响应
+func sendChatCompletion(client *http.Client, config models.AIClassifyConfig, jsonMode string, reqBody ChatRequest) (chatResp *ChatResponse, err error) {
+	if LLMBudgetExceeded() {
+		return nil, errLLMBudgetExceeded
+	}
+
+	// secret:// 引用的密钥在发送请求时才解析
+	apiKey, err := ResolveSecret(config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	apiBase := config.GetAPIBase()
+	provider, err := resolveLLMProvider(config.GetProvider())
 	if err != nil {
 		return nil, err
 	}
 
+	// 调试日志记录最终发送的请求（含自动降级后的响应格式）与原始响应
+	start := time.Now()
+	defer func() {
+		recordLLMDebug(config.GetProvider(), apiBase, apiKey, reqBody, chatResp, err, time.Since(start))
+	}()
+
+	// 已知不支持结构化输出的模型直接使用 json_object
+	schemaKey := config.GetProvider() + "|" + apiBase + "|" + reqBody.Model
+	if jsonMode == "auto" && isJSONSchemaRequest(reqBody) && isJSONSchemaUnsupported(schemaKey) {
+		reqBody.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+
+	chatResp, err = rateLimitedChatCompletion(provider, client, apiBase, apiKey, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只有接口明确拒绝结构化输出时才降级，限流、服务端错误等按原错误返回（可由备用接口接管）
+	if chatResp.Error != nil && jsonMode == "auto" && isJSONSchemaRequest(reqBody) && isJSONSchemaRejection(chatResp) {
+		log.Printf("[LLM兼容] 模型 [%s] 不支持结构化输出 (JSON Schema)，自动降级为 json_object: %s", reqBody.Model, chatResp.Error.Message)
+		markJSONSchemaUnsupported(schemaKey)
+		reqBody.ResponseFormat = &ResponseFormat{Type: "json_object"}
+
+		chatResp, err = rateLimitedChatCompletion(provider, client, apiBase, apiKey, reqBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if chatResp.Error != nil && shouldRetryWithoutJSONMode(jsonMode, reqBody, chatResp.Error.Message) {
 		log.Printf("[LLM兼容] 模型 [%s] 不支持 response_format=json_object，自动降级为提示词约束 JSON 输出", reqBody.Model)
 		reqBody.ResponseFormat = nil
 
-		chatResp, err = doChatCompletionRequest(client, apiBase, apiKey, reqBody)
+		chatResp, err = rateLimitedChatCompletion(provider, client, apiBase, apiKey, reqBody)
 		if err != nil {
 			return nil, err
 		}
 	}
+	recordLLMUsage(reqBody, chatResp)
 
 	if chatResp.Error != nil {
 		return nil, fmt.Errorf("API错误: %s", chatResp.Error.Message)
@@ -118,6 +194,7 @@ func sendChatCompletion(client *http.Client, apiBase, apiKey, jsonMode string, r
 	return chatResp, nil
 }
 
+// doChatCompletionRequest 调用 OpenAI 兼容的 /chat/completions 接口
 func doChatCompletionRequest(client *http.Client, apiBase, apiKey string, reqBody ChatRequest) (*ChatResponse, error) {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -148,6 +225,7 @@ func doChatCompletionRequest(client *http.Client, apiBase, apiKey string, reqBod
 	if err := json.Unmarshal(body, &chatResp); err != nil {
 		return nil, fmt.Errorf("解析响应失败: %w (Body: %s)", err, string(body))
 	}
+	chatResp.StatusCode = resp.StatusCode
 
 	return &chatResp, nil
 }
@@ -192,19 +270,95 @@ func maybeEnableJSONObjectResponseFormat(reqBody *ChatRequest, jsonMode string,
 	reqBody.ResponseFormat = nil
 }
 
-func buildBatchOutputConstraint(categories []models.Category) string {
+func buildBatchOutputConstraint(categories []models.Category, maxLabels int, withScores, withConfidence bool) string {
 	categoryIDs := make([]string, 0, len(categories))
 	for _, cat := range categories {
 		categoryIDs = append(categoryIDs, cat.ID)
 	}
 
-	return "\n\n输出要求（必须全部满足）：" +
+	structure := "{\"results\":{\"文章ID\":\"类别ID\"}"
+	valueRules := "\n4. `results` 中每个值必须且只能是以下类别 ID 之一：" + strings.Join(categoryIDs, ", ") + "。" +
+		"\n5. 每篇文章都必须返回一个类别 ID；不允许返回空字符串、null、数组、对象或新造类别 ID。"
+	if maxLabels > 1 {
+		structure = "{\"results\":{\"文章ID\":[\"类别ID\"]}"
+		valueRules = fmt.Sprintf("\n4. `results` 中每个值必须是由 1 到 %d 个类别 ID 组成的数组，类别 ID 只能是以下之一：%s。", maxLabels, strings.Join(categoryIDs, ", ")) +
+			"\n5. 数组按相关程度从高到低排列，第一个为主类别；只有文章确实同时属于多个类别时才返回多个，不允许空数组、重复或新造类别 ID。"
+	}
+	if withScores {
+		structure += ",\"scores\":{\"文章ID\":相关度}"
+	}
+	if withConfidence {
+		structure += ",\"confidence\":{\"文章ID\":置信度}"
+	}
+	structure += "}"
+	constraint := "\n\n输出要求（必须全部满足）：" +
 		"\n1. 只返回一个 JSON 对象，不要返回 markdown、代码块、解释、前后缀文本。" +
-		"\n2. JSON 顶层结构必须是：{\"results\":{\"文章ID\":\"类别ID\"}}。" +
+		"\n2. JSON 顶层结构必须是：" + structure + "。" +
 		"\n3. `results` 中每个键必须是输入里的文章 ID 字符串。" +
-		"\n4. `results` 中每个值必须且只能是以下类别 ID 之一：" + strings.Join(categoryIDs, ", ") + "。" +
-		"\n5. 每篇文章都必须返回一个类别 ID；不允许返回空字符串、null、数组、对象或新造类别 ID。" +
+		valueRules +
 		"\n6. 无法完全确定时，也必须选择最接近的类别 ID。"
+	rule := 7
+	if withScores {
+		constraint += fmt.Sprintf("\n%d. `scores` 中每篇文章都必须有一个 0 到 100 的整数，表示文章与用户兴趣描述的相关程度（100 为完全相关，0 为完全无关）。", rule)
+		rule++
+	}
+	if withConfidence {
+		constraint += fmt.Sprintf("\n%d. `confidence` 中每篇文章都必须有一个 0 到 100 的整数，表示你对所选类别的把握程度（100 为完全确定，难以判断时如实给出较低的值）。", rule)
+	}
+	return constraint
+}
+
+// buildCategoryInfo 构建提示词中的可用类别说明；类别有父子层级时按层级缩进列出，并要求选择最具体的子类别
+func buildCategoryInfo(categories []models.Category) string {
+	var b strings.Builder
+	b.WriteString("可用类别：\n")
+
+	known := make(map[string]bool, len(categories))
+	for _, cat := range categories {
+		known[cat.ID] = true
+	}
+	children := make(map[string][]models.Category)
+	var roots []models.Category
+	for _, cat := range categories {
+		if cat.ParentID != "" && known[cat.ParentID] && cat.ParentID != cat.ID {
+			children[cat.ParentID] = append(children[cat.ParentID], cat)
+		} else {
+			roots = append(roots, cat)
+		}
+	}
+	if len(children) == 0 {
+		for _, cat := range categories {
+			b.WriteString(fmt.Sprintf("- %s (%s): %s\n", cat.ID, cat.Name, cat.Description))
+		}
+		return b.String()
+	}
+
+	written := make(map[string]bool, len(categories))
+	var write func(cat models.Category, depth int)
+	write = func(cat models.Category, depth int) {
+		if written[cat.ID] {
+			return
+		}
+		written[cat.ID] = true
+		indent := strings.Repeat("  ", depth)
+		if len(children[cat.ID]) > 0 {
+			b.WriteString(fmt.Sprintf("%s- %s (%s): %s [父类别，不可直接选择]\n", indent, cat.ID, cat.Name, cat.Description))
+		} else {
+			b.WriteString(fmt.Sprintf("%s- %s (%s): %s\n", indent, cat.ID, cat.Name, cat.Description))
+		}
+		for _, child := range children[cat.ID] {
+			write(child, depth+1)
+		}
+	}
+	for _, cat := range roots {
+		write(cat, 0)
+	}
+	// 父子关系成环的类别没有根，按顶级类别列出
+	for _, cat := range categories {
+		write(cat, 0)
+	}
+	b.WriteString("类别按层级列出，请选择最具体的子类别，不要选择带有子类别的父类别。\n")
+	return b.String()
 }
 
 func buildSingleOutputConstraint(categories []models.Category) string {
@@ -222,7 +376,8 @@ func buildSingleOutputConstraint(categories []models.Category) string {
 }
 
 // ClassifyBatchItems 对一批RSS文章进行AI分类
-func (c *LLMClient) ClassifyBatchItems(items map[int]models.Item, strategy *models.ClassifyStrategy, categories []models.Category) (*BatchClassifyResponse, error) {
+// examples 为注入提示词的少样本示例（标题 → 类别）
+func (c *LLMClient) ClassifyBatchItems(items map[int]models.Item, strategy *models.ClassifyStrategy, categories []models.Category, examples []models.ClassifyExample) (*BatchClassifyResponse, error) {
 	if len(items) == 0 {
 		return &BatchClassifyResponse{Results: make(map[string]string)}, nil
 	}
@@ -251,9 +406,12 @@ func (c *LLMClient) ClassifyBatchItems(items map[int]models.Item, strategy *mode
 
 	// 构建类别信息
 	var categoryInfo strings.Builder
-	categoryInfo.WriteString("可用类别：\n")
-	for _, cat := range categories {
-		categoryInfo.WriteString(fmt.Sprintf("- %s (%s): %s\n", cat.ID, cat.Name, cat.Description))
+	categoryInfo.WriteString(buildCategoryInfo(categories))
+	if len(examples) > 0 {
+		categoryInfo.WriteString("\n分类示例（标题 → 类别ID，请参考这些示例的分类标准）：\n")
+		for _, example := range examples {
+			categoryInfo.WriteString(fmt.Sprintf("- %s → %s\n", example.Title, example.Category))
+		}
 	}
 
 	// 获取系统提示词
@@ -262,13 +420,27 @@ func (c *LLMClient) ClassifyBatchItems(items map[int]models.Item, strategy *mode
 		systemPrompt = strategy.CustomPrompt
 	}
 
+	// 配置了兴趣描述时同时要求返回相关度
+	interestProfile := c.config.GetInterestProfile()
+
+	// 配置了最低置信度时同时要求返回置信度
+	withConfidence := c.config.MinConfidence > 0
+	maxLabels := c.config.GetMaxLabels()
+
+	// 有层级时只能选择最具体的子类别
+	leaves := models.LeafCategories(categories)
+
 	// 强化输出约束，降低非结构化返回概率
-	systemPrompt += buildBatchOutputConstraint(categories)
+	systemPrompt += buildBatchOutputConstraint(leaves, maxLabels, interestProfile != "", withConfidence)
 
 	// 构建请求
 	systemContent := systemPrompt + "\n\n" + categoryInfo.String()
+	if interestProfile != "" {
+		systemContent += "\n用户兴趣描述：\n" + interestProfile + "\n"
+	}
 	reqBody := ChatRequest{
-		Model: c.config.GetModel(),
+		Model:   c.config.GetModel(),
+		Purpose: "classify",
 		Messages: []ChatMessage{
 			{Role: "system", Content: systemContent},
 			{Role: "user", Content: content},
@@ -278,15 +450,69 @@ func (c *LLMClient) ClassifyBatchItems(items map[int]models.Item, strategy *mode
 	}
 	jsonMode := c.config.GetJSONMode()
 	maybeEnableJSONObjectResponseFormat(&reqBody, jsonMode, systemContent, content)
+	// 支持结构化输出的接口按 JSON Schema 约束输出，避免自由文本解析失败
+	if jsonMode == "auto" || jsonMode == "json_schema" {
+		reqBody.ResponseFormat = &ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &JSONSchemaFormat{
+				Name:   "batch_classification",
+				Strict: true,
+				Schema: buildBatchClassifySchema(indices, leaves, maxLabels, interestProfile != "", withConfidence),
+			},
+		}
+	}
 
-	chatResp, err := sendChatCompletion(c.client, c.config.GetAPIBase(), c.config.APIKey, jsonMode, reqBody)
+	chatResp, err := sendChatCompletion(c.client, c.config, jsonMode, reqBody)
 	if err != nil {
 		return nil, err
 	}
 
 	// 解析批量分类结果
 	responseContent := chatResp.Choices[0].Message.Content
-	return parseBatchClassifyResponse(responseContent)
+	resp, err := parseBatchClassifyResponse(responseContent)
+	if err != nil {
+		return nil, err
+	}
+	if interestProfile != "" {
+		resp.Scores = parseScoreMap(responseContent, "scores")
+	}
+	if withConfidence {
+		resp.Confidence = parseScoreMap(responseContent, "confidence")
+	}
+	return resp, nil
+}
+
+// parseScoreMap 解析批量分类响应中指定字段的打分（相关度 scores、置信度 confidence），
+// 分数可能以数字或字符串形式返回，超出范围时截断到 0-100
+func parseScoreMap(content, field string) map[string]int {
+	jsonStr := extractJSON(content)
+	if jsonStr == "" {
+		jsonStr = content
+	}
+
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
+		return nil
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(parsed[field], &values); err != nil {
+		return nil
+	}
+	scores := make(map[string]int, len(values))
+	for id, raw := range values {
+		value, err := strconv.ParseFloat(strings.Trim(string(raw), `" `), 64)
+		if err != nil {
+			continue
+		}
+		score := int(math.Round(value))
+		if score < 0 {
+			score = 0
+		} else if score > 100 {
+			score = 100
+		}
+		scores[id] = score
+	}
+	return scores
 }
 
 // parseBatchClassifyResponse 解析批量分类响应
@@ -303,6 +529,16 @@ func parseBatchClassifyResponse(content string) (*BatchClassifyResponse, error)
 		return &standardResp, nil
 	}
 
+	// 多标签：{"results": {"0": ["cat1", "cat2"], "1": "cat3"}}
+	var labelResp struct {
+		Results map[string]json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &labelResp); err == nil && len(labelResp.Results) > 0 {
+		if resp, ok := parseLabelResults(labelResp.Results); ok {
+			return resp, nil
+		}
+	}
+
 	// 尝试解析直接的 Map 结构 {"0": "cat1", "1": "cat2"}
 	var mapResp map[string]string
 	if err := json.Unmarshal([]byte(jsonStr), &mapResp); err == nil {
@@ -324,6 +560,41 @@ func parseBatchClassifyResponse(content string) (*BatchClassifyResponse, error)
 	return nil, fmt.Errorf("无法解析批量分类响应: %s", content)
 }
 
+// parseLabelResults 解析多标签分类结果，每个值可以是类别 ID 或类别 ID 数组（第一个为主类别，重复项忽略）
+func parseLabelResults(raw map[string]json.RawMessage) (*BatchClassifyResponse, bool) {
+	resp := &BatchClassifyResponse{
+		Results: make(map[string]string, len(raw)),
+		Labels:  make(map[string][]string),
+	}
+	for id, value := range raw {
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			resp.Results[id] = single
+			continue
+		}
+		var labels []string
+		if err := json.Unmarshal(value, &labels); err != nil || len(labels) == 0 {
+			return nil, false
+		}
+		seen := make(map[string]bool, len(labels))
+		unique := make([]string, 0, len(labels))
+		for _, label := range labels {
+			if label != "" && !seen[label] {
+				seen[label] = true
+				unique = append(unique, label)
+			}
+		}
+		if len(unique) == 0 {
+			return nil, false
+		}
+		resp.Results[id] = unique[0]
+		if len(unique) > 1 {
+			resp.Labels[id] = unique
+		}
+	}
+	return resp, true
+}
+
 // ClassifyItemWithCategories 对RSS文章进行AI分类
 // categories: 可用的类别列表
 // keywordOnly: 如果为true，只进行关键词过滤，不调用AI
@@ -380,23 +651,20 @@ func (c *LLMClient) ClassifyItemWithCategories(item models.Item, strategy *model
 	content := buildItemContent(item)
 
 	// 构建类别信息
-	var categoryInfo strings.Builder
-	categoryInfo.WriteString("可用类别：\n")
-	for _, cat := range categories {
-		categoryInfo.WriteString(fmt.Sprintf("- %s (%s): %s\n", cat.ID, cat.Name, cat.Description))
-	}
+	categoryInfo := buildCategoryInfo(categories)
 
 	// 获取系统提示词
 	systemPrompt := c.config.GetSystemPrompt()
 	if strategy != nil && strategy.CustomPrompt != "" {
 		systemPrompt = strategy.CustomPrompt
 	}
-	systemPrompt += buildSingleOutputConstraint(categories)
+	systemPrompt += buildSingleOutputConstraint(models.LeafCategories(categories))
 
 	// 构建请求
-	systemContent := systemPrompt + "\n\n" + categoryInfo.String()
+	systemContent := systemPrompt + "\n\n" + categoryInfo
 	reqBody := ChatRequest{
-		Model: c.config.GetModel(),
+		Model:   c.config.GetModel(),
+		Purpose: "classify",
 		Messages: []ChatMessage{
 			{Role: "system", Content: systemContent},
 			{Role: "user", Content: content},
@@ -407,7 +675,7 @@ func (c *LLMClient) ClassifyItemWithCategories(item models.Item, strategy *model
 	jsonMode := c.config.GetJSONMode()
 	maybeEnableJSONObjectResponseFormat(&reqBody, jsonMode, systemContent, content)
 
-	chatResp, err := sendChatCompletion(c.client, c.config.GetAPIBase(), c.config.APIKey, jsonMode, reqBody)
+	chatResp, err := sendChatCompletion(c.client, c.config, jsonMode, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -557,11 +825,29 @@ type classifyResult struct {
 // ClassifyItems 对Feed中的Items进行AI分类（并行处理 + 批量请求）
 // 返回带有分类信息的Items
 func ClassifyItems(items []models.Item, rssURL string) []models.Item {
+	hits := make(filterHits)
+	result := classifyItems(items, rssURL, getClassifyStrategy(rssURL), true, nil, hits)
+	saveFilterHits(rssURL, hits)
+	return result
+}
+
+// classifyItems 按指定的分类策略对条目分类并过滤
+// useCache 为 false 时既不读取也不写入分类缓存（用于预览尚未保存的配置）
+// reasons 不为 nil 时记录被过滤条目的过滤原因（链接 -> keyword/category/relevance/script）；hits 不为 nil 时记录各规则的命中
+func classifyItems(items []models.Item, rssURL string, strategy *models.ClassifyStrategy, useCache bool, reasons map[string]string, hits filterHits) []models.Item {
 	config := globals.RssUrls.AIClassify
-	strategy := getClassifyStrategy(rssURL)
 
 	// 检查是否只使用关键词过滤（不使用AI）
-	useAI := ShouldUseAI(rssURL)
+	useAI := strategyUsesAI(strategy)
+	if useAI && LLMBudgetExceeded() {
+		log.Printf("[AI预算] 源 [%s]: 今日 AI 调用已超出预算，仅进行关键词过滤", rssURL)
+		useAI = false
+		// 未经 AI 分类的条目没有类别，不应用类别黑白名单，避免条目被全部过滤
+		fallback := *strategy
+		fallback.CategoryWhitelist = nil
+		fallback.CategoryBlacklist = nil
+		strategy = &fallback
+	}
 	keywordOnly := !useAI
 
 	client := NewLLMClient(config)
@@ -572,7 +858,8 @@ func ClassifyItems(items []models.Item, rssURL string) []models.Item {
 	if strategy != nil && len(strategy.BoundCategories) > 0 {
 		boundCats := make([]models.Category, 0)
 		boundMap := make(map[string]bool)
-		for _, id := range strategy.BoundCategories {
+		// 绑定父类别时同时绑定其所有子类别
+		for _, id := range globals.RssUrls.ExpandCategoryIDs(strategy.BoundCategories) {
 			boundMap[id] = true
 		}
 		for _, cat := range categories {
@@ -597,16 +884,30 @@ func ClassifyItems(items []models.Item, rssURL string) []models.Item {
 	finalItems := make([]models.Item, len(items))
 	copy(finalItems, items)
 
+	// 解析自定义提示词中的模板引用与变量
+	if strategy != nil && strategy.CustomPrompt != "" {
+		rendered := *strategy
+		rendered.CustomPrompt = renderPrompt(strategy.CustomPrompt, rssURL, categories)
+		strategy = &rendered
+	}
+
+	// 分类上下文指纹：提示词、兴趣描述或类别集合变化后，旧的缓存结果自动失效
+	contextHash := classifyContextHash(config, strategy, categories)
+
 	// 待处理任务列表
 	type classifyTask struct {
 		index int
 		item  models.Item
+		hash  string
 	}
 	pendingTasks := make([]classifyTask, 0)
 
 	// 1. 先检查关键词过滤，再检查缓存
 	cacheHits := 0
 	keywordHits := 0
+	correctionHits := 0
+	// 命中的无指纹缓存（升级前生成或类别迁移后清空），记录当前指纹供之后校验
+	hashBackfills := make(map[string]string)
 	globals.ClassifyCacheLock.RLock()
 	for i, item := range items {
 		// 1.1 检查关键词过滤（即便启用了AI，关键词过滤也优先进行以节省资源）
@@ -614,6 +915,7 @@ func ClassifyItems(items []models.Item, rssURL string) []models.Item {
 			// 使用 ClassifyItemWithCategories 来统一处理关键词过滤逻辑（传 keywordOnly=true）
 			resp, _ := client.ClassifyItemWithCategories(item, strategy, categories, true)
 			if resp != nil {
+				hits.addKeywordResult(item, strategy, resp.Category)
 				if resp.Category == "_filtered" {
 					finalItems[i].Category = resp.Category
 					keywordHits++
@@ -633,32 +935,50 @@ func ClassifyItems(items []models.Item, rssURL string) []models.Item {
 			}
 		}
 
-		// 1.2 检查缓存
+		// 1.2 人工修正的结果优先，不再交给 AI
+		if correction, ok := getClassifyCorrection(item.Link); ok {
+			finalItems[i].Category = correction.Category
+			correctionHits++
+			continue
+		}
+
+		// 1.3 检查缓存（内容指纹不一致说明标题、描述或分类上下文已变化，缓存失效）
+		hash := classifyContentHash(item, contextHash)
 		cacheEntry, cached := globals.ClassifyCache[item.Link]
-		if cached && cacheEntry.Category != "" {
+		if useCache && cached && cacheEntry.Category != "" && classifyCacheValid(cacheEntry, hash) {
 			// 如果命中关键词白名单，但缓存里是过滤标记，则忽略缓存进入 AI 处理（以防规则更新）
 			if finalItems[i].ForceKeep && cacheEntry.Category == "_filtered" {
 				// 忽略缓存，进入 AI 处理获取分类标签
 			} else {
 				finalItems[i].Category = cacheEntry.Category
+				finalItems[i].Categories = cacheEntry.Categories
+				finalItems[i].Relevance = cacheEntry.Relevance
+				finalItems[i].Confidence = cacheEntry.Confidence
+				if cacheEntry.Hash == "" {
+					hashBackfills[item.Link] = hash
+				}
 				cacheHits++
 				continue
 			}
 		}
 
 		// 关键词和缓存都没搞定，交给后续处理
-		pendingTasks = append(pendingTasks, classifyTask{index: i, item: item})
+		pendingTasks = append(pendingTasks, classifyTask{index: i, item: item, hash: hash})
 	}
 	globals.ClassifyCacheLock.RUnlock()
+	backfillClassifyCacheHashes(hashBackfills)
 
 	// 更新统计
 	if keywordHits > 0 {
 		log.Printf("[关键词过滤] 源 [%s]: 关键词匹配 %d 篇", rssURL, keywordHits)
 	}
+	if correctionHits > 0 {
+		log.Printf("[分类修正] 源 [%s]: 使用人工修正 %d 篇", rssURL, correctionHits)
+	}
 
 	// 如果没有待处理任务，直接返回
 	if len(pendingTasks) == 0 {
-		return applyFiltersAndReturn(finalItems, strategy, rssURL, 0, 0, cacheHits)
+		return applyFiltersAndReturn(finalItems, strategy, rssURL, 0, 0, cacheHits, reasons, hits)
 	}
 
 	// 2. 只有关键词过滤的情况，不需要AI，直接在本地处理
@@ -666,13 +986,23 @@ func ClassifyItems(items []models.Item, rssURL string) []models.Item {
 		for _, task := range pendingTasks {
 			resp, _ := client.ClassifyItemWithCategories(task.item, strategy, categories, true)
 			finalItems[task.index].Category = resp.Category
+			hits.addKeywordResult(task.item, strategy, resp.Category)
 		}
-		return applyFiltersAndReturn(finalItems, strategy, rssURL, len(pendingTasks), 0, cacheHits)
+		return applyFiltersAndReturn(finalItems, strategy, rssURL, len(pendingTasks), 0, cacheHits, reasons, hits)
 	}
 
 	// 3. AI 批量处理
+	// 少样本示例：源配置的示例在前，最近的人工修正在后
+	var examples []models.ClassifyExample
+	if strategy != nil {
+		examples = append(examples, strategy.Examples...)
+		examples = append(examples, recentCorrectionExamples(rssURL, strategy.CorrectionExamples)...)
+	}
+
 	// 每次批量处理的数量 (Batch Size)
 	batchSize := config.GetBatchSize()
+	// 每篇文章最多的类别数（多标签分类）
+	maxLabels := config.GetMaxLabels()
 
 	// 计算需要的批次数量
 	numBatches := (len(pendingTasks) + batchSize - 1) / batchSize
@@ -707,85 +1037,212 @@ func ClassifyItems(items []models.Item, rssURL string) []models.Item {
 			defer wg.Done()
 			defer func() { <-sem }() // 释放信号量
 
-			// 构建当前批次的 map
-			batchItemsMap := make(map[int]models.Item)
-			for _, t := range tasks {
-				batchItemsMap[t.index] = t.item
-			}
+			// requestBatch 请求一个批次的分类结果（含重试与备用接口）
+			requestBatch := func(batch []classifyTask) (*BatchClassifyResponse, error) {
+				batchItemsMap := make(map[int]models.Item)
+				for _, t := range batch {
+					batchItemsMap[t.index] = t.item
+				}
 
-			// 分类
-			var resp *BatchClassifyResponse
-			var err error
+				var resp *BatchClassifyResponse
+				var err error
 
-			// 重试机制
-			maxRetries := config.GetRetryCount()
-			retryWait := time.Duration(config.GetRetryWait()) * time.Second
-			for attempt := 1; attempt <= maxRetries; attempt++ {
-				resp, err = client.ClassifyBatchItems(batchItemsMap, strategy, categories)
-				if err == nil {
-					break
-				}
-				if attempt < maxRetries {
-					retryType := "失败"
-					if strings.Contains(strings.ToLower(err.Error()), "timeout") || strings.Contains(err.Error(), "deadline exceeded") {
-						retryType = "超时"
+				// 重试机制
+				maxRetries := config.GetRetryCount()
+				retryWait := time.Duration(config.GetRetryWait()) * time.Second
+				for attempt := 1; attempt <= maxRetries; attempt++ {
+					resp, err = client.ClassifyBatchItems(batchItemsMap, strategy, categories, examples)
+					if err == nil || errors.Is(err, errLLMBudgetExceeded) {
+						break
+					}
+					if attempt < maxRetries {
+						retryType := "失败"
+						if strings.Contains(strings.ToLower(err.Error()), "timeout") || strings.Contains(err.Error(), "deadline exceeded") {
+							retryType = "超时"
+						}
+						log.Printf("[重试] 批量分类请求%s (第 %d/%d 次重试): %v", retryType, attempt, maxRetries-1, err)
+						time.Sleep(retryWait)
 					}
-					log.Printf("[重试] 批量分类请求%s (第 %d/%d 次重试): %v", retryType, attempt, maxRetries-1, err)
-					time.Sleep(retryWait)
 				}
-			}
-
-			mu.Lock()
-			defer mu.Unlock()
 
-			if err != nil {
-				log.Printf("[分类失败] 批量请求失败 (包含 %d 篇文章): %v", len(tasks), err)
-				failedItems += len(tasks)
-				return
+				// 主接口重试后仍失败，依次尝试备用接口
+				if err != nil && !errors.Is(err, errLLMBudgetExceeded) && len(config.Fallbacks) > 0 {
+					resp, err = classifyBatchWithFallbacks(config, batchItemsMap, strategy, categories, examples, err)
+				}
+				return resp, err
 			}
 
-			// 处理响应
-			for _, t := range tasks {
-				idxStr := fmt.Sprintf("%d", t.index)
+			// 分类：响应中缺少的文章拆分为更小的批次重新提交（逐轮减半，最小为单篇），仍缺少才记为失败
+			remaining := tasks
+			size := len(tasks)
+			for len(remaining) > 0 {
+				var missing []classifyTask
+				for start := 0; start < len(remaining); start += size {
+					end := start + size
+					if end > len(remaining) {
+						end = len(remaining)
+					}
+					sub := remaining[start:end]
+
+					resp, err := requestBatch(sub)
+					if err != nil {
+						log.Printf("[分类失败] 批量请求失败 (包含 %d 篇文章): %v", len(sub), err)
+						mu.Lock()
+						failedItems += len(sub)
+						mu.Unlock()
+						continue
+					}
 
-				// 查找结果 (先尝试 string key)
-				categoryID, ok := resp.Results[idxStr]
-				if !ok {
-					// 某些模型可能会返回不纯的 key, 尝试遍历查找（如果 key 包含 index）
-					// 这里简单处理：如果找不到，记为失败
-					failedItems++
-					continue
+					// 处理响应
+					mu.Lock()
+					for _, t := range sub {
+						idxStr := fmt.Sprintf("%d", t.index)
+						categoryID, ok := resp.Results[idxStr]
+						if !ok {
+							missing = append(missing, t)
+							continue
+						}
+
+						// 应用结果
+						finalItems[t.index].Category = categoryID
+						finalItems[t.index].Categories = nil
+						if labels := resp.Labels[idxStr]; maxLabels > 1 && len(labels) > 1 {
+							if len(labels) > maxLabels {
+								labels = labels[:maxLabels]
+							}
+							finalItems[t.index].Categories = labels
+						}
+						if score, ok := resp.Scores[idxStr]; ok {
+							finalItems[t.index].Relevance = &score
+						}
+						if confidence, ok := resp.Confidence[idxStr]; ok {
+							finalItems[t.index].Confidence = &confidence
+						}
+						newItems++
+
+						if categoryID != "" && categoryID != "_keep" && categoryID != "_filtered" {
+							log.Printf("[分类完成] 文章 [%s]: %s", finalItems[t.index].Title, categoryID)
+						}
+
+						// 存入缓存
+						if useCache {
+							globals.ClassifyCacheLock.Lock()
+							globals.ClassifyCache[finalItems[t.index].Link] = models.ClassifyCacheEntry{
+								Category:   categoryID,
+								Categories: finalItems[t.index].Categories,
+								Relevance:  finalItems[t.index].Relevance,
+								Confidence: finalItems[t.index].Confidence,
+								Hash:       t.hash,
+								// 分类时间用于数据保留按时间清理
+								ClassifiedAt: time.Now().Unix(),
+							}
+							globals.ClassifyCacheLock.Unlock()
+						}
+					}
+					mu.Unlock()
 				}
 
-				// 应用结果
-				finalItems[t.index].Category = categoryID
-				newItems++
-
-				if categoryID != "" && categoryID != "_keep" && categoryID != "_filtered" {
-					log.Printf("[分类完成] 文章 [%s]: %s", finalItems[t.index].Title, categoryID)
+				if len(missing) == 0 {
+					break
 				}
-
-				// 存入缓存
-				globals.ClassifyCacheLock.Lock()
-				globals.ClassifyCache[finalItems[t.index].Link] = models.ClassifyCacheEntry{
-					Category: categoryID,
+				if size == 1 {
+					log.Printf("[分类失败] 单篇请求仍未返回结果 (%d 篇文章)", len(missing))
+					mu.Lock()
+					failedItems += len(missing)
+					mu.Unlock()
+					break
 				}
-				globals.ClassifyCacheLock.Unlock()
+				size /= 2
+				log.Printf("[分类补发] 批量响应缺少 %d 篇文章的结果，按每批 %d 篇重新提交", len(missing), size)
+				remaining = missing
 			}
 
 			// 标记数据已变更
-			MarkDataChanged()
+			if useCache {
+				MarkDataChanged()
+			}
 
 		}(batchTasks)
 	}
 
 	wg.Wait()
 
-	return applyFiltersAndReturn(finalItems, strategy, rssURL, newItems, failedItems, cacheHits)
+	return applyFiltersAndReturn(finalItems, strategy, rssURL, newItems, failedItems, cacheHits, reasons, hits)
+}
+
+// classifyPromptVersion 内置分类提示词（输出约束等）的版本，修改内置提示词时递增以使旧的分类缓存失效
+const classifyPromptVersion = 1
+
+// classifyContextHash 计算分类上下文的指纹：内置提示词版本、系统提示词、兴趣描述、类别集合与分类示例
+func classifyContextHash(config models.AIClassifyConfig, strategy *models.ClassifyStrategy, categories []models.Category) string {
+	systemPrompt := config.GetSystemPrompt()
+	if strategy != nil && strategy.CustomPrompt != "" {
+		systemPrompt = strategy.CustomPrompt
+	}
+
+	h := sha1.New()
+	fmt.Fprintf(h, "v%d\n%s\n%s\n", classifyPromptVersion, systemPrompt, config.GetInterestProfile())
+	// 启用置信度后输出约束变化，旧缓存没有置信度，需要重新分类
+	if config.MinConfidence > 0 {
+		fmt.Fprint(h, "confidence\n")
+	}
+	// 多标签的输出格式不同，切换单/多标签或修改类别数上限后需要重新分类
+	if maxLabels := config.GetMaxLabels(); maxLabels > 1 {
+		fmt.Fprintf(h, "labels=%d\n", maxLabels)
+	}
+	for _, cat := range categories {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", cat.ID, cat.Name, cat.Description)
+		if cat.ParentID != "" {
+			fmt.Fprintf(h, "parent\x00%s\n", cat.ParentID)
+		}
+	}
+	// 源配置的分类示例（人工修正的示例不计入，避免每次修正都使已分类的条目重新分类）
+	if strategy != nil {
+		for _, example := range strategy.Examples {
+			fmt.Fprintf(h, "example\x00%s\x00%s\n", example.Title, example.Category)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// classifyCacheValid 缓存的指纹与当前指纹一致时有效；没有指纹的缓存沿用一次，命中后补写当前指纹
+func classifyCacheValid(entry models.ClassifyCacheEntry, hash string) bool {
+	return entry.Hash == "" || entry.Hash == hash
+}
+
+// backfillClassifyCacheHashes 为命中的无指纹缓存补写指纹（map[链接]指纹），此后内容或分类上下文变化时缓存正常失效
+func backfillClassifyCacheHashes(hashes map[string]string) {
+	if len(hashes) == 0 {
+		return
+	}
+	updated := make(map[string]models.ClassifyCacheEntry, len(hashes))
+	globals.ClassifyCacheLock.Lock()
+	for link, hash := range hashes {
+		entry, ok := globals.ClassifyCache[link]
+		if !ok || entry.Hash != "" {
+			continue
+		}
+		entry.Hash = hash
+		globals.ClassifyCache[link] = entry
+		updated[link] = entry
+	}
+	globals.ClassifyCacheLock.Unlock()
+
+	for link, entry := range updated {
+		if err := DBSaveClassifyCache(link, entry); err != nil {
+			log.Printf("保存分类缓存失败 [%s]: %v", link, err)
+		}
+	}
+}
+
+// classifyContentHash 计算条目的分类缓存指纹：发送给 AI 的条目内容 + 分类上下文指纹
+func classifyContentHash(item models.Item, contextHash string) string {
+	sum := sha1.Sum([]byte(contextHash + "\n" + buildItemContent(item)))
+	return hex.EncodeToString(sum[:])
 }
 
 // applyFiltersAndReturn 应用后续过滤并返回
-func applyFiltersAndReturn(items []models.Item, strategy *models.ClassifyStrategy, rssURL string, newItems, failedItems, cacheHits int) []models.Item {
+func applyFiltersAndReturn(items []models.Item, strategy *models.ClassifyStrategy, rssURL string, newItems, failedItems, cacheHits int, reasons map[string]string, hits filterHits) []models.Item {
 	// 统计输出
 	if newItems > 0 || failedItems > 0 {
 		log.Printf("[分类统计] 源 [%s]: 新分类 %d 篇，失败 %d 篇 | 缓存命中 %d 篇",
@@ -799,6 +1256,9 @@ func applyFiltersAndReturn(items []models.Item, strategy *models.ClassifyStrateg
 		// 如果被标记为 _filtered 且不是强制保留，则过滤
 		if item.Category == "_filtered" && !item.ForceKeep {
 			keywordFilteredCount++
+			if reasons != nil {
+				reasons[item.Link] = "keyword"
+			}
 			continue
 		}
 		filteredItems = append(filteredItems, item)
@@ -807,19 +1267,40 @@ func applyFiltersAndReturn(items []models.Item, strategy *models.ClassifyStrateg
 		log.Printf("[关键词过滤] 源 [%s]: 过滤掉 %d 篇文章", rssURL, keywordFilteredCount)
 	}
 
-	// 2. 应用类别黑白名单过滤
+	// 2. 处理低置信度的分类结果
+	filteredItems, uncertain := applyConfidenceThreshold(filteredItems, globals.RssUrls.AIClassify, rssURL)
+
+	// 3. 应用类别黑白名单过滤
 	if strategy != nil && (len(strategy.CategoryWhitelist) > 0 || len(strategy.CategoryBlacklist) > 0) {
-		filteredItems = applyCategoryFilter(filteredItems, strategy)
+		before := filteredItems
+		filteredItems = applyCategoryFilter(filteredItems, strategy, uncertain)
+		recordFilterReasons(reasons, before, filteredItems, "category")
+		hits.addCategoryRemoved(before, filteredItems, strategy)
+	}
+
+	// 4. 应用最低相关度过滤
+	if strategy != nil && strategy.MinRelevance > 0 {
+		var relevanceFiltered int
+		before := filteredItems
+		filteredItems, relevanceFiltered = filterItemsByRelevance(filteredItems, strategy.MinRelevance)
+		recordFilterReasons(reasons, before, filteredItems, "relevance")
+		hits.addRemoved(before, filteredItems, filterRuleRelevance, strconv.Itoa(strategy.MinRelevance))
+		if relevanceFiltered > 0 {
+			log.Printf("[相关度过滤] 源 [%s]: 过滤掉 %d 篇相关度低于 %d 的文章", rssURL, relevanceFiltered, strategy.MinRelevance)
+		}
 	}
 
 	// 应用脚本规则过滤
 	if strategy != nil && strategy.IsScriptFilterEnabled() && strategy.ScriptFilterContent != "" {
 		beforeScriptCount := len(filteredItems)
+		before := filteredItems
 		var err error
-		filteredItems, err = ApplyScriptFilter(filteredItems, strategy.ScriptFilterContent, rssURL)
+		filteredItems, err = ApplyScriptFilter(filteredItems, strategy, rssURL)
 		if err != nil {
 			log.Printf("[脚本规则过滤失败] 源 [%s]: %v，保留原始条目", rssURL, err)
 		} else {
+			recordFilterReasons(reasons, before, filteredItems, "script")
+			hits.addRemoved(before, filteredItems, filterRuleScript, "")
 			filteredByScript := beforeScriptCount - len(filteredItems)
 			if filteredByScript > 0 {
 				log.Printf("[脚本规则过滤] 源 [%s]: 过滤前 %d 篇，过滤后 %d 篇，过滤 %d 篇",
@@ -831,8 +1312,90 @@ func applyFiltersAndReturn(items []models.Item, strategy *models.ClassifyStrateg
 	return filteredItems
 }
 
+// recordFilterReasons 将 before 中存在而 after 中不存在的条目记录为因 reason 被过滤（reasons 为 nil 时不记录）
+func recordFilterReasons(reasons map[string]string, before, after []models.Item, reason string) {
+	if reasons == nil || len(before) == len(after) {
+		return
+	}
+	kept := make(map[string]bool, len(after))
+	for _, item := range after {
+		kept[item.Link] = true
+	}
+	for _, item := range before {
+		if !kept[item.Link] {
+			// 链接已被改写的条目按原始链接记录
+			link := item.Link
+			if item.OriginalLink != "" {
+				link = item.OriginalLink
+			}
+			reasons[link] = reason
+		}
+	}
+}
+
+// filterItemsByRelevance 过滤相关度低于 minRelevance 的条目，返回保留的条目与过滤数量
+// 未打分的条目以及被关键词强制保留的条目不受影响
+func filterItemsByRelevance(items []models.Item, minRelevance int) ([]models.Item, int) {
+	if minRelevance <= 0 {
+		return items, 0
+	}
+	filtered := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		if item.Relevance != nil && *item.Relevance < minRelevance && !item.ForceKeep {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered, len(items) - len(filtered)
+}
+
+// resolveClassifyCategory 按最低置信度配置得到分类结果实际使用的类别：
+// 置信度低于阈值时，review 归入 _review，uncategorized 与 keyword 不设类别
+func resolveClassifyCategory(config models.AIClassifyConfig, category string, confidence *int) string {
+	if config.MinConfidence <= 0 || confidence == nil || *confidence >= config.MinConfidence {
+		return category
+	}
+	if category == "" || strings.HasPrefix(category, "_") {
+		return category
+	}
+	if config.GetLowConfidenceAction() == "review" {
+		return "_review"
+	}
+	return ""
+}
+
+// applyConfidenceThreshold 处理置信度低于阈值的分类结果，返回处理后的条目，
+// 以及按 keyword 方式处理、不参与类别黑白名单过滤的条目链接；被关键词强制保留的条目不受影响
+func applyConfidenceThreshold(items []models.Item, config models.AIClassifyConfig, rssURL string) ([]models.Item, map[string]bool) {
+	if config.MinConfidence <= 0 {
+		return items, nil
+	}
+	action := config.GetLowConfidenceAction()
+	uncertain := make(map[string]bool)
+	lowCount := 0
+	for i, item := range items {
+		if item.ForceKeep {
+			continue
+		}
+		category := resolveClassifyCategory(config, item.Category, item.Confidence)
+		if category == item.Category {
+			continue
+		}
+		items[i].Category = category
+		items[i].Categories = nil
+		lowCount++
+		if action == "keyword" {
+			uncertain[item.Link] = true
+		}
+	}
+	if lowCount > 0 {
+		log.Printf("[置信度] 源 [%s]: %d 篇文章分类置信度低于 %d，按 %s 处理", rssURL, lowCount, config.MinConfidence, action)
+	}
+	return items, uncertain
+}
+
 // applyCategoryFilter 应用类别黑白名单过滤
-func applyCategoryFilter(items []models.Item, strategy *models.ClassifyStrategy) []models.Item {
+func applyCategoryFilter(items []models.Item, strategy *models.ClassifyStrategy, bypass map[string]bool) []models.Item {
 	if strategy == nil {
 		return items
 	}
@@ -840,32 +1403,34 @@ func applyCategoryFilter(items []models.Item, strategy *models.ClassifyStrategy)
 	// 构建白名单和黑名单映射
 	whitelistMap := make(map[string]bool)
 	blacklistMap := make(map[string]bool)
-	for _, cat := range strategy.CategoryWhitelist {
+	// 父类别自动包含其所有子类别
+	for _, cat := range globals.RssUrls.ExpandCategoryIDs(strategy.CategoryWhitelist) {
 		whitelistMap[cat] = true
 	}
-	for _, cat := range strategy.CategoryBlacklist {
+	for _, cat := range globals.RssUrls.ExpandCategoryIDs(strategy.CategoryBlacklist) {
 		blacklistMap[cat] = true
 	}
 
 	filtered := make([]models.Item, 0, len(items))
 	for _, item := range items {
 		// 如果是被关键词标记为 _keep 的，或者标记为强制保留的（如白名单命中），直接保留，跳过类别过滤
-		if item.Category == "_keep" || item.ForceKeep {
+		// 低置信度待复核或仅按关键词处理的条目同样跳过类别过滤
+		if item.Category == "_keep" || item.Category == "_review" || item.ForceKeep || bypass[item.Link] {
 			filtered = append(filtered, item)
 			continue
 		}
 
-		// 如果有白名单，只保留白名单中的类别
+		// 如果有白名单，只保留白名单中的类别（多标签时任一类别在白名单中即保留）
 		if len(whitelistMap) > 0 {
-			if whitelistMap[item.Category] {
+			if hasAnyCategory(item, whitelistMap) {
 				filtered = append(filtered, item)
 			}
 			continue
 		}
 
-		// 如果有黑名单，过滤掉黑名单中的类别
+		// 如果有黑名单，过滤掉黑名单中的类别（多标签时任一类别在黑名单中即过滤）
 		if len(blacklistMap) > 0 {
-			if !blacklistMap[item.Category] {
+			if !hasAnyCategory(item, blacklistMap) {
 				filtered = append(filtered, item)
 			}
 			continue
@@ -881,6 +1446,16 @@ func applyCategoryFilter(items []models.Item, strategy *models.ClassifyStrategy)
 	return filtered
 }
 
+// hasAnyCategory 判断条目的任一类别是否在集合中
+func hasAnyCategory(item models.Item, set map[string]bool) bool {
+	for _, cat := range item.CategoryIDs() {
+		if set[cat] {
+			return true
+		}
+	}
+	return false
+}
+
 // getClassifyStrategy 获取指定URL的分类策略
 func getClassifyStrategy(rssURL string) *models.ClassifyStrategy {
 	for _, source := range globals.RssUrls.Sources {
@@ -893,10 +1468,13 @@ func getClassifyStrategy(rssURL string) *models.ClassifyStrategy {
 
 // ShouldFilter 检查是否应该启用过滤（关键词或AI或脚本）
 func ShouldFilter(rssURL string) bool {
+	return strategyNeedsFilter(getClassifyStrategy(rssURL))
+}
+
+// strategyNeedsFilter 检查分类策略是否启用了过滤（关键词或AI或脚本）
+func strategyNeedsFilter(strategy *models.ClassifyStrategy) bool {
 	config := globals.RssUrls.AIClassify
 
-	// 获取该源的特定策略
-	strategy := getClassifyStrategy(rssURL)
 	if strategy == nil {
 		return false
 	}
@@ -912,7 +1490,7 @@ func ShouldFilter(rssURL string) bool {
 	}
 
 	// 检查是否启用AI分类（需要全局AI分类启用且有API Key）
-	if config.Enabled && config.APIKey != "" && strategy.IsAIEnabled() {
+	if config.Enabled && config.HasAPIAccess() && strategy.IsAIEnabled() {
 		return true
 	}
 
@@ -921,12 +1499,16 @@ func ShouldFilter(rssURL string) bool {
 
 // ShouldUseAI 检查是否应该使用AI分类
 func ShouldUseAI(rssURL string) bool {
+	return strategyUsesAI(getClassifyStrategy(rssURL))
+}
+
+// strategyUsesAI 检查分类策略是否使用AI分类（需要全局AI分类启用且有API Key）
+func strategyUsesAI(strategy *models.ClassifyStrategy) bool {
 	config := globals.RssUrls.AIClassify
-	if !config.Enabled || config.APIKey == "" {
+	if !config.Enabled || !config.HasAPIAccess() {
 		return false
 	}
 
-	strategy := getClassifyStrategy(rssURL)
 	if strategy == nil {
 		return false
 	}
@@ -934,17 +1516,35 @@ func ShouldUseAI(rssURL string) bool {
 	return strategy.IsAIEnabled()
 }
 
-// ApplyScriptFilter 应用脚本规则过滤
-// 脚本通过 stdin 接收所有条目的 JSON 数组，返回过滤后的条目 JSON 数组
-// 输入格式：[{"title":"标题1","link":"链接1","pubDate":"时间1",...}, ...]
-// 输出格式：[{"title":"标题1","link":"链接1","pubDate":"时间1",...}, ...]
-func ApplyScriptFilter(items []models.Item, scriptContent string, rssURL string) ([]models.Item, error) {
+// ApplyScriptFilter 应用脚本规则过滤，lang 为 javascript（内置引擎）、wasm（WASM 插件）或 bash（旧版，调用系统 bash）
+func ApplyScriptFilter(items []models.Item, strategy *models.ClassifyStrategy, rssURL string) ([]models.Item, error) {
 	if len(items) == 0 {
 		return items, nil
 	}
 
-	// 创建超时 context（复用 AI 的超时配置）
-	timeout := time.Duration(globals.RssUrls.AIClassify.GetTimeout()) * time.Second
+	if err := checkScriptAllowed(); err != nil {
+		return items, err
+	}
+
+	// 不超过沙箱的最长执行时间
+	timeout := scriptTimeout(time.Duration(strategy.GetScriptTimeout()) * time.Second)
+	scriptContent := strategy.ScriptFilterContent
+	switch strategy.GetScriptFilterLang() {
+	case "javascript":
+		return applyJSScriptFilter(items, scriptContent, rssURL, timeout)
+	case "wasm":
+		// wasm 模式下脚本内容为插件ID
+		return applyPluginFilter(items, strings.TrimSpace(scriptContent), rssURL, timeout)
+	default:
+		return applyBashScriptFilter(items, scriptContent, timeout)
+	}
+}
+
+// applyBashScriptFilter 使用 bash 执行过滤脚本
+// 脚本通过 stdin 接收所有条目的 JSON 数组，返回过滤后的条目 JSON 数组
+// 输入格式：[{"title":"标题1","link":"链接1","pubDate":"时间1",...}, ...]
+// 输出格式：[{"title":"标题1","link":"链接1","pubDate":"时间1",...}, ...]
+func applyBashScriptFilter(items []models.Item, scriptContent string, timeout time.Duration) ([]models.Item, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -954,8 +1554,11 @@ func ApplyScriptFilter(items []models.Item, scriptContent string, rssURL string)
 		return items, fmt.Errorf("序列化条目失败: %w", err)
 	}
 
-	// 使用 bash -c 直接执行脚本内容
-	cmd := exec.CommandContext(ctx, "bash", "-c", scriptContent)
+	// 使用 bash -c 直接执行脚本内容（按沙箱配置限制资源）
+	cmd, err := newScriptCommand(ctx, scriptContent, "")
+	if err != nil {
+		return items, err
+	}
 	cmd.Stdin = bytes.NewReader(itemsJSON)
 
 	output, err := cmd.Output()
@@ -969,15 +1572,25 @@ func ApplyScriptFilter(items []models.Item, scriptContent string, rssURL string)
 		return items, fmt.Errorf("脚本执行失败: %w", err)
 	}
 
-	// 如果输出为空，表示过滤掉了所有条目
+	// 解析脚本输出（应该是过滤后的条目数组）
+	filteredItems, err := parseScriptItemsOutput(output)
+	if err != nil {
+		return items, err
+	}
+
+	return filteredItems, nil
+}
+
+// parseScriptItemsOutput 解析脚本输出的条目列表
+// 支持 JSON 数组或 JSON Lines（每行一个 JSON 对象），输出为空表示没有条目
+func parseScriptItemsOutput(output []byte) ([]models.Item, error) {
 	trimmedOutput := strings.TrimSpace(string(output))
 	if trimmedOutput == "" {
 		return []models.Item{}, nil
 	}
 
-	// 解析脚本输出（应该是过滤后的条目数组）
-	var filteredItems []models.Item
-	if err := json.Unmarshal(output, &filteredItems); err != nil {
+	var items []models.Item
+	if err := json.Unmarshal(output, &items); err != nil {
 		// 尝试解析是否是 JSON Lines 格式（每行一个 JSON 对象）
 		lines := strings.Split(trimmedOutput, "\n")
 		var itemsL []models.Item
@@ -998,8 +1611,8 @@ func ApplyScriptFilter(items []models.Item, scriptContent string, rssURL string)
 		if validJSONLines && len(itemsL) > 0 {
 			return itemsL, nil
 		}
-		return items, fmt.Errorf("解析脚本输出失败: %w, 输出: %s", err, string(output))
+		return nil, fmt.Errorf("解析脚本输出失败: %w, 输出: %s", err, string(output))
 	}
 
-	return filteredItems, nil
+	return items, nil
 }
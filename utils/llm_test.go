@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"path/filepath"
+	"testing"
+)
+
+// setupTestDB 在临时目录中初始化数据库，测试结束后关闭
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	oldDataDir, oldDatabaseFile := DataDir, DatabaseFile
+	DataDir = dir
+	DatabaseFile = filepath.Join(dir, "feedora.db")
+	if err := InitDatabase(); err != nil {
+		t.Fatalf("InitDatabase: %v", err)
+	}
+	t.Cleanup(func() {
+		CloseDatabase()
+		DataDir, DatabaseFile = oldDataDir, oldDatabaseFile
+	})
+}
+
+func TestClassifyCacheLegacyRowBackfillsHash(t *testing.T) {
+	setupTestDB(t)
+
+	const link = "https://example.com/post"
+	legacy := models.ClassifyCacheEntry{Category: "tech"}
+	if err := DBSaveClassifyCache(link, legacy); err != nil {
+		t.Fatalf("DBSaveClassifyCache: %v", err)
+	}
+	globals.ClassifyCache = map[string]models.ClassifyCacheEntry{link: legacy}
+
+	// 无指纹的旧缓存命中一次
+	if !classifyCacheValid(legacy, "hash-v1") {
+		t.Fatal("legacy row without hash should be used once")
+	}
+	backfillClassifyCacheHashes(map[string]string{link: "hash-v1"})
+
+	entry := globals.ClassifyCache[link]
+	if entry.Hash != "hash-v1" {
+		t.Fatalf("in-memory hash = %q, want backfilled", entry.Hash)
+	}
+	stored, err := DBLoadClassifyCache()
+	if err != nil {
+		t.Fatalf("DBLoadClassifyCache: %v", err)
+	}
+	if stored[link].Hash != "hash-v1" {
+		t.Fatalf("stored hash = %q, want backfilled", stored[link].Hash)
+	}
+
+	// 补写指纹后，条目内容变化时缓存失效
+	if classifyCacheValid(entry, "hash-v2") {
+		t.Fatal("backfilled row should be invalidated by a changed hash")
+	}
+	if !classifyCacheValid(entry, "hash-v1") {
+		t.Fatal("backfilled row should stay valid for the same hash")
+	}
+
+	// 已有指纹的条目不会被覆盖
+	backfillClassifyCacheHashes(map[string]string{link: "hash-v3"})
+	if got := globals.ClassifyCache[link].Hash; got != "hash-v1" {
+		t.Fatalf("hash = %q, want unchanged", got)
+	}
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"errors"
+	"feedora/globals"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// errLLMBudgetExceeded 今日 AI 调用已超出预算
+var errLLMBudgetExceeded = errors.New("今日 AI 调用已超出预算")
+
+var (
+	// 当天的用量（日期变化后重新从数据库加载）
+	llmUsageDate string
+	llmUsage     DBLLMUsage
+	llmUsageLock sync.Mutex
+	// 最近一次发送超预算通知的日期，避免重复通知
+	llmBudgetNotifiedDate string
+)
+
+// LLMUsageStats 今日 AI 调用用量与预算（/api/stats）
+type LLMUsageStats struct {
+	Date             string  `json:"date"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	Cost             float64 `json:"cost,omitempty"`
+	DailyTokens      int     `json:"dailyTokens,omitempty"`
+	DailyCost        float64 `json:"dailyCost,omitempty"`
+	Exceeded         bool    `json:"exceeded"`
+}
+
+// currentLLMUsage 获取今日用量，调用方需持有 llmUsageLock
+func currentLLMUsage() (string, DBLLMUsage) {
+	today := time.Now().Format("2006-01-02")
+	if llmUsageDate != today {
+		usage, err := DBLoadLLMUsage(today)
+		if err != nil {
+			log.Printf("[AI预算] 加载今日用量失败: %v", err)
+		}
+		llmUsageDate = today
+		llmUsage = usage
+	}
+	return llmUsageDate, llmUsage
+}
+
+// LLMBudgetExceeded 今日 AI 调用是否已超出预算（未设置预算时始终为 false）
+func LLMBudgetExceeded() bool {
+	budget := globals.RssUrls.AIClassify.Budget
+	if !budget.Enabled() {
+		return false
+	}
+	llmUsageLock.Lock()
+	defer llmUsageLock.Unlock()
+	_, usage := currentLLMUsage()
+	return budget.Exceeded(usage.PromptTokens, usage.CompletionTokens)
+}
+
+// recordLLMUsage 累计一次 AI 调用的用量，接口未返回用量时按字符数估算
+// 用量首次超出预算时记录日志，并按配置发送通知
+func recordLLMUsage(reqBody ChatRequest, chatResp *ChatResponse) {
+	promptTokens, completionTokens := 0, 0
+	if chatResp.Usage != nil {
+		promptTokens, completionTokens = chatResp.Usage.PromptTokens, chatResp.Usage.CompletionTokens
+	} else {
+		for _, msg := range reqBody.Messages {
+			promptTokens += estimateTokens(msg.Content)
+		}
+		for _, choice := range chatResp.Choices {
+			completionTokens += estimateTokens(choice.Message.Content)
+		}
+	}
+
+	budget := globals.RssUrls.AIClassify.Budget
+	llmUsageLock.Lock()
+	date, usage := currentLLMUsage()
+	wasExceeded := budget.Enabled() && budget.Exceeded(usage.PromptTokens, usage.CompletionTokens)
+	usage.PromptTokens += promptTokens
+	usage.CompletionTokens += completionTokens
+	usage.Requests++
+	llmUsage = usage
+	nowExceeded := budget.Enabled() && budget.Exceeded(usage.PromptTokens, usage.CompletionTokens)
+	notify := nowExceeded && !wasExceeded && budget.Notify && llmBudgetNotifiedDate != date
+	if notify {
+		llmBudgetNotifiedDate = date
+	}
+	llmUsageLock.Unlock()
+
+	if err := DBSaveLLMUsage(date, usage); err != nil {
+		log.Printf("[AI预算] 保存用量失败: %v", err)
+	}
+	if nowExceeded && !wasExceeded {
+		message := fmt.Sprintf("今日 AI 调用已超出预算（%d 次请求，输入 %d / 输出 %d tokens），当天剩余时间将跳过 AI 分类，仅进行关键词过滤",
+			usage.Requests, usage.PromptTokens, usage.CompletionTokens)
+		log.Printf("[AI预算] %s", message)
+		if notify {
+			go SendNotification("AI 调用超出预算", message)
+		}
+	}
+}
+
+// estimateTokens 粗略估算文本的 token 数（约 3 个字符 1 个 token）
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 2) / 3
+}
+
+// GetLLMUsageStats 获取今日 AI 调用用量与预算
+func GetLLMUsageStats() LLMUsageStats {
+	budget := globals.RssUrls.AIClassify.Budget
+	llmUsageLock.Lock()
+	date, usage := currentLLMUsage()
+	llmUsageLock.Unlock()
+
+	return LLMUsageStats{
+		Date:             date,
+		Requests:         usage.Requests,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             budget.Cost(usage.PromptTokens, usage.CompletionTokens),
+		DailyTokens:      budget.DailyTokens,
+		DailyCost:        budget.DailyCost,
+		Exceeded:         budget.Enabled() && budget.Exceeded(usage.PromptTokens, usage.CompletionTokens),
+	}
+}
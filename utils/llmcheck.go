@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"feedora/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LLMCheckResult AI 接口连通性检查结果
+type LLMCheckResult struct {
+	// 检查的接口：primary 或 fallbacks[i]
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	APIBase  string `json:"apiBase"`
+	Model    string `json:"model"`
+	Success  bool   `json:"success"`
+	// 请求耗时（毫秒）
+	LatencyMs int64 `json:"latencyMs"`
+	// 模型的回复内容（截断）
+	Response string `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CheckLLMProvider 使用指定配置发送一个简单的提示词，检查密钥、接口地址与模型是否可用
+func CheckLLMProvider(name string, config models.AIClassifyConfig) LLMCheckResult {
+	result := LLMCheckResult{
+		Name:     name,
+		Provider: config.GetProvider(),
+		APIBase:  redactAPIBase(config.GetAPIBase()),
+		Model:    config.GetModel(),
+	}
+	if !config.HasAPIAccess() {
+		result.Error = "未配置 API 密钥"
+		return result
+	}
+
+	reqBody := ChatRequest{
+		Model:   config.GetModel(),
+		Purpose: "test",
+		Messages: []ChatMessage{
+			{Role: "system", Content: "这是一次接口连通性测试，请只回复 OK。"},
+			{Role: "user", Content: "ping"},
+		},
+		Temperature: config.GetTemperature(),
+		MaxTokens:   16,
+	}
+	client := &http.Client{
+		Timeout: time.Duration(config.GetTimeout()) * time.Second,
+	}
+
+	start := time.Now()
+	chatResp, err := sendChatCompletion(client, config, "prompt_only", reqBody)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Success = true
+	result.Response = truncateString(strings.TrimSpace(chatResp.Choices[0].Message.Content), 100)
+	return result
+}
+
+// CheckLLMProviders 检查主接口，includeFallbacks 为 true 时依次检查各备用接口
+func CheckLLMProviders(config models.AIClassifyConfig, includeFallbacks bool) []LLMCheckResult {
+	results := []LLMCheckResult{CheckLLMProvider("primary", config)}
+	if !includeFallbacks {
+		return results
+	}
+	for i, fallback := range config.Fallbacks {
+		results = append(results, CheckLLMProvider(fmt.Sprintf("fallbacks[%d]", i), config.WithFallback(fallback)))
+	}
+	return results
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"feedora/globals"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LLMDebugEntry 一次 AI 调用的调试记录
+type LLMDebugEntry struct {
+	Time     string `json:"time"`
+	Purpose  string `json:"purpose,omitempty"`
+	Provider string `json:"provider"`
+	APIBase  string `json:"apiBase"`
+	Model    string `json:"model"`
+	// 最终使用的响应格式（json_schema / json_object / 空表示提示词约束），自动降级后为降级后的格式
+	ResponseFormat string        `json:"responseFormat,omitempty"`
+	Messages       []ChatMessage `json:"messages"`
+	// 模型返回的原始内容（解析前）
+	Response         string `json:"response,omitempty"`
+	Error            string `json:"error,omitempty"`
+	DurationMs       int64  `json:"durationMs"`
+	PromptTokens     int    `json:"promptTokens,omitempty"`
+	CompletionTokens int    `json:"completionTokens,omitempty"`
+}
+
+var (
+	// 最近的 AI 调用记录（按时间顺序，超出 aiClassify.debugLog 时丢弃最早的记录）
+	llmDebugEntries []LLMDebugEntry
+	llmDebugLock    sync.Mutex
+)
+
+// redactSecret 将文本中出现的密钥替换为 ***
+func redactSecret(text, secret string) string {
+	if secret == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, secret, "***")
+}
+
+// redactAPIBase 隐藏 API 地址中的凭据（用户信息与 key/token 类查询参数）
+func redactAPIBase(apiBase string) string {
+	u, err := url.Parse(apiBase)
+	if err != nil {
+		return apiBase
+	}
+	if u.User != nil {
+		u.User = url.User("***")
+	}
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "key") || strings.Contains(lower, "token") || strings.Contains(lower, "secret") {
+			query.Set(key, "***")
+		}
+	}
+	u.RawQuery = query.Encode()
+	return strings.ReplaceAll(u.String(), "%2A%2A%2A", "***")
+}
+
+// recordLLMDebug 记录一次 AI 调用的提示词与原始响应，未启用调试日志时不记录
+func recordLLMDebug(provider, apiBase, apiKey string, reqBody ChatRequest, chatResp *ChatResponse, callErr error, duration time.Duration) {
+	limit := globals.RssUrls.AIClassify.GetDebugLog()
+	if limit == 0 {
+		return
+	}
+
+	entry := LLMDebugEntry{
+		Time:       time.Now().Format(time.RFC3339),
+		Purpose:    reqBody.Purpose,
+		Provider:   provider,
+		APIBase:    redactAPIBase(redactSecret(apiBase, apiKey)),
+		Model:      reqBody.Model,
+		Messages:   make([]ChatMessage, 0, len(reqBody.Messages)),
+		DurationMs: duration.Milliseconds(),
+	}
+	if reqBody.ResponseFormat != nil {
+		entry.ResponseFormat = reqBody.ResponseFormat.Type
+	}
+	for _, msg := range reqBody.Messages {
+		msg.Content = redactSecret(msg.Content, apiKey)
+		entry.Messages = append(entry.Messages, msg)
+	}
+	if chatResp != nil {
+		if len(chatResp.Choices) > 0 {
+			entry.Response = redactSecret(chatResp.Choices[0].Message.Content, apiKey)
+		}
+		if chatResp.Usage != nil {
+			entry.PromptTokens = chatResp.Usage.PromptTokens
+			entry.CompletionTokens = chatResp.Usage.CompletionTokens
+		}
+	}
+	if callErr != nil {
+		entry.Error = redactSecret(callErr.Error(), apiKey)
+	}
+
+	llmDebugLock.Lock()
+	llmDebugEntries = append(llmDebugEntries, entry)
+	if len(llmDebugEntries) > limit {
+		llmDebugEntries = append([]LLMDebugEntry(nil), llmDebugEntries[len(llmDebugEntries)-limit:]...)
+	}
+	llmDebugLock.Unlock()
+}
+
+// GetLLMDebugEntries 获取最近的 AI 调用记录（最新的在前），purpose 非空时只返回该用途的调用，limit <= 0 表示全部
+func GetLLMDebugEntries(purpose string, limit int) []LLMDebugEntry {
+	llmDebugLock.Lock()
+	defer llmDebugLock.Unlock()
+
+	result := make([]LLMDebugEntry, 0, len(llmDebugEntries))
+	for i := len(llmDebugEntries) - 1; i >= 0; i-- {
+		if purpose != "" && llmDebugEntries[i].Purpose != purpose {
+			continue
+		}
+		result = append(result, llmDebugEntries[i])
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// ClearLLMDebugEntries 清空 AI 调用记录，返回清除的条数
+func ClearLLMDebugEntries() int {
+	llmDebugLock.Lock()
+	defer llmDebugLock.Unlock()
+	n := len(llmDebugEntries)
+	llmDebugEntries = nil
+	return n
+}
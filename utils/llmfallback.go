@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"feedora/models"
+	"log"
+	"sync"
+	"time"
+)
+
+// llmFailoverHistoryLimit 保留的最近切换事件数
+const llmFailoverHistoryLimit = 20
+
+// LLMFailoverEvent 一次切换到备用接口的记录
+type LLMFailoverEvent struct {
+	Time string `json:"time"`
+	// 切换前的接口（provider/model）
+	From string `json:"from"`
+	// 切换到的备用接口（provider/model）
+	To string `json:"to"`
+	// 切换前接口的错误
+	Error string `json:"error"`
+	// 备用接口是否调用成功
+	Success bool `json:"success"`
+}
+
+// LLMHealthStats AI 接口切换统计（/api/stats）
+type LLMHealthStats struct {
+	// 自启动以来切换到备用接口的次数
+	Failovers int `json:"failovers"`
+	// 其中备用接口调用成功的次数
+	FailoverSuccesses int `json:"failoverSuccesses"`
+	// 最近的切换事件（新的在前）
+	Recent []LLMFailoverEvent `json:"recent"`
+}
+
+var (
+	llmHealth     = LLMHealthStats{Recent: []LLMFailoverEvent{}}
+	llmHealthLock sync.Mutex
+)
+
+// describeLLM 接口描述，用于日志与切换记录
+func describeLLM(config models.AIClassifyConfig) string {
+	return config.GetProvider() + "/" + config.GetModel()
+}
+
+// classifyBatchWithFallbacks 主接口重试后仍失败时，按顺序使用备用接口进行批量分类（每个备用接口请求一次）
+func classifyBatchWithFallbacks(config models.AIClassifyConfig, items map[int]models.Item, strategy *models.ClassifyStrategy, categories []models.Category, examples []models.ClassifyExample, primaryErr error) (*BatchClassifyResponse, error) {
+	lastErr := primaryErr
+	from := describeLLM(config)
+	for _, fallback := range config.Fallbacks {
+		fallbackConfig := config.WithFallback(fallback)
+		if !fallbackConfig.HasAPIAccess() {
+			continue
+		}
+		to := describeLLM(fallbackConfig)
+		log.Printf("[接口切换] %s 请求失败，切换到备用接口 %s (包含 %d 篇文章): %v", from, to, len(items), lastErr)
+
+		resp, err := NewLLMClient(fallbackConfig).ClassifyBatchItems(items, strategy, categories, examples)
+		recordLLMFailover(from, to, lastErr, err == nil)
+		if err == nil {
+			return resp, nil
+		}
+		if errors.Is(err, errLLMBudgetExceeded) {
+			return nil, err
+		}
+		lastErr = err
+		from = to
+	}
+	return nil, lastErr
+}
+
+// recordLLMFailover 记录一次切换事件
+func recordLLMFailover(from, to string, cause error, success bool) {
+	event := LLMFailoverEvent{
+		Time:    time.Now().Format(time.RFC3339),
+		From:    from,
+		To:      to,
+		Error:   truncateString(cause.Error(), 200),
+		Success: success,
+	}
+
+	llmHealthLock.Lock()
+	defer llmHealthLock.Unlock()
+	llmHealth.Failovers++
+	if success {
+		llmHealth.FailoverSuccesses++
+	}
+	llmHealth.Recent = append([]LLMFailoverEvent{event}, llmHealth.Recent...)
+	if len(llmHealth.Recent) > llmFailoverHistoryLimit {
+		llmHealth.Recent = llmHealth.Recent[:llmFailoverHistoryLimit]
+	}
+}
+
+// GetLLMHealthStats 获取 AI 接口切换统计
+func GetLLMHealthStats() LLMHealthStats {
+	llmHealthLock.Lock()
+	defer llmHealthLock.Unlock()
+	stats := llmHealth
+	stats.Recent = append([]LLMFailoverEvent{}, llmHealth.Recent...)
+	return stats
+}
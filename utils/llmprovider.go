@@ -0,0 +1,391 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// LLMProvider 大模型接口适配器：把统一的 OpenAI 格式请求转换为各厂商的接口格式，并把响应转换回 OpenAI 格式
+type LLMProvider interface {
+	ChatCompletion(client *http.Client, apiBase, apiKey string, reqBody ChatRequest) (*ChatResponse, error)
+}
+
+// LLMProviderFunc 将普通函数适配为 LLMProvider
+type LLMProviderFunc func(client *http.Client, apiBase, apiKey string, reqBody ChatRequest) (*ChatResponse, error)
+
+// ChatCompletion 调用函数本身
+func (f LLMProviderFunc) ChatCompletion(client *http.Client, apiBase, apiKey string, reqBody ChatRequest) (*ChatResponse, error) {
+	return f(client, apiBase, apiKey, reqBody)
+}
+
+var (
+	llmProviders     = make(map[string]LLMProvider)
+	llmProvidersLock sync.RWMutex
+)
+
+func init() {
+	RegisterLLMProvider("openai", LLMProviderFunc(doChatCompletionRequest))
+	RegisterLLMProvider("anthropic", LLMProviderFunc(doAnthropicRequest))
+	RegisterLLMProvider("gemini", LLMProviderFunc(doGeminiRequest))
+	RegisterLLMProvider("ollama", LLMProviderFunc(doOllamaRequest))
+}
+
+// RegisterLLMProvider 注册大模型接口适配器（已存在时覆盖），配置中 aiClassify.provider 为该名称时使用
+func RegisterLLMProvider(name string, provider LLMProvider) {
+	llmProvidersLock.Lock()
+	llmProviders[strings.ToLower(name)] = provider
+	llmProvidersLock.Unlock()
+}
+
+// resolveLLMProvider 获取接口类型对应的适配器
+func resolveLLMProvider(name string) (LLMProvider, error) {
+	llmProvidersLock.RLock()
+	defer llmProvidersLock.RUnlock()
+	provider, ok := llmProviders[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("不支持的接口类型: %s", name)
+	}
+	return provider, nil
+}
+
+// postLLMJSON 发送 JSON 请求并读取响应体
+func postLLMJSON(client *http.Client, apiURL string, headers map[string]string, payload interface{}) ([]byte, error) {
+	body, _, err := postLLMJSONStatus(client, apiURL, headers, payload)
+	return body, err
+}
+
+// postLLMJSONStatus 与 postLLMJSON 相同，另外返回 HTTP 状态码（用于区分错误类型）
+func postLLMJSONStatus(client *http.Client, apiURL string, headers map[string]string, payload interface{}) ([]byte, int, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, 0, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("读取响应失败: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
+
+// splitSystemMessages 拆分系统提示词与对话消息（Anthropic 与 Gemini 的系统提示词单独传递）
+func splitSystemMessages(messages []ChatMessage) (string, []ChatMessage) {
+	var system []string
+	var rest []ChatMessage
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		rest = append(rest, msg)
+	}
+	return strings.Join(system, "\n\n"), rest
+}
+
+// newChatUsage 构建 token 用量，接口未返回用量时为 nil
+func newChatUsage(promptTokens, completionTokens int) *ChatUsage {
+	if promptTokens == 0 && completionTokens == 0 {
+		return nil
+	}
+	return &ChatUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// ===== Anthropic Messages API =====
+
+type anthropicRequest struct {
+	Model       string          `json:"model"`
+	System      string          `json:"system,omitempty"`
+	Messages    []ChatMessage   `json:"messages"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature float64         `json:"temperature,omitempty"`
+	Tools       []anthropicTool `json:"tools,omitempty"`
+	ToolChoice  *struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	} `json:"tool_choice,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input,omitempty"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// doAnthropicRequest 调用 Anthropic /messages 接口
+// 不支持 response_format：json_schema 通过强制调用一个以该结构为参数的工具实现，工具参数作为响应内容返回；
+// json_object 依赖提示词约束
+func doAnthropicRequest(client *http.Client, apiBase, apiKey string, reqBody ChatRequest) (*ChatResponse, error) {
+	system, messages := splitSystemMessages(reqBody.Messages)
+	maxTokens := reqBody.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 1024
+	}
+	payload := anthropicRequest{
+		Model:       reqBody.Model,
+		System:      system,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: reqBody.Temperature,
+	}
+	if isJSONSchemaRequest(reqBody) {
+		name := reqBody.ResponseFormat.JSONSchema.Name
+		payload.Tools = []anthropicTool{{
+			Name:        name,
+			Description: "按要求的结构提交结果",
+			InputSchema: reqBody.ResponseFormat.JSONSchema.Schema,
+		}}
+		payload.ToolChoice = &struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		}{Type: "tool", Name: name}
+	}
+
+	body, status, err := postLLMJSONStatus(client, strings.TrimSuffix(apiBase, "/")+"/messages", map[string]string{
+		"x-api-key":         apiKey,
+		"anthropic-version": "2023-06-01",
+	}, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result anthropicResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w (Body: %s)", err, string(body))
+	}
+
+	chatResp := &ChatResponse{ID: result.ID, Object: "chat.completion", Model: result.Model, StatusCode: status}
+	chatResp.Usage = newChatUsage(result.Usage.InputTokens, result.Usage.OutputTokens)
+	if result.Error != nil {
+		chatResp.Error = &ChatError{Message: result.Error.Message, Type: result.Error.Type}
+		return chatResp, nil
+	}
+	var text strings.Builder
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			// 结构化输出以工具参数返回，作为唯一的响应内容
+			text.Reset()
+			text.Write(block.Input)
+		}
+	}
+	if text.Len() > 0 {
+		chatResp.Choices = []ChatChoice{{
+			Message:      ChatMessage{Role: "assistant", Content: text.String()},
+			FinishReason: result.StopReason,
+		}}
+	}
+	return chatResp, nil
+}
+
+// ===== Gemini generateContent API =====
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent `json:"contents"`
+	GenerationConfig  struct {
+		Temperature      float64                `json:"temperature,omitempty"`
+		MaxOutputTokens  int                    `json:"maxOutputTokens,omitempty"`
+		ResponseMimeType string                 `json:"responseMimeType,omitempty"`
+		ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
+	} `json:"generationConfig"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	ModelVersion string `json:"modelVersion"`
+	Error        *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error,omitempty"`
+}
+
+// doGeminiRequest 调用 Gemini models/{model}:generateContent 接口
+// response_format=json_object 对应 responseMimeType=application/json，json_schema 另外转换为 responseSchema
+func doGeminiRequest(client *http.Client, apiBase, apiKey string, reqBody ChatRequest) (*ChatResponse, error) {
+	system, messages := splitSystemMessages(reqBody.Messages)
+	var payload geminiRequest
+	if system != "" {
+		payload.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: system}}}
+	}
+	for _, msg := range messages {
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "model"
+		}
+		payload.Contents = append(payload.Contents, geminiContent{Role: role, Parts: []geminiPart{{Text: msg.Content}}})
+	}
+	payload.GenerationConfig.Temperature = reqBody.Temperature
+	payload.GenerationConfig.MaxOutputTokens = reqBody.MaxTokens
+	if reqBody.ResponseFormat != nil && reqBody.ResponseFormat.Type == "json_object" {
+		payload.GenerationConfig.ResponseMimeType = "application/json"
+	}
+	if isJSONSchemaRequest(reqBody) {
+		payload.GenerationConfig.ResponseMimeType = "application/json"
+		// responseSchema 为 OpenAPI 子集，不支持 additionalProperties
+		payload.GenerationConfig.ResponseSchema = stripSchemaKeys(reqBody.ResponseFormat.JSONSchema.Schema, "additionalProperties")
+	}
+
+	apiURL := fmt.Sprintf("%s/models/%s:generateContent", strings.TrimSuffix(apiBase, "/"), url.PathEscape(reqBody.Model))
+	body, status, err := postLLMJSONStatus(client, apiURL, map[string]string{"x-goog-api-key": apiKey}, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result geminiResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w (Body: %s)", err, string(body))
+	}
+
+	chatResp := &ChatResponse{Object: "chat.completion", Model: result.ModelVersion, StatusCode: status}
+	chatResp.Usage = newChatUsage(result.UsageMetadata.PromptTokenCount, result.UsageMetadata.CandidatesTokenCount)
+	if result.Error != nil {
+		chatResp.Error = &ChatError{Message: result.Error.Message, Type: result.Error.Status, Code: fmt.Sprint(result.Error.Code)}
+		return chatResp, nil
+	}
+	for i, candidate := range result.Candidates {
+		var text strings.Builder
+		for _, part := range candidate.Content.Parts {
+			text.WriteString(part.Text)
+		}
+		chatResp.Choices = append(chatResp.Choices, ChatChoice{
+			Index:        i,
+			Message:      ChatMessage{Role: "assistant", Content: text.String()},
+			FinishReason: candidate.FinishReason,
+		})
+	}
+	return chatResp, nil
+}
+
+// ===== Ollama /api/chat =====
+
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	// "json" 或 JSON Schema 对象
+	Format  interface{} `json:"format,omitempty"`
+	Options struct {
+		Temperature float64 `json:"temperature,omitempty"`
+		NumPredict  int     `json:"num_predict,omitempty"`
+	} `json:"options"`
+}
+
+type ollamaResponse struct {
+	Model      string      `json:"model"`
+	CreatedAt  string      `json:"created_at"`
+	Message    ChatMessage `json:"message"`
+	DoneReason string      `json:"done_reason"`
+	// 输入 / 输出 token 数
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error,omitempty"`
+}
+
+// doOllamaRequest 调用本地 Ollama 的原生 /api/chat 接口（非流式），无需 API Key
+// response_format=json_object 对应 format=json，json_schema 对应 format 为该结构
+func doOllamaRequest(client *http.Client, apiBase, apiKey string, reqBody ChatRequest) (*ChatResponse, error) {
+	payload := ollamaRequest{
+		Model:    reqBody.Model,
+		Messages: reqBody.Messages,
+	}
+	payload.Options.Temperature = reqBody.Temperature
+	payload.Options.NumPredict = reqBody.MaxTokens
+	if reqBody.ResponseFormat != nil && reqBody.ResponseFormat.Type == "json_object" {
+		payload.Format = "json"
+	}
+	if isJSONSchemaRequest(reqBody) {
+		payload.Format = reqBody.ResponseFormat.JSONSchema.Schema
+	}
+
+	// 经反向代理访问并设置了密钥时仍附带认证头
+	headers := map[string]string{}
+	if apiKey != "" {
+		headers["Authorization"] = "Bearer " + apiKey
+	}
+	body, status, err := postLLMJSONStatus(client, strings.TrimSuffix(apiBase, "/")+"/api/chat", headers, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ollamaResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w (Body: %s)", err, string(body))
+	}
+
+	chatResp := &ChatResponse{Object: "chat.completion", Model: result.Model, StatusCode: status}
+	chatResp.Usage = newChatUsage(result.PromptEvalCount, result.EvalCount)
+	if result.Error != "" {
+		chatResp.Error = &ChatError{Message: result.Error}
+		return chatResp, nil
+	}
+	if result.Message.Content != "" {
+		chatResp.Choices = []ChatChoice{{
+			Message:      ChatMessage{Role: "assistant", Content: result.Message.Content},
+			FinishReason: result.DoneReason,
+		}}
+	}
+	return chatResp, nil
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"feedora/globals"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenBucket 令牌桶：容量为每分钟上限，按每秒 上限/60 的速度补充
+type tokenBucket struct {
+	capacity float64
+	tokens   float64
+	rate     float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		last:     now,
+	}
+}
+
+// refill 按经过的时间补充令牌
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// wait 获取 n 个令牌还需等待的时间（n 超过容量时按容量计算）
+func (b *tokenBucket) wait(n float64) time.Duration {
+	if n > b.capacity {
+		n = b.capacity
+	}
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+var (
+	// 请求数与 token 数令牌桶，未设置上限时为 nil
+	llmRequestBucket *tokenBucket
+	llmTokenBucket   *tokenBucket
+	// 当前令牌桶对应的上限，配置变化后重建
+	llmRateLimitRPM  int
+	llmRateLimitTPM  int
+	llmRateLimitLock sync.Mutex
+)
+
+// syncLLMRateLimiter 配置的上限变化时重建令牌桶，调用方需持有 llmRateLimitLock
+func syncLLMRateLimiter(now time.Time) {
+	config := globals.RssUrls.AIClassify.RateLimit
+	if config.RPM != llmRateLimitRPM {
+		llmRateLimitRPM = config.RPM
+		llmRequestBucket = nil
+		if config.RPM > 0 {
+			llmRequestBucket = newTokenBucket(config.RPM, now)
+		}
+	}
+	if config.TPM != llmRateLimitTPM {
+		llmRateLimitTPM = config.TPM
+		llmTokenBucket = nil
+		if config.TPM > 0 {
+			llmTokenBucket = newTokenBucket(config.TPM, now)
+		}
+	}
+}
+
+// waitLLMRateLimit 阻塞直到请求数与 token 数令牌桶都有足够余量，然后扣除 1 次请求与预估的 token 数
+func waitLLMRateLimit(estimatedTokens int) {
+	waited := time.Duration(0)
+	for {
+		now := time.Now()
+		llmRateLimitLock.Lock()
+		syncLLMRateLimiter(now)
+		wait := time.Duration(0)
+		if llmRequestBucket != nil {
+			llmRequestBucket.refill(now)
+			wait = llmRequestBucket.wait(1)
+		}
+		if llmTokenBucket != nil {
+			llmTokenBucket.refill(now)
+			if w := llmTokenBucket.wait(float64(estimatedTokens)); w > wait {
+				wait = w
+			}
+		}
+		if wait == 0 {
+			if llmRequestBucket != nil {
+				llmRequestBucket.tokens--
+			}
+			if llmTokenBucket != nil {
+				llmTokenBucket.tokens -= float64(estimatedTokens)
+			}
+			llmRateLimitLock.Unlock()
+			if waited >= time.Second {
+				log.Printf("[AI限流] 达到速率限制，已等待 %v", waited.Round(time.Second))
+			}
+			return
+		}
+		llmRateLimitLock.Unlock()
+		time.Sleep(wait)
+		waited += wait
+	}
+}
+
+// adjustLLMTokens 请求完成后按实际用量修正 token 桶（delta 为实际用量与预估值之差，可为负）
+func adjustLLMTokens(delta int) {
+	llmRateLimitLock.Lock()
+	defer llmRateLimitLock.Unlock()
+	if llmTokenBucket == nil {
+		return
+	}
+	llmTokenBucket.tokens -= float64(delta)
+	if llmTokenBucket.tokens > llmTokenBucket.capacity {
+		llmTokenBucket.tokens = llmTokenBucket.capacity
+	}
+}
+
+// rateLimitedChatCompletion 在全局速率限制下调用接口适配器
+// 请求前按输入内容预估 token 数占用额度，响应后按接口返回的实际用量修正
+func rateLimitedChatCompletion(provider LLMProvider, client *http.Client, apiBase, apiKey string, reqBody ChatRequest) (*ChatResponse, error) {
+	estimated := 0
+	for _, msg := range reqBody.Messages {
+		estimated += estimateTokens(msg.Content)
+	}
+	waitLLMRateLimit(estimated)
+
+	chatResp, err := provider.ChatCompletion(client, apiBase, apiKey, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if chatResp.Usage != nil {
+		adjustLLMTokens(chatResp.Usage.TotalTokens - estimated)
+	} else {
+		// 接口未返回用量时，输出部分同样按字符数估算
+		completion := 0
+		for _, choice := range chatResp.Choices {
+			completion += estimateTokens(choice.Message.Content)
+		}
+		adjustLLMTokens(completion)
+	}
+	return chatResp, nil
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"feedora/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// 拒绝过结构化输出的模型（provider|apiBase|model），auto 模式下不再尝试
+	jsonSchemaUnsupported     = make(map[string]bool)
+	jsonSchemaUnsupportedLock sync.RWMutex
+)
+
+// isJSONSchemaRequest 请求是否使用 JSON Schema 结构化输出
+func isJSONSchemaRequest(reqBody ChatRequest) bool {
+	return reqBody.ResponseFormat != nil && reqBody.ResponseFormat.Type == "json_schema" && reqBody.ResponseFormat.JSONSchema != nil
+}
+
+func isJSONSchemaUnsupported(key string) bool {
+	jsonSchemaUnsupportedLock.RLock()
+	defer jsonSchemaUnsupportedLock.RUnlock()
+	return jsonSchemaUnsupported[key]
+}
+
+func markJSONSchemaUnsupported(key string) {
+	jsonSchemaUnsupportedLock.Lock()
+	jsonSchemaUnsupported[key] = true
+	jsonSchemaUnsupportedLock.Unlock()
+}
+
+// isJSONSchemaRejection 错误响应是否表示接口不支持结构化输出：4xx 请求错误（不含认证失败、超时与限流），
+// 且错误信息或错误码提到 response_format / json_schema 等结构化输出参数
+func isJSONSchemaRejection(chatResp *ChatResponse) bool {
+	if chatResp == nil || chatResp.Error == nil {
+		return false
+	}
+	switch status := chatResp.StatusCode; {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden,
+		status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return false
+	case status != 0 && (status < 400 || status >= 500):
+		return false
+	}
+
+	text := strings.ToLower(chatResp.Error.Message + " " + chatResp.Error.Code + " " + chatResp.Error.Type)
+	for _, keyword := range []string{"response_format", "json_schema", "responseschema", "response_schema", "structured output", "input_schema", "tool_choice"} {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildBatchClassifySchema 构建批量分类的输出结构：results 中每篇文章 ID 必须出现且值只能是可用类别 ID，
+// 多标签时 results 的值为类别 ID 数组（数量上限由调用方截断，部分接口不支持 maxItems），
+// 配置了兴趣描述时 scores 中每篇文章 ID 对应一个整数相关度，配置了最低置信度时 confidence 同理
+func buildBatchClassifySchema(indices []int, categories []models.Category, maxLabels int, withScores, withConfidence bool) map[string]interface{} {
+	categoryIDs := make([]string, 0, len(categories))
+	for _, cat := range categories {
+		categoryIDs = append(categoryIDs, cat.ID)
+	}
+
+	ids := make([]string, 0, len(indices))
+	resultProps := make(map[string]interface{}, len(indices))
+	scoreProps := make(map[string]interface{}, len(indices))
+	confidenceProps := make(map[string]interface{}, len(indices))
+	categorySchema := map[string]interface{}{"type": "string", "enum": categoryIDs}
+	if maxLabels > 1 {
+		categorySchema = map[string]interface{}{"type": "array", "items": categorySchema}
+	}
+	for _, idx := range indices {
+		id := strconv.Itoa(idx)
+		ids = append(ids, id)
+		resultProps[id] = categorySchema
+		scoreProps[id] = map[string]interface{}{"type": "integer", "description": "0-100 的相关度"}
+		confidenceProps[id] = map[string]interface{}{"type": "integer", "description": "0-100 的分类置信度"}
+	}
+
+	properties := map[string]interface{}{
+		"results": map[string]interface{}{
+			"type":                 "object",
+			"properties":           resultProps,
+			"required":             ids,
+			"additionalProperties": false,
+		},
+	}
+	required := []string{"results"}
+	if withScores {
+		properties["scores"] = map[string]interface{}{
+			"type":                 "object",
+			"properties":           scoreProps,
+			"required":             ids,
+			"additionalProperties": false,
+		}
+		required = append(required, "scores")
+	}
+	if withConfidence {
+		properties["confidence"] = map[string]interface{}{
+			"type":                 "object",
+			"properties":           confidenceProps,
+			"required":             ids,
+			"additionalProperties": false,
+		}
+		required = append(required, "confidence")
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// stripSchemaKeys 复制 JSON Schema 并递归删除指定关键字（用于只支持部分关键字的接口）
+func stripSchemaKeys(schema map[string]interface{}, keys ...string) map[string]interface{} {
+	skip := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		skip[key] = true
+	}
+	var strip func(value interface{}) interface{}
+	strip = func(value interface{}) interface{} {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		result := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			if skip[k] {
+				continue
+			}
+			result[k] = strip(v)
+		}
+		return result
+	}
+	return strip(schema).(map[string]interface{})
+}
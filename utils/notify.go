@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notifyClient 通知发送使用的 HTTP 客户端
+var notifyClient = &http.Client{
+	Timeout: 15 * time.Second,
+}
+
+// SendNotification 通过所有已配置的渠道发送通知（异步，失败仅记录日志）
+func SendNotification(title, message string) {
+	config := globals.RssUrls.Notification
+	if !config.Enabled || len(config.Channels) == 0 {
+		return
+	}
+
+	for _, channel := range config.Channels {
+		go func(ch models.NotifyChannel) {
+			if err := sendToChannel(ch, title, message); err != nil {
+				log.Printf("[通知] 发送失败 | 渠道: %s | 标题: %s | 错误: %v", ch.Type, title, err)
+			}
+		}(channel)
+	}
+}
+
+// sendToChannel 向单个渠道发送通知
+func sendToChannel(channel models.NotifyChannel, title, message string) error {
+	var req *http.Request
+	var err error
+
+	// secret:// 引用的令牌与地址在发送时才解析
+	if channel.Token, err = ResolveSecret(channel.Token); err != nil {
+		return err
+	}
+	if channel.URL, err = ResolveSecret(channel.URL); err != nil {
+		return err
+	}
+
+	switch channel.Type {
+	case "webhook":
+		if channel.URL == "" {
+			return fmt.Errorf("webhook 地址未配置")
+		}
+		payload, _ := json.Marshal(map[string]string{
+			"title":   title,
+			"message": message,
+		})
+		req, err = http.NewRequest("POST", channel.URL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+	case "ntfy":
+		if channel.URL == "" {
+			return fmt.Errorf("ntfy 主题地址未配置")
+		}
+		req, err = http.NewRequest("POST", channel.URL, strings.NewReader(message))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Title", title)
+		if channel.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+channel.Token)
+		}
+	case "telegram":
+		if channel.Token == "" || channel.ChatID == "" {
+			return fmt.Errorf("telegram 的 token 或 chatId 未配置")
+		}
+		apiBase := channel.URL
+		if apiBase == "" {
+			apiBase = "https://api.telegram.org"
+		}
+		payload, _ := json.Marshal(map[string]string{
+			"chat_id": channel.ChatID,
+			"text":    title + "\n\n" + message,
+		})
+		apiURL := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(apiBase, "/"), channel.Token)
+		req, err = http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+	default:
+		return fmt.Errorf("不支持的通知渠道类型: %s", channel.Type)
+	}
+
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notifiedLinkTTL 已通知链接的去重有效期，期间同一规则不会重复通知同一条目
+const notifiedLinkTTL = 7 * 24 * time.Hour
+
+// pendingNotification 合并窗口内等待发送的新条目
+type pendingNotification struct {
+	Rule      models.NotifyRule
+	SourceURL string
+	Items     []models.Item
+}
+
+var (
+	// 等待发送的通知: map[规则序号|源URL] -> 待发送条目
+	pendingNotifications     = make(map[string]*pendingNotification)
+	pendingNotificationsLock sync.Mutex
+
+	// 已通知的链接: map[规则序号|链接] -> 通知时间
+	notifiedLinks     = make(map[string]time.Time)
+	notifiedLinksLock sync.Mutex
+)
+
+// notifyNewItems 将源本次抓取到的新条目按通知规则分发，同一规则同一源的条目在合并窗口内汇总为一条通知
+func notifyNewItems(sourceURL string, items []models.Item) {
+	config := globals.RssUrls.Notification
+	if !config.Enabled || len(config.Rules) == 0 || len(items) == 0 {
+		return
+	}
+
+	for i, rule := range config.Rules {
+		if !notifyRuleMatchesSource(rule, sourceURL) {
+			continue
+		}
+		var matched []models.Item
+		for _, item := range items {
+			if notifyRuleMatchesItem(rule, item) && markNotified(i, item.Link) {
+				matched = append(matched, item)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%d|%s", i, sourceURL)
+		pendingNotificationsLock.Lock()
+		pending, exists := pendingNotifications[key]
+		if !exists {
+			pending = &pendingNotification{Rule: rule, SourceURL: sourceURL}
+			pendingNotifications[key] = pending
+		}
+		pending.Items = append(pending.Items, matched...)
+		pendingNotificationsLock.Unlock()
+
+		if exists {
+			// 窗口已开启，等待到期后统一发送
+			continue
+		}
+		if rule.Window <= 0 {
+			flushPendingNotification(key)
+		} else {
+			time.AfterFunc(time.Duration(rule.Window)*time.Minute, func() {
+				flushPendingNotification(key)
+			})
+		}
+	}
+}
+
+// notifyUpdatedItems 对启用了 updates 的通知规则，发送本次检测到标题或描述被修改的条目（不合并窗口，逐条或汇总为一条）
+func notifyUpdatedItems(sourceURL string, items []models.Item) {
+	config := globals.RssUrls.Notification
+	if !config.Enabled || len(config.Rules) == 0 || len(items) == 0 {
+		return
+	}
+
+	sourceName := getSourceDisplayName(sourceURL)
+	for i, rule := range config.Rules {
+		if !rule.Updates || !notifyRuleMatchesSource(rule, sourceURL) {
+			continue
+		}
+		var matched []models.Item
+		for _, item := range items {
+			// 同一条目的每次修改各通知一次
+			if notifyRuleMatchesItem(rule, item) && markNotified(i, "updated|"+item.Link+"|"+item.ContentHash) {
+				matched = append(matched, item)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		prefix := ""
+		if rule.Name != "" {
+			prefix = "[" + rule.Name + "] "
+		}
+		if len(matched) == 1 {
+			SendNotification(prefix+"[已更新] "+matched[0].Title, sourceName+"\n"+matched[0].Link)
+			continue
+		}
+		topN := rule.GetTopN()
+		if topN > len(matched) {
+			topN = len(matched)
+		}
+		lines := make([]string, 0, topN)
+		for _, item := range matched[:topN] {
+			lines = append(lines, "• "+item.Title+"\n"+item.Link)
+		}
+		SendNotification(
+			fmt.Sprintf("%s%s: %d 条内容已更新", prefix, sourceName, len(matched)),
+			fmt.Sprintf("前 %d 条:\n%s", topN, strings.Join(lines, "\n")),
+		)
+	}
+}
+
+// flushPendingNotification 发送合并窗口内的条目：单条直接通知，多条汇总为一条并列出前 N 条
+func flushPendingNotification(key string) {
+	pendingNotificationsLock.Lock()
+	pending, ok := pendingNotifications[key]
+	delete(pendingNotifications, key)
+	pendingNotificationsLock.Unlock()
+	if !ok || len(pending.Items) == 0 {
+		return
+	}
+
+	sourceName := getSourceDisplayName(pending.SourceURL)
+	prefix := ""
+	if pending.Rule.Name != "" {
+		prefix = "[" + pending.Rule.Name + "] "
+	}
+
+	items := pending.Items
+	if len(items) == 1 {
+		SendNotification(prefix+items[0].Title, sourceName+"\n"+items[0].Link)
+		return
+	}
+
+	// 有热度分数时按分数排列，否则保持抓取顺序（时间倒序）
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
+	topN := pending.Rule.GetTopN()
+	if topN > len(items) {
+		topN = len(items)
+	}
+	lines := make([]string, 0, topN)
+	for _, item := range items[:topN] {
+		lines = append(lines, "• "+item.Title+"\n"+item.Link)
+	}
+	log.Printf("[通知] 合并发送 | 源: %s | 条目数: %d", pending.SourceURL, len(items))
+	SendNotification(
+		fmt.Sprintf("%s%s: %d 条新内容", prefix, sourceName, len(items)),
+		fmt.Sprintf("前 %d 条:\n%s", topN, strings.Join(lines, "\n")),
+	)
+}
+
+// markNotified 记录规则已通知过该链接，已通知过（且未过期）时返回 false
+func markNotified(ruleIndex int, link string) bool {
+	key := fmt.Sprintf("%d|%s", ruleIndex, link)
+	now := time.Now()
+
+	notifiedLinksLock.Lock()
+	defer notifiedLinksLock.Unlock()
+	if at, ok := notifiedLinks[key]; ok && now.Sub(at) < notifiedLinkTTL {
+		return false
+	}
+	notifiedLinks[key] = now
+
+	// 顺带清理过期记录
+	for k, at := range notifiedLinks {
+		if now.Sub(at) >= notifiedLinkTTL {
+			delete(notifiedLinks, k)
+		}
+	}
+	return true
+}
+
+// notifyRuleMatchesSource 判断源是否在规则的范围内
+func notifyRuleMatchesSource(rule models.NotifyRule, sourceURL string) bool {
+	if len(rule.Sources) == 0 && len(rule.Folders) == 0 {
+		return true
+	}
+	for _, u := range rule.Sources {
+		if u == sourceURL {
+			return true
+		}
+	}
+	for _, id := range rule.Folders {
+		folder := globals.RssUrls.GetFolderByID(id)
+		if folder == nil {
+			continue
+		}
+		for _, u := range getFolderSourceURLs(*folder) {
+			if u == sourceURL {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notifyRuleMatchesItem 判断条目是否满足规则的类别与关键词条件
+func notifyRuleMatchesItem(rule models.NotifyRule, item models.Item) bool {
+	if len(rule.Categories) > 0 {
+		matched := false
+		for _, cat := range globals.RssUrls.ExpandCategoryIDs(rule.Categories) {
+			if item.HasCategory(cat) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(rule.Keywords) > 0 {
+		for _, keyword := range rule.Keywords {
+			if containsKeyword(item.Title, keyword) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
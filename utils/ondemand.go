@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"feedora/globals"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 按需抓取：设置了 onDemand 的源不参与定时抓取。客户端请求其所在卡片的第一页（/api/feeds/items 不带 cursor）时，
+// 距上次按需抓取超过 fetch.onDemandTTL 的源立即抓取，等待抓取完成（最多 onDemandWait）后再返回卡片内容
+
+// onDemandWait 请求卡片时等待按需抓取完成的最长时间，超时后先返回缓存的内容，抓取完成后通过推送更新
+const onDemandWait = 15 * time.Second
+
+var (
+	// 按需抓取的源最近一次抓取的时间: map[RSS URL] -> 时间
+	onDemandFetched = make(map[string]time.Time)
+	// 正在抓取的源: map[RSS URL] -> 抓取结束时关闭的通道
+	onDemandInflight = make(map[string]chan struct{})
+	onDemandLock     sync.Mutex
+)
+
+// isOnDemandSource 检查源是否设置了按需抓取
+func isOnDemandSource(rssURL string) bool {
+	source := globals.RssUrls.GetSourceByURL(rssURL)
+	return source != nil && source.OnDemand && source.GetType() != "webhook"
+}
+
+// hasOnDemandSource 检查源列表中是否有按需抓取的源
+func hasOnDemandSource(urls []string) bool {
+	for _, u := range urls {
+		if isOnDemandSource(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// cardSourceURLs 返回卡片（源 URL 或 folder:{id}）包含的源
+func cardSourceURLs(cardLink string) []string {
+	if strings.HasPrefix(cardLink, "folder:") {
+		folder := globals.RssUrls.GetFolderByID(strings.TrimPrefix(cardLink, "folder:"))
+		if folder == nil {
+			return nil
+		}
+		return getFolderSourceURLs(*folder)
+	}
+	return []string{cardLink}
+}
+
+// FetchOnDemand 抓取卡片中已到期的按需源，等待抓取完成后返回（最多 onDemandWait）
+func FetchOnDemand(cardLink string) {
+	var pending []chan struct{}
+	for _, u := range cardSourceURLs(cardLink) {
+		if !isOnDemandSource(u) {
+			continue
+		}
+		if done := startOnDemandFetch(u); done != nil {
+			pending = append(pending, done)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	timeout := time.After(onDemandWait)
+	for _, done := range pending {
+		select {
+		case <-done:
+		case <-timeout:
+			log.Printf("[按需抓取] 卡片 %s 的源在 %v 内未抓取完成，先返回缓存的内容", cardLink, onDemandWait)
+			return
+		}
+	}
+}
+
+// startOnDemandFetch 源已到期时在后台开始抓取，返回抓取结束时关闭的通道；
+// 源正在抓取时返回同一通道，未到期时返回 nil
+func startOnDemandFetch(rssURL string) chan struct{} {
+	onDemandLock.Lock()
+	defer onDemandLock.Unlock()
+	if done, ok := onDemandInflight[rssURL]; ok {
+		return done
+	}
+	if last, ok := onDemandFetched[rssURL]; ok && time.Since(last) < globals.RssUrls.Fetch.GetOnDemandTTL() {
+		return nil
+	}
+
+	done := make(chan struct{})
+	onDemandInflight[rssURL] = done
+	go func() {
+		defer close(done)
+		// 失败时同样记录抓取时间，避免频繁请求卡片时反复抓取失败的源
+		if err := UpdateFeed(rssURL, time.Now().Format(time.RFC3339), false); err != nil {
+			log.Printf("[按需抓取] URL [%s] 抓取失败: %v", rssURL, err)
+		}
+		onDemandLock.Lock()
+		delete(onDemandInflight, rssURL)
+		onDemandFetched[rssURL] = time.Now()
+		onDemandLock.Unlock()
+	}()
+	return done
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCardSourceURLs(t *testing.T) {
+	saved := globals.RssUrls
+	defer func() { globals.RssUrls = saved }()
+	globals.RssUrls = models.Config{
+		Sources: []models.Source{{URL: "https://a.example/feed", OnDemand: true}, {URL: "https://b.example/feed"}},
+		Folders: []models.Folder{{ID: "f1", Entries: []models.FolderEntry{{SourceURL: "https://a.example/feed"}, {SourceURL: "https://b.example/feed"}}}},
+	}
+
+	if got := cardSourceURLs("folder:f1"); !reflect.DeepEqual(got, []string{"https://a.example/feed", "https://b.example/feed"}) {
+		t.Fatalf("cardSourceURLs(folder:f1) = %v", got)
+	}
+	if got := cardSourceURLs("folder:missing"); got != nil {
+		t.Fatalf("cardSourceURLs(folder:missing) = %v, want nil", got)
+	}
+	if !hasOnDemandSource(cardSourceURLs("folder:f1")) {
+		t.Fatal("folder with an on-demand source should be on demand")
+	}
+	if hasOnDemandSource(cardSourceURLs("https://b.example/feed")) {
+		t.Fatal("regular source should not be on demand")
+	}
+}
+
+func TestStartOnDemandFetchTTL(t *testing.T) {
+	saved := globals.RssUrls
+	defer func() { globals.RssUrls = saved }()
+	globals.RssUrls = models.Config{Fetch: models.FetchConfig{OnDemandTTL: 5}}
+
+	const url = "https://ondemand.example/feed"
+	onDemandLock.Lock()
+	onDemandFetched[url] = time.Now().Add(-time.Minute)
+	onDemandLock.Unlock()
+	defer func() {
+		onDemandLock.Lock()
+		delete(onDemandFetched, url)
+		onDemandLock.Unlock()
+	}()
+
+	// 未到期时不抓取
+	if done := startOnDemandFetch(url); done != nil {
+		t.Fatal("source fetched within onDemandTTL")
+	}
+
+	// 正在抓取时返回同一通道
+	inflight := make(chan struct{})
+	onDemandLock.Lock()
+	onDemandInflight[url] = inflight
+	onDemandLock.Unlock()
+	defer func() {
+		onDemandLock.Lock()
+		delete(onDemandInflight, url)
+		onDemandLock.Unlock()
+	}()
+	if done := startOnDemandFetch(url); done != inflight {
+		t.Fatal("expected the in-flight fetch to be shared")
+	}
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/base64"
+	"feedora/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 卡片条目分页的默认每页条数与每页上限
+const (
+	DefaultFeedPageSize = 50
+	MaxFeedPageSize     = 500
+)
+
+// 分页游标记录上一页最后一条的位置、发布时间与链接：下一页从该链接之后开始，
+// 期间有新条目插入到前面时不会重复或遗漏；该链接已不在卡片中（如不显示已读的卡片中被标记为已读、被清理）时
+// 按发布时间从卡片中排在它之后的第一条继续，条目没有时间时退回按位置继续
+
+// itemCursor 解码后的分页游标
+type itemCursor struct {
+	offset  int
+	link    string
+	time    time.Time
+	hasTime bool
+}
+
+// encodeItemCursor 编码分页游标，item 为上一页最后一条
+func encodeItemCursor(offset int, item models.Item) string {
+	sortTime := ""
+	if t, ok := getItemSortTime(item); ok {
+		sortTime = strconv.FormatInt(t.UnixNano(), 10)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset) + "\n" + sortTime + "\n" + item.Link))
+}
+
+// decodeItemCursor 解码分页游标（兼容不含发布时间的旧游标）
+func decodeItemCursor(cursor string) (itemCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return itemCursor{}, fmt.Errorf("无效的游标")
+	}
+	parts := strings.SplitN(string(raw), "\n", 3)
+	if len(parts) < 2 {
+		return itemCursor{}, fmt.Errorf("无效的游标")
+	}
+	offset, err := strconv.Atoi(parts[0])
+	if err != nil || offset < 0 {
+		return itemCursor{}, fmt.Errorf("无效的游标")
+	}
+	if len(parts) == 2 {
+		return itemCursor{offset: offset, link: parts[1]}, nil
+	}
+	decoded := itemCursor{offset: offset, link: parts[2]}
+	if parts[1] != "" {
+		nanos, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return itemCursor{}, fmt.Errorf("无效的游标")
+		}
+		decoded.time, decoded.hasTime = time.Unix(0, nanos), true
+	}
+	return decoded, nil
+}
+
+// resumeIndex 锚点链接已不在卡片中时，按发布时间找到排在锚点之后的第一条：
+// 卡片首条不早于末条时按倒序（取第一条早于锚点的条目），否则按升序（取第一条晚于锚点的条目）
+func resumeIndex(items []models.Item, anchor time.Time) int {
+	descending := true
+	first, firstOK := getItemSortTime(items[0])
+	last, lastOK := getItemSortTime(items[len(items)-1])
+	if firstOK && lastOK && first.Before(last) {
+		descending = false
+	}
+	for i, item := range items {
+		t, ok := getItemSortTime(item)
+		if !ok {
+			continue
+		}
+		if (descending && t.Before(anchor)) || (!descending && t.After(anchor)) {
+			return i
+		}
+	}
+	return len(items)
+}
+
+// NormalizeFeedPageSize 校正每页条数（未指定时使用默认值，超过上限时取上限）
+func NormalizeFeedPageSize(limit int) int {
+	if limit <= 0 {
+		return DefaultFeedPageSize
+	}
+	if limit > MaxFeedPageSize {
+		return MaxFeedPageSize
+	}
+	return limit
+}
+
+// pageItems 返回游标之后的一页条目及下一页的游标（没有更多条目时为空）
+func pageItems(items []models.Item, cursor string, limit int) ([]models.Item, string, error) {
+	start := 0
+	if cursor != "" {
+		anchor, err := decodeItemCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = anchor.offset
+		found := false
+		for i, item := range items {
+			if item.Link == anchor.link {
+				start = i + 1
+				found = true
+				break
+			}
+		}
+		if !found && anchor.hasTime && len(items) > 0 {
+			start = resumeIndex(items, anchor.time)
+		}
+		if start > len(items) {
+			start = len(items)
+		}
+	}
+
+	end := start + limit
+	if end >= len(items) {
+		return items[start:], "", nil
+	}
+	return items[start:end], encodeItemCursor(end, items[end-1]), nil
+}
+
+// PaginateFeeds 将各卡片的条目裁剪为第一页，并设置下一页的游标
+// 卡片统计（条目数、未读数）仍按全部条目计算
+func PaginateFeeds(feeds []models.Feed, limit int) []models.Feed {
+	limit = NormalizeFeedPageSize(limit)
+	for i := range feeds {
+		feeds[i].Items, feeds[i].NextCursor, _ = pageItems(feeds[i].Items, "", limit)
+	}
+	return feeds
+}
+
+// GetFeedItemsPage 获取卡片（源 URL 或 folder:{id}）中游标之后的一页条目，顺序与 /feeds 一致
+func GetFeedItemsPage(cardLink, cursor string, limit int) ([]models.Item, string, error) {
+	feed := GetFeed(cardLink)
+	if feed == nil {
+		return nil, "", fmt.Errorf("卡片不存在: %s", cardLink)
+	}
+	return pageItems(feed.Items, cursor, NormalizeFeedPageSize(limit))
+}
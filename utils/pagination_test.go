@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"feedora/models"
+	"testing"
+)
+
+func TestPageItemsAnchorRemoved(t *testing.T) {
+	items := []models.Item{
+		{Link: "https://a.example/5", PubDate: "2024-01-05 08:00:00"},
+		{Link: "https://a.example/4", PubDate: "2024-01-04 08:00:00"},
+		{Link: "https://a.example/3", PubDate: "2024-01-03 08:00:00"},
+		{Link: "https://a.example/2", PubDate: "2024-01-02 08:00:00"},
+		{Link: "https://a.example/1", PubDate: "2024-01-01 08:00:00"},
+	}
+	page, cursor, err := pageItems(items, "", 2)
+	if err != nil || len(page) != 2 || cursor == "" {
+		t.Fatalf("first page = %v, %q, %v", page, cursor, err)
+	}
+
+	// 不显示已读的卡片中，第一页的条目被标记为已读后从列表中移除
+	remaining := items[2:]
+	page, _, err = pageItems(remaining, cursor, 2)
+	if err != nil || len(page) != 2 || page[0].Link != "https://a.example/3" {
+		t.Fatalf("next page after anchor removed = %v, %v", page, err)
+	}
+
+	// 升序卡片同样从锚点之后继续
+	ascending := []models.Item{items[4], items[3], items[2], items[1], items[0]}
+	_, cursor, _ = pageItems(ascending, "", 2)
+	page, _, err = pageItems(ascending[1:], cursor, 2)
+	if err != nil || len(page) != 2 || page[0].Link != "https://a.example/3" {
+		t.Fatalf("ascending next page after anchor removed = %v, %v", page, err)
+	}
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -69,6 +68,9 @@ func InitPersistence() {
 	
 	// 加载已保存的数据
 	loadPersistedData()
+
+	// 记录启动时的配置版本，保证第一次修改前有可回滚的版本
+	recordConfigSnapshot(globals.RawConfig, "startup")
 	
 	// 启动定期保存任务
 	go autoSaveLoop()
@@ -81,12 +83,35 @@ func InitPersistence() {
 func loadPersistedData() {
 	// 加载分类缓存
 	loadClassifyCache()
+	// 加载人工修正的分类结果
+	loadClassifyCorrections()
 	// 加载已读状态
 	loadReadState()
 	// 加载后处理缓存
 	loadPostProcessCache()
 	// 加载条目缓存
 	loadItemsCache()
+	// 加载卡片展示选项覆盖
+	loadDisplayOverrides()
+	// 加载故事追踪
+	loadFollows()
+	// 加载订阅源健康状态
+	loadSourceHealth()
+}
+
+// loadDisplayOverrides 加载卡片展示选项覆盖
+func loadDisplayOverrides() {
+	overrides, err := DBLoadDisplayOverrides()
+	if err != nil {
+		log.Printf("读取展示选项覆盖失败: %v", err)
+		return
+	}
+
+	globals.DisplayOverridesLock.Lock()
+	globals.DisplayOverrides = overrides
+	globals.DisplayOverridesLock.Unlock()
+
+	log.Printf("[数据加载] 展示选项覆盖: 已加载 %d 条", len(overrides))
 }
 
 // loadClassifyCache 加载分类缓存
@@ -99,8 +124,8 @@ func loadClassifyCache() {
 	
 	globals.ClassifyCacheLock.Lock()
 	globals.ClassifyCache = make(map[string]models.ClassifyCacheEntry)
-	for link, category := range cache {
-		globals.ClassifyCache[link] = models.ClassifyCacheEntry{Category: category}
+	for link, entry := range cache {
+		globals.ClassifyCache[link] = entry
 	}
 	globals.ClassifyCacheLock.Unlock()
 	
@@ -118,6 +143,7 @@ func loadReadState() {
 	globals.ReadStateLock.Lock()
 	globals.ReadState = state
 	globals.ReadStateLock.Unlock()
+	loadReadStateChangeTimes()
 	
 	log.Printf("[数据加载] 已读状态: 已加载 %d 条", len(state))
 
@@ -150,6 +176,9 @@ func loadPostProcessCache() {
 			Title:       entry.Title,
 			Link:        entry.NewLink,
 			PubDate:     entry.PubDate,
+			Description: entry.Description,
+			Category:    entry.Category,
+			Metadata:    entry.Metadata,
 			ProcessedAt: entry.ProcessedAt,
 		}
 	}
@@ -172,22 +201,33 @@ func loadItemsCache() {
 		items := make([]models.Item, len(entries))
 		for i, entry := range entries {
 			items[i] = models.Item{
-				Title:        entry.Title,
-				Link:         entry.Link,
-				OriginalLink: entry.OriginalLink,
-				PubDate:      entry.PubDate,
-				FetchTime:    entry.FetchTime,
+				Title:         entry.Title,
+				Link:          entry.Link,
+				OriginalLink:  entry.OriginalLink,
+				PubDate:       entry.PubDate,
+				FetchTime:     entry.FetchTime,
+				Score:         entry.Score,
+				Comments:      entry.Comments,
+				Thumbnail:     entry.Thumbnail,
+				Duration:      entry.Duration,
+				OriginalTitle: entry.OriginalTitle,
+				Metadata:      entry.Metadata,
+				ContentHash:   entry.ContentHash,
+				Updated:       entry.Updated,
+				LastChanged:   entry.LastChanged,
 			}
 			// 从分类缓存中恢复类别，这对于文件夹过滤功能至关重要
 			globals.ClassifyCacheLock.RLock()
 			if cat, ok := globals.ClassifyCache[entry.Link]; ok {
-				items[i].Category = cat.Category
+				restoreClassification(&items[i], cat)
 			} else if entry.OriginalLink != "" {
 				if cat, ok := globals.ClassifyCache[entry.OriginalLink]; ok {
-					items[i].Category = cat.Category
+					restoreClassification(&items[i], cat)
 				}
 			}
 			globals.ClassifyCacheLock.RUnlock()
+			// 后处理修改的类别优先于分类结果
+			restorePostProcessCategory(&items[i])
 		}
 		globals.ItemsCache[rssURL] = items
 	}
@@ -199,23 +239,21 @@ func loadItemsCache() {
 		source := globals.RssUrls.GetSourceByURL(rssURL)
 		title := rssURL
 		icon := ""
-		showPubDate := false
-		showCategory := false
 		if source != nil {
 			if source.Name != "" {
 				title = source.Name
 			}
 			icon = GetIconForURL(rssURL)
-			showPubDate = source.ShowPubDate
-			showCategory = source.ShowCategory
 		}
 		
-		// 构造 AllItemLinks 和 AllItemTitles，防止首次更新时变动检测失效
+		// 构造 AllItemLinks、AllItemTitles 与 AllItemHashes，防止首次更新时变动检测失效
 		links := make([]string, len(items))
 		titles := make([]string, len(items))
+		hashes := make([]string, len(items))
 		for i, item := range items {
 			links[i] = item.Link
 			titles[i] = item.Title
+			hashes[i] = item.ContentHash
 		}
 
 		globals.DbMap[rssURL] = models.Feed{
@@ -226,11 +264,17 @@ func loadItemsCache() {
 			Custom:        map[string]string{"lastupdate": "已加载缓存"},
 			AllItemLinks:  links,
 			AllItemTitles: titles,
-			ShowPubDate:   showPubDate,
-			ShowCategory:  showCategory,
+			AllItemHashes: hashes,
 		}
 	}
 	globals.Lock.Unlock()
+
+	// 为缓存条目建立引用索引（缓存不含正文，仅用于被引用方的匹配）
+	globals.ItemsCacheLock.RLock()
+	for rssURL, items := range globals.ItemsCache {
+		indexItemCitations(rssURL, items)
+	}
+	globals.ItemsCacheLock.RUnlock()
 	
 	log.Printf("[数据加载] 条目缓存: 已加载 %d 个源", len(cache))
 }
@@ -273,7 +317,7 @@ func saveClassifyCache() {
 	defer globals.ClassifyCacheLock.RUnlock()
 	
 	for link, entry := range globals.ClassifyCache {
-		if err := DBSaveClassifyCache(link, entry.Category); err != nil {
+		if err := DBSaveClassifyCache(link, entry); err != nil {
 			log.Printf("保存分类缓存失败 [%s]: %v", link, err)
 		}
 	}
@@ -304,6 +348,9 @@ func savePostProcessCache() {
 			Title:       entry.Title,
 			NewLink:     entry.Link,
 			PubDate:     entry.PubDate,
+			Description: entry.Description,
+			Category:    entry.Category,
+			Metadata:    entry.Metadata,
 			ProcessedAt: entry.ProcessedAt,
 		}
 		if err := DBSavePostProcessCache(dbEntry); err != nil {
@@ -321,12 +368,21 @@ func saveItemsCache() {
 		entries := make([]DBItemsCacheEntry, len(items))
 		for i, item := range items {
 			entries[i] = DBItemsCacheEntry{
-				RssURL:       rssURL,
-				Title:        item.Title,
-				Link:         item.Link,
-				OriginalLink: item.OriginalLink,
-				PubDate:      item.PubDate,
-				FetchTime:    item.FetchTime,
+				RssURL:        rssURL,
+				Title:         item.Title,
+				Link:          item.Link,
+				OriginalLink:  item.OriginalLink,
+				PubDate:       item.PubDate,
+				FetchTime:     item.FetchTime,
+				Score:         item.Score,
+				Comments:      item.Comments,
+				Thumbnail:     item.Thumbnail,
+				Duration:      item.Duration,
+				OriginalTitle: item.OriginalTitle,
+				Metadata:      item.Metadata,
+				ContentHash:   item.ContentHash,
+				Updated:       item.Updated,
+				LastChanged:   item.LastChanged,
 			}
 		}
 		if err := DBSaveItemsCache(rssURL, entries); err != nil {
@@ -354,12 +410,21 @@ func SetItemsCache(rssURL string, items []models.Item) {
 		entries := make([]DBItemsCacheEntry, len(items))
 		for i, item := range items {
 			entries[i] = DBItemsCacheEntry{
-				RssURL:       rssURL,
-				Title:        item.Title,
-				Link:         item.Link,
-				OriginalLink: item.OriginalLink,
-				PubDate:      item.PubDate,
-				FetchTime:    item.FetchTime,
+				RssURL:        rssURL,
+				Title:         item.Title,
+				Link:          item.Link,
+				OriginalLink:  item.OriginalLink,
+				PubDate:       item.PubDate,
+				FetchTime:     item.FetchTime,
+				Score:         item.Score,
+				Comments:      item.Comments,
+				Thumbnail:     item.Thumbnail,
+				Duration:      item.Duration,
+				OriginalTitle: item.OriginalTitle,
+				Metadata:      item.Metadata,
+				ContentHash:   item.ContentHash,
+				Updated:       item.Updated,
+				LastChanged:   item.LastChanged,
 			}
 		}
 		if err := DBSaveItemsCache(rssURL, entries); err != nil {
@@ -406,6 +471,9 @@ func SetPostProcessCache(link string, entry models.PostProcessCacheEntry) {
 			Title:       entry.Title,
 			NewLink:     entry.Link,
 			PubDate:     entry.PubDate,
+			Description: entry.Description,
+			Category:    entry.Category,
+			Metadata:    entry.Metadata,
 			ProcessedAt: entry.ProcessedAt,
 		}
 		if err := DBSavePostProcessCache(dbEntry); err != nil {
@@ -451,9 +519,11 @@ func IsRead(link string) bool {
 
 // MarkRead 标记文章为已读
 func MarkRead(link string) {
-	now := time.Now().Unix()
+	at := time.Now().UnixMilli()
+	now := at / 1000
 	globals.ReadStateLock.Lock()
 	globals.ReadState[link] = now
+	changes := noteReadStateChanges([]string{link}, true, at)
 	globals.ReadStateLock.Unlock()
 	
 	// 异步保存到数据库
@@ -461,12 +531,14 @@ func MarkRead(link string) {
 		if err := DBSaveReadState(link, now); err != nil {
 			log.Printf("保存已读状态失败 [%s]: %v", link, err)
 		}
+		saveReadStateChanges(changes)
 	}()
 }
 
 // MarkReadBatch 批量标记文章为已读
 func MarkReadBatch(links []string) {
-	now := time.Now().Unix()
+	at := time.Now().UnixMilli()
+	now := at / 1000
 	states := make(map[string]int64, len(links))
 	
 	globals.ReadStateLock.Lock()
@@ -474,6 +546,7 @@ func MarkReadBatch(links []string) {
 		globals.ReadState[link] = now
 		states[link] = now
 	}
+	changes := noteReadStateChanges(links, true, at)
 	globals.ReadStateLock.Unlock()
 	
 	// 异步保存到数据库
@@ -481,6 +554,7 @@ func MarkReadBatch(links []string) {
 		if err := DBSaveReadStateBatch(states); err != nil {
 			log.Printf("批量保存已读状态失败: %v", err)
 		}
+		saveReadStateChanges(changes)
 	}()
 }
 
@@ -488,6 +562,7 @@ func MarkReadBatch(links []string) {
 func MarkUnread(link string) {
 	globals.ReadStateLock.Lock()
 	delete(globals.ReadState, link)
+	changes := noteReadStateChanges([]string{link}, false, time.Now().UnixMilli())
 	globals.ReadStateLock.Unlock()
 	
 	// 异步从数据库删除
@@ -495,13 +570,20 @@ func MarkUnread(link string) {
 		if err := DBDeleteReadState(link); err != nil {
 			log.Printf("删除已读状态失败 [%s]: %v", link, err)
 		}
+		saveReadStateChanges(changes)
 	}()
 }
 
 // ClearAllReadState 清除所有已读状态
 func ClearAllReadState() {
 	globals.ReadStateLock.Lock()
+	// 其他设备需要得知这些条目变为未读
+	links := make([]string, 0, len(globals.ReadState))
+	for link := range globals.ReadState {
+		links = append(links, link)
+	}
 	globals.ReadState = make(map[string]int64)
+	changes := noteReadStateChanges(links, false, time.Now().UnixMilli())
 	globals.ReadStateLock.Unlock()
 	
 	// 异步从数据库清空
@@ -509,6 +591,7 @@ func ClearAllReadState() {
 		if err := DBClearReadState(); err != nil {
 			log.Printf("清空已读状态失败: %v", err)
 		}
+		saveReadStateChanges(changes)
 	}()
 }
 
@@ -525,7 +608,16 @@ func autoCleanupLoop() {
 	ticker := time.NewTicker(time.Duration(CleanupInterval) * time.Hour)
 	defer ticker.Stop()
 	
+	// 启动时先执行一次数据保留清理、源的条目保留与自动标记已读
+	purgeExpiredData()
+	pruneRetainedSources()
+	autoMarkReadExpired()
+	
 	for range ticker.C {
+		// 数据保留模式按时间清理，不依赖 DbMap 是否完整
+		purgeExpiredData()
+		pruneRetainedSources()
+		autoMarkReadExpired()
 		if isDbMapReady() {
 			cleanupPersistentData()
 		} else {
@@ -578,10 +670,17 @@ func cleanupPersistentData() {
 	if err != nil {
 		log.Printf("[数据清理] 图标缓存清理失败: %v", err)
 	}
+	// 清理超过有效期的图片代理缓存
+	cleanedImages := cleanupImageCache()
+	cleanedOGImages := cleanupOGImageCache()
+	// 清理超过保留天数的过滤规则命中记录
+	if _, err := DBCleanupFilterHits(filterHitsRetentionDays); err != nil {
+		log.Printf("[数据清理] 过滤规则命中记录清理失败: %v", err)
+	}
 
-	if cleanedClassifyCache > 0 || cleanedReadState > 0 || cleanedPostProcessCache > 0 || cleanedItemsCache > 0 || cleanedIcons > 0 {
-		log.Printf("[数据清理] 清理完成: 分类缓存 %d 条，已读状态 %d 条，后处理缓存 %d 条，条目缓存 %d 个源，图标缓存 %d 条", 
-			cleanedClassifyCache, cleanedReadState, cleanedPostProcessCache, cleanedItemsCache, cleanedIcons)
+	if cleanedClassifyCache > 0 || cleanedReadState > 0 || cleanedPostProcessCache > 0 || cleanedItemsCache > 0 || cleanedIcons > 0 || cleanedImages > 0 || cleanedOGImages > 0 {
+		log.Printf("[数据清理] 清理完成: 分类缓存 %d 条，已读状态 %d 条，后处理缓存 %d 条，条目缓存 %d 个源，图标缓存 %d 条，图片缓存 %d 条，og:image 缓存 %d 条", 
+			cleanedClassifyCache, cleanedReadState, cleanedPostProcessCache, cleanedItemsCache, cleanedIcons, cleanedImages, cleanedOGImages)
 	} else {
 		log.Println("[数据清理] 清理完成: 暂无需要清理的数据")
 	}
@@ -713,10 +812,22 @@ func cleanupReadState(validLinks map[string]bool) int {
 		delete(globals.ReadState, link)
 	}
 	
+	// 不再有效的条目的变化记录（包括未读记录）对同步也没有意义
+	var changeLinks []string
+	for link, changedAt := range readStateChangedAt {
+		if !validLinks[link] && now-changedAt/1000 >= gracePeriod {
+			delete(readStateChangedAt, link)
+			changeLinks = append(changeLinks, link)
+		}
+	}
+	
 	// 从数据库删除
 	if len(toDelete) > 0 {
 		go DBDeleteReadStateBatch(toDelete)
 	}
+	if len(changeLinks) > 0 {
+		go DBDeleteReadStateChangesBatch(changeLinks)
+	}
 	
 	return len(toDelete)
 }
@@ -781,10 +892,14 @@ func cleanupItemsCache() int {
 
 // GetCacheItems 获取指定URL的缓存条目数配置
 // 返回值: -1表示禁用缓存，0表示自动缓存所有过滤后的条目，>0表示缓存指定数量
-// 注意：未在配置中找到的源默认返回0（自动缓存）
+// 注意：未在配置中找到的源默认返回0（自动缓存）；推送源未设置时默认保留 defaultWebhookCacheItems 条
 func GetCacheItems(rssURL string) int {
 	for _, source := range globals.RssUrls.Sources {
 		if source.URL == rssURL {
+			if source.CacheItems == 0 && source.GetType() == "webhook" {
+				// 推送源每次只携带新条目，需依赖缓存累积历史条目
+				return defaultWebhookCacheItems
+			}
 			return source.CacheItems
 		}
 	}
@@ -793,7 +908,17 @@ func GetCacheItems(rssURL string) int {
 
 // SaveConfig 保存配置到 config.json
 func SaveConfig(config models.Config) error {
-	data, err := json.MarshalIndent(config, "", "    ")
+	if err := writeConfigFile(config); err != nil {
+		return err
+	}
+	// 记录配置版本，用于查看历史与回滚
+	recordConfigSnapshot(config, "save")
+	return nil
+}
+
+// writeConfigFile 将配置写入配置文件
+func writeConfigFile(config models.Config) error {
+	data, err := marshalConfig(config)
 	if err != nil {
 		return err
 	}
@@ -844,6 +969,28 @@ func CleanupReadStateOnConfigChange() {
 	}
 }
 
+// restorePostProcessCategory 恢复后处理修改的类别（按原始链接查询后处理缓存）
+func restorePostProcessCategory(item *models.Item) {
+	link := item.OriginalLink
+	if link == "" {
+		link = item.Link
+	}
+	if entry, ok := GetPostProcessCache(link); ok && entry.Category != "" {
+		item.Category = entry.Category
+		item.Categories = nil
+	}
+}
+
+// restoreClassification 将分类缓存中的结果恢复到条目，并按当前最低置信度配置处理低置信度类别
+func restoreClassification(item *models.Item, entry models.ClassifyCacheEntry) {
+	item.Category = resolveClassifyCategory(globals.RssUrls.AIClassify, entry.Category, entry.Confidence)
+	if item.Category == entry.Category {
+		item.Categories = entry.Categories
+	}
+	item.Relevance = entry.Relevance
+	item.Confidence = entry.Confidence
+}
+
 // ClearClassifyCacheForSource 清除指定源的AI分类缓存
 func ClearClassifyCacheForSource(rssURL string) int {
 	articleLinks := collectArticleLinksForSource(rssURL)
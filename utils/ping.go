@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"feedora/globals"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// 正在进行中的通知抓取: map[RSS URL] -> 是否有待处理的通知
+	pingInFlight     = make(map[string]bool)
+	pingInFlightLock sync.Mutex
+)
+
+// PingSources 处理外部生成器的更新通知，立即在轮询之外抓取对应的源
+// 同一源的抓取进行中时再次收到通知，会在本次抓取结束后再补抓一次；返回已接受和未知的源
+func PingSources(urls []string) ([]string, []string) {
+	accepted := make([]string, 0, len(urls))
+	unknown := make([]string, 0)
+	for _, u := range urls {
+		source := globals.RssUrls.GetSourceByURL(u)
+		if source == nil || source.GetType() == "webhook" {
+			unknown = append(unknown, u)
+			continue
+		}
+		accepted = append(accepted, u)
+
+		pingInFlightLock.Lock()
+		_, running := pingInFlight[u]
+		// 抓取进行中时标记待补抓，否则登记为进行中
+		pingInFlight[u] = running
+		pingInFlightLock.Unlock()
+		if running {
+			log.Printf("[更新通知] 源正在抓取，完成后补抓: %s", u)
+			continue
+		}
+		go runPingUpdate(u)
+	}
+	return accepted, unknown
+}
+
+// runPingUpdate 执行通知触发的抓取，抓取期间收到的通知合并为一次补抓
+func runPingUpdate(rssURL string) {
+	for {
+		now := time.Now()
+		log.Printf("[更新通知] 开始抓取: %s", rssURL)
+		if err := UpdateFeedWithOptions(rssURL, now.Format(time.RFC3339), false, false); err != nil {
+			log.Printf("[更新通知] 抓取失败: %s | 错误: %v", rssURL, err)
+		}
+
+		// 重置轮询计时，避免紧接着再次轮询
+		lutLock.Lock()
+		lastUpdateTimes[rssURL] = now
+		lutLock.Unlock()
+
+		pingInFlightLock.Lock()
+		if !pingInFlight[rssURL] {
+			delete(pingInFlight, rssURL)
+			pingInFlightLock.Unlock()
+			return
+		}
+		pingInFlight[rssURL] = false
+		pingInFlightLock.Unlock()
+	}
+}
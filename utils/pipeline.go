@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PipelineStepMetrics 流水线单个步骤的执行统计
+type PipelineStepMetrics struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// 输入与输出的条目数
+	Input  int `json:"input"`
+	Output int `json:"output"`
+	// 被该步骤修改（标题、链接、描述或类别变化）的条目数
+	Modified int   `json:"modified"`
+	Duration int64 `json:"duration"`
+	// 执行失败时的错误（失败的步骤保留输入条目）
+	Error string `json:"error,omitempty"`
+}
+
+// PipelineRun 一次流水线执行的统计
+type PipelineRun struct {
+	RanAt string                `json:"ranAt"`
+	Steps []PipelineStepMetrics `json:"steps"`
+}
+
+// PipelineStats 源的流水线统计
+type PipelineStats struct {
+	// 最近一次执行
+	Last PipelineRun `json:"last"`
+	// 自启动以来的累计（按步骤顺序，步骤配置变化后重新累计）
+	Runs  int                   `json:"runs"`
+	Total []PipelineStepMetrics `json:"total"`
+}
+
+var (
+	pipelineStats     = make(map[string]*PipelineStats)
+	pipelineStatsLock sync.Mutex
+)
+
+// getPipeline 获取源的处理流水线
+func getPipeline(rssURL string) []models.PipelineStep {
+	source := globals.RssUrls.GetSourceByURL(rssURL)
+	if source == nil {
+		return nil
+	}
+	return source.Pipeline
+}
+
+// HasPipeline 检查源是否配置了处理流水线
+func HasPipeline(rssURL string) bool {
+	return len(getPipeline(rssURL)) > 0
+}
+
+// RunSourcePipeline 按源配置的流水线处理条目，并记录各步骤的统计
+func RunSourcePipeline(items []models.Item, rssURL string) []models.Item {
+	hits := make(filterHits)
+	result, metrics := runPipeline(items, rssURL, getPipeline(rssURL), true, nil, hits)
+	recordPipelineRun(rssURL, metrics)
+	saveFilterHits(rssURL, hits)
+	return result
+}
+
+// runPipeline 按顺序执行流水线步骤，返回保留的条目与各步骤统计
+// useCache 为 false 时分类与改写步骤不读写缓存；reasons 不为 nil 时记录被过滤条目对应的步骤名称；hits 不为 nil 时记录各规则的命中
+func runPipeline(items []models.Item, rssURL string, steps []models.PipelineStep, useCache bool, reasons map[string]string, hits filterHits) ([]models.Item, []PipelineStepMetrics) {
+	metrics := make([]PipelineStepMetrics, 0, len(steps))
+	rewriteCacheUsed := false
+	for _, step := range steps {
+		start := time.Now()
+		m := PipelineStepMetrics{Name: step.GetName(), Type: step.Type, Input: len(items)}
+
+		before := items
+		var err error
+		switch step.Type {
+		case "keyword":
+			items = runKeywordStep(items, step.Classify, hits)
+		case "script":
+			if step.Classify != nil {
+				items, err = ApplyScriptFilter(items, step.Classify, rssURL)
+			}
+		case "ai-classify":
+			if step.Classify != nil {
+				strategy := *step.Classify
+				enabled, disabled := true, false
+				strategy.AIEnabled = &enabled
+				strategy.KeywordEnabled = &disabled
+				strategy.ScriptFilterEnabled = &disabled
+				items = classifyItems(items, rssURL, &strategy, useCache, nil, hits)
+			}
+		case "regex":
+			items = applyRewriteRules(items, rssURL, step.Rules)
+		case "ai-rewrite":
+			if step.Rewrite != nil {
+				config := *step.Rewrite
+				config.Enabled = true
+				config.Mode = "ai"
+				// 后处理缓存按条目链接保存，只有第一个改写步骤使用缓存，避免多个改写步骤互相覆盖
+				items = postProcessItems(items, rssURL, &config, useCache && !rewriteCacheUsed)
+				rewriteCacheUsed = true
+			}
+		case "dedup":
+			items = dedupItems(items, step.DedupBy)
+		default:
+			log.Printf("[处理流水线] 源 [%s]: 未知的步骤类型 %s，已跳过", rssURL, step.Type)
+		}
+
+		if err != nil {
+			log.Printf("[处理流水线] 源 [%s] 步骤 [%s] 执行失败: %v，保留原始条目", rssURL, m.Name, err)
+			m.Error = err.Error()
+			items = before
+		}
+		m.Output = len(items)
+		m.Modified = countModifiedItems(before, items)
+		m.Duration = time.Since(start).Milliseconds()
+		metrics = append(metrics, m)
+		recordFilterReasons(reasons, before, items, m.Name)
+		if step.Type == "script" {
+			hits.addRemoved(before, items, filterRuleScript, m.Name)
+		}
+	}
+	return items, metrics
+}
+
+// runKeywordStep 按保留/过滤关键词与白名单模式过滤条目，命中保留关键词的条目在后续分类步骤中跳过类别过滤
+func runKeywordStep(items []models.Item, strategy *models.ClassifyStrategy, hits filterHits) []models.Item {
+	if strategy == nil {
+		return items
+	}
+	client := NewLLMClient(globals.RssUrls.AIClassify)
+	kept := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		resp, _ := client.ClassifyItemWithCategories(item, strategy, nil, true)
+		if resp != nil {
+			hits.addKeywordResult(item, strategy, resp.Category)
+			if resp.Category == "_filtered" {
+				continue
+			}
+			if resp.Category == "_keep" {
+				item.ForceKeep = true
+			}
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+// dedupItems 按链接或标题去重，保留首次出现的条目
+func dedupItems(items []models.Item, by string) []models.Item {
+	seen := make(map[string]bool, len(items))
+	kept := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		key := item.Link
+		if by == "title" {
+			key = strings.ToLower(strings.Join(strings.Fields(item.Title), " "))
+		}
+		if key != "" && seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+// countModifiedItems 统计 after 中相对 before 修改了标题、链接、描述或类别的条目数（按原始链接对应）
+func countModifiedItems(before, after []models.Item) int {
+	original := make(map[string]models.Item, len(before))
+	for _, item := range before {
+		original[item.Link] = item
+	}
+	modified := 0
+	for _, item := range after {
+		key := item.Link
+		if item.OriginalLink != "" {
+			if _, ok := original[key]; !ok {
+				key = item.OriginalLink
+			}
+		}
+		prev, ok := original[key]
+		if !ok {
+			continue
+		}
+		if prev.Title != item.Title || prev.Link != item.Link || prev.Description != item.Description || prev.Category != item.Category {
+			modified++
+		}
+	}
+	return modified
+}
+
+// recordPipelineRun 记录一次流水线执行的统计
+func recordPipelineRun(rssURL string, metrics []PipelineStepMetrics) {
+	pipelineStatsLock.Lock()
+	defer pipelineStatsLock.Unlock()
+
+	stats, ok := pipelineStats[rssURL]
+	if !ok {
+		stats = &PipelineStats{}
+		pipelineStats[rssURL] = stats
+	}
+	stats.Last = PipelineRun{RanAt: time.Now().Format("2006-01-02 15:04:05"), Steps: metrics}
+
+	// 步骤配置变化后重新累计
+	if len(stats.Total) != len(metrics) {
+		stats.Runs = 0
+		stats.Total = make([]PipelineStepMetrics, len(metrics))
+	}
+	for i, m := range metrics {
+		total := &stats.Total[i]
+		if total.Name != m.Name || total.Type != m.Type {
+			*total = PipelineStepMetrics{Name: m.Name, Type: m.Type}
+		}
+		total.Input += m.Input
+		total.Output += m.Output
+		total.Modified += m.Modified
+		total.Duration += m.Duration
+		if m.Error != "" {
+			total.Error = m.Error
+		}
+	}
+	stats.Runs++
+}
+
+// GetPipelineStats 获取源的流水线统计（未执行过时返回 false）
+func GetPipelineStats(rssURL string) (PipelineStats, bool) {
+	pipelineStatsLock.Lock()
+	defer pipelineStatsLock.Unlock()
+	stats, ok := pipelineStats[rssURL]
+	if !ok {
+		return PipelineStats{}, false
+	}
+	result := *stats
+	result.Total = append([]PipelineStepMetrics(nil), stats.Total...)
+	return result, true
+}
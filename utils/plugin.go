@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// WASM 插件钩子名称，作为命令行参数（argv[1]）与环境变量 FEEDORA_HOOK 传给插件
+const (
+	pluginHookFilter    = "filter"
+	pluginHookTransform = "transform"
+)
+
+// compiledPlugin 已编译的插件，文件修改后重新编译
+type compiledPlugin struct {
+	module  wazero.CompiledModule
+	modTime time.Time
+	size    int64
+}
+
+// wasmPageSize WASM 线性内存的页大小（64 KiB）
+const wasmPageSize = 64 << 10
+
+var (
+	// 所有插件共享的 WASM 运行时（仅提供 WASI，不挂载文件系统、没有网络）及其内存上限（页数）
+	pluginRuntime      wazero.Runtime
+	pluginRuntimePages uint32
+
+	// 已编译的插件: map[.wasm 文件路径] -> 编译结果（属于当前的 pluginRuntime）
+	compiledPlugins = make(map[string]compiledPlugin)
+	// 保护 pluginRuntime 与 compiledPlugins
+	compiledPluginsLock sync.Mutex
+)
+
+// getPluginRuntime 获取 WASM 运行时，首次使用或内存上限变化时创建（调用方需持有 compiledPluginsLock）
+func getPluginRuntime() wazero.Runtime {
+	pages := uint32(engineMemoryLimit() / wasmPageSize)
+	if pluginRuntime != nil && pluginRuntimePages == pages {
+		return pluginRuntime
+	}
+
+	ctx := context.Background()
+	// 超时取消 context 时立即终止插件执行（如死循环），线性内存不超过沙箱的内存上限
+	config := wazero.NewRuntimeConfig().WithCloseOnContextDone(true).WithMemoryLimitPages(pages)
+	// 旧运行时可能仍有插件在执行，不主动关闭；编译结果属于旧运行时，需要重新编译
+	pluginRuntime = wazero.NewRuntimeWithConfig(ctx, config)
+	pluginRuntimePages = pages
+	wasi_snapshot_preview1.MustInstantiate(ctx, pluginRuntime)
+	compiledPlugins = make(map[string]compiledPlugin)
+	return pluginRuntime
+}
+
+// compilePlugin 编译插件，文件未变化时复用上次的编译结果，返回编译所用的运行时
+func compilePlugin(ctx context.Context, path string) (wazero.Runtime, wazero.CompiledModule, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取插件失败: %w", err)
+	}
+
+	compiledPluginsLock.Lock()
+	defer compiledPluginsLock.Unlock()
+
+	runtime := getPluginRuntime()
+	if cached, ok := compiledPlugins[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return runtime, cached.module, nil
+	}
+
+	binary, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取插件失败: %w", err)
+	}
+	module, err := runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, nil, fmt.Errorf("编译插件失败: %w", err)
+	}
+	// 旧的编译结果可能仍在其他 goroutine 中使用，不主动关闭
+	compiledPlugins[path] = compiledPlugin{module: module, modTime: info.ModTime(), size: info.Size()}
+	return runtime, module, nil
+}
+
+// runPlugin 以 WASI 命令方式运行插件：input 写入标准输入，返回标准输出
+// 插件通过 argv[1] 或环境变量 FEEDORA_HOOK 判断钩子类型，FEEDORA_SOURCE 为当前订阅源URL
+func runPlugin(pluginID, hook string, input []byte, rssURL string, timeout time.Duration) ([]byte, error) {
+	if err := checkScriptAllowed(); err != nil {
+		return nil, err
+	}
+	plugin := globals.RssUrls.GetPlugin(pluginID)
+	if plugin == nil {
+		return nil, fmt.Errorf("插件不存在: %s", pluginID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	runtime, module, err := compilePlugin(ctx, plugin.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(plugin.ID, hook).
+		WithEnv("FEEDORA_HOOK", hook).
+		WithEnv("FEEDORA_SOURCE", rssURL).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+
+	instance, err := runtime.InstantiateModule(ctx, module, config)
+	if instance != nil {
+		_ = instance.Close(context.Background())
+	}
+	if err != nil {
+		var exitErr *sys.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 0 {
+			return stdout.Bytes(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("插件执行超时（超过 %v）", timeout)
+		}
+		return nil, fmt.Errorf("插件执行失败: %v, stderr: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// applyPluginFilter 使用 WASM 插件过滤条目，输入输出格式与 bash 过滤脚本一致（条目 JSON 数组）
+func applyPluginFilter(items []models.Item, pluginID string, rssURL string, timeout time.Duration) ([]models.Item, error) {
+	input, err := json.Marshal(items)
+	if err != nil {
+		return items, fmt.Errorf("序列化条目失败: %w", err)
+	}
+	output, err := runPlugin(pluginID, pluginHookFilter, input, rssURL, timeout)
+	if err != nil {
+		return items, err
+	}
+	filtered, err := parseScriptItemsOutput(output)
+	if err != nil {
+		return items, err
+	}
+	return filtered, nil
+}
@@ -18,9 +18,12 @@ import (
 
 // PostProcessResponse AI后处理响应结构
 type PostProcessResponse struct {
-	Title   string `json:"title,omitempty"`
-	Link    string `json:"link,omitempty"`
-	PubDate string `json:"pubDate,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Link        string            `json:"link,omitempty"`
+	PubDate     string            `json:"pubDate,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Category    string            `json:"category,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
 // postProcessResult 后处理结果
@@ -33,11 +36,28 @@ type postProcessResult struct {
 
 // PostProcessItems 对Feed条目进行后处理（并行处理）
 func PostProcessItems(items []models.Item, rssURL string) []models.Item {
-	config := getPostProcessConfig(rssURL)
+	return postProcessItems(items, rssURL, getPostProcessConfig(rssURL), true)
+}
+
+// postProcessItems 按指定的后处理配置处理条目
+// useCache 为 false 时既不读取也不写入后处理缓存（用于预览尚未保存的配置）
+func postProcessItems(items []models.Item, rssURL string, config *models.PostProcessConfig, useCache bool) []models.Item {
 	if config == nil || !config.Enabled {
 		return items
 	}
 
+	// 规则模式在本地直接替换，无需并发、重试与缓存
+	if config.GetMode() == "rules" {
+		return applyRewriteRules(items, rssURL, config.Rules)
+	}
+
+	// 解析提示词中的模板引用与变量
+	if config.Prompt != "" {
+		rendered := *config
+		rendered.Prompt = renderPrompt(config.Prompt, rssURL, globals.RssUrls.AIClassify.GetCategories(&globals.RssUrls))
+		config = &rendered
+	}
+
 	// 记录开始日志
 	mode := config.GetMode()
 	modifyFields := []string{}
@@ -50,6 +70,15 @@ func PostProcessItems(items []models.Item, rssURL string) []models.Item {
 	if config.ModifyPubDate {
 		modifyFields = append(modifyFields, "发布时间")
 	}
+	if config.ModifyDescription {
+		modifyFields = append(modifyFields, "描述")
+	}
+	if config.ModifyCategory {
+		modifyFields = append(modifyFields, "类别")
+	}
+	if config.ModifyMetadata {
+		modifyFields = append(modifyFields, "元数据")
+	}
 	log.Printf("[后处理开始] 源 [%s] | 模式: %s | 待处理: %d 条 | 修改字段: %s",
 		rssURL, mode, len(items), strings.Join(modifyFields, ", "))
 
@@ -89,20 +118,20 @@ func PostProcessItems(items []models.Item, rssURL string) []models.Item {
 
 				// 先检查缓存
 				cacheEntry, cached := GetPostProcessCache(originalLink)
-				if cached {
+				if useCache && cached {
 					// 使用缓存结果
-					if config.ModifyTitle && cacheEntry.Title != "" {
-						result.item.Title = cacheEntry.Title
-					}
-					if config.ModifyLink && cacheEntry.Link != "" {
-						// 保存原始链接（如果还没有的话）
-						if result.item.OriginalLink == "" {
-							result.item.OriginalLink = result.item.Link
-						}
-						result.item.Link = cacheEntry.Link
-					}
-					if config.ModifyPubDate && cacheEntry.PubDate != "" {
-						result.item.PubDate = cacheEntry.PubDate
+					originalItemLink := result.item.Link
+					result.item = applyPostProcessResponse(result.item, config, PostProcessResponse{
+						Title:       cacheEntry.Title,
+						Link:        cacheEntry.Link,
+						PubDate:     cacheEntry.PubDate,
+						Description: cacheEntry.Description,
+						Category:    cacheEntry.Category,
+						Metadata:    cacheEntry.Metadata,
+					})
+					// 保存原始链接（如果还没有的话）
+					if result.item.Link != originalItemLink && result.item.OriginalLink == "" {
+						result.item.OriginalLink = originalItemLink
 					}
 					result.fromCache = true
 				} else {
@@ -115,9 +144,12 @@ func PostProcessItems(items []models.Item, rssURL string) []models.Item {
 					var lastErr error
 
 					for attempt := 1; attempt <= maxRetries; attempt++ {
-						if config.GetMode() == "script" {
+						switch config.GetMode() {
+						case "script":
 							processedItem, lastErr = processItemWithScript(job.item, config)
-						} else {
+						case "plugin":
+							processedItem, lastErr = processItemWithPlugin(job.item, rssURL, config)
+						default:
 							processedItem, lastErr = processItemWithAI(job.item, config)
 						}
 
@@ -158,6 +190,15 @@ func PostProcessItems(items []models.Item, rssURL string) []models.Item {
 						if config.ModifyPubDate && processedItem.PubDate != job.item.PubDate {
 							changes = append(changes, fmt.Sprintf("时间: [%s] -> [%s]", job.item.PubDate, processedItem.PubDate))
 						}
+						if config.ModifyDescription && processedItem.Description != job.item.Description {
+							changes = append(changes, "描述已修改")
+						}
+						if config.ModifyCategory && processedItem.Category != job.item.Category {
+							changes = append(changes, fmt.Sprintf("类别: [%s] -> [%s]", job.item.Category, processedItem.Category))
+						}
+						if config.ModifyMetadata && len(processedItem.Metadata) > len(job.item.Metadata) {
+							changes = append(changes, fmt.Sprintf("元数据: %d 项", len(processedItem.Metadata)))
+						}
 						if len(changes) > 0 {
 							log.Printf("[后处理成功] 条目 [%s] | %s", truncateString(job.item.Title, 30), strings.Join(changes, ", "))
 						}
@@ -175,7 +216,18 @@ func PostProcessItems(items []models.Item, rssURL string) []models.Item {
 						if config.ModifyPubDate {
 							entry.PubDate = processedItem.PubDate
 						}
-						SetPostProcessCache(originalLink, entry)
+						if config.ModifyDescription && processedItem.Description != job.item.Description {
+							entry.Description = processedItem.Description
+						}
+						if config.ModifyCategory && processedItem.Category != job.item.Category {
+							entry.Category = processedItem.Category
+						}
+						if config.ModifyMetadata {
+							entry.Metadata = processedItem.Metadata
+						}
+						if useCache {
+							SetPostProcessCache(originalLink, entry)
+						}
 					}
 				}
 
@@ -224,6 +276,10 @@ func PostProcessItems(items []models.Item, rssURL string) []models.Item {
 		} else {
 			newItems++
 		}
+		// 后处理输出的描述与抓取内容同样按源的策略清洗
+		if config.ModifyDescription {
+			result.item.Description = sanitizeDescription(rssURL, result.item.Description)
+		}
 		processedItems = append(processedItems, result.item)
 	}
 
@@ -262,7 +318,7 @@ func ShouldPostProcess(rssURL string) bool {
 // processItemWithAI 使用AI处理条目
 func processItemWithAI(item models.Item, config *models.PostProcessConfig) (models.Item, error) {
 	aiConfig := globals.RssUrls.AIClassify
-	if aiConfig.APIKey == "" {
+	if !aiConfig.HasAPIAccess() {
 		return item, fmt.Errorf("AI API Key未配置")
 	}
 
@@ -271,23 +327,26 @@ func processItemWithAI(item models.Item, config *models.PostProcessConfig) (mode
 	if prompt == "" {
 		prompt = "请对以下RSS条目进行处理。"
 	}
-	prompt += "\n\n输出要求（必须全部满足）：" +
-		"\n1. 只返回一个 JSON 对象，不要返回 markdown、代码块、解释或额外文本。" +
-		"\n2. 仅允许出现这三个字段：title、link、pubDate。" +
-		"\n3. 未修改的字段也要保留原值，不要留空，不要省略。" +
-		"\n4. `title`、`link`、`pubDate` 的值都必须是字符串。" +
-		"\n5. 输出格式必须是：{\"title\":\"...\",\"link\":\"...\",\"pubDate\":\"...\"}。"
+	prompt += postProcessOutputRequirements(config)
 
 	// 构建条目内容
-	itemJSON, _ := json.Marshal(map[string]string{
+	input := map[string]string{
 		"title":   item.Title,
 		"link":    item.Link,
 		"pubDate": item.PubDate,
-	})
+	}
+	if config.ModifyDescription {
+		input["description"] = item.Description
+	}
+	if config.ModifyCategory {
+		input["category"] = item.Category
+	}
+	itemJSON, _ := json.Marshal(input)
 
 	// 构建请求
 	reqBody := ChatRequest{
-		Model: aiConfig.GetModel(),
+		Model:   aiConfig.GetModel(),
+		Purpose: "postprocess",
 		Messages: []ChatMessage{
 			{Role: "system", Content: prompt},
 			{Role: "user", Content: string(itemJSON)},
@@ -302,7 +361,7 @@ func processItemWithAI(item models.Item, config *models.PostProcessConfig) (mode
 	client := &http.Client{
 		Timeout: time.Duration(aiConfig.GetTimeout()) * time.Second,
 	}
-	chatResp, err := sendChatCompletion(client, aiConfig.GetAPIBase(), aiConfig.APIKey, jsonMode, reqBody)
+	chatResp, err := sendChatCompletion(client, aiConfig, jsonMode, reqBody)
 	if err != nil {
 		return item, err
 	}
@@ -316,51 +375,107 @@ func processItemWithAI(item models.Item, config *models.PostProcessConfig) (mode
 		return item, fmt.Errorf("解析后处理响应失败: %w, 内容: %s", err, responseContent)
 	}
 
-	// 应用处理结果
+	return applyPostProcessResponse(item, config, postProcessResp), nil
+}
+
+// postProcessOutputRequirements 构建 AI 后处理的输出格式要求（按配置允许修改的字段）
+func postProcessOutputRequirements(config *models.PostProcessConfig) string {
+	fields := []string{"title", "link", "pubDate"}
+	if config.ModifyDescription {
+		fields = append(fields, "description")
+	}
+	if config.ModifyCategory {
+		fields = append(fields, "category")
+	}
+	example := make([]string, 0, len(fields)+1)
+	for _, field := range fields {
+		example = append(example, fmt.Sprintf("\"%s\":\"...\"", field))
+	}
+
+	requirements := "\n\n输出要求（必须全部满足）：" +
+		"\n1. 只返回一个 JSON 对象，不要返回 markdown、代码块、解释或额外文本。" +
+		fmt.Sprintf("\n2. 字符串字段仅允许：%s。", strings.Join(fields, "、")) +
+		"\n3. 未修改的字段也要保留原值，不要留空，不要省略。" +
+		"\n4. 以上字段的值都必须是字符串。"
+	if config.ModifyCategory {
+		categories := globals.RssUrls.AIClassify.GetCategories(&globals.RssUrls)
+		ids := make([]string, 0, len(categories))
+		for _, cat := range categories {
+			ids = append(ids, fmt.Sprintf("%s（%s）", cat.ID, cat.Name))
+		}
+		requirements += fmt.Sprintf("\n- `category` 必须是以下类别ID之一：%s。", strings.Join(ids, "、"))
+	}
+	if config.ModifyMetadata {
+		requirements += "\n- 可额外输出 `metadata` 对象，键和值都必须是字符串，用于附加信息（如摘要、作者、标签）。"
+		example = append(example, "\"metadata\":{\"key\":\"value\"}")
+	}
+	requirements += fmt.Sprintf("\n5. 输出格式必须是：{%s}。", strings.Join(example, ","))
+	return requirements
+}
+
+// applyPostProcessResponse 按配置将后处理结果（AI、脚本、插件输出或缓存）应用到条目
+// 类别必须是已配置的类别ID，元数据与条目已有的元数据合并
+func applyPostProcessResponse(item models.Item, config *models.PostProcessConfig, resp PostProcessResponse) models.Item {
 	processedItem := item
-	if config.ModifyTitle && postProcessResp.Title != "" {
-		processedItem.Title = postProcessResp.Title
+	if config.ModifyTitle && resp.Title != "" {
+		processedItem.Title = resp.Title
+	}
+	if config.ModifyLink && resp.Link != "" {
+		processedItem.Link = resp.Link
+	}
+	if config.ModifyPubDate && resp.PubDate != "" {
+		processedItem.PubDate = resp.PubDate
+	}
+	if config.ModifyDescription && resp.Description != "" {
+		processedItem.Description = resp.Description
 	}
-	if config.ModifyLink && postProcessResp.Link != "" {
-		processedItem.Link = postProcessResp.Link
+	if config.ModifyCategory && resp.Category != "" && resp.Category != item.Category {
+		if isConfiguredCategory(resp.Category) {
+			processedItem.Category = resp.Category
+			processedItem.Categories = nil
+		} else {
+			log.Printf("[后处理] 条目 [%s]: 未知的类别ID %s，已忽略", truncateString(item.Title, 30), resp.Category)
+		}
 	}
-	if config.ModifyPubDate && postProcessResp.PubDate != "" {
-		processedItem.PubDate = postProcessResp.PubDate
+	if config.ModifyMetadata && len(resp.Metadata) > 0 {
+		metadata := make(map[string]string, len(item.Metadata)+len(resp.Metadata))
+		for k, v := range item.Metadata {
+			metadata[k] = v
+		}
+		for k, v := range resp.Metadata {
+			metadata[k] = v
+		}
+		processedItem.Metadata = metadata
 	}
+	return processedItem
+}
 
-	return processedItem, nil
+// isConfiguredCategory 判断类别ID是否为已配置的类别
+func isConfiguredCategory(id string) bool {
+	for _, cat := range globals.RssUrls.AIClassify.GetCategories(&globals.RssUrls) {
+		if cat.ID == id {
+			return true
+		}
+	}
+	return false
 }
 
 // processItemWithScript 使用脚本处理条目
 func processItemWithScript(item models.Item, config *models.PostProcessConfig) (models.Item, error) {
-	// 创建超时 context（复用 AI 的超时配置）
-	timeout := time.Duration(globals.RssUrls.AIClassify.GetTimeout()) * time.Second
+	// 创建超时 context（不超过沙箱的最长执行时间）
+	timeout := scriptTimeout(time.Duration(config.GetScriptTimeout()) * time.Second)
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	// 将条目转换为JSON
-	itemJSON, err := json.Marshal(map[string]string{
-		"title":       item.Title,
-		"link":        item.Link,
-		"pubDate":     item.PubDate,
-		"source":      item.Source,
-		"description": item.Description,
-	})
+	itemJSON, err := postProcessScriptInput(item)
 	if err != nil {
-		return item, fmt.Errorf("序列化条目失败: %w", err)
+		return item, err
 	}
 
-	var cmd *exec.Cmd
-
-	// 优先使用内联脚本内容
-	if config.ScriptContent != "" {
-		// 使用 bash -c 直接执行脚本内容
-		cmd = exec.CommandContext(ctx, "bash", "-c", config.ScriptContent)
-	} else if config.ScriptPath != "" {
-		// 使用脚本文件
-		cmd = exec.CommandContext(ctx, config.ScriptPath)
-	} else {
-		return item, fmt.Errorf("脚本内容或脚本路径未配置")
+	// 优先使用内联脚本内容，其次使用脚本文件（按沙箱配置限制资源）
+	cmd, err := newScriptCommand(ctx, config.ScriptContent, config.ScriptPath)
+	if err != nil {
+		return item, err
 	}
 
 	cmd.Stdin = bytes.NewReader(itemJSON)
@@ -376,23 +491,47 @@ func processItemWithScript(item models.Item, config *models.PostProcessConfig) (
 		return item, fmt.Errorf("脚本执行失败: %w", err)
 	}
 
-	// 解析脚本输出
-	var postProcessResp PostProcessResponse
-	if err := json.Unmarshal(output, &postProcessResp); err != nil {
-		return item, fmt.Errorf("解析脚本输出失败: %w, 输出: %s", err, string(output))
+	return applyPostProcessOutput(item, config, output)
+}
+
+// processItemWithPlugin 使用 WASM 插件处理条目，输入输出格式与后处理脚本一致
+func processItemWithPlugin(item models.Item, rssURL string, config *models.PostProcessConfig) (models.Item, error) {
+	itemJSON, err := postProcessScriptInput(item)
+	if err != nil {
+		return item, err
 	}
 
-	// 应用处理结果
-	processedItem := item
-	if config.ModifyTitle && postProcessResp.Title != "" {
-		processedItem.Title = postProcessResp.Title
+	// 不超过沙箱的最长执行时间
+	timeout := scriptTimeout(time.Duration(config.GetScriptTimeout()) * time.Second)
+	output, err := runPlugin(config.Plugin, pluginHookTransform, itemJSON, rssURL, timeout)
+	if err != nil {
+		return item, err
 	}
-	if config.ModifyLink && postProcessResp.Link != "" {
-		processedItem.Link = postProcessResp.Link
+	return applyPostProcessOutput(item, config, output)
+}
+
+// postProcessScriptInput 将条目转换为后处理脚本/插件的输入 JSON
+func postProcessScriptInput(item models.Item) ([]byte, error) {
+	itemJSON, err := json.Marshal(map[string]string{
+		"title":       item.Title,
+		"link":        item.Link,
+		"pubDate":     item.PubDate,
+		"source":      item.Source,
+		"description": item.Description,
+		"category":    item.Category,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化条目失败: %w", err)
 	}
-	if config.ModifyPubDate && postProcessResp.PubDate != "" {
-		processedItem.PubDate = postProcessResp.PubDate
+	return itemJSON, nil
+}
+
+// applyPostProcessOutput 解析后处理脚本/插件的输出，按配置修改条目字段
+func applyPostProcessOutput(item models.Item, config *models.PostProcessConfig, output []byte) (models.Item, error) {
+	var postProcessResp PostProcessResponse
+	if err := json.Unmarshal(output, &postProcessResp); err != nil {
+		return item, fmt.Errorf("解析脚本输出失败: %w, 输出: %s", err, string(output))
 	}
 
-	return processedItem, nil
+	return applyPostProcessResponse(item, config, postProcessResp), nil
 }
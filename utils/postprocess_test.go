@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"feedora/models"
+	"reflect"
+	"testing"
+)
+
+func TestApplyPostProcessResponse(t *testing.T) {
+	item := models.Item{
+		Title:       "原标题",
+		Description: "原描述",
+		Category:    "tech",
+		Metadata:    map[string]string{"author": "张三"},
+	}
+	resp := PostProcessResponse{
+		Title:       "新标题",
+		Description: "新描述",
+		Category:    "not-configured",
+		Metadata:    map[string]string{"readingTime": "5"},
+	}
+
+	got := applyPostProcessResponse(item, &models.PostProcessConfig{ModifyDescription: true, ModifyCategory: true, ModifyMetadata: true}, resp)
+	if got.Title != item.Title {
+		t.Errorf("Title = %q, want unchanged without modifyTitle", got.Title)
+	}
+	if got.Description != "新描述" {
+		t.Errorf("Description = %q, want %q", got.Description, "新描述")
+	}
+	if got.Category != "tech" {
+		t.Errorf("Category = %q, want unknown category ignored", got.Category)
+	}
+	if want := map[string]string{"author": "张三", "readingTime": "5"}; !reflect.DeepEqual(got.Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", got.Metadata, want)
+	}
+	if len(item.Metadata) != 1 {
+		t.Errorf("input metadata modified: %v", item.Metadata)
+	}
+}
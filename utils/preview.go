@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"feedora/models"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// PreviewTiming 预览各阶段耗时（毫秒）
+type PreviewTiming struct {
+	Fetch       int64 `json:"fetch"`
+	Classify    int64 `json:"classify"`
+	PostProcess int64 `json:"postProcess"`
+	// 配置了处理流水线时流水线的总耗时（此时不单独统计分类与后处理耗时）
+	Pipeline int64 `json:"pipeline,omitempty"`
+	Total    int64 `json:"total"`
+}
+
+// SourcePreview 订阅源预览结果
+type SourcePreview struct {
+	// 抓取到的源标题
+	Title string `json:"title"`
+	// 抓取到的条目数
+	Fetched int `json:"fetched"`
+	// 应用最大条目数限制后参与处理的条目数
+	Processed int `json:"processed"`
+	// 被关键词、类别或脚本规则过滤掉的条目数
+	Filtered int `json:"filtered"`
+	// 保留条目中各类别的条目数
+	Categories map[string]int `json:"categories"`
+	// 最终保留的条目
+	Items []models.Item `json:"items"`
+	// 被过滤掉的条目
+	FilteredItems []models.Item `json:"filteredItems"`
+	Timing        PreviewTiming `json:"timing"`
+}
+
+// PreviewSource 按候选配置完整执行一次抓取、分类过滤、后处理与标题翻译流程，不写入任何缓存或配置
+// 分类与后处理不使用缓存，结果反映候选配置的实际效果（翻译结果只与原文相关，仍使用并写入翻译缓存）
+func PreviewSource(source models.Source) (*SourcePreview, error) {
+	start := time.Now()
+
+	result, err := fetchSourceFeed(source)
+	if err != nil {
+		return nil, fmt.Errorf("抓取失败: %w", err)
+	}
+	sanitizeFeed(source, result)
+	preview := &SourcePreview{
+		Title:         result.Title,
+		Fetched:       len(result.Items),
+		Categories:    make(map[string]int),
+		Items:         []models.Item{},
+		FilteredItems: []models.Item{},
+	}
+	preview.Timing.Fetch = time.Since(start).Milliseconds()
+
+	items := buildPreviewItems(source, result)
+	preview.Processed = len(items)
+
+	// 分类与过滤
+	kept := items
+	if len(source.Pipeline) > 0 {
+		pipelineStart := time.Now()
+		kept, _ = runPipeline(items, source.URL, source.Pipeline, false, nil, nil)
+		preview.Timing.Pipeline = time.Since(pipelineStart).Milliseconds()
+
+		keptLinks := make(map[string]bool, len(kept))
+		for _, item := range kept {
+			link := item.Link
+			if item.OriginalLink != "" {
+				link = item.OriginalLink
+			}
+			keptLinks[link] = true
+			for _, cat := range item.CategoryIDs() {
+				preview.Categories[cat]++
+			}
+		}
+		for _, item := range items {
+			if !keptLinks[item.Link] {
+				preview.FilteredItems = append(preview.FilteredItems, item)
+			}
+		}
+		preview.Filtered = len(preview.FilteredItems)
+	} else if strategyNeedsFilter(source.Classify) {
+		classifyStart := time.Now()
+		kept = classifyItems(items, source.URL, source.Classify, false, nil, nil)
+		preview.Timing.Classify = time.Since(classifyStart).Milliseconds()
+
+		// classifyItems 只返回保留的条目，其余即为被过滤的条目
+		keptLinks := make(map[string]bool, len(kept))
+		for _, item := range kept {
+			keptLinks[item.Link] = true
+			for _, cat := range item.CategoryIDs() {
+				preview.Categories[cat]++
+			}
+		}
+		for _, item := range items {
+			if !keptLinks[item.Link] {
+				preview.FilteredItems = append(preview.FilteredItems, item)
+			}
+		}
+		preview.Filtered = len(preview.FilteredItems)
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		if cmp := compareItemsByRecency(kept[i], kept[j]); cmp != 0 {
+			return cmp > 0
+		}
+		return kept[i].OriginalIndex < kept[j].OriginalIndex
+	})
+
+	// 后处理（流水线中的改写步骤已处理过）
+	if len(source.Pipeline) == 0 && source.PostProcess != nil && source.PostProcess.Enabled {
+		postStart := time.Now()
+		kept = postProcessItems(kept, source.URL, source.PostProcess, false)
+		preview.Timing.PostProcess = time.Since(postStart).Milliseconds()
+	}
+	kept = translateItems(kept, source.Translate)
+	kept = formatItemTitles(kept, source)
+
+	preview.Items = kept
+	preview.Timing.Total = time.Since(start).Milliseconds()
+	return preview, nil
+}
+
+// buildPreviewItems 将抓取结果构建为条目（与 processFeedResult 一致，但没有可恢复时间戳的旧缓存），并应用最大条目数限制
+func buildPreviewItems(source models.Source, result *gofeed.Feed) []models.Item {
+	now := time.Now()
+	formattedTime := now.Format(time.RFC3339)
+	items := make([]models.Item, 0, len(result.Items))
+	for idx, v := range result.Items {
+		pubDate := formattedTime
+		switch {
+		case source.RankingMode:
+			pubDate = now.Add(-time.Duration(idx) * time.Second).Format(time.RFC3339)
+		case source.IgnoreOriginalPubDate:
+		case v.PublishedParsed != nil:
+			pubDate = v.PublishedParsed.Format(time.RFC3339)
+		case v.UpdatedParsed != nil:
+			pubDate = v.UpdatedParsed.Format(time.RFC3339)
+		}
+		score, comments := parseItemEngagement(v)
+		thumbnail, duration := parseItemMedia(v)
+		items = append(items, models.Item{
+			Link:          v.Link,
+			Title:         v.Title,
+			Description:   v.Description,
+			Source:        result.Title,
+			PubDate:       pubDate,
+			FetchTime:     formattedTime,
+			Score:         score,
+			Comments:      comments,
+			Thumbnail:     thumbnail,
+			Duration:      duration,
+			OriginalIndex: idx,
+		})
+	}
+	if source.MaxItems > 0 && len(items) > source.MaxItems {
+		items = items[:source.MaxItems]
+	}
+	return items
+}
+
+var (
+	// 各源最近一次抓取到的原始内容（仅保存在内存中），用于按当前或候选规则重放处理流程
+	lastFetchedFeeds     = make(map[string]*gofeed.Feed)
+	lastFetchedFeedsLock sync.RWMutex
+)
+
+// rememberFetchedFeed 记录源最近一次抓取到的原始内容
+func rememberFetchedFeed(url string, feed *gofeed.Feed) {
+	lastFetchedFeedsLock.Lock()
+	lastFetchedFeeds[url] = feed
+	lastFetchedFeedsLock.Unlock()
+}
+
+// getFetchedFeed 获取源最近一次抓取到的原始内容
+func getFetchedFeed(url string) (*gofeed.Feed, bool) {
+	lastFetchedFeedsLock.RLock()
+	defer lastFetchedFeedsLock.RUnlock()
+	feed, ok := lastFetchedFeeds[url]
+	return feed, ok
+}
+
+// FilteredPreviewItem 被过滤的条目及过滤原因
+type FilteredPreviewItem struct {
+	Item models.Item `json:"item"`
+	// 过滤原因: keyword（关键词）/ category（类别黑白名单）/ relevance（最低相关度）/ script（脚本规则）
+	// 配置了处理流水线时为过滤该条目的步骤名称
+	Reason string `json:"reason"`
+}
+
+// TransformedPreviewItem 被后处理或翻译修改的条目（处理前后对比）
+type TransformedPreviewItem struct {
+	Before models.Item `json:"before"`
+	After  models.Item `json:"after"`
+	// 发生变化的字段: title / description / link
+	Changed []string `json:"changed"`
+}
+
+// PipelinePreview 按规则重放处理流程的结果
+type PipelinePreview struct {
+	// 参与处理的原始条目数
+	Total       int                      `json:"total"`
+	Kept        []models.Item            `json:"kept"`
+	Filtered    []FilteredPreviewItem    `json:"filtered"`
+	Transformed []TransformedPreviewItem `json:"transformed"`
+	// 配置了处理流水线时各步骤的执行统计
+	Steps []PipelineStepMetrics `json:"steps,omitempty"`
+}
+
+// PreviewPipeline 对源最近一次抓取到的原始条目重放关键词、脚本、类别过滤与后处理流程，返回处理前后的对比，不重新抓取
+// useCache 为 false 时分类与后处理不读写缓存（用于尚未保存的候选规则）
+func PreviewPipeline(source models.Source, useCache bool) (*PipelinePreview, error) {
+	result, ok := getFetchedFeed(source.URL)
+	if !ok {
+		return nil, fmt.Errorf("源 %s 尚未抓取过，没有可用于预览的条目", source.URL)
+	}
+	items := buildPreviewItems(source, result)
+	preview := &PipelinePreview{
+		Total:       len(items),
+		Kept:        []models.Item{},
+		Filtered:    []FilteredPreviewItem{},
+		Transformed: []TransformedPreviewItem{},
+	}
+
+	kept := items
+	before := make(map[string]models.Item, len(items))
+	if len(source.Pipeline) > 0 {
+		// 流水线中的过滤与改写步骤交替执行，以原始条目作为对比基准
+		for _, item := range items {
+			before[item.Link] = item
+		}
+		reasons := make(map[string]string)
+		kept, preview.Steps = runPipeline(items, source.URL, source.Pipeline, useCache, reasons, nil)
+		keptLinks := make(map[string]bool, len(kept))
+		for _, item := range kept {
+			link := item.Link
+			if item.OriginalLink != "" {
+				link = item.OriginalLink
+			}
+			keptLinks[link] = true
+		}
+		for _, item := range items {
+			if !keptLinks[item.Link] {
+				preview.Filtered = append(preview.Filtered, FilteredPreviewItem{Item: item, Reason: reasons[item.Link]})
+			}
+		}
+	} else if strategyNeedsFilter(source.Classify) {
+		reasons := make(map[string]string)
+		kept = classifyItems(items, source.URL, source.Classify, useCache, reasons, nil)
+		keptLinks := make(map[string]bool, len(kept))
+		for _, item := range kept {
+			keptLinks[item.Link] = true
+		}
+		for _, item := range items {
+			if !keptLinks[item.Link] {
+				preview.Filtered = append(preview.Filtered, FilteredPreviewItem{Item: item, Reason: reasons[item.Link]})
+			}
+		}
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		if cmp := compareItemsByRecency(kept[i], kept[j]); cmp != 0 {
+			return cmp > 0
+		}
+		return kept[i].OriginalIndex < kept[j].OriginalIndex
+	})
+
+	if len(source.Pipeline) == 0 {
+		for _, item := range kept {
+			before[item.Link] = item
+		}
+		if source.PostProcess != nil && source.PostProcess.Enabled {
+			kept = postProcessItems(kept, source.URL, source.PostProcess, useCache)
+		}
+	}
+	kept = translateItems(kept, source.Translate)
+	kept = formatItemTitles(kept, source)
+
+	for _, item := range kept {
+		link := item.Link
+		if item.OriginalLink != "" {
+			link = item.OriginalLink
+		}
+		original, ok := before[link]
+		if !ok {
+			continue
+		}
+		var changed []string
+		if item.Title != original.Title {
+			changed = append(changed, "title")
+		}
+		if item.Description != original.Description {
+			changed = append(changed, "description")
+		}
+		if item.Link != original.Link {
+			changed = append(changed, "link")
+		}
+		if len(changed) > 0 {
+			preview.Transformed = append(preview.Transformed, TransformedPreviewItem{Before: original, After: item, Changed: changed})
+		}
+	}
+	preview.Kept = kept
+	return preview, nil
+}
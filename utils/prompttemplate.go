@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// renderPrompt 解析提示词中的 template://名称 引用并替换变量：
+// {{categories}} 可用类别列表，{{source}} 源名称，{{language}} 源的翻译目标语言（未配置时为 zh-CN）
+// 引用的模板不存在时返回空字符串（即回退为默认提示词）
+func renderPrompt(prompt, rssURL string, categories []models.Category) string {
+	content, err := globals.RssUrls.ResolvePrompt(prompt)
+	if err != nil {
+		log.Printf("[提示词模板] 源 [%s]: %v，使用默认提示词", rssURL, err)
+		return ""
+	}
+	if !strings.Contains(content, "{{") {
+		return content
+	}
+
+	sourceName := rssURL
+	language := models.TranslateConfig{}.GetTargetLang()
+	if source := globals.RssUrls.GetSourceByURL(rssURL); source != nil {
+		if source.Name != "" {
+			sourceName = source.Name
+		}
+		if source.Translate != nil {
+			language = source.Translate.GetTargetLang()
+		}
+	}
+
+	var categoryList strings.Builder
+	for _, cat := range categories {
+		categoryList.WriteString(fmt.Sprintf("- %s (%s): %s\n", cat.ID, cat.Name, cat.Description))
+	}
+
+	return strings.NewReplacer(
+		"{{categories}}", strings.TrimSuffix(categoryList.String(), "\n"),
+		"{{source}}", sourceName,
+		"{{language}}", language,
+	).Replace(content)
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"feedora/globals"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 图标与图片代理（/api/icon、/api/image）按请求参数中的地址发起请求，需要防止借此访问内网服务（SSRF）：
+// 只允许 http(s)，请求前检查地址（含 DNS 解析结果）与每一次重定向，建立连接时再检查实际连接的 IP，
+// DNS 重绑定也无法绕过。proxyGuard.allowHosts 中的主机不受内网地址限制，denyHosts 中的主机始终拒绝
+
+// errProxyHostBlocked 代理目标被访问限制拒绝
+var errProxyHostBlocked = errors.New("不允许代理访问该地址")
+
+// cgnatNet 运营商级 NAT 地址段（100.64.0.0/10），同样视为内网
+var cgnatNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+var (
+	guardedTransport     http.RoundTripper
+	guardedTransportOnce sync.Once
+	// 按超时时间复用的客户端: map[超时] -> 客户端
+	guardedClients     = make(map[time.Duration]*http.Client)
+	guardedClientsLock sync.Mutex
+)
+
+// newGuardedClient 获取代理请求使用的 HTTP 客户端（最多跟随 10 次重定向，每次重定向都检查目标地址）
+// 超时相同的调用共用同一个客户端，所有客户端共用 proxy 连接池
+func newGuardedClient(timeout time.Duration) *http.Client {
+	guardedTransportOnce.Do(func() {
+		guardedTransport = &bandwidthTransport{base: globals.NewUserAgentTransport(newGuardedHTTPTransport())}
+	})
+	guardedClientsLock.Lock()
+	defer guardedClientsLock.Unlock()
+	if client, ok := guardedClients[timeout]; ok {
+		return client
+	}
+	client := &http.Client{
+		Transport: guardedTransport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return checkProxyTarget(req.Context(), req.URL)
+		},
+	}
+	guardedClients[timeout] = client
+	return client
+}
+
+// newGuardedHTTPTransport 创建 proxy 连接池，在建立连接时检查实际连接的 IP；连接环境变量中配置的 HTTP 代理时不检查
+func newGuardedHTTPTransport() http.RoundTripper {
+	plain := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	guarded := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isBlockedProxyIP(ip) {
+				return fmt.Errorf("%w: %s", errProxyHostBlocked, host)
+			}
+			return nil
+		},
+	}
+	// 两种连接都按 dns 配置解析主机，guarded 在解析后检查实际连接的 IP
+	plainDial, guardedDial := resolvingDialContext(plain), resolvingDialContext(guarded)
+	return newPooledTransport("proxy", func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if isEnvProxyAddr(addr) || matchesProxyHost(host, globals.RssUrls.ProxyGuard.AllowHosts) {
+			return plainDial(ctx, network, addr)
+		}
+		return guardedDial(ctx, network, addr)
+	})
+}
+
+// checkProxyURL 检查代理目标地址：协议、访问限制以及 DNS 解析到的 IP
+func checkProxyURL(raw string) error {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return checkProxyTarget(ctx, parsed)
+}
+
+// IsProxyBlocked 判断错误是否由代理访问限制引起
+func IsProxyBlocked(err error) bool {
+	return errors.Is(err, errProxyHostBlocked)
+}
+
+// guardedGet 检查目标地址后发起 GET 请求
+func guardedGet(client *http.Client, raw string) (*http.Response, error) {
+	if err := checkProxyURL(raw); err != nil {
+		return nil, err
+	}
+	return client.Get(raw)
+}
+
+// checkProxyTarget 检查代理目标（也用于每一次重定向）
+func checkProxyTarget(ctx context.Context, target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("%w: 不支持的协议 %q", errProxyHostBlocked, target.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	if host == "" {
+		return errors.New("地址缺少主机")
+	}
+
+	guard := globals.RssUrls.ProxyGuard
+	if matchesProxyHost(host, guard.DenyHosts) {
+		return fmt.Errorf("%w: %s", errProxyHostBlocked, host)
+	}
+	if matchesProxyHost(host, guard.AllowHosts) {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if isBlockedProxyIP(ip) {
+			return fmt.Errorf("%w: %s", errProxyHostBlocked, host)
+		}
+		return nil
+	}
+	ips, err := lookupHostIPs(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if isBlockedProxyIP(ip) {
+			return fmt.Errorf("%w: %s (%s)", errProxyHostBlocked, host, ip)
+		}
+	}
+	return nil
+}
+
+// isBlockedProxyIP 判断 IP 是否为禁止代理访问的地址：内网、回环、链路本地（含云服务元数据地址）、组播、未指定地址，
+// 以及 denyHosts 中的地址；allowHosts 中的地址除外
+func isBlockedProxyIP(ip net.IP) bool {
+	guard := globals.RssUrls.ProxyGuard
+	if matchesProxyIP(ip, guard.DenyHosts) {
+		return true
+	}
+	if matchesProxyIP(ip, guard.AllowHosts) {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() ||
+		cgnatNet.Contains(ip) || (ip.To4() != nil && ip.To4()[0] == 0)
+}
+
+// matchesProxyHost 判断主机是否匹配列表中的域名（含子域名）、IP 或 CIDR
+func matchesProxyHost(host string, entries []string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return matchesProxyIP(ip, entries)
+	}
+	for _, entry := range entries {
+		domain := normalizeBlockedDomain(entry)
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesProxyIP 判断 IP 是否匹配列表中的 IP 或 CIDR
+func matchesProxyIP(ip net.IP, entries []string) bool {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if other := net.ParseIP(strings.Trim(entry, "[]")); other != nil && other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnvProxyAddr 判断连接地址是否为环境变量（HTTP_PROXY / HTTPS_PROXY）中配置的代理服务器
+func isEnvProxyAddr(addr string) bool {
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		value := strings.TrimSpace(os.Getenv(name))
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "://") {
+			value = "http://" + value
+		}
+		proxyURL, err := url.Parse(value)
+		if err != nil || proxyURL.Hostname() == "" {
+			continue
+		}
+		port := proxyURL.Port()
+		if port == "" {
+			port = "80"
+			if proxyURL.Scheme == "https" {
+				port = "443"
+			}
+		}
+		if net.JoinHostPort(proxyURL.Hostname(), port) == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"net"
+	"testing"
+)
+
+func TestCheckProxyURL(t *testing.T) {
+	globals.RssUrls = models.Config{ProxyGuard: models.ProxyGuardConfig{
+		AllowHosts: []string{"192.168.1.10", "10.1.0.0/16"},
+		DenyHosts:  []string{"10.1.2.3", "8.8.4.4"},
+	}}
+	defer func() { globals.RssUrls = models.Config{} }()
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://127.0.0.1:8080/", true},
+		{"http://[::1]/", true},
+		{"http://10.0.0.1/", true},
+		{"http://100.64.0.1/", true},
+		{"http://0.0.0.0/", true},
+		{"http://[fd00::1]/", true},
+		{"file:///etc/passwd", true},
+		{"gopher://1.1.1.1/", true},
+		{"http://8.8.8.8/favicon.ico", false},
+		{"http://8.8.4.4/", true},
+		{"http://192.168.1.10/", false},
+		{"http://10.1.9.9/", false},
+		{"http://10.1.2.3/", true},
+	}
+	for _, tt := range tests {
+		err := checkProxyURL(tt.url)
+		if blocked := IsProxyBlocked(err); blocked != tt.blocked {
+			t.Errorf("checkProxyURL(%q) = %v, want blocked %v", tt.url, err, tt.blocked)
+		}
+	}
+}
+
+func TestMatchesProxyHost(t *testing.T) {
+	entries := []string{"example.com", "*.internal.net", "203.0.113.0/24"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"cdn.example.com", true},
+		{"notexample.com", false},
+		{"a.internal.net", true},
+		{"203.0.113.7", true},
+		{"203.0.114.7", false},
+	}
+	for _, tt := range tests {
+		if got := matchesProxyHost(tt.host, entries); got != tt.want {
+			t.Errorf("matchesProxyHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+	if isBlockedProxyIP(net.ParseIP("93.184.216.34")) {
+		t.Error("public IP should not be blocked")
+	}
+}
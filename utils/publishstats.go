@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"feedora/globals"
+	"math"
+	"sort"
+	"time"
+)
+
+// 订阅源发布统计：按条目缓存中的发布时间与首次抓取时间，统计最近若干天每天的条目数、每次抓取带来的新条目数
+// 与发布间隔，用于调整 maxItems 与 refreshCount。同一次抓取中新出现的条目抓取时间相同，据此还原各次抓取
+
+// 发布统计的默认与最大时间窗口（天）
+const (
+	publishStatsDefaultDays = 30
+	publishStatsMaxDays     = 365
+)
+
+// PublishDayCount 某一天的条目数
+type PublishDayCount struct {
+	Date string `json:"date"`
+	// 当天发布的条目数（按条目的发布时间）
+	Published int `json:"published"`
+	// 当天首次抓取到的条目数（按条目的抓取时间）
+	Fetched int `json:"fetched"`
+}
+
+// PublishStats 订阅源的发布统计
+type PublishStats struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	Days  int    `json:"days"`
+	// 缓存中最早的条目抓取时间，早于此时间的数据不在缓存中（统计窗口可能未被完全覆盖）
+	DataSince string `json:"dataSince,omitempty"`
+	// 每天的条目数，按日期升序，没有条目的日期计为 0
+	Daily []PublishDayCount `json:"daily"`
+	// 时间窗口内发布与首次抓取到的条目总数
+	Published int `json:"published"`
+	Fetched   int `json:"fetched"`
+	// 日均发布条目数
+	AvgPerDay float64 `json:"avgPerDay"`
+	// 带来新条目的抓取次数，平均与最多每次带来的新条目数
+	NewItemFetches   int     `json:"newItemFetches"`
+	AvgItemsPerFetch float64 `json:"avgItemsPerFetch"`
+	MaxItemsPerFetch int     `json:"maxItemsPerFetch"`
+	// 相邻两篇条目发布间隔的中位数（分钟）
+	MedianPublishGap float64 `json:"medianPublishGap"`
+	// 按当前时段规则与 refreshCount 估算的每天抓取次数，以及其中带来新条目的比例（0-1）
+	FetchesPerDay   float64 `json:"fetchesPerDay"`
+	ProductiveRatio float64 `json:"productiveRatio"`
+	// 当前配置的 maxItems 与 refreshCount
+	MaxItems     int `json:"maxItems"`
+	RefreshCount int `json:"refreshCount"`
+}
+
+// GetPublishStats 统计订阅源最近 days 天的发布情况（days 超出范围时使用默认值）
+func GetPublishStats(rssURL string, days int) (PublishStats, error) {
+	if days <= 0 || days > publishStatsMaxDays {
+		days = publishStatsDefaultDays
+	}
+	stats := PublishStats{URL: rssURL, Days: days, Daily: make([]PublishDayCount, 0, days)}
+	if source := globals.RssUrls.GetSourceByURL(rssURL); source != nil {
+		stats.MaxItems = source.MaxItems
+		stats.RefreshCount = source.RefreshCount
+	}
+	globals.Lock.RLock()
+	if feed, ok := globals.DbMap[rssURL]; ok {
+		stats.Title = feed.Title
+	}
+	globals.Lock.RUnlock()
+
+	entries, err := DBLoadItemsCacheForURL(rssURL)
+	if err != nil {
+		return stats, err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	start := today.AddDate(0, 0, -(days - 1))
+	daily := make(map[string]*PublishDayCount, days)
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		stats.Daily = append(stats.Daily, PublishDayCount{Date: day.Format("2006-01-02")})
+	}
+	for i := range stats.Daily {
+		daily[stats.Daily[i].Date] = &stats.Daily[i]
+	}
+
+	var earliest time.Time
+	var published []time.Time
+	batches := make(map[string]int)
+	for _, entry := range entries {
+		if fetched, ok := parseTimestamp(entry.FetchTime); ok {
+			if earliest.IsZero() || fetched.Before(earliest) {
+				earliest = fetched
+			}
+			if count := daily[fetched.In(time.Local).Format("2006-01-02")]; count != nil {
+				count.Fetched++
+				stats.Fetched++
+				batches[entry.FetchTime]++
+			}
+		}
+		if pub, ok := parseTimestamp(entry.PubDate); ok {
+			if count := daily[pub.In(time.Local).Format("2006-01-02")]; count != nil {
+				count.Published++
+				stats.Published++
+				published = append(published, pub)
+			}
+		}
+	}
+	if !earliest.IsZero() {
+		stats.DataSince = earliest.In(time.Local).Format(time.RFC3339)
+	}
+
+	stats.AvgPerDay = roundStat(float64(stats.Published) / float64(days))
+	stats.NewItemFetches = len(batches)
+	for _, count := range batches {
+		if count > stats.MaxItemsPerFetch {
+			stats.MaxItemsPerFetch = count
+		}
+	}
+	if stats.NewItemFetches > 0 {
+		stats.AvgItemsPerFetch = roundStat(float64(stats.Fetched) / float64(stats.NewItemFetches))
+	}
+	stats.MedianPublishGap = roundStat(medianGapMinutes(published))
+
+	stats.FetchesPerDay = roundStat(estimateDailyFetches(stats.RefreshCount))
+	if stats.FetchesPerDay > 0 {
+		// 只按缓存覆盖的天数估算抓取次数
+		covered := float64(days)
+		if !earliest.IsZero() && earliest.After(start) {
+			covered = math.Max(now.Sub(earliest).Hours()/24, 1)
+		}
+		stats.ProductiveRatio = math.Round(math.Min(float64(stats.NewItemFetches)/(stats.FetchesPerDay*covered), 1)*100) / 100
+	}
+	return stats, nil
+}
+
+// estimateDailyFetches 按时段规则估算源每天的抓取次数（refreshCount 覆盖各规则的默认次数）
+func estimateDailyFetches(refreshCount int) float64 {
+	total := 0.0
+	for _, s := range globals.RssUrls.Schedules {
+		if s.StartTime == "" || s.EndTime == "" || s.StartTime == s.EndTime {
+			continue
+		}
+		startTime, err1 := time.Parse("15:04:05", s.StartTime)
+		endTime, err2 := time.Parse("15:04:05", s.EndTime)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		count := s.DefaultCount
+		if refreshCount > 0 {
+			count = refreshCount
+		}
+		interval := s.BaseRefresh * count
+		if interval <= 0 {
+			continue
+		}
+		minutes := endTime.Sub(startTime).Minutes()
+		if minutes < 0 {
+			// 跨天的时段
+			minutes += 24 * 60
+		}
+		total += minutes / float64(interval)
+	}
+	return total
+}
+
+// medianGapMinutes 计算相邻时间间隔的中位数（分钟），不足两个时间点时返回 0
+func medianGapMinutes(times []time.Time) float64 {
+	if len(times) < 2 {
+		return 0
+	}
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	gaps := make([]float64, 0, len(sorted)-1)
+	for i := 1; i < len(sorted); i++ {
+		gaps = append(gaps, sorted[i].Sub(sorted[i-1]).Minutes())
+	}
+	sort.Float64s(gaps)
+	mid := len(gaps) / 2
+	if len(gaps)%2 == 0 {
+		return (gaps[mid-1] + gaps[mid]) / 2
+	}
+	return gaps[mid]
+}
+
+// roundStat 保留一位小数
+func roundStat(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"testing"
+	"time"
+)
+
+func TestEstimateDailyFetches(t *testing.T) {
+	globals.RssUrls = models.Config{Schedules: []models.FetchSchedule{
+		{StartTime: "08:00:00", EndTime: "20:00:00", BaseRefresh: 10, DefaultCount: 3},
+		{StartTime: "20:00:00", EndTime: "08:00:00", BaseRefresh: 60, DefaultCount: 2},
+		{StartTime: "", EndTime: "08:00:00", BaseRefresh: 5, DefaultCount: 1},
+	}}
+	defer func() { globals.RssUrls = models.Config{} }()
+
+	if got := estimateDailyFetches(0); got != 24+6 {
+		t.Errorf("estimateDailyFetches(0) = %v, want 30", got)
+	}
+	if got := estimateDailyFetches(1); got != 72+12 {
+		t.Errorf("estimateDailyFetches(1) = %v, want 84", got)
+	}
+}
+
+func TestMedianGapMinutes(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := []time.Time{base.Add(90 * time.Minute), base, base.Add(30 * time.Minute), base.Add(100 * time.Minute)}
+	if got := medianGapMinutes(times); got != 30 {
+		t.Errorf("medianGapMinutes = %v, want 30", got)
+	}
+	if got := medianGapMinutes(times[:1]); got != 0 {
+		t.Errorf("medianGapMinutes(single) = %v, want 0", got)
+	}
+}
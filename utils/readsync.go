@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"feedora/globals"
+	"log"
+	"time"
+)
+
+// 多端已读状态同步：每次已读/未读变化都记入变化日志（每个链接只保留最近一次，seq 递增作为游标），
+// 客户端凭上次拿到的游标增量拉取其他设备或标签页的变化，并批量提交本地变化。
+// 同一链接的变化按时间戳合并，时间较新者生效，多端最终收敛到相同状态而不会互相覆盖
+
+// ReadStateSyncChange 单条已读状态变化
+type ReadStateSyncChange struct {
+	Link string `json:"link"`
+	Read bool   `json:"read"`
+	// 变化时间（Unix 毫秒）
+	At int64 `json:"at"`
+}
+
+// ReadStateSyncResult 同步结果
+type ReadStateSyncResult struct {
+	// 新游标，下次同步时回传
+	Cursor int64 `json:"cursor"`
+	// 为 true 时 changes 是全部已读条目的快照，客户端应以其替换本地状态（未提供游标或游标已失效时）
+	Full bool `json:"full"`
+	// 游标之后的变化（全量时为全部已读条目）
+	Changes []ReadStateSyncChange `json:"changes"`
+	// 本次提交中被接受的变化数，其余变化早于服务端已有的变化而被忽略
+	Accepted int `json:"accepted"`
+}
+
+// readStateChangedAt 各链接最近一次已读状态变化的时间（毫秒），受 globals.ReadStateLock 保护
+var readStateChangedAt = make(map[string]int64)
+
+// loadReadStateChangeTimes 加载各链接最近一次变化的时间，用于合并时判断先后
+func loadReadStateChangeTimes() {
+	times, err := DBLoadReadStateChangeTimes()
+	if err != nil {
+		log.Printf("读取已读状态变化记录失败: %v", err)
+		return
+	}
+	globals.ReadStateLock.Lock()
+	readStateChangedAt = times
+	globals.ReadStateLock.Unlock()
+}
+
+// noteReadStateChanges 记录本地产生的已读状态变化（调用方需持有 ReadStateLock），返回需要写入变化日志的记录
+func noteReadStateChanges(links []string, read bool, at int64) []ReadStateSyncChange {
+	changes := make([]ReadStateSyncChange, 0, len(links))
+	for _, link := range links {
+		readStateChangedAt[link] = at
+		changes = append(changes, ReadStateSyncChange{Link: link, Read: read, At: at})
+	}
+	return changes
+}
+
+// saveReadStateChanges 写入变化日志
+func saveReadStateChanges(changes []ReadStateSyncChange) {
+	if err := DBSaveReadStateChanges(changes); err != nil {
+		log.Printf("保存已读状态变化记录失败: %v", err)
+	}
+}
+
+// lastReadStateChange 链接最近一次变化的时间（调用方需持有 ReadStateLock）
+// 升级前标记的已读状态没有变化记录，按标记已读的时间计
+func lastReadStateChange(link string) int64 {
+	if at, ok := readStateChangedAt[link]; ok {
+		return at
+	}
+	if readAt, ok := globals.ReadState[link]; ok {
+		return readAt * 1000
+	}
+	return 0
+}
+
+// SyncReadState 合并客户端提交的变化并返回游标之后的变化
+// cursor 为 0 或大于服务端当前游标（如数据库被重置）时返回全部已读条目的快照
+func SyncReadState(cursor int64, changes []ReadStateSyncChange) (ReadStateSyncResult, error) {
+	var result ReadStateSyncResult
+	now := time.Now().UnixMilli()
+
+	var accepted []ReadStateSyncChange
+	readStates := make(map[string]int64)
+	var unreadLinks []string
+	globals.ReadStateLock.Lock()
+	for _, change := range changes {
+		if change.Link == "" {
+			continue
+		}
+		// 时钟超前的设备不能让自己的变化永远胜出
+		if change.At > now || change.At <= 0 {
+			change.At = now
+		}
+		if change.At <= lastReadStateChange(change.Link) {
+			continue
+		}
+		readStateChangedAt[change.Link] = change.At
+		if change.Read {
+			globals.ReadState[change.Link] = change.At / 1000
+			readStates[change.Link] = change.At / 1000
+		} else {
+			delete(globals.ReadState, change.Link)
+			unreadLinks = append(unreadLinks, change.Link)
+		}
+		accepted = append(accepted, change)
+	}
+	globals.ReadStateLock.Unlock()
+	result.Accepted = len(accepted)
+
+	if err := DBSaveReadStateBatch(readStates); err != nil {
+		return result, err
+	}
+	if err := DBDeleteReadStateBatch(unreadLinks); err != nil {
+		return result, err
+	}
+	if err := DBSaveReadStateChanges(accepted); err != nil {
+		return result, err
+	}
+
+	since, latest, err := DBListReadStateChangesSince(cursor)
+	if err != nil {
+		return result, err
+	}
+	result.Cursor = latest
+	if cursor > 0 && cursor <= latest {
+		result.Changes = since
+		return result, nil
+	}
+
+	result.Full = true
+	result.Changes = make([]ReadStateSyncChange, 0)
+	globals.ReadStateLock.RLock()
+	for link := range globals.ReadState {
+		result.Changes = append(result.Changes, ReadStateSyncChange{Link: link, Read: true, At: lastReadStateChange(link)})
+	}
+	globals.ReadStateLock.RUnlock()
+	return result, nil
+}
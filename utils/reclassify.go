@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+)
+
+// ReclassifyResult 重新分类的结果统计
+type ReclassifyResult struct {
+	URL      string `json:"url"`
+	Cleared  int    `json:"cleared"`
+	Total    int    `json:"total"`
+	Kept     int    `json:"kept"`
+	Filtered int    `json:"filtered"`
+}
+
+// reclassifyKey 条目参与分类时使用的链接（后处理前的原始链接）
+func reclassifyKey(item models.Item) string {
+	if item.OriginalLink != "" {
+		return item.OriginalLink
+	}
+	return item.Link
+}
+
+// ReclassifySource 清除指定源的分类缓存，并立即对当前展示的条目重新分类
+// 不重新抓取源，分类输入使用后处理与翻译前的原始链接和标题，结果按原链接写回展示条目
+func ReclassifySource(rssURL string) (ReclassifyResult, error) {
+	result := ReclassifyResult{URL: rssURL}
+	if !ShouldFilter(rssURL) {
+		return result, fmt.Errorf("源未启用分类过滤: %s", rssURL)
+	}
+
+	globals.Lock.RLock()
+	feed, ok := globals.DbMap[rssURL]
+	globals.Lock.RUnlock()
+	if !ok {
+		return result, fmt.Errorf("源尚未加载: %s", rssURL)
+	}
+
+	result.Cleared = ClearClassifyCacheForSource(rssURL)
+
+	// 还原为分类时的输入：原始链接、原始标题，清空旧的分类结果
+	inputs := make([]models.Item, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		input := item
+		input.Link = reclassifyKey(item)
+		if item.OriginalTitle != "" {
+			input.Title = item.OriginalTitle
+		}
+		input.Category = ""
+		input.Categories = nil
+		input.Relevance = nil
+		input.Confidence = nil
+		inputs = append(inputs, input)
+	}
+	result.Total = len(inputs)
+	if len(inputs) == 0 {
+		return result, nil
+	}
+
+	log.Printf("[重新分类] 源 [%s]: 清除缓存 %d 条，重新分类 %d 篇", rssURL, result.Cleared, len(inputs))
+	classified := ClassifyItems(inputs, rssURL)
+
+	passed := make(map[string]models.Item, len(classified))
+	for _, item := range classified {
+		passed[item.Link] = item
+	}
+
+	// 写回时重新读取 DbMap，期间源可能已被更新，仅处理仍在展示中的条目
+	globals.Lock.Lock()
+	current, ok := globals.DbMap[rssURL]
+	if !ok {
+		globals.Lock.Unlock()
+		return result, fmt.Errorf("源已被移除: %s", rssURL)
+	}
+	items := make([]models.Item, 0, len(current.Items))
+	var filteredLinks []string
+	for _, item := range current.Items {
+		key := reclassifyKey(item)
+		newItem, kept := passed[key]
+		if !kept {
+			filteredLinks = append(filteredLinks, key)
+			continue
+		}
+		item.Category = newItem.Category
+		item.Categories = newItem.Categories
+		item.Relevance = newItem.Relevance
+		item.Confidence = newItem.Confidence
+		items = append(items, item)
+	}
+	current.FilteredCount += len(current.Items) - len(items)
+	current.Items = items
+	globals.DbMap[rssURL] = current
+	globals.Lock.Unlock()
+
+	if len(filteredLinks) > 0 {
+		recordFilteredLinks(rssURL, filteredLinks)
+	}
+	result.Kept = len(items)
+	result.Filtered = len(filteredLinks)
+	log.Printf("[重新分类] 源 [%s]: 保留 %d 篇，过滤 %d 篇", rssURL, result.Kept, result.Filtered)
+	return result, nil
+}
+
+// ReclassifyAll 对所有启用 AI 分类且已加载的源重新分类，返回各源的结果
+func ReclassifyAll() []ReclassifyResult {
+	var urls []string
+	for _, source := range globals.RssUrls.Sources {
+		if ShouldUseAI(source.URL) {
+			urls = append(urls, source.URL)
+		}
+	}
+
+	results := make([]ReclassifyResult, 0, len(urls))
+	for _, url := range urls {
+		res, err := ReclassifySource(url)
+		if err != nil {
+			log.Printf("[重新分类] %v", err)
+			continue
+		}
+		results = append(results, res)
+	}
+	return results
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// 订阅地址的永久重定向（301 / 308）：抓取时记录重定向链，全部为永久重定向且新地址抓取成功时，
+// 将配置中的源地址（及文件夹、分组、通知规则中的引用）更新为新地址，并把按源地址保存的缓存迁移过去
+
+// redirectRecorderKey 抓取请求上下文中重定向记录的键
+type redirectRecorderKey struct{}
+
+// redirectRecorder 记录一次抓取经过的重定向
+type redirectRecorder struct {
+	// 从原地址开始经连续的永久重定向到达的地址
+	target string
+	// 重定向链中出现过临时重定向，之后的地址不再记录
+	temporary bool
+}
+
+var (
+	// 抓取时检测到的永久重定向: map[原地址] -> 新地址，在源处理完成后应用
+	permanentRedirects     = make(map[string]string)
+	permanentRedirectsLock sync.Mutex
+)
+
+func init() {
+	globals.Fp.Client.CheckRedirect = checkFeedRedirect
+}
+
+// checkFeedRedirect 与默认策略一样最多跟随 10 次重定向，并为订阅抓取请求记录永久重定向
+func checkFeedRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	recorder, ok := req.Context().Value(redirectRecorderKey{}).(*redirectRecorder)
+	if !ok || recorder.temporary {
+		return nil
+	}
+	if req.Response != nil && (req.Response.StatusCode == http.StatusMovedPermanently || req.Response.StatusCode == http.StatusPermanentRedirect) {
+		recorder.target = req.URL.String()
+	} else {
+		recorder.temporary = true
+	}
+	return nil
+}
+
+// fetchHTTPFeed 抓取 RSS / Atom / JSON Feed 地址，配置中的源被永久重定向时记录新地址
+func fetchHTTPFeed(source models.Source) (*gofeed.Feed, error) {
+	recorder := &redirectRecorder{}
+	ctx := withSourceTLS(context.WithValue(context.Background(), redirectRecorderKey{}, recorder), source)
+	feed, err := fetchFeedURL(ctx, source.URL)
+	if err != nil {
+		return nil, err
+	}
+	if recorder.target != "" && recorder.target != source.URL && globals.RssUrls.GetSourceByURL(source.URL) != nil {
+		permanentRedirectsLock.Lock()
+		permanentRedirects[source.URL] = recorder.target
+		permanentRedirectsLock.Unlock()
+	}
+	return feed, nil
+}
+
+// takePermanentRedirect 取出源在本次抓取中检测到的永久重定向地址
+func takePermanentRedirect(rssURL string) (string, bool) {
+	permanentRedirectsLock.Lock()
+	defer permanentRedirectsLock.Unlock()
+	target, ok := permanentRedirects[rssURL]
+	delete(permanentRedirects, rssURL)
+	return target, ok
+}
+
+// migrateSourceURL 将源地址更新为永久重定向后的新地址：先写入配置，再迁移按源地址保存的运行时数据
+// 配置保存后由文件监听防抖重新加载，迁移在重新加载前完成，新地址的卡片保留原有条目、缓存与已读状态
+func migrateSourceURL(oldURL, newURL string) {
+	if globals.RssUrls.GetSourceByURL(newURL) != nil {
+		log.Printf("[永久重定向] 源 %s 已永久重定向到 %s，但新地址已在订阅列表中，未自动更新", oldURL, newURL)
+		return
+	}
+	if err := updateConfig(func(conf *models.Config) error {
+		return renameSourceURL(conf, oldURL, newURL)
+	}); err != nil {
+		log.Printf("[永久重定向] 源 %s 已永久重定向到 %s，自动更新地址失败: %v", oldURL, newURL, err)
+		return
+	}
+
+	globals.Lock.Lock()
+	if feed, ok := globals.DbMap[oldURL]; ok {
+		feed.Link = newURL
+		globals.DbMap[newURL] = feed
+		delete(globals.DbMap, oldURL)
+	}
+	globals.Lock.Unlock()
+
+	globals.ItemsCacheLock.Lock()
+	if items, ok := globals.ItemsCache[oldURL]; ok {
+		globals.ItemsCache[newURL] = items
+		delete(globals.ItemsCache, oldURL)
+	}
+	globals.ItemsCacheLock.Unlock()
+
+	globals.DisplayOverridesLock.Lock()
+	if flags, ok := globals.DisplayOverrides[oldURL]; ok {
+		globals.DisplayOverrides[newURL] = flags
+		delete(globals.DisplayOverrides, oldURL)
+	}
+	globals.DisplayOverridesLock.Unlock()
+
+	clearSourceError(oldURL)
+	if err := DBRenameSourceURL(oldURL, newURL); err != nil {
+		log.Printf("[永久重定向] 迁移源 %s 的缓存记录失败: %v", oldURL, err)
+	}
+	log.Printf("[永久重定向] 源地址已更新: %s → %s", oldURL, newURL)
+}
+
+// renameSourceURL 在配置中将源地址及其在文件夹、分组布局、通知规则中的引用替换为新地址
+// 地址使用了环境变量占位符时无法安全改写，需要手动更新
+func renameSourceURL(conf *models.Config, oldURL, newURL string) error {
+	index := -1
+	for i, source := range globals.RssUrls.Sources {
+		if source.URL == oldURL {
+			index = i
+			break
+		}
+	}
+	// 原始配置与展开后的配置源顺序一致
+	if index < 0 || index >= len(conf.Sources) {
+		return fmt.Errorf("源不在配置中")
+	}
+	if conf.Sources[index].URL != oldURL {
+		return fmt.Errorf("地址使用了环境变量占位符（%s），请手动更新", conf.Sources[index].URL)
+	}
+	conf.Sources[index].URL = newURL
+
+	for i := range conf.Folders {
+		for j := range conf.Folders[i].Entries {
+			if conf.Folders[i].Entries[j].SourceURL == oldURL {
+				conf.Folders[i].Entries[j].SourceURL = newURL
+			}
+		}
+	}
+	for i := range conf.LayoutGroups {
+		for j := range conf.LayoutGroups[i].Items {
+			if conf.LayoutGroups[i].Items[j].SourceURL == oldURL {
+				conf.LayoutGroups[i].Items[j].SourceURL = newURL
+			}
+		}
+	}
+	for i := range conf.Notification.Rules {
+		for j, url := range conf.Notification.Rules[i].Sources {
+			if strings.TrimSpace(url) == oldURL {
+				conf.Notification.Rules[i].Sources[j] = newURL
+			}
+		}
+	}
+	return nil
+}
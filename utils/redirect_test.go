@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchHTTPFeedRecordsPermanentRedirect(t *testing.T) {
+	const rss = `<?xml version="1.0"?><rss version="2.0"><channel><title>t</title><item><title>a</title><link>https://a.example.com/1</link></item></channel></rss>`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(rss))
+	})
+	mux.Handle("/moved", http.RedirectHandler("/feed", http.StatusMovedPermanently))
+	mux.Handle("/temp", http.RedirectHandler("/feed", http.StatusFound))
+	mux.Handle("/chain", http.RedirectHandler("/temp", http.StatusPermanentRedirect))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/feed", ""},
+		{"/moved", "/feed"},
+		{"/temp", ""},
+		{"/chain", "/temp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			source := models.Source{URL: server.URL + tt.path}
+			globals.RssUrls = models.Config{Sources: []models.Source{source}}
+			defer func() { globals.RssUrls = models.Config{} }()
+
+			if _, err := fetchHTTPFeed(source); err != nil {
+				t.Fatalf("fetchHTTPFeed: %v", err)
+			}
+			target, ok := takePermanentRedirect(source.URL)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("unexpected redirect to %s", target)
+				}
+				return
+			}
+			if target != server.URL+tt.want {
+				t.Errorf("redirect target = %q, want %q", target, server.URL+tt.want)
+			}
+		})
+	}
+}
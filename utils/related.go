@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"math"
+	"sort"
+)
+
+// 相关条目：在其他订阅源已收录的条目中查找与指定条目报道同一事件的条目，依据依次为
+// 规范化后相同的链接、标题关键词重合度，以及（启用语义向量时）向量相似度，便于在不同媒体的报道间跳转
+
+// 相关条目数量的默认与最大值，以及标题相似的判定条件
+const (
+	relatedDefaultLimit   = 10
+	relatedMaxLimit       = 50
+	relatedTitleThreshold = 0.5
+	relatedTitleMinShared = 2
+)
+
+// 相关依据
+const (
+	relatedReasonURL       = "url"
+	relatedReasonTitle     = "title"
+	relatedReasonEmbedding = "embedding"
+)
+
+// RelatedItem 相关条目
+type RelatedItem struct {
+	Link    string `json:"link"`
+	Title   string `json:"title"`
+	Source  string `json:"source"`
+	PubDate string `json:"pubDate,omitempty"`
+	Read    bool   `json:"read"`
+	// 相关依据：url（链接相同）、title（标题相似）、embedding（语义相似）
+	Reason string `json:"reason"`
+	// 相似度（0-1），链接相同时为 1
+	Score float64 `json:"score"`
+}
+
+// RelatedItems 条目的相关条目
+type RelatedItems struct {
+	Link    string        `json:"link"`
+	Title   string        `json:"title"`
+	Source  string        `json:"source"`
+	Related []RelatedItem `json:"related"`
+}
+
+// relatedCandidate 其他源中的候选条目
+type relatedCandidate struct {
+	item      models.Item
+	sourceURL string
+}
+
+// GetRelatedItems 获取其他订阅源中与条目相关的条目，按相似度降序，最多 limit 条（超出范围时使用默认值）
+// 条目不在已抓取的条目中时返回 false
+func GetRelatedItems(link string, limit int) (RelatedItems, bool) {
+	if limit <= 0 || limit > relatedMaxLimit {
+		limit = relatedDefaultLimit
+	}
+	target, targetSource, ok := findCachedItem(link)
+	if !ok {
+		return RelatedItems{}, false
+	}
+	result := RelatedItems{Link: link, Title: target.Title, Source: getSourceDisplayName(targetSource), Related: []RelatedItem{}}
+
+	var candidates []relatedCandidate
+	globals.Lock.RLock()
+	for sourceURL, feed := range globals.DbMap {
+		if sourceURL == targetSource {
+			continue
+		}
+		for _, item := range feed.Items {
+			if item.Link != "" && item.Link != link {
+				candidates = append(candidates, relatedCandidate{item: item, sourceURL: sourceURL})
+			}
+		}
+	}
+	globals.Lock.RUnlock()
+
+	targetURL := normalizeCitationURL(relatedItemURL(target))
+	targetKeywords := extractFollowKeywords(target.Title)
+	var targetVector []float32
+	threshold := 0.0
+	if embeddingEnabled() {
+		ensureEmbeddingsLoaded(globals.RssUrls.Embedding.GetModel())
+		targetVector, _ = getItemEmbedding(link)
+		threshold = globals.RssUrls.Embedding.GetThreshold()
+	}
+
+	// 同一链接可能被多个源收录（如文件夹与单独的源），只保留相似度最高的一条
+	best := make(map[string]RelatedItem)
+	for _, c := range candidates {
+		reason, score := relatedMatch(targetURL, targetKeywords, targetVector, threshold, c.item)
+		if reason == "" {
+			continue
+		}
+		if existing, ok := best[c.item.Link]; ok && existing.Score >= score {
+			continue
+		}
+		best[c.item.Link] = RelatedItem{
+			Link:    c.item.Link,
+			Title:   c.item.Title,
+			Source:  getSourceDisplayName(c.sourceURL),
+			PubDate: c.item.PubDate,
+			Read:    IsRead(c.item.Link),
+			Reason:  reason,
+			Score:   score,
+		}
+	}
+
+	for _, item := range best {
+		result.Related = append(result.Related, item)
+	}
+	sort.Slice(result.Related, func(i, j int) bool {
+		a, b := result.Related[i], result.Related[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Link < b.Link
+	})
+	if len(result.Related) > limit {
+		result.Related = result.Related[:limit]
+	}
+	return result, true
+}
+
+// relatedMatch 判断候选条目是否与目标条目相关，返回相关依据与相似度（不相关时依据为空）
+func relatedMatch(targetURL string, targetKeywords []string, targetVector []float32, threshold float64, item models.Item) (string, float64) {
+	if targetURL != "" && normalizeCitationURL(relatedItemURL(item)) == targetURL {
+		return relatedReasonURL, 1
+	}
+	reason, score := "", 0.0
+	if similarity := titleSimilarity(targetKeywords, extractFollowKeywords(item.Title)); similarity >= relatedTitleThreshold {
+		reason, score = relatedReasonTitle, similarity
+	}
+	if targetVector != nil {
+		if vector, ok := getItemEmbedding(item.Link); ok {
+			if similarity := vectorCosine(targetVector, vector); similarity >= threshold && similarity > score {
+				reason, score = relatedReasonEmbedding, similarity
+			}
+		}
+	}
+	return reason, math.Round(score*100) / 100
+}
+
+// relatedItemURL 返回条目的原始链接（后处理改写前），用于比对不同源收录的同一文章
+func relatedItemURL(item models.Item) string {
+	if item.OriginalLink != "" {
+		return item.OriginalLink
+	}
+	return item.Link
+}
+
+// titleSimilarity 计算两组标题关键词的重合度（Dice 系数），共同关键词少于 2 个时视为不相似
+func titleSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, keyword := range a {
+		set[keyword] = true
+	}
+	shared := 0
+	for _, keyword := range b {
+		if set[keyword] {
+			shared++
+		}
+	}
+	if shared < relatedTitleMinShared {
+		return 0
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
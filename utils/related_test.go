@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"testing"
+)
+
+func TestGetRelatedItems(t *testing.T) {
+	saved := globals.DbMap
+	defer func() { globals.DbMap = saved }()
+	globals.DbMap = map[string]models.Feed{
+		"https://a.example.com/feed": {Items: []models.Item{
+			{Link: "https://a.example.com/gpt5", Title: "OpenAI releases GPT-5 with longer context"},
+			{Link: "https://a.example.com/other", Title: "OpenAI hires new CFO"},
+		}},
+		"https://b.example.com/feed": {Items: []models.Item{
+			{Link: "https://b.example.com/1", Title: "GPT-5 released by OpenAI: longer context window"},
+			{Link: "https://b.example.com/2", Title: "Rust 1.80 released"},
+			{Link: "https://b.example.com/3?utm_source=rss", Title: "A link post", OriginalLink: "https://www.a.example.com/gpt5/?utm_source=rss"},
+		}},
+	}
+
+	related, ok := GetRelatedItems("https://a.example.com/gpt5", 0)
+	if !ok {
+		t.Fatal("GetRelatedItems() did not find the item")
+	}
+	if len(related.Related) != 2 {
+		t.Fatalf("GetRelatedItems() returned %d items, want 2: %+v", len(related.Related), related.Related)
+	}
+	if got := related.Related[0]; got.Link != "https://b.example.com/3?utm_source=rss" || got.Reason != relatedReasonURL || got.Score != 1 {
+		t.Errorf("first related item = %+v, want url match", got)
+	}
+	if got := related.Related[1]; got.Link != "https://b.example.com/1" || got.Reason != relatedReasonTitle {
+		t.Errorf("second related item = %+v, want title match", got)
+	}
+
+	if _, ok := GetRelatedItems("https://missing.example.com/", 0); ok {
+		t.Error("GetRelatedItems() found a missing item")
+	}
+}
@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"log"
+	"sync"
+	"time"
+)
+
+// RetentionCounts 数据保留清理删除的数据条数
+type RetentionCounts struct {
+	Items            int `json:"items"`
+	ReadState        int `json:"readState"`
+	ClassifyCache    int `json:"classifyCache"`
+	PostProcessCache int `json:"postProcessCache"`
+	FollowItems      int `json:"followItems"`
+	Follows          int `json:"follows"`
+	Translations     int `json:"translations"`
+	Corrections      int `json:"corrections"`
+	ExternalIDs      int `json:"externalIds"`
+	LLMUsage         int `json:"llmUsage"`
+	ConfigHistory    int `json:"configHistory"`
+}
+
+// add 累加删除条数
+func (c *RetentionCounts) add(other RetentionCounts) {
+	c.Items += other.Items
+	c.ReadState += other.ReadState
+	c.ClassifyCache += other.ClassifyCache
+	c.PostProcessCache += other.PostProcessCache
+	c.FollowItems += other.FollowItems
+	c.Follows += other.Follows
+	c.Translations += other.Translations
+	c.Corrections += other.Corrections
+	c.ExternalIDs += other.ExternalIDs
+	c.LLMUsage += other.LLMUsage
+	c.ConfigHistory += other.ConfigHistory
+}
+
+// RetentionStats 数据保留清理统计
+type RetentionStats struct {
+	Enabled bool `json:"enabled"`
+	Days    int  `json:"days"`
+	// 最近一次清理时间（未执行过时为空）
+	LastRunAt string `json:"lastRunAt,omitempty"`
+	// 最近一次清理的删除条数
+	LastDeleted RetentionCounts `json:"lastDeleted"`
+	// 自启动以来累计删除条数
+	TotalDeleted RetentionCounts `json:"totalDeleted"`
+}
+
+var (
+	retentionStats     RetentionStats
+	retentionStatsLock sync.Mutex
+)
+
+// retentionCutoff 获取数据保留的截止时间，未启用时返回 false
+func retentionCutoff() (time.Time, bool) {
+	config := globals.RssUrls.Retention
+	if !config.Enabled {
+		return time.Time{}, false
+	}
+	return time.Now().AddDate(0, 0, -config.GetDays()), true
+}
+
+// autoReadDays 获取源的自动标记已读天数，0 表示不自动标记
+func autoReadDays(source models.Source) int {
+	switch {
+	case source.AutoReadDays < 0:
+		return 0
+	case source.AutoReadDays > 0:
+		return source.AutoReadDays
+	default:
+		return globals.RssUrls.Retention.AutoReadDays
+	}
+}
+
+// autoMarkReadExpired 将早于各源自动标记已读天数的未读条目标记为已读，返回标记的条目数
+func autoMarkReadExpired() int {
+	now := time.Now()
+	var links []string
+	globals.Lock.RLock()
+	for _, source := range globals.RssUrls.Sources {
+		days := autoReadDays(source)
+		if days <= 0 {
+			continue
+		}
+		feed, ok := globals.DbMap[source.URL]
+		if !ok {
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -days)
+		for _, item := range feed.Items {
+			if item.Link != "" && isItemExpired(item, cutoff) && !IsRead(item.Link) {
+				links = append(links, item.Link)
+			}
+		}
+	}
+	globals.Lock.RUnlock()
+
+	if len(links) > 0 {
+		MarkReadBatch(links)
+		log.Printf("[自动已读] 已将 %d 个过期条目标记为已读", len(links))
+	}
+	return len(links)
+}
+
+// isItemExpired 判断条目是否早于截止时间（发布时间优先，其次抓取时间；无时间的条目保留）
+func isItemExpired(item models.Item, cutoff time.Time) bool {
+	t, ok := getItemSortTime(item)
+	return ok && t.Before(cutoff)
+}
+
+// sourceRetentionDays 获取源的条目保留天数，0 表示不限制
+func sourceRetentionDays(rssURL string) int {
+	if source := globals.RssUrls.GetSourceByURL(rssURL); source != nil && source.RetentionDays > 0 {
+		return source.RetentionDays
+	}
+	return 0
+}
+
+// itemsCutoff 获取源条目的保留截止时间：全局数据保留与源 retentionDays 中较晚的一个，均未设置时返回 false
+func itemsCutoff(rssURL string) (time.Time, bool) {
+	cutoff, ok := retentionCutoff()
+	if days := sourceRetentionDays(rssURL); days > 0 {
+		if sourceCutoff := time.Now().AddDate(0, 0, -days); !ok || sourceCutoff.After(cutoff) {
+			return sourceCutoff, true
+		}
+	}
+	return cutoff, ok
+}
+
+// dropExpiredItems 丢弃早于源保留截止时间的条目，避免已清理的条目在下次抓取时重新出现
+func dropExpiredItems(rssURL string, items []models.Item) []models.Item {
+	cutoff, ok := itemsCutoff(rssURL)
+	if !ok {
+		return items
+	}
+	return keepUnexpiredItems(items, cutoff)
+}
+
+// keepUnexpiredItems 返回不早于截止时间的条目
+func keepUnexpiredItems(items []models.Item, cutoff time.Time) []models.Item {
+	kept := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		if !isItemExpired(item, cutoff) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// pruneRetainedSources 删除设置了 retentionDays 的源中超过保留天数的条目（卡片与条目缓存），与全局数据保留是否启用无关
+func pruneRetainedSources() {
+	now := time.Now()
+	var displayed, cached int
+	for _, source := range globals.RssUrls.Sources {
+		if source.RetentionDays <= 0 {
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -source.RetentionDays)
+
+		globals.Lock.Lock()
+		if feed, ok := globals.DbMap[source.URL]; ok {
+			if kept := keepUnexpiredItems(feed.Items, cutoff); len(kept) < len(feed.Items) {
+				displayed += len(feed.Items) - len(kept)
+				feed.Items = kept
+				globals.DbMap[source.URL] = feed
+			}
+		}
+		globals.Lock.Unlock()
+
+		if items, ok := GetItemsCache(source.URL); ok {
+			if kept := keepUnexpiredItems(items, cutoff); len(kept) < len(items) {
+				cached += len(items) - len(kept)
+				if len(kept) == 0 {
+					DeleteItemsCache(source.URL)
+				} else {
+					SetItemsCache(source.URL, kept)
+				}
+			}
+		}
+	}
+	if displayed > 0 || cached > 0 {
+		log.Printf("[条目保留] 已删除超过源保留天数的条目: 卡片 %d 条，条目缓存 %d 条", displayed, cached)
+	}
+}
+
+// purgeExpiredData 数据保留模式：彻底删除早于保留天数的条目、已读状态、分类与后处理缓存、故事追踪记录
+func purgeExpiredData() {
+	cutoff, ok := retentionCutoff()
+	if !ok {
+		return
+	}
+
+	var counts RetentionCounts
+	purgedLinks := make(map[string]bool)
+	// 仍保留的条目链接，按时间清理数据库残留记录时跳过
+	liveLinks := make(map[string]bool)
+
+	// 条目（DbMap 中的展示条目）
+	globals.Lock.Lock()
+	for url, feed := range globals.DbMap {
+		kept := make([]models.Item, 0, len(feed.Items))
+		for _, item := range feed.Items {
+			if isItemExpired(item, cutoff) {
+				purgedLinks[item.Link] = true
+				if item.OriginalLink != "" {
+					purgedLinks[item.OriginalLink] = true
+				}
+				continue
+			}
+			kept = append(kept, item)
+			markLiveLink(liveLinks, item)
+		}
+		if len(kept) < len(feed.Items) {
+			feed.Items = kept
+			globals.DbMap[url] = feed
+		}
+	}
+	globals.Lock.Unlock()
+
+	// 条目缓存
+	expiredCaches := make(map[string][]models.Item)
+	globals.ItemsCacheLock.RLock()
+	for url, items := range globals.ItemsCache {
+		kept := make([]models.Item, 0, len(items))
+		for _, item := range items {
+			if isItemExpired(item, cutoff) {
+				purgedLinks[item.Link] = true
+				if item.OriginalLink != "" {
+					purgedLinks[item.OriginalLink] = true
+				}
+				continue
+			}
+			kept = append(kept, item)
+			markLiveLink(liveLinks, item)
+		}
+		if len(kept) < len(items) {
+			expiredCaches[url] = kept
+		}
+	}
+	globals.ItemsCacheLock.RUnlock()
+	for url, kept := range expiredCaches {
+		if len(kept) == 0 {
+			DeleteItemsCache(url)
+		} else {
+			SetItemsCache(url, kept)
+		}
+	}
+	counts.Items = len(purgedLinks)
+	if len(purgedLinks) > 0 {
+		links := make([]string, 0, len(purgedLinks))
+		for link := range purgedLinks {
+			links = append(links, link)
+		}
+		if err := DBDeleteItemUIDsForLinks(links); err != nil {
+			log.Printf("[数据保留] 删除外部ID映射失败: %v", err)
+		}
+		purgeEmbeddings(links)
+	}
+	// 外部ID映射：数据库中不在内存的条目（如已从订阅源消失）按UID分配时间清理
+	if n, err := DBDeleteItemUIDsOlderThan(cutoff.Unix(), liveLinks); err != nil {
+		log.Printf("[数据保留] 删除外部ID映射失败: %v", err)
+	} else {
+		counts.ExternalIDs = n
+	}
+
+	// 已读状态：标记已读的时间早于截止时间，或对应条目已被清理
+	var readLinks []string
+	globals.ReadStateLock.Lock()
+	for link, readAt := range globals.ReadState {
+		if readAt < cutoff.Unix() || purgedLinks[link] {
+			delete(globals.ReadState, link)
+			readLinks = append(readLinks, link)
+		}
+	}
+	globals.ReadStateLock.Unlock()
+	if len(readLinks) > 0 {
+		if err := DBDeleteReadStateBatch(readLinks); err != nil {
+			log.Printf("[数据保留] 删除已读状态失败: %v", err)
+		}
+	}
+	counts.ReadState = len(readLinks)
+	// 数据库中可能残留未加载到内存的记录
+	if n, err := DBDeleteReadStateOlderThan(cutoff.Unix(), nil); err != nil {
+		log.Printf("[数据保留] 删除已读状态失败: %v", err)
+	} else {
+		counts.ReadState += n
+	}
+	// 已读状态变化记录：变化时间早于截止时间
+	globals.ReadStateLock.Lock()
+	for link, changedAt := range readStateChangedAt {
+		if changedAt < cutoff.UnixMilli() {
+			delete(readStateChangedAt, link)
+		}
+	}
+	globals.ReadStateLock.Unlock()
+	if _, err := DBDeleteReadStateChangesOlderThan(cutoff.UnixMilli()); err != nil {
+		log.Printf("[数据保留] 删除已读状态变化记录失败: %v", err)
+	}
+
+	// 分类缓存：被清理条目的分类结果，以及不再对应任何条目且分类时间早于截止时间的结果
+	var classifyLinks []string
+	globals.ClassifyCacheLock.Lock()
+	for link, entry := range globals.ClassifyCache {
+		stale := !liveLinks[link] && entry.ClassifiedAt > 0 && entry.ClassifiedAt < cutoff.Unix()
+		if purgedLinks[link] || stale {
+			delete(globals.ClassifyCache, link)
+			classifyLinks = append(classifyLinks, link)
+		}
+	}
+	globals.ClassifyCacheLock.Unlock()
+	if len(classifyLinks) > 0 {
+		if err := DBDeleteClassifyCacheBatch(classifyLinks); err != nil {
+			log.Printf("[数据保留] 删除分类缓存失败: %v", err)
+		}
+	}
+	counts.ClassifyCache = len(classifyLinks)
+	if n, err := DBDeleteClassifyCacheOlderThan(cutoff.Unix(), liveLinks); err != nil {
+		log.Printf("[数据保留] 删除分类缓存失败: %v", err)
+	} else {
+		counts.ClassifyCache += n
+	}
+
+	// 后处理缓存：被清理条目或处理时间早于截止时间的结果
+	var postProcessLinks []string
+	PostProcessCacheLock.Lock()
+	for link, entry := range PostProcessCache {
+		processedAt, ok := parseTimestamp(entry.ProcessedAt)
+		if purgedLinks[link] || (ok && processedAt.Before(cutoff)) {
+			delete(PostProcessCache, link)
+			postProcessLinks = append(postProcessLinks, link)
+		}
+	}
+	PostProcessCacheLock.Unlock()
+	if len(postProcessLinks) > 0 {
+		if err := DBDeletePostProcessCacheBatch(postProcessLinks); err != nil {
+			log.Printf("[数据保留] 删除后处理缓存失败: %v", err)
+		}
+	}
+	counts.PostProcessCache = len(postProcessLinks)
+
+	// 故事追踪：过期的线索条目，以及创建时间早于截止时间的已归档追踪
+	counts.FollowItems, counts.Follows = purgeExpiredFollows(cutoff.Unix())
+
+	// 翻译缓存：翻译时间早于截止时间的译文
+	counts.Translations = purgeExpiredTranslations(cutoff.Unix())
+
+	// 人工修正：修正时间早于截止时间或对应条目已被清理（包含条目链接与标题）
+	counts.Corrections = purgeExpiredCorrections(cutoff.Unix(), purgedLinks)
+
+	// LLM 用量：早于截止日期的按日统计
+	if n, err := DBDeleteLLMUsageBefore(cutoff.Format("2006-01-02")); err != nil {
+		log.Printf("[数据保留] 删除LLM用量统计失败: %v", err)
+	} else {
+		counts.LLMUsage = n
+	}
+
+	// 配置历史：早于截止时间的版本（始终保留最新版本）
+	if n, err := DBDeleteConfigSnapshotsOlderThan(cutoff.Unix()); err != nil {
+		log.Printf("[数据保留] 删除配置历史失败: %v", err)
+	} else {
+		counts.ConfigHistory = n
+	}
+
+	retentionStatsLock.Lock()
+	retentionStats.LastRunAt = time.Now().Format("2006-01-02 15:04:05")
+	retentionStats.LastDeleted = counts
+	retentionStats.TotalDeleted.add(counts)
+	retentionStatsLock.Unlock()
+
+	log.Printf("[数据保留] 已删除早于 %s 的数据: 条目 %d，已读状态 %d，分类缓存 %d，后处理缓存 %d，追踪线索 %d，追踪 %d，翻译缓存 %d，分类修正 %d，外部ID映射 %d，LLM用量 %d，配置历史 %d",
+		cutoff.Format("2006-01-02"), counts.Items, counts.ReadState, counts.ClassifyCache,
+		counts.PostProcessCache, counts.FollowItems, counts.Follows, counts.Translations, counts.Corrections,
+		counts.ExternalIDs, counts.LLMUsage, counts.ConfigHistory)
+}
+
+// markLiveLink 记录仍保留条目的链接（含原始链接）
+func markLiveLink(liveLinks map[string]bool, item models.Item) {
+	liveLinks[item.Link] = true
+	if item.OriginalLink != "" {
+		liveLinks[item.OriginalLink] = true
+	}
+}
+
+// GetRetentionStats 获取数据保留清理统计
+func GetRetentionStats() RetentionStats {
+	retentionStatsLock.Lock()
+	defer retentionStatsLock.Unlock()
+	stats := retentionStats
+	stats.Enabled = globals.RssUrls.Retention.Enabled
+	stats.Days = globals.RssUrls.Retention.GetDays()
+	return stats
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDropExpiredItemsSourceRetention(t *testing.T) {
+	saved := globals.RssUrls
+	defer func() { globals.RssUrls = saved }()
+	globals.RssUrls = models.Config{Sources: []models.Source{
+		{URL: "https://a.example.com/feed", RetentionDays: 7},
+		{URL: "https://b.example.com/feed"},
+	}}
+
+	now := time.Now()
+	items := []models.Item{
+		{Link: "recent", PubDate: now.AddDate(0, 0, -1).Format(time.RFC3339)},
+		{Link: "old", PubDate: now.AddDate(0, 0, -10).Format(time.RFC3339)},
+		{Link: "fetched", FetchTime: now.AddDate(0, 0, -3).Format(time.RFC3339)},
+		{Link: "undated"},
+	}
+	links := func(items []models.Item) []string {
+		var result []string
+		for _, item := range items {
+			result = append(result, item.Link)
+		}
+		return result
+	}
+
+	if got, want := links(dropExpiredItems("https://a.example.com/feed", items)), []string{"recent", "fetched", "undated"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dropExpiredItems() with retentionDays = %v, want %v", got, want)
+	}
+	if got := dropExpiredItems("https://b.example.com/feed", items); len(got) != len(items) {
+		t.Errorf("dropExpiredItems() without retention dropped items: %v", links(got))
+	}
+
+	// 全局数据保留更短时以全局为准
+	globals.RssUrls.Retention = models.RetentionConfig{Enabled: true, Days: 2}
+	if got, want := links(dropExpiredItems("https://a.example.com/feed", items)), []string{"recent", "undated"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dropExpiredItems() with global retention = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"feedora/models"
+	"log"
+	"regexp"
+)
+
+// compiledRewriteRule 编译后的正则替换规则
+type compiledRewriteRule struct {
+	field       string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// compileRewriteRules 编译正则替换规则，跳过字段未知或正则无效的规则
+func compileRewriteRules(rssURL string, rules []models.RewriteRule) []compiledRewriteRule {
+	compiled := make([]compiledRewriteRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Field != "title" && rule.Field != "link" {
+			log.Printf("[后处理规则] 源 [%s]: 第 %d 条规则的字段无效: %s，已跳过", rssURL, i+1, rule.Field)
+			continue
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			log.Printf("[后处理规则] 源 [%s]: 第 %d 条规则的正则无效: %v，已跳过", rssURL, i+1, err)
+			continue
+		}
+		compiled = append(compiled, compiledRewriteRule{field: rule.Field, pattern: re, replacement: rule.Replacement})
+	}
+	return compiled
+}
+
+// applyRewriteRules 按顺序对条目的标题与链接应用正则替换规则（后处理的 rules 模式）
+// 链接被修改时保留原始链接，供缓存查询与已读状态使用
+func applyRewriteRules(items []models.Item, rssURL string, rules []models.RewriteRule) []models.Item {
+	compiled := compileRewriteRules(rssURL, rules)
+	if len(compiled) == 0 {
+		return items
+	}
+
+	result := make([]models.Item, len(items))
+	changed := 0
+	for i, item := range items {
+		for _, rule := range compiled {
+			switch rule.field {
+			case "title":
+				item.Title = rule.pattern.ReplaceAllString(item.Title, rule.replacement)
+			case "link":
+				item.Link = rule.pattern.ReplaceAllString(item.Link, rule.replacement)
+			}
+		}
+		if item.Link != items[i].Link && item.OriginalLink == "" {
+			item.OriginalLink = items[i].Link
+		}
+		if item.Title != items[i].Title || item.Link != items[i].Link {
+			changed++
+		}
+		result[i] = item
+	}
+	log.Printf("[后处理规则] 源 [%s] | 规则: %d 条 | 修改条目: %d/%d", rssURL, len(compiled), changed, len(items))
+	return result
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"feedora/models"
+	"reflect"
+	"testing"
+)
+
+func TestApplyRewriteRules(t *testing.T) {
+	items := []models.Item{
+		{Title: "【转载】Go 1.22 发布 | 原文", Link: "https://example.com/redirect?to=https%3A%2F%2Fgo.dev%2Fblog"},
+		{Title: "无需修改", Link: "https://example.com/post/1"},
+	}
+	rules := []models.RewriteRule{
+		{Field: "title", Pattern: `^【转载】`, Replacement: ""},
+		{Field: "title", Pattern: ` \| 原文$`, Replacement: ""},
+		{Field: "link", Pattern: `^https://example\.com/redirect\?to=(.+)$`, Replacement: "https://go.dev/blog"},
+		{Field: "summary", Pattern: `.*`, Replacement: ""},
+		{Field: "title", Pattern: `(`, Replacement: ""},
+	}
+
+	got := applyRewriteRules(items, "https://example.com/feed", rules)
+	if got[0].Title != "Go 1.22 发布" {
+		t.Errorf("Title = %q, want %q", got[0].Title, "Go 1.22 发布")
+	}
+	if got[0].Link != "https://go.dev/blog" || got[0].OriginalLink != items[0].Link {
+		t.Errorf("Link = %q, OriginalLink = %q", got[0].Link, got[0].OriginalLink)
+	}
+	if !reflect.DeepEqual(got[1], items[1]) {
+		t.Errorf("unchanged item = %+v, want %+v", got[1], items[1])
+	}
+}
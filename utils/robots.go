@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"bufio"
+	"feedora/globals"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robots.txt 遵循：源设置 respectRobots 后，抓取文章页面（如 og:image 缩略图）前检查站点的 robots.txt，
+// 禁止抓取的页面直接跳过。robots.txt 按站点缓存，匹配规则参照 RFC 9309（最长匹配优先，长度相同时 Allow 优先）
+
+// robotsUserAgent 匹配 robots.txt 中 User-agent 分组使用的名称，没有专门的分组时使用 * 分组
+const robotsUserAgent = "feedora"
+
+// robots.txt 的缓存时长、大小上限与请求超时
+const (
+	robotsCacheTTL      = 24 * time.Hour
+	robotsErrorCacheTTL = time.Hour
+	robotsMaxSize       = 512 * 1024
+	robotsFetchTimeout  = 10 * time.Second
+)
+
+// robotsRule 一条 Allow / Disallow 规则
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsRules 站点 robots.txt 中适用于 feedora 的规则，disallowAll 表示 robots.txt 暂时无法获取，按全部禁止处理
+type robotsRules struct {
+	rules       []robotsRule
+	disallowAll bool
+}
+
+// robotsEntry 缓存的站点规则
+type robotsEntry struct {
+	rules   robotsRules
+	expires time.Time
+}
+
+var (
+	// robots.txt 缓存: map[scheme://host] -> 规则
+	robotsCache     = make(map[string]robotsEntry)
+	robotsCacheLock sync.Mutex
+)
+
+// sourceRespectsRobots 检查源是否设置了遵循 robots.txt
+func sourceRespectsRobots(rssURL string) bool {
+	source := globals.RssUrls.GetSourceByURL(rssURL)
+	return source != nil && source.RespectRobots
+}
+
+// robotsAllowed 检查站点的 robots.txt 是否允许抓取该页面
+func robotsAllowed(pageURL string) bool {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	site := u.Scheme + "://" + u.Host
+
+	robotsCacheLock.Lock()
+	entry, ok := robotsCache[site]
+	robotsCacheLock.Unlock()
+	if !ok || time.Now().After(entry.expires) {
+		entry = fetchRobots(site)
+		robotsCacheLock.Lock()
+		robotsCache[site] = entry
+		robotsCacheLock.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return entry.rules.allowed(path)
+}
+
+// fetchRobots 下载并解析站点的 robots.txt：不存在（4xx）时全部允许，服务器错误或无法连接时暂时全部禁止
+func fetchRobots(site string) robotsEntry {
+	client := &http.Client{
+		Transport: globals.Fp.Client.Transport,
+		Timeout:   robotsFetchTimeout,
+	}
+	resp, err := client.Get(site + "/robots.txt")
+	if err != nil {
+		log.Printf("[robots.txt] 获取 %s 失败，暂不抓取该站点的页面: %v", site, err)
+		return robotsEntry{rules: robotsRules{disallowAll: true}, expires: time.Now().Add(robotsErrorCacheTTL)}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		log.Printf("[robots.txt] 获取 %s 失败，暂不抓取该站点的页面: %s", site, resp.Status)
+		return robotsEntry{rules: robotsRules{disallowAll: true}, expires: time.Now().Add(robotsErrorCacheTTL)}
+	case resp.StatusCode >= 400:
+		return robotsEntry{expires: time.Now().Add(robotsCacheTTL)}
+	}
+	return robotsEntry{
+		rules:   parseRobots(io.LimitReader(resp.Body, robotsMaxSize), robotsUserAgent),
+		expires: time.Now().Add(robotsCacheTTL),
+	}
+}
+
+// parseRobots 解析 robots.txt，返回名称与 agent 匹配的分组中的规则，没有匹配的分组时使用 * 分组
+func parseRobots(r io.Reader, agent string) robotsRules {
+	agent = strings.ToLower(agent)
+	var matched, wildcard []robotsRule
+	hasMatched := false
+	// 当前分组的 User-agent 是否匹配 agent / *，以及是否正在读取 User-agent 行
+	groupMatched, groupWildcard, inAgents := false, false, false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				groupMatched, groupWildcard, inAgents = false, false, true
+			}
+			name := strings.ToLower(value)
+			if name == "*" {
+				groupWildcard = true
+			} else if name != "" && strings.Contains(agent, name) {
+				groupMatched = true
+				hasMatched = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			// 空的 Disallow 表示不限制
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{allow: key == "allow", pattern: value}
+			if groupMatched {
+				matched = append(matched, rule)
+			}
+			if groupWildcard {
+				wildcard = append(wildcard, rule)
+			}
+		default:
+			inAgents = false
+		}
+	}
+	if hasMatched {
+		return robotsRules{rules: matched}
+	}
+	return robotsRules{rules: wildcard}
+}
+
+// allowed 按最长匹配的规则判断路径是否允许抓取，没有匹配的规则时允许
+func (r robotsRules) allowed(path string) bool {
+	if r.disallowAll {
+		return false
+	}
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !robotsPatternMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allow, longest = rule.allow, n
+		}
+	}
+	return allow
+}
+
+// robotsPatternMatch 判断路径是否匹配规则（* 匹配任意字符，结尾的 $ 表示匹配到路径末尾）
+func robotsPatternMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if !anchored {
+		return true
+	}
+	// 以 $ 结尾时最后一段需要位于路径末尾
+	last := parts[len(parts)-1]
+	return rest == "" || (len(parts) > 1 && strings.HasSuffix(path, last))
+}
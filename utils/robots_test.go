@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRobotsRules(t *testing.T) {
+	const robots = `
+User-agent: *
+Disallow: /private/
+Disallow: /*.pdf$
+Allow: /private/public
+
+User-agent: BadBot
+User-agent: Feedora
+Disallow: /no-feedora
+
+User-agent: Other
+Disallow: /
+`
+	wildcard := parseRobots(strings.NewReader(robots), "somebot")
+	for path, want := range map[string]bool{
+		"/":                    true,
+		"/private/x":           false,
+		"/private/public/page": true,
+		"/docs/a.pdf":          false,
+		"/docs/a.pdf?download": true,
+		"/no-feedora":          true,
+	} {
+		if got := wildcard.allowed(path); got != want {
+			t.Errorf("* group allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	// 有专门的分组时只使用该分组
+	own := parseRobots(strings.NewReader(robots), robotsUserAgent)
+	if own.allowed("/no-feedora") || !own.allowed("/private/x") {
+		t.Errorf("feedora group rules = %+v", own.rules)
+	}
+
+	if (robotsRules{disallowAll: true}).allowed("/") {
+		t.Error("disallowAll rules allowed a path")
+	}
+}
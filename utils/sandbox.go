@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context"
+	"feedora/globals"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errScriptsDisabled 配置了 scriptSandbox.disabled 时执行脚本返回的错误
+var errScriptsDisabled = fmt.Errorf("脚本执行已被禁用（scriptSandbox.disabled）")
+
+// checkScriptAllowed 检查是否允许执行脚本
+func checkScriptAllowed() error {
+	if globals.RssUrls.ScriptSandbox.Disabled {
+		return errScriptsDisabled
+	}
+	return nil
+}
+
+// scriptTimeout 按沙箱的最长执行时间限制脚本超时时间
+func scriptTimeout(timeout time.Duration) time.Duration {
+	return globals.RssUrls.ScriptSandbox.LimitTimeout(timeout)
+}
+
+// engineMemoryLimit 内置引擎（JavaScript 过滤与 WASM 插件）的内存上限（字节）
+func engineMemoryLimit() uint64 {
+	return uint64(globals.RssUrls.ScriptSandbox.GetEngineMemoryMB()) << 20
+}
+
+// watchHeapGrowth 定期检查执行期间的堆增长，超过 limit 字节时调用一次 onExceed，返回停止检查的函数
+// 堆为进程共享，统计中包含同时运行的其他任务分配的内存，只作为防止失控脚本耗尽服务内存的粗略上限
+func watchHeapGrowth(limit uint64, onExceed func()) (stop func()) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	base := stats.HeapAlloc
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > base && stats.HeapAlloc-base > limit {
+					onExceed()
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// newScriptCommand 按沙箱配置创建外部脚本命令：scriptContent 非空时通过 bash -c 执行，否则执行 scriptPath
+// 配置了 CPU/内存限制时通过 bash 的 ulimit 设置后再执行脚本（限制对脚本启动的所有子进程生效）
+func newScriptCommand(ctx context.Context, scriptContent, scriptPath string) (*exec.Cmd, error) {
+	if err := checkScriptAllowed(); err != nil {
+		return nil, err
+	}
+	if scriptContent == "" && scriptPath == "" {
+		return nil, fmt.Errorf("脚本内容或脚本路径未配置")
+	}
+	sandbox := globals.RssUrls.ScriptSandbox
+
+	var limits []string
+	if sandbox.CPUTime > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -t %d", sandbox.CPUTime))
+	}
+	if sandbox.MemoryMB > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -v %d", sandbox.MemoryMB*1024))
+	}
+
+	var cmd *exec.Cmd
+	switch {
+	case len(limits) > 0 && scriptContent != "":
+		cmd = exec.CommandContext(ctx, "bash", "-c", strings.Join(limits, " && ")+" && eval \"$0\"", scriptContent)
+	case len(limits) > 0:
+		// 脚本路径通过 $0 传入，避免拼接到命令字符串中
+		cmd = exec.CommandContext(ctx, "bash", "-c", strings.Join(limits, " && ")+" && exec \"$0\"", scriptPath)
+	case scriptContent != "":
+		cmd = exec.CommandContext(ctx, "bash", "-c", scriptContent)
+	default:
+		cmd = exec.CommandContext(ctx, scriptPath)
+	}
+
+	if sandbox.WorkDir != "" {
+		dir, err := filepath.Abs(sandbox.WorkDir)
+		if err != nil {
+			return nil, fmt.Errorf("脚本工作目录无效: %w", err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建脚本工作目录失败: %w", err)
+		}
+		cmd.Dir = dir
+		cmd.Env = append(scriptBaseEnv(sandbox.IsCleanEnv()), "HOME="+dir, "TMPDIR="+dir)
+	} else if sandbox.IsCleanEnv() {
+		cmd.Env = scriptBaseEnv(true)
+	}
+
+	if sandbox.NoNetwork {
+		if err := isolateScriptNetwork(cmd); err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
+
+// scriptBaseEnv 脚本的基础环境变量：clean 为 true 时只保留 PATH 与 LANG，否则继承程序的环境变量
+func scriptBaseEnv(clean bool) []string {
+	if !clean {
+		return os.Environ()
+	}
+	env := []string{"PATH=" + os.Getenv("PATH")}
+	if lang := os.Getenv("LANG"); lang != "" {
+		env = append(env, "LANG="+lang)
+	}
+	return env
+}
@@ -0,0 +1,22 @@
+//go:build linux
+
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolateScriptNetwork 在新的用户与网络命名空间中执行脚本：命名空间内只有未启用的回环网卡，无法访问网络
+// 当前用户映射为命名空间内的同一用户，文件权限不变
+func isolateScriptNetwork(cmd *exec.Cmd) error {
+	uid, gid := os.Getuid(), os.Getgid()
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+	cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+	cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+	return nil
+}
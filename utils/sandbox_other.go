@@ -0,0 +1,14 @@
+//go:build !linux
+
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// isolateScriptNetwork 当前系统不支持网络隔离，拒绝执行脚本而不是在有网络的情况下运行
+func isolateScriptNetwork(cmd *exec.Cmd) error {
+	return fmt.Errorf("当前系统 (%s) 不支持 scriptSandbox.noNetwork", runtime.GOOS)
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/mmcdole/gofeed"
+)
+
+var (
+	sanitizePolicies = map[string]*bluemonday.Policy{
+		// strip: 去除全部标签，只保留文本
+		"strip": bluemonday.StrictPolicy(),
+		// basic: 保留基本格式与链接
+		"basic": withLinkPolicy(basicPolicy()),
+		// images: 在 basic 的基础上保留图片
+		"images": withLinkPolicy(bluemonday.UGCPolicy()),
+	}
+
+	// trackingPixelPattern 宽或高为 0/1 像素的图片（跟踪像素）；清洗后的属性统一使用双引号
+	trackingPixelPattern = regexp.MustCompile(`<img[^>]*\s(?:width|height)="[01]"[^>]*>`)
+)
+
+// basicPolicy 与 bluemonday.UGCPolicy 相同的格式、链接、列表与表格规则，但不允许图片
+func basicPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowStandardAttributes()
+	p.AllowStandardURLs()
+	p.AllowElements("article", "aside", "section", "summary", "hgroup",
+		"h1", "h2", "h3", "h4", "h5", "h6",
+		"br", "div", "hr", "p", "span", "wbr",
+		"abbr", "acronym", "cite", "code", "dfn", "em", "mark", "s", "samp", "strong", "sub", "sup", "var",
+		"b", "i", "pre", "small", "strike", "tt", "u")
+	p.AllowAttrs("open").Matching(regexp.MustCompile(`(?i)^(|open)$`)).OnElements("details")
+	p.AllowAttrs("cite").OnElements("blockquote", "q")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("datetime").Matching(bluemonday.ISO8601).OnElements("time", "del", "ins")
+	p.AllowLists()
+	p.AllowTables()
+	return p
+}
+
+// withLinkPolicy 只允许绝对的 http(s) / mailto 地址，链接不向外部页面传递来源页面
+func withLinkPolicy(p *bluemonday.Policy) *bluemonday.Policy {
+	p.AllowRelativeURLs(false)
+	p.RequireNoFollowOnLinks(true)
+	p.RequireNoReferrerOnLinks(true)
+	return p
+}
+
+// sanitizeFeed 按源配置的清洗策略清洗抓取结果中条目的描述与正文
+func sanitizeFeed(source models.Source, feed *gofeed.Feed) {
+	policy := source.GetSanitize()
+	for _, item := range feed.Items {
+		if item == nil {
+			continue
+		}
+		item.Description = sanitizeHTML(policy, item.Description)
+		item.Content = sanitizeHTML(policy, item.Content)
+	}
+}
+
+// sanitizeSourceFeed 按 URL 对应源的清洗策略清洗抓取结果（源不在配置中时使用默认策略）
+func sanitizeSourceFeed(rssURL string, feed *gofeed.Feed) {
+	source := models.Source{URL: rssURL}
+	if s := globals.RssUrls.GetSourceByURL(rssURL); s != nil {
+		source = *s
+	}
+	sanitizeFeed(source, feed)
+}
+
+// sanitizeDescription 按 URL 对应源的清洗策略清洗单个描述（用于后处理修改后的描述）
+func sanitizeDescription(rssURL, description string) string {
+	source := models.Source{URL: rssURL}
+	if s := globals.RssUrls.GetSourceByURL(rssURL); s != nil {
+		source = *s
+	}
+	return sanitizeHTML(source.GetSanitize(), description)
+}
+
+// sanitizeHTML 按策略清洗 HTML：去除不允许的标签（保留其文本）、脚本等危险元素（连同内容）、事件属性与不安全的链接，
+// 以及 1x1 跟踪像素
+func sanitizeHTML(policy, input string) string {
+	if input == "" || !strings.ContainsAny(input, "<&") {
+		return input
+	}
+	output := sanitizePolicies[policy].Sanitize(input)
+	if policy == "images" {
+		output = trackingPixelPattern.ReplaceAllString(output, "")
+	}
+	return strings.TrimSpace(output)
+}
+
+// isSafeURL 只允许绝对的 http(s) 地址（链接额外允许 mailto:）
+func isSafeURL(raw string, allowMailto bool) bool {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return parsed.Host != ""
+	case "mailto":
+		return allowMailto
+	default:
+		return false
+	}
+}
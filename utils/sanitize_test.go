@@ -0,0 +1,30 @@
+package utils
+
+import "testing"
+
+func TestSanitizePolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		input  string
+		want   string
+	}{
+		{"plain text", "images", "Hello world", "Hello world"},
+		{"script dropped with content", "images", `<p>Hi<script>alert(1)</script></p>`, `<p>Hi</p>`},
+		{"event handler removed", "images", `<img src="https://a.example.com/x.png" onerror="alert(1)">`, `<img src="https://a.example.com/x.png">`},
+		{"javascript link removed", "basic", `<a href="javascript:alert(1)">x</a>`, `x`},
+		{"relative link removed", "basic", `<a href="/local">x</a>`, `x`},
+		{"link gets rel", "basic", `<a href="https://a.example.com/" target="_blank">x</a>`, `<a href="https://a.example.com/" rel="nofollow noreferrer">x</a>`},
+		{"tracking pixel removed", "images", `<p>a<img src="https://t.example.com/p.gif" width="1" height="1"></p>`, `<p>a</p>`},
+		{"sized image kept", "images", `<img src="https://a.example.com/x.png" width="10">`, `<img src="https://a.example.com/x.png" width="10">`},
+		{"images not allowed in basic", "basic", `<p><img src="https://a.example.com/x.png">text</p>`, `<p>text</p>`},
+		{"strip keeps text", "strip", `<p>a &amp; <b>b</b></p><style>p{}</style>`, `a &amp; b`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeHTML(tt.policy, tt.input); got != tt.want {
+				t.Errorf("sanitizeHTML(%q, %q) = %q, want %q", tt.policy, tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"feedora/models"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// fetchScriptFeed 执行脚本虚拟源，将脚本输出的条目转换为 gofeed.Feed
+// 脚本输出格式与 ApplyScriptFilter 一致：[{"title":"标题","link":"链接","pubDate":"时间","description":"描述"}, ...]
+func fetchScriptFeed(source models.Source) (*gofeed.Feed, error) {
+	if source.Script == nil {
+		return nil, fmt.Errorf("脚本源未配置脚本")
+	}
+
+	// 创建超时 context（不超过沙箱的最长执行时间）
+	timeout := scriptTimeout(time.Duration(source.Script.GetScriptTimeout()) * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd, err := newScriptCommand(ctx, source.Script.ScriptContent, source.Script.ScriptPath)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("脚本执行超时（超过 %v）", timeout)
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("脚本执行失败: %s, stderr: %s", err, string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("脚本执行失败: %w", err)
+	}
+
+	items, err := parseScriptItemsOutput(output)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildFeedFromItems(source.Name, source.URL, items), nil
+}
+
+// buildFeedFromItems 将脚本/推送得到的条目转换为 gofeed.Feed，缺少标题或链接的条目会被忽略
+func buildFeedFromItems(title, link string, items []models.Item) *gofeed.Feed {
+	feed := &gofeed.Feed{
+		Title: title,
+		Link:  link,
+		Items: make([]*gofeed.Item, 0, len(items)),
+	}
+	for _, item := range items {
+		itemTitle := strings.TrimSpace(item.Title)
+		itemLink := strings.TrimSpace(item.Link)
+		if itemTitle == "" || itemLink == "" {
+			continue
+		}
+		feed.Items = append(feed.Items, &gofeed.Item{
+			Title:           itemTitle,
+			Link:            itemLink,
+			Description:     item.Description,
+			PublishedParsed: parseJSONTime(item.PubDate),
+		})
+	}
+	return feed
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"encoding/json"
+	"feedora/globals"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretPrefix 配置中引用密钥的前缀: secret://名称 或 secret://解析器/名称（如 secret://vault/llm-key#apiKey）
+const secretPrefix = "secret://"
+
+// SecretResolver 密钥解析器：根据名称读取密钥，不存在时返回 errSecretNotFound
+type SecretResolver interface {
+	Resolve(name string) (string, error)
+}
+
+// SecretResolverFunc 将普通函数适配为 SecretResolver
+type SecretResolverFunc func(name string) (string, error)
+
+// Resolve 调用函数本身
+func (f SecretResolverFunc) Resolve(name string) (string, error) {
+	return f(name)
+}
+
+// errSecretNotFound 解析器中不存在该密钥（按顺序查找时继续尝试下一个解析器）
+var errSecretNotFound = fmt.Errorf("密钥不存在")
+
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+var (
+	secretResolvers     = make(map[string]SecretResolver)
+	secretResolversLock sync.RWMutex
+
+	secretCache     = make(map[string]cachedSecret)
+	secretCacheLock sync.Mutex
+
+	vaultClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+func init() {
+	RegisterSecretResolver("env", SecretResolverFunc(resolveEnvSecret))
+	RegisterSecretResolver("docker", SecretResolverFunc(resolveDockerSecret))
+	RegisterSecretResolver("file", SecretResolverFunc(resolveFileSecret))
+	RegisterSecretResolver("vault", SecretResolverFunc(resolveVaultSecret))
+}
+
+// RegisterSecretResolver 注册密钥解析器（已存在时覆盖），自行构建时可接入其他密钥管理服务
+func RegisterSecretResolver(name string, resolver SecretResolver) {
+	secretResolversLock.Lock()
+	secretResolvers[name] = resolver
+	secretResolversLock.Unlock()
+}
+
+// ResolveSecret 解析 secret:// 引用，其他值原样返回
+// 指定了解析器（secret://vault/llm-key）时只查该解析器，否则按配置的顺序依次查找
+func ResolveSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, secretPrefix) {
+		return value, nil
+	}
+	ref := strings.TrimPrefix(value, secretPrefix)
+
+	secretCacheLock.Lock()
+	if cached, ok := secretCache[ref]; ok && time.Now().Before(cached.expiresAt) {
+		secretCacheLock.Unlock()
+		return cached.value, nil
+	}
+	secretCacheLock.Unlock()
+
+	order := globals.RssUrls.Secrets.GetOrder()
+	name := ref
+	if idx := strings.Index(ref, "/"); idx > 0 {
+		secretResolversLock.RLock()
+		_, ok := secretResolvers[ref[:idx]]
+		secretResolversLock.RUnlock()
+		if ok {
+			order, name = []string{ref[:idx]}, ref[idx+1:]
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("无效的密钥引用: %s", value)
+	}
+
+	for _, resolverName := range order {
+		secretResolversLock.RLock()
+		resolver, ok := secretResolvers[resolverName]
+		secretResolversLock.RUnlock()
+		if !ok {
+			continue
+		}
+		secret, err := resolver.Resolve(name)
+		if err == errSecretNotFound {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("解析密钥 %s 失败（%s）: %w", value, resolverName, err)
+		}
+
+		ttl := time.Duration(globals.RssUrls.Secrets.GetCacheTTL()) * time.Second
+		secretCacheLock.Lock()
+		secretCache[ref] = cachedSecret{value: secret, expiresAt: time.Now().Add(ttl)}
+		secretCacheLock.Unlock()
+		return secret, nil
+	}
+	return "", fmt.Errorf("未找到密钥: %s", value)
+}
+
+// resolveEnvSecret 从环境变量读取：secret://llm-key 对应 FEEDORA_SECRET_LLM_KEY，
+// 指定解析器时（secret://env/OPENAI_API_KEY）直接使用该变量名
+func resolveEnvSecret(name string) (string, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	key := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	if value, ok := os.LookupEnv("FEEDORA_SECRET_" + strings.ToUpper(key)); ok {
+		return value, nil
+	}
+	return "", errSecretNotFound
+}
+
+// resolveDockerSecret 读取 Docker / Kubernetes 挂载的密钥文件 /run/secrets/名称
+func resolveDockerSecret(name string) (string, error) {
+	return readSecretFile("/run/secrets", name)
+}
+
+// resolveFileSecret 读取密钥目录（默认数据目录下的 secrets）中的同名文件
+func resolveFileSecret(name string) (string, error) {
+	dir := globals.RssUrls.Secrets.Dir
+	if dir == "" {
+		dir = filepath.Join(getDataDir(), "secrets")
+	}
+	return readSecretFile(dir, name)
+}
+
+// readSecretFile 读取目录中的密钥文件（去掉末尾换行，名称不能跳出目录）
+func readSecretFile(dir, name string) (string, error) {
+	cleaned := filepath.Clean("/" + name)
+	data, err := os.ReadFile(filepath.Join(dir, cleaned))
+	if os.IsNotExist(err) {
+		return "", errSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveVaultSecret 从 Vault KV v2 引擎读取：secret://vault/路径#字段（字段默认 value）
+func resolveVaultSecret(name string) (string, error) {
+	config := globals.RssUrls.Secrets.Vault
+	if config == nil || config.Address == "" {
+		return "", errSecretNotFound
+	}
+	path, field := name, config.GetField()
+	if idx := strings.LastIndex(name, "#"); idx >= 0 {
+		path, field = name[:idx], name[idx+1:]
+	}
+	token := config.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+
+	apiURL := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(config.Address, "/"), config.GetMount(), strings.Trim(path, "/"))
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := vaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	value, ok := result.Data.Data[field]
+	if !ok {
+		return "", errSecretNotFound
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"feedora/models"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// socialTitleMaxLength 社交动态转换为标题时的最大长度
+const socialTitleMaxLength = 120
+
+// mastodonStatus Mastodon 动态（仅包含用到的字段）
+type mastodonStatus struct {
+	URL         string          `json:"url"`
+	URI         string          `json:"uri"`
+	CreatedAt   string          `json:"created_at"`
+	Content     string          `json:"content"`
+	SpoilerText string          `json:"spoiler_text"`
+	InReplyToID *string         `json:"in_reply_to_id"`
+	Reblog      *mastodonStatus `json:"reblog"`
+	Account     struct {
+		Acct        string `json:"acct"`
+		DisplayName string `json:"display_name"`
+	} `json:"account"`
+}
+
+// blueskyFeedResponse Bluesky getAuthorFeed / getFeed 响应
+type blueskyFeedResponse struct {
+	Feed []struct {
+		Post struct {
+			URI    string `json:"uri"`
+			Author struct {
+				Handle      string `json:"handle"`
+				DisplayName string `json:"displayName"`
+			} `json:"author"`
+			Record struct {
+				Text      string    `json:"text"`
+				CreatedAt string    `json:"createdAt"`
+				Reply     *struct{} `json:"reply"`
+			} `json:"record"`
+			IndexedAt string `json:"indexedAt"`
+		} `json:"post"`
+		Reason *struct {
+			Type string `json:"$type"`
+		} `json:"reason"`
+	} `json:"feed"`
+}
+
+// fetchMastodonFeed 通过 Mastodon 公开 API 抓取账号或话题动态
+// 源地址形如 https://mastodon.social/@user 或 https://mastodon.social/tags/golang
+func fetchMastodonFeed(source models.Source) (*gofeed.Feed, error) {
+	u, err := url.Parse(source.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("无效的 Mastodon 地址: %s", source.URL)
+	}
+	options := source.GetSocialOptions()
+	instance := u.Scheme + "://" + u.Host
+	path := strings.Trim(u.Path, "/")
+
+	var apiURL, title string
+	switch {
+	case strings.HasPrefix(path, "tags/"):
+		tag := strings.TrimPrefix(path, "tags/")
+		apiURL = fmt.Sprintf("%s/api/v1/timelines/tag/%s?limit=%d", instance, url.PathEscape(tag), options.GetLimit(40))
+		title = "#" + tag
+	case strings.HasPrefix(path, "@"):
+		acct := strings.TrimPrefix(path, "@")
+		var account struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"display_name"`
+		}
+		lookupURL := fmt.Sprintf("%s/api/v1/accounts/lookup?acct=%s", instance, url.QueryEscape(acct))
+		if err := fetchJSON(lookupURL, nil, &account); err != nil {
+			return nil, fmt.Errorf("查询 Mastodon 账号失败: %w", err)
+		}
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(options.GetLimit(40)))
+		if options.ExcludeReplies {
+			query.Set("exclude_replies", "true")
+		}
+		if options.ExcludeReposts {
+			query.Set("exclude_reblogs", "true")
+		}
+		apiURL = fmt.Sprintf("%s/api/v1/accounts/%s/statuses?%s", instance, account.ID, query.Encode())
+		title = account.DisplayName
+		if title == "" {
+			title = "@" + acct
+		}
+	default:
+		return nil, fmt.Errorf("无法识别的 Mastodon 地址（应为 /@用户 或 /tags/话题）: %s", source.URL)
+	}
+
+	var statuses []mastodonStatus
+	if err := fetchJSON(apiURL, nil, &statuses); err != nil {
+		return nil, err
+	}
+
+	feed := &gofeed.Feed{
+		Title: title,
+		Link:  source.URL,
+		Items: make([]*gofeed.Item, 0, len(statuses)),
+	}
+	for _, status := range statuses {
+		post := status
+		if status.Reblog != nil {
+			if options.ExcludeReposts {
+				continue
+			}
+			post = *status.Reblog
+		}
+		if options.ExcludeReplies && post.InReplyToID != nil {
+			continue
+		}
+
+		link := post.URL
+		if link == "" {
+			link = post.URI
+		}
+		text := html.UnescapeString(stripHTML(post.Content))
+		itemTitle := post.SpoilerText
+		if itemTitle == "" {
+			itemTitle = text
+		}
+		if itemTitle == "" {
+			// 纯媒体动态没有文字内容
+			itemTitle = "[媒体] @" + post.Account.Acct
+		}
+
+		feed.Items = append(feed.Items, &gofeed.Item{
+			Title:           truncateString(itemTitle, socialTitleMaxLength),
+			Link:            link,
+			Description:     post.Content,
+			PublishedParsed: parseJSONTime(post.CreatedAt),
+		})
+	}
+	return feed, nil
+}
+
+// fetchBlueskyFeed 通过 Bluesky 公开 AppView API 抓取用户动态或自定义 Feed
+// 源地址形如 https://bsky.app/profile/alice.bsky.social 或 https://bsky.app/profile/alice.bsky.social/feed/whats-hot
+func fetchBlueskyFeed(source models.Source) (*gofeed.Feed, error) {
+	u, err := url.Parse(source.URL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 Bluesky 地址: %s", source.URL)
+	}
+	options := source.GetSocialOptions()
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "profile" {
+		return nil, fmt.Errorf("无法识别的 Bluesky 地址（应为 /profile/用户 或 /profile/用户/feed/名称）: %s", source.URL)
+	}
+	actor := parts[1]
+
+	const apiBase = "https://public.api.bsky.app/xrpc"
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(options.GetLimit(50)))
+
+	var apiURL, title string
+	if len(parts) >= 4 && parts[2] == "feed" {
+		did := actor
+		if !strings.HasPrefix(did, "did:") {
+			var resolved struct {
+				DID string `json:"did"`
+			}
+			resolveURL := apiBase + "/com.atproto.identity.resolveHandle?handle=" + url.QueryEscape(actor)
+			if err := fetchJSON(resolveURL, nil, &resolved); err != nil {
+				return nil, fmt.Errorf("解析 Bluesky 用户失败: %w", err)
+			}
+			did = resolved.DID
+		}
+		query.Set("feed", fmt.Sprintf("at://%s/app.bsky.feed.generator/%s", did, parts[3]))
+		apiURL = apiBase + "/app.bsky.feed.getFeed?" + query.Encode()
+		title = parts[3]
+	} else {
+		query.Set("actor", actor)
+		if options.ExcludeReplies {
+			query.Set("filter", "posts_no_replies")
+		}
+		apiURL = apiBase + "/app.bsky.feed.getAuthorFeed?" + query.Encode()
+		title = "@" + actor
+	}
+
+	var response blueskyFeedResponse
+	if err := fetchJSON(apiURL, nil, &response); err != nil {
+		return nil, err
+	}
+
+	feed := &gofeed.Feed{
+		Title: title,
+		Link:  source.URL,
+		Items: make([]*gofeed.Item, 0, len(response.Feed)),
+	}
+	for _, entry := range response.Feed {
+		if entry.Reason != nil && options.ExcludeReposts {
+			continue
+		}
+		post := entry.Post
+		if options.ExcludeReplies && post.Record.Reply != nil {
+			continue
+		}
+		link := blueskyPostURL(post.Author.Handle, post.URI)
+		if link == "" {
+			continue
+		}
+		if len(parts) < 4 && post.Author.DisplayName != "" && post.Author.Handle == actor {
+			feed.Title = post.Author.DisplayName
+		}
+
+		itemTitle := strings.TrimSpace(post.Record.Text)
+		if itemTitle == "" {
+			itemTitle = "[媒体] @" + post.Author.Handle
+		}
+		pubDate := parseJSONTime(post.Record.CreatedAt)
+		if pubDate == nil {
+			pubDate = parseJSONTime(post.IndexedAt)
+		}
+
+		feed.Items = append(feed.Items, &gofeed.Item{
+			Title:           truncateString(strings.Join(strings.Fields(itemTitle), " "), socialTitleMaxLength),
+			Link:            link,
+			Description:     html.EscapeString(post.Record.Text),
+			PublishedParsed: pubDate,
+		})
+	}
+	return feed, nil
+}
+
+// blueskyPostURL 将 at://did/app.bsky.feed.post/rkey 转换为 bsky.app 网页地址
+func blueskyPostURL(handle, uri string) string {
+	idx := strings.LastIndex(uri, "/")
+	if idx < 0 || idx == len(uri)-1 {
+		return ""
+	}
+	rkey := uri[idx+1:]
+	if handle == "" {
+		// 没有 handle 时使用 URI 中的 DID
+		handle = strings.SplitN(strings.TrimPrefix(uri, "at://"), "/", 2)[0]
+	}
+	return fmt.Sprintf("https://bsky.app/profile/%s/post/%s", handle, rkey)
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"feedora/models"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// sortKey 排序表达式中的单个排序键
+type sortKey struct {
+	Field string
+	Desc  bool
+}
+
+// sortItemContext 排序时的附加信息
+type sortItemContext struct {
+	// 已读状态快照
+	readState map[string]int64
+	// 条目所属源在文件夹中的优先级（条目链接 -> 文件夹条目序号，越小越靠前）
+	sourcePriority map[string]int
+	// 条目在所属源中的位置（条目链接 -> 序号，即源抓取结果中的顺序，榜单源为排名）
+	sourceOrder map[string]int
+}
+
+var (
+	// 已解析的排序表达式缓存，避免每次构建卡片时重复解析
+	sortExprCache     = make(map[string][]sortKey)
+	sortExprCacheLock sync.Mutex
+)
+
+// supportedSortFields 支持的排序字段
+var supportedSortFields = map[string]bool{
+	"pubDate":        true,
+	"fetchTime":      true,
+	"title":          true,
+	"source":         true,
+	"category":       true,
+	"unread":         true,
+	"sourcePriority": true,
+	"sourceOrder":    true,
+	"index":          true,
+	"score":          true,
+	"comments":       true,
+	"relevance":      true,
+}
+
+// parseSortExpression 解析排序表达式，如 "unread desc, pubDate desc"
+// 每个排序键由字段名和可选的 asc/desc 组成，默认为 asc
+func parseSortExpression(expr string) ([]sortKey, error) {
+	keys := make([]sortKey, 0)
+	for _, part := range strings.Split(expr, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("无法解析排序键: %q", strings.TrimSpace(part))
+		}
+		if !supportedSortFields[fields[0]] {
+			return nil, fmt.Errorf("不支持的排序字段: %s", fields[0])
+		}
+		key := sortKey{Field: fields[0]}
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, fmt.Errorf("排序方向只能为 asc 或 desc: %s", fields[1])
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// getSortKeys 获取（带缓存的）排序表达式解析结果，解析失败时仅记录一次日志并返回 nil
+func getSortKeys(expr string) []sortKey {
+	sortExprCacheLock.Lock()
+	defer sortExprCacheLock.Unlock()
+
+	if keys, ok := sortExprCache[expr]; ok {
+		return keys
+	}
+	keys, err := parseSortExpression(expr)
+	if err != nil {
+		log.Printf("[排序] 排序表达式无效，使用默认排序 | 表达式: %s | 错误: %v", expr, err)
+		keys = nil
+	}
+	sortExprCache[expr] = keys
+	return keys
+}
+
+// applySortExpression 按排序表达式对条目排序（返回新切片，不修改原切片），表达式为空或无效时原样返回
+func applySortExpression(items []models.Item, expr string, sourcePriority, sourceOrder map[string]int) []models.Item {
+	expr = strings.TrimSpace(expr)
+	if expr == "" || len(items) < 2 {
+		return items
+	}
+	keys := getSortKeys(expr)
+	if len(keys) == 0 {
+		return items
+	}
+
+	ctx := sortItemContext{
+		readState:      GetReadState(),
+		sourcePriority: sourcePriority,
+		sourceOrder:    sourceOrder,
+	}
+
+	sorted := make([]models.Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, key := range keys {
+			cmp := compareItemsByField(sorted[i], sorted[j], key.Field, ctx)
+			if cmp == 0 {
+				continue
+			}
+			if key.Desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	return sorted
+}
+
+// compareItemsByField 按单个字段比较两个条目，left > right 返回 1，left < right 返回 -1
+func compareItemsByField(left, right models.Item, field string, ctx sortItemContext) int {
+	switch field {
+	case "pubDate":
+		return compareItemsByRecency(left, right)
+	case "fetchTime":
+		return compareTimestampStrings(left.FetchTime, right.FetchTime)
+	case "title":
+		return strings.Compare(strings.ToLower(left.Title), strings.ToLower(right.Title))
+	case "source":
+		return strings.Compare(left.Source, right.Source)
+	case "category":
+		return strings.Compare(left.Category, right.Category)
+	case "unread":
+		return compareInts(unreadValue(left, ctx), unreadValue(right, ctx))
+	case "sourcePriority":
+		return compareInts(priorityValue(left, ctx), priorityValue(right, ctx))
+	case "sourceOrder":
+		return compareInts(sourceOrderValue(left, ctx), sourceOrderValue(right, ctx))
+	case "index":
+		return compareInts(left.OriginalIndex, right.OriginalIndex)
+	case "score":
+		return compareInts(left.Score, right.Score)
+	case "comments":
+		return compareInts(left.Comments, right.Comments)
+	case "relevance":
+		return compareInts(relevanceValue(left), relevanceValue(right))
+	}
+	return 0
+}
+
+// unreadValue 未读返回 1，已读返回 0
+func unreadValue(item models.Item, ctx sortItemContext) int {
+	if _, read := ctx.readState[item.Link]; read {
+		return 0
+	}
+	return 1
+}
+
+// relevanceValue 获取条目的相关度，未打分时排在已打分条目之后（desc 时）
+func relevanceValue(item models.Item) int {
+	if item.Relevance == nil {
+		return -1
+	}
+	return *item.Relevance
+}
+
+// priorityValue 获取条目所属源的优先级，未知时排在最后
+func priorityValue(item models.Item, ctx sortItemContext) int {
+	if p, ok := ctx.sourcePriority[item.Link]; ok {
+		return p
+	}
+	return 1 << 30
+}
+
+func sourceOrderValue(item models.Item, ctx sortItemContext) int {
+	if p, ok := ctx.sourceOrder[item.Link]; ok {
+		return p
+	}
+	return 1 << 30
+}
+
+func compareInts(left, right int) int {
+	if left > right {
+		return 1
+	}
+	if left < right {
+		return -1
+	}
+	return 0
+}
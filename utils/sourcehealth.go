@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"feedora/globals"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sourceHealth 订阅源健康状态，用于故障通知的状态切换判断及失效源检测
+type sourceHealth struct {
+	// 连续失败次数
+	Failures int
+	// 本次故障的首次失败时间
+	FailingSince time.Time
+	// 是否已发送本次故障的通知（仅在已通知时发送恢复通知）
+	Notified bool
+	// 最近一次发送故障通知的时间
+	LastNotified time.Time
+	// 被标记为失效的时间，零值表示未失效
+	DeadSince time.Time
+}
+
+// SourceHealthStatus 持续失败中的订阅源（/api/stats 的 sourceHealth）
+type SourceHealthStatus struct {
+	URL  string `json:"url"`
+	Name string `json:"name"`
+	// 连续失败次数
+	Failures int `json:"failures"`
+	// 本次故障的首次失败时间
+	FailingSince string `json:"failingSince"`
+	// 最近一次抓取错误
+	LastError string `json:"lastError,omitempty"`
+	// 是否已被标记为失效（停止定时抓取）
+	Dead      bool   `json:"dead"`
+	DeadSince string `json:"deadSince,omitempty"`
+}
+
+var (
+	// 订阅源健康状态: map[RSS URL] -> 状态
+	sourceHealthMap     = make(map[string]*sourceHealth)
+	sourceHealthMapLock sync.Mutex
+)
+
+// trackSourceFailure 记录一次抓取失败，连续失败达到阈值时发送一次故障通知
+func trackSourceFailure(rssURL string, err error) {
+	config := globals.RssUrls.Notification
+	now := time.Now()
+
+	sourceHealthMapLock.Lock()
+	health, ok := sourceHealthMap[rssURL]
+	if !ok {
+		health = &sourceHealth{}
+		sourceHealthMap[rssURL] = health
+	}
+	if health.Failures == 0 {
+		health.FailingSince = now
+	}
+	health.Failures++
+
+	// 连续失败超过设定时长时标记为失效
+	deadConfig := globals.RssUrls.DeadFeeds
+	markedDead := deadConfig.Enabled && health.DeadSince.IsZero() && now.Sub(health.FailingSince) >= deadConfig.GetDuration()
+	if markedDead {
+		health.DeadSince = now
+	}
+	failingSince, deadSince := health.FailingSince, health.DeadSince
+
+	shouldNotify := config.SourceErrors &&
+		!health.Notified &&
+		health.Failures >= config.GetSourceErrorThreshold() &&
+		now.Sub(health.LastNotified) >= time.Duration(config.GetSourceErrorCooldown())*time.Minute &&
+		!isSourceErrorMuted(rssURL)
+	if shouldNotify {
+		health.Notified = true
+		health.LastNotified = now
+	}
+	failures := health.Failures
+	sourceHealthMapLock.Unlock()
+
+	// 持久化失败状态，重启后继续累计失败时长
+	if err := DBSaveSourceHealth(rssURL, failures, failingSince, deadSince); err != nil {
+		log.Printf("[失效检测] 保存源状态失败 [%s]: %v", rssURL, err)
+	}
+	if markedDead {
+		log.Printf("[失效检测] 源已连续失败 %v，标记为失效并停止定时抓取: %s", now.Sub(failingSince).Round(time.Hour), rssURL)
+		if deadConfig.Notify && !isSourceErrorMuted(rssURL) {
+			SendNotification(
+				"订阅源已失效: "+getSourceDisplayName(rssURL),
+				fmt.Sprintf("地址: %s\n错误: %v\n自 %s 起连续失败 %d 次，已停止定时抓取，手动刷新成功后自动恢复",
+					rssURL, err, failingSince.Format("2006-01-02 15:04"), failures),
+			)
+		}
+	}
+
+	if shouldNotify {
+		log.Printf("[故障通知] 源进入故障状态: %s | 连续失败 %d 次", rssURL, failures)
+		SendNotification(
+			"订阅源抓取失败: "+getSourceDisplayName(rssURL),
+			fmt.Sprintf("地址: %s\n错误: %v\n连续失败 %d 次", rssURL, err, failures),
+		)
+	}
+}
+
+// trackSourceRecovery 记录一次抓取成功，若之前已发送故障通知则发送恢复通知
+func trackSourceRecovery(rssURL string) {
+	sourceHealthMapLock.Lock()
+	health, ok := sourceHealthMap[rssURL]
+	if !ok || health.Failures == 0 {
+		sourceHealthMapLock.Unlock()
+		return
+	}
+	notified := health.Notified
+	wasDead := !health.DeadSince.IsZero()
+	duration := time.Since(health.FailingSince)
+	health.Failures = 0
+	health.Notified = false
+	health.DeadSince = time.Time{}
+	sourceHealthMapLock.Unlock()
+
+	if err := DBDeleteSourceHealth(rssURL); err != nil {
+		log.Printf("[失效检测] 清除源状态失败 [%s]: %v", rssURL, err)
+	}
+	if wasDead {
+		log.Printf("[失效检测] 失效源已恢复，重新开始定时抓取: %s", rssURL)
+		if globals.RssUrls.DeadFeeds.Notify && !isSourceErrorMuted(rssURL) && !notified {
+			SendNotification(
+				"订阅源已恢复: "+getSourceDisplayName(rssURL),
+				fmt.Sprintf("地址: %s\n故障持续: %v", rssURL, duration.Round(time.Minute)),
+			)
+		}
+	}
+
+	if notified && globals.RssUrls.Notification.SourceErrors && !isSourceErrorMuted(rssURL) {
+		log.Printf("[故障通知] 源已恢复: %s | 故障持续 %v", rssURL, duration.Round(time.Second))
+		SendNotification(
+			"订阅源已恢复: "+getSourceDisplayName(rssURL),
+			fmt.Sprintf("地址: %s\n故障持续: %v", rssURL, duration.Round(time.Minute)),
+		)
+	}
+}
+
+// isSourceErrorMuted 判断源是否关闭了故障通知
+func isSourceErrorMuted(rssURL string) bool {
+	source := globals.RssUrls.GetSourceByURL(rssURL)
+	return source != nil && source.MuteErrors
+}
+
+// getSourceDisplayName 获取源的展示名称（未设置名称时使用地址）
+func getSourceDisplayName(rssURL string) string {
+	if source := globals.RssUrls.GetSourceByURL(rssURL); source != nil && source.Name != "" {
+		return source.Name
+	}
+	return rssURL
+}
+
+// IsSourceDead 判断源是否已被标记为失效（关闭失效源检测后全部恢复定时抓取）
+func IsSourceDead(rssURL string) bool {
+	if !globals.RssUrls.DeadFeeds.Enabled {
+		return false
+	}
+	sourceHealthMapLock.Lock()
+	defer sourceHealthMapLock.Unlock()
+	health, ok := sourceHealthMap[rssURL]
+	return ok && !health.DeadSince.IsZero()
+}
+
+// loadSourceHealth 加载持久化的源失败状态，已不在配置中的源的记录直接删除
+func loadSourceHealth() {
+	loaded, err := DBLoadSourceHealth()
+	if err != nil {
+		log.Printf("读取订阅源健康状态失败: %v", err)
+		return
+	}
+
+	failing, dead := 0, 0
+	sourceHealthMapLock.Lock()
+	for url, health := range loaded {
+		if globals.RssUrls.GetSourceByURL(url) == nil {
+			if err := DBDeleteSourceHealth(url); err != nil {
+				log.Printf("删除订阅源健康状态失败 [%s]: %v", url, err)
+			}
+			continue
+		}
+		sourceHealthMap[url] = health
+		failing++
+		if !health.DeadSince.IsZero() {
+			dead++
+		}
+	}
+	sourceHealthMapLock.Unlock()
+
+	log.Printf("[数据加载] 订阅源健康状态: %d 个源持续失败中，其中 %d 个已失效", failing, dead)
+}
+
+// GetSourceHealthStats 获取持续失败中的订阅源，已失效的在前，其余按失败开始时间排序
+func GetSourceHealthStats() []SourceHealthStatus {
+	deadEnabled := globals.RssUrls.DeadFeeds.Enabled
+	result := make([]SourceHealthStatus, 0)
+
+	sourceHealthMapLock.Lock()
+	for url, health := range sourceHealthMap {
+		if health.Failures == 0 || globals.RssUrls.GetSourceByURL(url) == nil {
+			continue
+		}
+		status := SourceHealthStatus{
+			URL:          url,
+			Failures:     health.Failures,
+			FailingSince: health.FailingSince.Format(time.RFC3339),
+			Dead:         deadEnabled && !health.DeadSince.IsZero(),
+		}
+		if status.Dead {
+			status.DeadSince = health.DeadSince.Format(time.RFC3339)
+		}
+		result = append(result, status)
+	}
+	sourceHealthMapLock.Unlock()
+
+	for i := range result {
+		result[i].Name = getSourceDisplayName(result[i].URL)
+		if e, ok := GetSourceError(result[i].URL); ok {
+			result[i].LastError = e.Message
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Dead != result[j].Dead {
+			return result[i].Dead
+		}
+		return result[i].FailingSince < result[j].FailingSince
+	})
+	return result
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 源级 TLS 选项：抓取设置了 tls 的源时，请求经由按该源选项（跳过校验 / 额外信任的 CA）单独创建的连接池发出，
+// 其他源与其他请求仍使用默认的证书校验。选项通过请求的 context 传递，见 withSourceTLS
+
+// sourceTLSKey context 中保存源 TLS 选项的键
+type sourceTLSKey struct{}
+
+var (
+	// 按 TLS 选项创建的 Transport: map[选项] -> Transport
+	sourceTLSTransports     = make(map[models.SourceTLSConfig]http.RoundTripper)
+	sourceTLSTransportsLock sync.Mutex
+)
+
+// withSourceTLS 在 context 中记录源的 TLS 选项（未设置时原样返回）
+func withSourceTLS(ctx context.Context, source models.Source) context.Context {
+	if source.TLS == nil || (!source.TLS.InsecureSkipVerify && strings.TrimSpace(source.TLS.CA) == "") {
+		return ctx
+	}
+	return context.WithValue(ctx, sourceTLSKey{}, *source.TLS)
+}
+
+// sourceTLSTransport 请求的 context 中带有源 TLS 选项时使用对应的 Transport，否则使用 base
+type sourceTLSTransport struct {
+	base http.RoundTripper
+}
+
+func (t *sourceTLSTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	options, ok := req.Context().Value(sourceTLSKey{}).(models.SourceTLSConfig)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	transport, err := getSourceTLSTransport(options)
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+// getSourceTLSTransport 获取（首次使用时创建）TLS 选项对应的 Transport
+func getSourceTLSTransport(options models.SourceTLSConfig) (http.RoundTripper, error) {
+	sourceTLSTransportsLock.Lock()
+	defer sourceTLSTransportsLock.Unlock()
+	if transport, ok := sourceTLSTransports[options]; ok {
+		return transport, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: options.InsecureSkipVerify}
+	if ca := strings.TrimSpace(options.CA); ca != "" {
+		pool, err := loadSourceCA(ca)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	// 复制订阅抓取连接池的参数，连接统计计入 feed 连接池
+	base := feedPool.transport.Clone()
+	base.TLSClientConfig = config
+	transport := globals.NewUserAgentTransport(feedPool.withTransport(base))
+	sourceTLSTransports[options] = transport
+	return transport, nil
+}
+
+// loadSourceCA 读取 CA 证书（PEM 内容或文件路径），与系统证书合并为证书池
+func loadSourceCA(ca string) (*x509.CertPool, error) {
+	data := []byte(ca)
+	if !strings.HasPrefix(ca, "-----BEGIN") {
+		var err error
+		if data, err = os.ReadFile(ca); err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("CA 证书中没有有效的 PEM 证书")
+	}
+	return pool, nil
+}
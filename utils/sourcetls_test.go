@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"context"
+	"encoding/pem"
+	"feedora/globals"
+	"feedora/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSourceTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	get := func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := globals.Fp.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	if err := get(context.Background()); err == nil {
+		t.Error("request to self-signed server succeeded without TLS options")
+	}
+	for _, options := range []models.SourceTLSConfig{{CA: ca}, {InsecureSkipVerify: true}} {
+		ctx := withSourceTLS(context.Background(), models.Source{URL: server.URL, TLS: &options})
+		if err := get(ctx); err != nil {
+			t.Errorf("request with TLS options %+v failed: %v", options, err)
+		}
+	}
+}
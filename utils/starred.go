@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// 收藏条目：保存从其他阅读器导入的收藏（星标）文章，与订阅源的条目缓存分开保存，不随条目清理删除
+
+// StarredItem 收藏的条目
+type StarredItem struct {
+	Link        string `json:"link"`
+	Title       string `json:"title"`
+	SourceURL   string `json:"sourceUrl,omitempty"`
+	SourceTitle string `json:"sourceTitle,omitempty"`
+	PubDate     string `json:"pubDate,omitempty"`
+	// 收藏时间（RFC3339），导入时未提供则为导入时间
+	StarredAt string `json:"starredAt"`
+	// 导入来源: miniflux / freshrss / ttrss
+	Origin string `json:"origin,omitempty"`
+}
+
+// starredAt 返回收藏时间，无法解析时为当前时间
+func (s StarredItem) starredAt() time.Time {
+	if t, ok := parseTimestamp(s.StarredAt); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// SaveStarredItems 保存收藏条目（已收藏的条目保持不变），返回新增的条数
+func SaveStarredItems(items []StarredItem) (int, error) {
+	if DB == nil {
+		return 0, errors.New("数据库未初始化")
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+	return DBSaveStarredItems(items)
+}
+
+// GetStarredItems 获取收藏条目，按收藏时间倒序
+func GetStarredItems() ([]StarredItem, error) {
+	if DB == nil {
+		return nil, errors.New("数据库未初始化")
+	}
+	return DBLoadStarredItems()
+}
+
+// RemoveStarredItem 取消收藏，返回条目是否存在
+func RemoveStarredItem(link string) (bool, error) {
+	if DB == nil {
+		return false, errors.New("数据库未初始化")
+	}
+	return DBDeleteStarredItem(link)
+}
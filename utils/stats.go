@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"math"
+	"sync"
+	"time"
+)
+
+// statsAvgDays 计算日均条目数使用的时间窗口（天）
+const statsAvgDays = 7
+
+// SourceError 订阅源最近一次抓取错误
+type SourceError struct {
+	Message string
+	Time    time.Time
+}
+
+// filteredDayRecord 某个源当天被过滤的条目链接
+type filteredDayRecord struct {
+	Day   string
+	Links map[string]bool
+}
+
+var (
+	// 订阅源最近一次抓取错误: map[RSS URL] -> 错误
+	sourceErrors     = make(map[string]SourceError)
+	sourceErrorsLock sync.RWMutex
+
+	// 当天被过滤的条目: map[RSS URL] -> 记录
+	filteredToday     = make(map[string]*filteredDayRecord)
+	filteredTodayLock sync.Mutex
+)
+
+// recordSourceError 记录订阅源抓取错误
+func recordSourceError(rssURL string, err error) {
+	sourceErrorsLock.Lock()
+	sourceErrors[rssURL] = SourceError{Message: err.Error(), Time: time.Now()}
+	sourceErrorsLock.Unlock()
+	trackSourceFailure(rssURL, err)
+}
+
+// clearSourceError 抓取成功后清除错误记录
+func clearSourceError(rssURL string) {
+	sourceErrorsLock.Lock()
+	delete(sourceErrors, rssURL)
+	sourceErrorsLock.Unlock()
+	trackSourceRecovery(rssURL)
+}
+
+// GetSourceError 获取订阅源最近一次抓取错误
+func GetSourceError(rssURL string) (SourceError, bool) {
+	sourceErrorsLock.RLock()
+	defer sourceErrorsLock.RUnlock()
+	e, ok := sourceErrors[rssURL]
+	return e, ok
+}
+
+// recordFilteredLinks 记录本次处理中被过滤的条目（按天去重累计）
+func recordFilteredLinks(rssURL string, links []string) {
+	if len(links) == 0 {
+		return
+	}
+	today := time.Now().Format("2006-01-02")
+
+	filteredTodayLock.Lock()
+	defer filteredTodayLock.Unlock()
+	record, ok := filteredToday[rssURL]
+	if !ok || record.Day != today {
+		record = &filteredDayRecord{Day: today, Links: make(map[string]bool)}
+		filteredToday[rssURL] = record
+	}
+	for _, link := range links {
+		record.Links[link] = true
+	}
+}
+
+// getFilteredTodayCount 获取订阅源今天被过滤的条目数
+func getFilteredTodayCount(rssURL string) int {
+	today := time.Now().Format("2006-01-02")
+
+	filteredTodayLock.Lock()
+	defer filteredTodayLock.Unlock()
+	record, ok := filteredToday[rssURL]
+	if !ok || record.Day != today {
+		return 0
+	}
+	return len(record.Links)
+}
+
+// buildFeedStats 计算卡片统计信息，sourceURLs 为卡片包含的订阅源（文件夹为多个）
+func buildFeedStats(items []models.Item, sourceURLs []string, now time.Time) *models.FeedStats {
+	stats := &models.FeedStats{
+		CachedItems: len(items),
+	}
+
+	cutoff := now.AddDate(0, 0, -statsAvgDays)
+	recent := 0
+	globals.ReadStateLock.RLock()
+	for _, item := range items {
+		if _, read := globals.ReadState[item.Link]; !read {
+			stats.Unread++
+		}
+		if t, ok := getItemSortTime(item); ok && t.After(cutoff) {
+			recent++
+		}
+	}
+	globals.ReadStateLock.RUnlock()
+	stats.AvgPerDay = math.Round(float64(recent)/statsAvgDays*10) / 10
+
+	var lastError SourceError
+	for _, u := range sourceURLs {
+		stats.FilteredToday += getFilteredTodayCount(u)
+		if e, ok := GetSourceError(u); ok && e.Time.After(lastError.Time) {
+			lastError = e
+		}
+	}
+	if lastError.Message != "" {
+		stats.LastError = lastError.Message
+		stats.LastErrorAt = lastError.Time.Format(time.RFC3339)
+	}
+	return stats
+}
+
+// getFolderSourceURLs 获取文件夹包含的所有订阅源 URL（含分类包与子文件夹展开）
+func getFolderSourceURLs(folder models.Folder) []string {
+	urls := make([]string, 0, len(folder.Entries))
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	// 已展开的文件夹，防止循环引用
+	visited := make(map[string]bool)
+	var collect func(folder models.Folder)
+	collect = func(folder models.Folder) {
+		visited[folder.ID] = true
+		for _, entry := range folder.Entries {
+			switch {
+			case entry.CategoryPackageId != "":
+				for _, src := range globals.RssUrls.GetSourcesByPackageId(entry.CategoryPackageId) {
+					add(src.URL)
+				}
+			case entry.FolderID != "":
+				if sub := globals.RssUrls.GetFolderByID(entry.FolderID); sub != nil && !visited[sub.ID] {
+					collect(*sub)
+				}
+			default:
+				add(entry.SourceURL)
+			}
+		}
+	}
+	collect(folder)
+	return urls
+}
+
+// DataStats 当前数据量统计（/api/stats）
+type DataStats struct {
+	Sources          int            `json:"sources"`
+	Items            int            `json:"items"`
+	CachedItems      int            `json:"cachedItems"`
+	ReadState        int            `json:"readState"`
+	ClassifyCache    int            `json:"classifyCache"`
+	PostProcessCache int            `json:"postProcessCache"`
+	Follows          int            `json:"follows"`
+	Retention        RetentionStats `json:"retention"`
+	LLMUsage         LLMUsageStats  `json:"llmUsage"`
+	LLMHealth        LLMHealthStats `json:"llmHealth"`
+	Bandwidth        BandwidthStats `json:"bandwidth"`
+	Fetch            FetchStats     `json:"fetch"`
+	HTTP             HTTPStats      `json:"http"`
+	// 持续失败中的订阅源（含已失效的源）
+	SourceHealth []SourceHealthStatus `json:"sourceHealth"`
+}
+
+// GetDataStats 获取当前各类数据的条数及数据保留清理统计
+func GetDataStats() DataStats {
+	stats := DataStats{Retention: GetRetentionStats(), LLMUsage: GetLLMUsageStats(), LLMHealth: GetLLMHealthStats(), Bandwidth: GetBandwidthStats(), Fetch: GetFetchStats(), HTTP: GetHTTPStats(), SourceHealth: GetSourceHealthStats()}
+
+	globals.Lock.RLock()
+	stats.Sources = len(globals.DbMap)
+	for _, feed := range globals.DbMap {
+		stats.Items += len(feed.Items)
+	}
+	globals.Lock.RUnlock()
+
+	globals.ItemsCacheLock.RLock()
+	for _, items := range globals.ItemsCache {
+		stats.CachedItems += len(items)
+	}
+	globals.ItemsCacheLock.RUnlock()
+
+	globals.ReadStateLock.RLock()
+	stats.ReadState = len(globals.ReadState)
+	globals.ReadStateLock.RUnlock()
+
+	globals.ClassifyCacheLock.RLock()
+	stats.ClassifyCache = len(globals.ClassifyCache)
+	globals.ClassifyCacheLock.RUnlock()
+
+	PostProcessCacheLock.RLock()
+	stats.PostProcessCache = len(PostProcessCache)
+	PostProcessCacheLock.RUnlock()
+
+	followsLock.Lock()
+	stats.Follows = len(follows)
+	followsLock.Unlock()
+
+	return stats
+}
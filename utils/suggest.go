@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// suggestSampleSize 试抓取后参与分类的条目数
+	suggestSampleSize = 10
+	// suggestMinCategoryShare 类别占比达到该值时建议绑定
+	suggestMinCategoryShare = 0.2
+	// suggestMinScore 文件夹/分组建议的最低得分
+	suggestMinScore = 0.3
+	// suggestMaxPlacements 文件夹/分组建议的最大数量
+	suggestMaxPlacements = 3
+)
+
+// CategoryShare 试分类结果中某个类别的条目数
+type CategoryShare struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// PlacementSuggestion 文件夹或分组建议
+type PlacementSuggestion struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// SourceSuggestion 新订阅源的归类建议
+type SourceSuggestion struct {
+	// 抓取到的源标题
+	Title string `json:"title"`
+	// 参与分类的条目数
+	SampleSize int `json:"sampleSize"`
+	// 试分类结果分布（未配置 AI 分类时为空）
+	Categories []CategoryShare `json:"categories"`
+	// 建议绑定的类别
+	BoundCategories []string `json:"boundCategories"`
+	// 建议加入的文件夹（按得分倒序）
+	Folders []PlacementSuggestion `json:"folders"`
+	// 建议加入的分组（按得分倒序）
+	Groups []PlacementSuggestion `json:"groups"`
+}
+
+// SuggestSourcePlacement 试抓取新订阅源并对样本进行分类，根据与现有源的类别分布相似度
+// 及站点是否相同，建议绑定的类别以及适合加入的文件夹和分组
+func SuggestSourcePlacement(source models.Source) (*SourceSuggestion, error) {
+	feed, err := fetchSourceFeed(source)
+	if err != nil {
+		return nil, fmt.Errorf("试抓取失败: %w", err)
+	}
+
+	sample := make(map[int]models.Item)
+	for i, item := range feed.Items {
+		if i >= suggestSampleSize {
+			break
+		}
+		sample[i] = models.Item{Title: item.Title, Link: item.Link, Description: item.Description}
+	}
+
+	suggestion := &SourceSuggestion{
+		Title:           feed.Title,
+		SampleSize:      len(sample),
+		Categories:      []CategoryShare{},
+		BoundCategories: []string{},
+		Folders:         []PlacementSuggestion{},
+		Groups:          []PlacementSuggestion{},
+	}
+
+	sampleDist := classifySample(sample, suggestion)
+
+	// 现有各源的类别分布与站点
+	sourceDists := make(map[string]map[string]float64)
+	globals.Lock.RLock()
+	for _, s := range globals.RssUrls.Sources {
+		if cache, ok := globals.DbMap[s.URL]; ok {
+			sourceDists[s.URL] = categoryDistribution(cache.Items)
+		}
+	}
+	globals.Lock.RUnlock()
+	host := suggestHost(source.URL)
+
+	score := func(urls []string) (float64, string) {
+		merged := make(map[string]float64)
+		sameHost := false
+		for _, u := range urls {
+			for cat, share := range sourceDists[u] {
+				merged[cat] += share
+			}
+			if host != "" && suggestHost(u) == host {
+				sameHost = true
+			}
+		}
+		similarity := cosineSimilarity(sampleDist, merged)
+		total := 0.8 * similarity
+		var reasons []string
+		if similarity > 0 {
+			reasons = append(reasons, fmt.Sprintf("类别分布相似度 %.0f%%", similarity*100))
+		}
+		if sameHost {
+			total += 0.2
+			reasons = append(reasons, "包含同站点的订阅源")
+		}
+		return math.Round(total*100) / 100, strings.Join(reasons, "，")
+	}
+
+	for _, folder := range globals.RssUrls.Folders {
+		s, reason := score(getFolderSourceURLs(folder))
+		if s >= suggestMinScore {
+			suggestion.Folders = append(suggestion.Folders, PlacementSuggestion{ID: folder.ID, Name: folder.Name, Score: s, Reason: reason})
+		}
+	}
+	for _, group := range globals.RssUrls.LayoutGroups {
+		var urls []string
+		for _, item := range group.Items {
+			if item.Type == "source" {
+				urls = append(urls, item.SourceURL)
+			} else if folder := globals.RssUrls.GetFolderByID(item.FolderID); folder != nil {
+				urls = append(urls, getFolderSourceURLs(*folder)...)
+			}
+		}
+		s, reason := score(urls)
+		if s >= suggestMinScore {
+			suggestion.Groups = append(suggestion.Groups, PlacementSuggestion{ID: group.ID, Name: group.Name, Score: s, Reason: reason})
+		}
+	}
+	suggestion.Folders = topPlacements(suggestion.Folders)
+	suggestion.Groups = topPlacements(suggestion.Groups)
+	return suggestion, nil
+}
+
+// classifySample 使用全局 AI 分类配置对样本分类，填写分类结果与建议绑定的类别，返回类别分布
+// 未配置 AI 分类或分类失败时返回空分布（仅依据站点给出建议）
+func classifySample(sample map[int]models.Item, suggestion *SourceSuggestion) map[string]float64 {
+	config := globals.RssUrls.AIClassify
+	categories := config.GetCategories(&globals.RssUrls)
+	if !config.Enabled || !config.HasAPIAccess() || len(categories) == 0 || len(sample) == 0 {
+		return nil
+	}
+
+	resp, err := NewLLMClient(config).ClassifyBatchItems(sample, nil, categories, nil)
+	if err != nil {
+		log.Printf("[归类建议] 样本分类失败: %v", err)
+		return nil
+	}
+
+	names := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		names[cat.ID] = cat.Name
+	}
+	counts := make(map[string]int)
+	classified := 0
+	for _, catID := range resp.Results {
+		if _, ok := names[catID]; ok {
+			counts[catID]++
+			classified++
+		}
+	}
+	if classified == 0 {
+		return nil
+	}
+
+	dist := make(map[string]float64, len(counts))
+	for catID, count := range counts {
+		suggestion.Categories = append(suggestion.Categories, CategoryShare{ID: catID, Name: names[catID], Count: count})
+		dist[catID] = float64(count) / float64(classified)
+	}
+	sort.Slice(suggestion.Categories, func(i, j int) bool {
+		if suggestion.Categories[i].Count != suggestion.Categories[j].Count {
+			return suggestion.Categories[i].Count > suggestion.Categories[j].Count
+		}
+		return suggestion.Categories[i].ID < suggestion.Categories[j].ID
+	})
+	for _, share := range suggestion.Categories {
+		if dist[share.ID] >= suggestMinCategoryShare {
+			suggestion.BoundCategories = append(suggestion.BoundCategories, share.ID)
+		}
+	}
+	return dist
+}
+
+// categoryDistribution 统计条目的类别占比（忽略未分类的条目）
+func categoryDistribution(items []models.Item) map[string]float64 {
+	dist := make(map[string]float64)
+	total := 0
+	for _, item := range items {
+		if item.Category != "" {
+			dist[item.Category]++
+			total++
+		}
+	}
+	for cat := range dist {
+		dist[cat] /= float64(total)
+	}
+	return dist
+}
+
+// cosineSimilarity 计算两个类别分布的余弦相似度
+func cosineSimilarity(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for k, v := range a {
+		dot += v * b[k]
+		normA += v * v
+	}
+	for _, v := range b {
+		normB += v * v
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// suggestHost 获取地址的站点（去掉 www. 前缀）
+func suggestHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// topPlacements 按得分倒序保留前几项建议
+func topPlacements(placements []PlacementSuggestion) []PlacementSuggestion {
+	sort.SliceStable(placements, func(i, j int) bool {
+		return placements[i].Score > placements[j].Score
+	})
+	if len(placements) > suggestMaxPlacements {
+		placements = placements[:suggestMaxPlacements]
+	}
+	return placements
+}
+
+// AddSource 将新订阅源写入配置文件，并按需加入文件夹、分组及绑定类别
+// 配置文件变更后由文件监听自动重新加载
+func AddSource(source models.Source, folderIDs []string, groupID string, boundCategories []string) error {
+	err := updateConfig(func(conf *models.Config) error {
+		if hasConfigSource(conf, source.URL) {
+			return fmt.Errorf("订阅源已存在: %s", source.URL)
+		}
+
+		if len(boundCategories) > 0 {
+			if source.Classify == nil {
+				source.Classify = &models.ClassifyStrategy{}
+			}
+			source.Classify.BoundCategories = boundCategories
+		}
+		conf.Sources = append(conf.Sources, source)
+
+		for _, id := range folderIDs {
+			idx := findFolderIndex(conf, id)
+			if idx < 0 {
+				return fmt.Errorf("文件夹不存在: %s", id)
+			}
+			conf.Folders[idx].Entries = append(conf.Folders[idx].Entries, models.FolderEntry{SourceURL: source.URL})
+		}
+
+		if groupID != "" {
+			idx := findLayoutGroupIndex(conf, groupID)
+			if idx < 0 {
+				return fmt.Errorf("分组不存在: %s", groupID)
+			}
+			conf.LayoutGroups[idx].Items = append(conf.LayoutGroups[idx].Items, models.LayoutItem{Type: "source", SourceURL: source.URL})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("[配置] 已添加订阅源: %s", source.URL)
+	return nil
+}
@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+var (
+	// ogImagePattern 匹配文章页面中的 og:image / twitter:image meta 标签
+	ogImagePattern = regexp.MustCompile(`(?is)<meta\b[^>]*?(?:property|name)\s*=\s*["'](?:og:image(?::url)?|twitter:image)["'][^>]*>`)
+	// metaContentPattern 读取 meta 标签的 content 属性
+	metaContentPattern = regexp.MustCompile(`(?is)\bcontent\s*=\s*("[^"]*"|'[^']*')`)
+	// firstImgPattern 匹配 HTML 中第一张图片的 src
+	firstImgPattern = regexp.MustCompile(`(?is)<img\b[^>]*?\ssrc\s*=\s*("[^"]*"|'[^']*')`)
+
+	// imageExtensions 按扩展名判断未声明类型的附件是否为图片
+	imageExtensions = map[string]bool{
+		".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".avif": true,
+	}
+)
+
+// 抓取文章页面 og:image 的并发数与单次读取的页面大小上限
+const (
+	ogImageConcurrency = 4
+	ogImageMaxPageSize = 512 * 1024
+)
+
+// extractThumbnail 按顺序从条目图片、图片附件、media:content / media:thumbnail 与描述中的第一张图片提取缩略图
+func extractThumbnail(item *gofeed.Item) string {
+	if item.Image != nil && item.Image.URL != "" {
+		return item.Image.URL
+	}
+	for _, enclosure := range item.Enclosures {
+		if enclosure != nil && isImageEnclosure(enclosure.URL, enclosure.Type) {
+			return enclosure.URL
+		}
+	}
+	if thumbnail := mediaThumbnail(item.Extensions["media"]); thumbnail != "" {
+		return thumbnail
+	}
+	for _, content := range []string{item.Content, item.Description} {
+		if match := firstImgPattern.FindStringSubmatch(content); match != nil {
+			if src := unquoteAttr(match[1]); isSafeURL(src, false) {
+				return src
+			}
+		}
+	}
+	return ""
+}
+
+// isImageEnclosure 判断附件是否为图片（未声明类型时按扩展名判断）
+func isImageEnclosure(link, mimeType string) bool {
+	if link == "" {
+		return false
+	}
+	if mimeType != "" {
+		return strings.HasPrefix(strings.ToLower(mimeType), "image/")
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return imageExtensions[strings.ToLower(path.Ext(parsed.Path))]
+}
+
+// mediaThumbnail 读取 media:thumbnail 或图片类型的 media:content（含 media:group 中的子元素）
+func mediaThumbnail(media map[string][]ext.Extension) string {
+	if media == nil {
+		return ""
+	}
+	for _, thumb := range media["thumbnail"] {
+		if thumb.Attrs["url"] != "" {
+			return thumb.Attrs["url"]
+		}
+	}
+	for _, content := range media["content"] {
+		if content.Attrs["url"] != "" && (content.Attrs["medium"] == "image" || isImageEnclosure(content.Attrs["url"], content.Attrs["type"])) {
+			return content.Attrs["url"]
+		}
+		// media:content 内嵌的 media:thumbnail（如视频的封面）
+		if thumbnail := mediaThumbnail(content.Children); thumbnail != "" {
+			return thumbnail
+		}
+	}
+	for _, group := range media["group"] {
+		if thumbnail := mediaThumbnail(group.Children); thumbnail != "" {
+			return thumbnail
+		}
+	}
+	return ""
+}
+
+// unquoteAttr 去除属性值两侧的引号并解码 HTML 实体
+func unquoteAttr(quoted string) string {
+	if len(quoted) >= 2 {
+		quoted = quoted[1 : len(quoted)-1]
+	}
+	return strings.TrimSpace(html.UnescapeString(quoted))
+}
+
+// ShouldFetchOGImage 检查源是否启用了抓取文章页面的 og:image
+func ShouldFetchOGImage(rssURL string) bool {
+	source := globals.RssUrls.GetSourceByURL(rssURL)
+	return source != nil && source.OGImage
+}
+
+// fillOGImageThumbnails 为没有缩略图的条目抓取文章页面的 og:image（结果按链接缓存，未找到图片的页面也不再重复抓取）
+func fillOGImageThumbnails(items []models.Item, rssURL string) []models.Item {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, ogImageConcurrency)
+	respectRobots := sourceRespectsRobots(rssURL)
+	fetched, disallowed := 0, 0
+	var fetchedLock sync.Mutex
+
+	for i := range items {
+		if items[i].Thumbnail != "" || items[i].Link == "" {
+			continue
+		}
+		link := items[i].Link
+		if items[i].OriginalLink != "" {
+			link = items[i].OriginalLink
+		}
+		if image, ok, err := DBGetOGImage(link); err == nil && ok {
+			items[i].Thumbnail = image
+			continue
+		}
+		// 在启动抓取前逐条检查，同一站点的 robots.txt 只下载一次
+		if respectRobots && !robotsAllowed(link) {
+			disallowed++
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, link string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			image, err := fetchOGImage(link)
+			if err != nil {
+				// 请求失败不缓存，下次抓取重试
+				return
+			}
+			if err := DBSaveOGImage(link, image); err != nil {
+				log.Printf("[缩略图] 缓存 og:image 失败: %v", err)
+			}
+			items[i].Thumbnail = image
+			fetchedLock.Lock()
+			fetched++
+			fetchedLock.Unlock()
+		}(i, link)
+	}
+	wg.Wait()
+
+	if fetched > 0 {
+		log.Printf("[缩略图] 源 [%s]: 抓取了 %d 个文章页面的 og:image", rssURL, fetched)
+	}
+	if disallowed > 0 {
+		log.Printf("[缩略图] 源 [%s]: robots.txt 禁止抓取 %d 个文章页面，已跳过", rssURL, disallowed)
+	}
+	return items
+}
+
+// fetchOGImage 抓取文章页面并读取 og:image，页面没有图片时返回空字符串
+func fetchOGImage(link string) (string, error) {
+	if !isSafeURL(link, false) {
+		return "", nil
+	}
+	client := &http.Client{
+		Transport: globals.Fp.Client.Transport,
+		Timeout:   10 * time.Second,
+	}
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.Contains(strings.ToLower(contentType), "html") {
+		return "", nil
+	}
+
+	// og:image 位于 <head> 中，只读取页面开头部分
+	body, err := io.ReadAll(io.LimitReader(resp.Body, ogImageMaxPageSize))
+	if err != nil {
+		return "", err
+	}
+	return parseOGImage(string(body), resp.Request.URL), nil
+}
+
+// parseOGImage 从页面 HTML 中读取 og:image，相对地址按页面地址解析
+func parseOGImage(page string, base *url.URL) string {
+	for _, tag := range ogImagePattern.FindAllString(page, -1) {
+		match := metaContentPattern.FindStringSubmatch(tag)
+		if match == nil {
+			continue
+		}
+		image := unquoteAttr(match[1])
+		if image == "" {
+			continue
+		}
+		if base != nil {
+			if ref, err := url.Parse(image); err == nil {
+				image = base.ResolveReference(ref).String()
+			}
+		}
+		if isSafeURL(image, false) {
+			return image
+		}
+	}
+	return ""
+}
+
+// cleanupOGImageCache 清理超过 30 天的 og:image 缓存
+func cleanupOGImageCache() int {
+	cleaned, err := DBDeleteOGImagesOlderThan(time.Now().AddDate(0, 0, -30).Unix())
+	if err != nil {
+		log.Printf("[数据清理] og:image 缓存清理失败: %v", err)
+		return 0
+	}
+	return cleaned
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"bytes"
+	"feedora/globals"
+	"feedora/models"
+	"log"
+	"strings"
+	"text/template"
+)
+
+// titleTemplateData 标题模板可用的字段
+type titleTemplateData struct {
+	// 当前标题（已后处理、已翻译）
+	Title string
+	// 主类别名称与ID（未分类时为空）
+	Category   string
+	CategoryID string
+	// 全部类别名称（多标签分类）
+	Categories []string
+	// 源名称（未设置 name 时为抓取到的源标题）
+	Source    string
+	Link      string
+	PubDate   string
+	Relevance int
+	Score     int
+	Comments  int
+}
+
+// ShouldFormatTitles 检查源是否配置了标题模板
+func ShouldFormatTitles(rssURL string) bool {
+	source := globals.RssUrls.GetSourceByURL(rssURL)
+	return source != nil && strings.TrimSpace(source.TitleTemplate) != ""
+}
+
+// FormatTitles 按源的标题模板格式化条目标题
+func FormatTitles(items []models.Item, rssURL string) []models.Item {
+	source := globals.RssUrls.GetSourceByURL(rssURL)
+	if source == nil {
+		return items
+	}
+	return formatItemTitles(items, *source)
+}
+
+// formatItemTitles 使用 Go text/template 格式化条目标题（在分类、后处理与翻译之后执行）
+// 模板无效时保持原标题；单个条目渲染失败或结果为空时保留该条目的原标题
+func formatItemTitles(items []models.Item, source models.Source) []models.Item {
+	if strings.TrimSpace(source.TitleTemplate) == "" || len(items) == 0 {
+		return items
+	}
+	tmpl, err := template.New("title").Option("missingkey=zero").Parse(source.TitleTemplate)
+	if err != nil {
+		log.Printf("[标题模板] 源 [%s]: 模板无效: %v", source.URL, err)
+		return items
+	}
+
+	categoryNames := make(map[string]string)
+	for _, cat := range globals.RssUrls.AIClassify.GetCategories(&globals.RssUrls) {
+		categoryNames[cat.ID] = cat.Name
+	}
+
+	result := make([]models.Item, len(items))
+	failed := 0
+	var buf bytes.Buffer
+	for i, item := range items {
+		result[i] = item
+		data := titleTemplateData{
+			Title:    item.Title,
+			Source:   item.Source,
+			Link:     item.Link,
+			PubDate:  item.PubDate,
+			Score:    item.Score,
+			Comments: item.Comments,
+		}
+		if source.Name != "" {
+			data.Source = source.Name
+		}
+		// 以 _ 开头的是内部标记（如 _keep / _review），不作为类别展示
+		if item.Category != "" && !strings.HasPrefix(item.Category, "_") {
+			data.CategoryID = item.Category
+			data.Category = categoryNames[item.Category]
+			if data.Category == "" {
+				data.Category = item.Category
+			}
+		}
+		for _, id := range item.CategoryIDs() {
+			if name, ok := categoryNames[id]; ok {
+				data.Categories = append(data.Categories, name)
+			}
+		}
+		if item.Relevance != nil {
+			data.Relevance = *item.Relevance
+		}
+
+		buf.Reset()
+		if err := tmpl.Execute(&buf, data); err != nil {
+			failed++
+			continue
+		}
+		if title := strings.TrimSpace(buf.String()); title != "" {
+			result[i].Title = title
+		}
+	}
+	if failed > 0 {
+		log.Printf("[标题模板] 源 [%s]: %d 篇条目渲染失败，保留原标题", source.URL, failed)
+	}
+	return result
+}
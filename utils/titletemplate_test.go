@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"feedora/models"
+	"testing"
+)
+
+func TestFormatItemTitles(t *testing.T) {
+	source := models.Source{
+		URL:           "https://example.com/feed",
+		Name:          "Example",
+		TitleTemplate: "{{if .Category}}[{{.Category}}] {{end}}{{.Title}} — {{.Source}}",
+	}
+	items := []models.Item{
+		{Title: "Hello", Category: "tech", Source: "Example Feed"},
+		{Title: "Kept by keyword", Category: "_keep"},
+	}
+
+	got := formatItemTitles(items, source)
+	if want := "[tech] Hello — Example"; got[0].Title != want {
+		t.Errorf("Title = %q, want %q", got[0].Title, want)
+	}
+	if want := "Kept by keyword — Example"; got[1].Title != want {
+		t.Errorf("Title = %q, want %q", got[1].Title, want)
+	}
+	if items[0].Title != "Hello" {
+		t.Errorf("input item modified: %q", items[0].Title)
+	}
+}
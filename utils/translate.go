@@ -0,0 +1,384 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+var (
+	// 翻译缓存: map[哈希(目标语言+原文)] -> 译文
+	translationCache     map[string]DBTranslationEntry
+	translationCacheLock sync.RWMutex
+	translationCacheOnce sync.Once
+)
+
+// translationLanguageNames 常用语言代码对应的名称（用于大模型提示词）
+var translationLanguageNames = map[string]string{
+	"zh":    "简体中文",
+	"zh-cn": "简体中文",
+	"zh-tw": "繁体中文",
+	"en":    "英文",
+	"ja":    "日文",
+	"ko":    "韩文",
+	"fr":    "法文",
+	"de":    "德文",
+	"es":    "西班牙文",
+	"ru":    "俄文",
+}
+
+// getTranslateConfig 获取源的标题翻译配置
+func getTranslateConfig(rssURL string) *models.TranslateConfig {
+	for _, source := range globals.RssUrls.Sources {
+		if source.URL == rssURL {
+			return source.Translate
+		}
+	}
+	return nil
+}
+
+// ShouldTranslate 检查源是否启用了标题翻译
+func ShouldTranslate(rssURL string) bool {
+	config := getTranslateConfig(rssURL)
+	return config != nil && config.Enabled
+}
+
+// TranslateItems 按源的翻译配置翻译条目标题
+func TranslateItems(items []models.Item, rssURL string) []models.Item {
+	return translateItems(items, getTranslateConfig(rssURL))
+}
+
+// translateItems 将条目标题翻译为目标语言，原标题保存在 OriginalTitle 中
+// 已翻译过（OriginalTitle 非空）或已是目标语言的标题跳过；译文按目标语言与原文的哈希缓存
+func translateItems(items []models.Item, config *models.TranslateConfig) []models.Item {
+	if config == nil || !config.Enabled || len(items) == 0 {
+		return items
+	}
+	targetLang := config.GetTargetLang()
+	ensureTranslationCacheLoaded()
+
+	// 收集需要翻译的标题（去重）
+	var missing []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		title := strings.TrimSpace(item.Title)
+		if item.OriginalTitle != "" || title == "" || seen[title] || isInTargetLanguage(title, targetLang) {
+			continue
+		}
+		seen[title] = true
+		if _, ok := getCachedTranslation(targetLang, title); !ok {
+			missing = append(missing, title)
+		}
+	}
+
+	if len(missing) > 0 {
+		engine := globals.RssUrls.Translation.GetEngine()
+		batchSize := globals.RssUrls.Translation.GetBatchSize()
+		for start := 0; start < len(missing); start += batchSize {
+			end := start + batchSize
+			if end > len(missing) {
+				end = len(missing)
+			}
+			batch := missing[start:end]
+			translated, err := requestTranslations(engine, batch, targetLang)
+			if err != nil {
+				log.Printf("[标题翻译] 翻译失败 (%s, %d 条): %v", engine, len(batch), err)
+				continue
+			}
+			saveTranslations(targetLang, batch, translated)
+		}
+	}
+
+	result := make([]models.Item, len(items))
+	translatedCount := 0
+	for i, item := range items {
+		result[i] = item
+		title := strings.TrimSpace(item.Title)
+		if item.OriginalTitle != "" || !seen[title] {
+			continue
+		}
+		if text, ok := getCachedTranslation(targetLang, title); ok && text != "" && text != title {
+			result[i].OriginalTitle = item.Title
+			result[i].Title = text
+			translatedCount++
+		}
+	}
+	if translatedCount > 0 {
+		log.Printf("[标题翻译] 已翻译 %d 条标题 (目标语言: %s，新请求: %d)", translatedCount, targetLang, len(missing))
+	}
+	return result
+}
+
+// translationKey 计算翻译缓存的键
+func translationKey(targetLang, text string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(targetLang) + "\n" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureTranslationCacheLoaded 首次使用时从数据库加载翻译缓存
+func ensureTranslationCacheLoaded() {
+	translationCacheOnce.Do(func() {
+		cache, err := DBLoadTranslationCache()
+		if err != nil {
+			log.Printf("[标题翻译] 加载翻译缓存失败: %v", err)
+			cache = make(map[string]DBTranslationEntry)
+		}
+		translationCacheLock.Lock()
+		translationCache = cache
+		translationCacheLock.Unlock()
+	})
+}
+
+// getCachedTranslation 读取缓存的译文
+func getCachedTranslation(targetLang, text string) (string, bool) {
+	translationCacheLock.RLock()
+	defer translationCacheLock.RUnlock()
+	entry, ok := translationCache[translationKey(targetLang, text)]
+	return entry.Text, ok
+}
+
+// saveTranslations 保存译文到内存与数据库（译文为空的不缓存，下次重试）
+func saveTranslations(targetLang string, texts, translated []string) {
+	now := time.Now().Unix()
+	entries := make(map[string]DBTranslationEntry, len(texts))
+	for i, text := range texts {
+		if i >= len(translated) || strings.TrimSpace(translated[i]) == "" {
+			continue
+		}
+		entries[translationKey(targetLang, text)] = DBTranslationEntry{Text: strings.TrimSpace(translated[i]), CreatedAt: now}
+	}
+
+	translationCacheLock.Lock()
+	for key, entry := range entries {
+		translationCache[key] = entry
+	}
+	translationCacheLock.Unlock()
+	if err := DBSaveTranslations(entries); err != nil {
+		log.Printf("[标题翻译] 保存翻译缓存失败: %v", err)
+	}
+}
+
+// purgeExpiredTranslations 删除早于截止时间的翻译缓存，返回删除条数
+func purgeExpiredTranslations(cutoff int64) int {
+	ensureTranslationCacheLoaded()
+	translationCacheLock.Lock()
+	for key, entry := range translationCache {
+		if entry.CreatedAt < cutoff {
+			delete(translationCache, key)
+		}
+	}
+	translationCacheLock.Unlock()
+
+	n, err := DBDeleteTranslationsOlderThan(cutoff)
+	if err != nil {
+		log.Printf("[数据保留] 删除翻译缓存失败: %v", err)
+	}
+	return n
+}
+
+// isInTargetLanguage 根据文字系统粗略判断标题是否已是目标语言（仅对中日韩目标语言判断，其余交给翻译引擎）
+func isInTargetLanguage(text, targetLang string) bool {
+	lang := strings.ToLower(targetLang)
+	// 其他文字按单词计数，避免夹杂的英文品牌名压过中日韩字符
+	var han, kana, hangul, words int
+	inWord := false
+	for _, r := range text {
+		isOther := false
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.IsLetter(r):
+			isOther = true
+			if !inWord {
+				words++
+			}
+		}
+		inWord = isOther
+	}
+	total := han + kana + hangul + words
+	if total == 0 {
+		return true
+	}
+	switch {
+	case strings.HasPrefix(lang, "zh"):
+		return kana == 0 && han*2 >= total
+	case strings.HasPrefix(lang, "ja"):
+		return kana > 0
+	case strings.HasPrefix(lang, "ko"):
+		return hangul*2 >= total
+	}
+	return false
+}
+
+// requestTranslations 调用翻译引擎，返回与输入顺序一致的译文
+func requestTranslations(engine string, texts []string, targetLang string) ([]string, error) {
+	switch engine {
+	case "deepl":
+		return translateWithDeepL(texts, targetLang)
+	case "libretranslate":
+		return translateWithLibreTranslate(texts, targetLang)
+	default:
+		return translateWithLLM(texts, targetLang)
+	}
+}
+
+// translateWithLLM 使用 aiClassify 配置的大模型批量翻译
+func translateWithLLM(texts []string, targetLang string) ([]string, error) {
+	aiConfig := globals.RssUrls.AIClassify
+	if !aiConfig.HasAPIAccess() {
+		return nil, fmt.Errorf("AI API Key未配置")
+	}
+	langName := translationLanguageNames[strings.ToLower(targetLang)]
+	if langName == "" {
+		langName = targetLang
+	}
+
+	prompt := fmt.Sprintf("你是一名专业的新闻标题译者。把用户给出的每个标题翻译为%s，保留专有名词、产品名和数字，译文简洁自然。"+
+		"已经是%s的标题原样返回。只输出 JSON：{\"results\": {\"编号\": \"译文\"}}，不要输出其他内容。", langName, langName)
+	input := make(map[string]string, len(texts))
+	for i, text := range texts {
+		input[strconv.Itoa(i)] = text
+	}
+	inputJSON, _ := json.Marshal(input)
+
+	reqBody := ChatRequest{
+		Model:   aiConfig.GetModel(),
+		Purpose: "translate",
+		Messages: []ChatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: string(inputJSON)},
+		},
+		Temperature: aiConfig.GetTemperature(),
+		// 翻译输出长度与输入相当，不受分类用的 maxTokens 限制
+		MaxTokens: 200 + 100*len(texts),
+	}
+	jsonMode := aiConfig.GetJSONMode()
+	maybeEnableJSONObjectResponseFormat(&reqBody, jsonMode, prompt)
+
+	client := &http.Client{
+		Timeout: time.Duration(aiConfig.GetTimeout()) * time.Second,
+	}
+	chatResp, err := sendChatCompletion(client, aiConfig, jsonMode, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Results map[string]string `json:"results"`
+	}
+	content := extractJSON(stripCodeFences(chatResp.Choices[0].Message.Content))
+	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+		return nil, fmt.Errorf("解析翻译结果失败: %w", err)
+	}
+	translated := make([]string, len(texts))
+	for i := range texts {
+		translated[i] = parsed.Results[strconv.Itoa(i)]
+	}
+	return translated, nil
+}
+
+// translateWithDeepL 调用 DeepL /v2/translate 接口
+func translateWithDeepL(texts []string, targetLang string) ([]string, error) {
+	config := globals.RssUrls.Translation
+	apiKey, err := ResolveSecret(config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("DeepL API Key未配置")
+	}
+
+	lang := strings.ToUpper(targetLang)
+	switch lang {
+	case "ZH", "ZH-CN", "ZH-HANS":
+		lang = "ZH-HANS"
+	case "ZH-TW", "ZH-HK", "ZH-HANT":
+		lang = "ZH-HANT"
+	case "EN":
+		lang = "EN-US"
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	body, err := postLLMJSON(client, strings.TrimSuffix(config.GetAPIBase(), "/")+"/v2/translate",
+		map[string]string{"Authorization": "DeepL-Auth-Key " + apiKey},
+		map[string]interface{}{"text": texts, "target_lang": lang})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w (Body: %s)", err, truncateString(string(body), 200))
+	}
+	if len(result.Translations) != len(texts) {
+		return nil, fmt.Errorf("API错误: %s", result.Message)
+	}
+	translated := make([]string, len(texts))
+	for i, t := range result.Translations {
+		translated[i] = t.Text
+	}
+	return translated, nil
+}
+
+// translateWithLibreTranslate 调用 LibreTranslate /translate 接口（可自行部署）
+func translateWithLibreTranslate(texts []string, targetLang string) ([]string, error) {
+	config := globals.RssUrls.Translation
+	apiKey, err := ResolveSecret(config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	lang := strings.ToLower(targetLang)
+	switch lang {
+	case "zh-tw", "zh-hk", "zh-hant":
+		lang = "zt"
+	default:
+		if idx := strings.Index(lang, "-"); idx > 0 {
+			lang = lang[:idx]
+		}
+	}
+
+	payload := map[string]interface{}{"q": texts, "source": "auto", "target": lang, "format": "text"}
+	if apiKey != "" {
+		payload["api_key"] = apiKey
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	body, err := postLLMJSON(client, strings.TrimSuffix(config.GetAPIBase(), "/")+"/translate", nil, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		TranslatedText []string `json:"translatedText"`
+		Error          string   `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w (Body: %s)", err, truncateString(string(body), 200))
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("API错误: %s", result.Error)
+	}
+	if len(result.TranslatedText) != len(texts) {
+		return nil, fmt.Errorf("返回的译文数 %d 与请求的标题数 %d 不一致", len(result.TranslatedText), len(texts))
+	}
+	return result.TranslatedText, nil
+}
@@ -0,0 +1,518 @@
+package utils
+
+import (
+	"encoding/json"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 热门话题检测：定时从所有订阅源统计窗口内的条目标题中提取关键词（本地按词频提取，或调用 AI 提取实体与话题词），
+// 统计每个关键词被多少条目、多少个订阅源提及，并与统计窗口之前的对比窗口比较，按「订阅源数 × 增长倍数」排序
+
+// trendingExamples 每个话题返回的示例条目数
+const trendingExamples = 5
+
+// TrendingItem 提及话题的条目
+type TrendingItem struct {
+	Title  string `json:"title"`
+	Link   string `json:"link"`
+	Source string `json:"source,omitempty"`
+}
+
+// TrendingTopic 一个热门话题
+type TrendingTopic struct {
+	Term string `json:"term"`
+	// 统计窗口内提及的条目数与订阅源数
+	Items   int `json:"items"`
+	Sources int `json:"sources"`
+	// 对比窗口内提及的条目数
+	Baseline int `json:"baseline"`
+	// 提及频率相对对比窗口的增长倍数（按窗口时长折算）
+	Growth float64 `json:"growth"`
+	// 排序得分：订阅源数 × 增长倍数
+	Score float64 `json:"score"`
+	// 最新的几条提及条目
+	Examples []TrendingItem `json:"examples"`
+}
+
+// Trending 热门话题检测结果
+type Trending struct {
+	GeneratedAt string `json:"generatedAt"`
+	// 统计窗口与对比窗口（小时）
+	Hours         int `json:"hours"`
+	BaselineHours int `json:"baselineHours"`
+	// 统计窗口内的条目数
+	ItemCount int `json:"itemCount"`
+	// 关键词提取方式: keyword（本地词频）/ ai
+	Method string          `json:"method"`
+	Topics []TrendingTopic `json:"topics"`
+}
+
+// trendingEntry 参与统计的条目
+type trendingEntry struct {
+	item   models.Item
+	source string
+	name   string
+}
+
+var (
+	latestTrending     *Trending
+	latestTrendingLock sync.RWMutex
+	// 串行检测，避免定时任务与手动请求同时调用 AI
+	trendingGenerateLock sync.Mutex
+
+	// AI 提取的关键词: map[链接] -> 关键词，已提取过的条目不再发送给 AI
+	trendingTerms     = make(map[string][]string)
+	trendingTermsLock sync.Mutex
+)
+
+// GetLatestTrending 获取最近一次检测的热门话题
+func GetLatestTrending() (*Trending, bool) {
+	latestTrendingLock.RLock()
+	defer latestTrendingLock.RUnlock()
+	return latestTrending, latestTrending != nil
+}
+
+// TrendingLoop 按配置的间隔检测热门话题
+func TrendingLoop() {
+	var lastRun time.Time
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		config := globals.RssUrls.Trending
+		if !config.Enabled || now.Sub(lastRun) < time.Duration(config.GetInterval())*time.Minute {
+			continue
+		}
+		lastRun = now
+		if _, err := GenerateTrending(); err != nil {
+			log.Printf("[热门话题] 检测失败: %v", err)
+		}
+	}
+}
+
+// GenerateTrending 统计所有订阅源最近的条目，检测热门话题
+func GenerateTrending() (*Trending, error) {
+	trendingGenerateLock.Lock()
+	defer trendingGenerateLock.Unlock()
+
+	config := globals.RssUrls.Trending
+	now := time.Now()
+	window := time.Duration(config.GetHours()) * time.Hour
+	baseline := time.Duration(config.GetBaselineHours()) * time.Hour
+	recent, previous := collectTrendingItems(now.Add(-window), now.Add(-window-baseline))
+
+	trending := &Trending{
+		GeneratedAt:   now.Format(time.RFC3339),
+		Hours:         config.GetHours(),
+		BaselineHours: config.GetBaselineHours(),
+		ItemCount:     len(recent),
+		Method:        "keyword",
+	}
+	extract := func(item models.Item) []string {
+		return extractTitleTerms(item.Title)
+	}
+	if config.UseAI {
+		terms, err := extractTermsWithAI(append(append([]trendingEntry(nil), recent...), previous...), config)
+		if err != nil {
+			log.Printf("[热门话题] AI 提取关键词失败，改用本地提取: %v", err)
+		} else {
+			trending.Method = "ai"
+			extract = func(item models.Item) []string {
+				return terms[item.Link]
+			}
+		}
+	}
+	trending.Topics = rankTrendingTerms(recent, previous, extract, config, window, baseline)
+
+	latestTrendingLock.Lock()
+	latestTrending = trending
+	latestTrendingLock.Unlock()
+	log.Printf("[热门话题] 已检测 | 条目: %d | 话题: %d | 方式: %s", trending.ItemCount, len(trending.Topics), trending.Method)
+	return trending, nil
+}
+
+// collectTrendingItems 收集所有订阅源在统计窗口（since 之后）与对比窗口（baselineSince 到 since）内的条目，按链接去重，按时间倒序
+func collectTrendingItems(since, baselineSince time.Time) (recent, previous []trendingEntry) {
+	seen := make(map[string]bool)
+	globals.Lock.RLock()
+	for _, source := range globals.RssUrls.Sources {
+		feed, ok := globals.DbMap[source.URL]
+		if !ok {
+			continue
+		}
+		for _, item := range feed.Items {
+			if item.Link == "" || seen[item.Link] || strings.Contains(item.Title, "⚠️") {
+				continue
+			}
+			t, ok := getItemSortTime(item)
+			if !ok || t.Before(baselineSince) {
+				continue
+			}
+			seen[item.Link] = true
+			entry := trendingEntry{item: item, source: source.URL, name: feed.Title}
+			if t.Before(since) {
+				previous = append(previous, entry)
+			} else {
+				recent = append(recent, entry)
+			}
+		}
+	}
+	globals.Lock.RUnlock()
+
+	for _, entries := range [][]trendingEntry{recent, previous} {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return compareItemsByRecency(entries[i].item, entries[j].item) > 0
+		})
+	}
+	return recent, previous
+}
+
+// trendingTermStat 关键词的统计
+type trendingTermStat struct {
+	term     string
+	links    map[string]bool
+	sources  map[string]bool
+	baseline int
+	examples []TrendingItem
+}
+
+// rankTrendingTerms 统计关键词并排序：至少出现在 minSources 个订阅源中，
+// 被同样条目提及的更长关键词（如「大模型」与「模型」）只保留更长的一个
+func rankTrendingTerms(recent, previous []trendingEntry, extract func(models.Item) []string, config models.TrendingConfig, window, baseline time.Duration) []TrendingTopic {
+	stopWords := make(map[string]bool, len(config.StopWords))
+	for _, word := range config.StopWords {
+		stopWords[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+
+	stats := make(map[string]*trendingTermStat)
+	for _, entry := range recent {
+		for _, term := range extract(entry.item) {
+			key := strings.ToLower(term)
+			if key == "" || stopWords[key] {
+				continue
+			}
+			stat, ok := stats[key]
+			if !ok {
+				stat = &trendingTermStat{term: term, links: make(map[string]bool), sources: make(map[string]bool)}
+				stats[key] = stat
+			}
+			if stat.links[entry.item.Link] {
+				continue
+			}
+			stat.links[entry.item.Link] = true
+			stat.sources[entry.source] = true
+			if len(stat.examples) < trendingExamples {
+				stat.examples = append(stat.examples, TrendingItem{Title: entry.item.Title, Link: entry.item.Link, Source: entry.name})
+			}
+		}
+	}
+	for _, entry := range previous {
+		counted := make(map[string]bool)
+		for _, term := range extract(entry.item) {
+			key := strings.ToLower(term)
+			if stat, ok := stats[key]; ok && !counted[key] {
+				counted[key] = true
+				stat.baseline++
+			}
+		}
+	}
+
+	minSources := config.GetMinSources()
+	candidates := make([]string, 0, len(stats))
+	for key, stat := range stats {
+		if len(stat.sources) >= minSources {
+			candidates = append(candidates, key)
+		}
+	}
+	// 先保留长的关键词，再丢弃被其包含且提及条目数相同的短关键词
+	sort.Slice(candidates, func(i, j int) bool {
+		if len(candidates[i]) != len(candidates[j]) {
+			return len(candidates[i]) > len(candidates[j])
+		}
+		return candidates[i] < candidates[j]
+	})
+	var kept []string
+	for _, key := range candidates {
+		covered := false
+		for _, longer := range kept {
+			if strings.Contains(longer, key) && len(stats[longer].links) == len(stats[key].links) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, key)
+		}
+	}
+
+	// 对比窗口的提及数按窗口时长折算到统计窗口
+	scale := window.Hours() / baseline.Hours()
+	topics := make([]TrendingTopic, 0, len(kept))
+	for _, key := range kept {
+		stat := stats[key]
+		growth := float64(len(stat.links)+1) / (float64(stat.baseline)*scale + 1)
+		topics = append(topics, TrendingTopic{
+			Term:     stat.term,
+			Items:    len(stat.links),
+			Sources:  len(stat.sources),
+			Baseline: stat.baseline,
+			Growth:   math.Round(growth*10) / 10,
+			Score:    math.Round(float64(len(stat.sources))*growth*10) / 10,
+			Examples: stat.examples,
+		})
+	}
+	sort.SliceStable(topics, func(i, j int) bool {
+		if topics[i].Score != topics[j].Score {
+			return topics[i].Score > topics[j].Score
+		}
+		if topics[i].Items != topics[j].Items {
+			return topics[i].Items > topics[j].Items
+		}
+		return topics[i].Term < topics[j].Term
+	})
+	if limit := config.GetMaxTopics(); len(topics) > limit {
+		topics = topics[:limit]
+	}
+	return topics
+}
+
+// 本地提取时忽略的常见词
+var trendingStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "that": true, "this": true, "what": true,
+	"how": true, "why": true, "when": true, "who": true, "are": true, "was": true, "were": true, "has": true,
+	"have": true, "had": true, "will": true, "can": true, "its": true, "you": true, "your": true, "our": true,
+	"new": true, "not": true, "but": true, "all": true, "more": true, "about": true, "after": true, "over": true,
+	"into": true, "just": true, "now": true, "than": true, "out": true, "get": true, "gets": true, "says": true,
+	"said": true, "via": true, "one": true, "two": true, "first": true, "year": true, "years": true, "day": true,
+	"week": true, "today": true, "show": true, "ask": true, "could": true, "would": true, "should": true,
+	"may": true, "been": true, "they": true, "their": true, "there": true, "here": true, "his": true, "her": true,
+	"she": true, "him": true, "use": true, "using": true, "make": true, "most": true, "some": true, "why's": true,
+	"我们": true, "你们": true, "他们": true, "什么": true, "怎么": true, "如何": true, "为什么": true, "一个": true,
+	"这个": true, "那个": true, "可以": true, "没有": true, "已经": true, "今天": true, "昨天": true, "明天": true,
+	"目前": true, "正在": true, "表示": true, "宣布": true, "发布": true, "最新": true, "消息": true, "回应": true,
+	"问题": true, "时间": true, "一些": true, "不是": true, "就是": true, "还是": true, "以及": true, "进行": true,
+	"相关": true, "关于": true, "网友": true, "视频": true, "官方": true, "重磅": true, "突发": true, "热议": true,
+}
+
+// 中文关键词首尾不应出现的字（助词、介词、连词等）
+const trendingHanBoundary = "的了是在和与及或也都被把将为对从等这那个之其又就还吗呢吧啊么着过给让向于"
+
+// extractTitleTerms 在本地从标题中提取候选关键词（同一标题中去重）：
+// 以空格分词的文字取单词及相邻两词组成的词组，中文取 2 到 4 字的片段（不做分词，由跨源统计与包含关系筛选）
+func extractTitleTerms(title string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if term != "" && !seen[term] && !trendingStopWords[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	runes := []rune(title)
+	prevWord := ""
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.Is(unicode.Han, r):
+			j := i
+			for j < len(runes) && unicode.Is(unicode.Han, runes[j]) {
+				j++
+			}
+			for _, gram := range hanGrams(runes[i:j]) {
+				add(gram)
+			}
+			prevWord = ""
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && !unicode.Is(unicode.Han, runes[j]) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune("-.+#'", runes[j])) {
+				j++
+			}
+			word := strings.ToLower(strings.TrimRight(string(runes[i:j]), "-.'"))
+			i = j
+			if !isTrendingWord(word) {
+				prevWord = ""
+				continue
+			}
+			add(word)
+			if prevWord != "" {
+				add(prevWord + " " + word)
+			}
+			prevWord = word
+			// 只有以空格分隔的相邻单词组成词组
+			if i < len(runes) && runes[i] != ' ' {
+				prevWord = ""
+			}
+		default:
+			if r != ' ' {
+				prevWord = ""
+			}
+			i++
+		}
+	}
+	return terms
+}
+
+// isTrendingWord 判断单词是否可作为关键词：至少 3 个字符（含数字时 2 个），不是纯数字与常见词
+func isTrendingWord(word string) bool {
+	if trendingStopWords[word] {
+		return false
+	}
+	length := len([]rune(word))
+	hasDigit, hasLetter := false, false
+	for _, r := range word {
+		if unicode.IsDigit(r) {
+			hasDigit = true
+		} else if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	if !hasLetter {
+		return false
+	}
+	return length >= 3 || (hasDigit && length >= 2)
+}
+
+// hanGrams 取中文片段中 2 到 4 字的子串，首尾为助词、介词等以及跨过「的」的子串除外
+func hanGrams(run []rune) []string {
+	var grams []string
+	for n := 2; n <= 4; n++ {
+		for i := 0; i+n <= len(run); i++ {
+			gram := string(run[i : i+n])
+			if strings.ContainsRune(trendingHanBoundary, run[i]) || strings.ContainsRune(trendingHanBoundary, run[i+n-1]) || strings.ContainsRune(gram, '的') {
+				continue
+			}
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
+
+// extractTermsWithAI 调用 AI 提取条目标题中的实体与话题词，返回 map[链接] -> 关键词
+// 已提取过的条目使用缓存，每次最多发送 maxItems 条新条目（优先发送统计窗口内的条目）
+func extractTermsWithAI(entries []trendingEntry, config models.TrendingConfig) (map[string][]string, error) {
+	aiConfig := globals.RssUrls.AIClassify
+	if !aiConfig.HasAPIAccess() {
+		return nil, fmt.Errorf("AI API Key未配置")
+	}
+
+	trendingTermsLock.Lock()
+	var pending []models.Item
+	for _, entry := range entries {
+		if _, ok := trendingTerms[entry.item.Link]; !ok && len(pending) < config.GetMaxItems() {
+			pending = append(pending, entry.item)
+		}
+	}
+	trendingTermsLock.Unlock()
+
+	if len(pending) > 0 {
+		if LLMBudgetExceeded() {
+			return nil, errLLMBudgetExceeded
+		}
+		extracted, err := requestTrendingTerms(pending, aiConfig)
+		if err != nil {
+			return nil, err
+		}
+		trendingTermsLock.Lock()
+		for _, item := range pending {
+			// 没有返回关键词的条目同样记录，避免重复发送
+			trendingTerms[item.Link] = extracted[item.Link]
+		}
+		trendingTermsLock.Unlock()
+	}
+
+	// 只保留仍在统计范围内的条目
+	trendingTermsLock.Lock()
+	defer trendingTermsLock.Unlock()
+	current := make(map[string]bool, len(entries))
+	result := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		current[entry.item.Link] = true
+		result[entry.item.Link] = trendingTerms[entry.item.Link]
+	}
+	for link := range trendingTerms {
+		if !current[link] {
+			delete(trendingTerms, link)
+		}
+	}
+	return result, nil
+}
+
+// requestTrendingTerms 发送条目标题给 AI，返回 map[链接] -> 关键词
+func requestTrendingTerms(items []models.Item, aiConfig models.AIClassifyConfig) (map[string][]string, error) {
+	prompt := "你是一名资讯编辑。用户会给出一组新闻标题（编号和标题）。请从每个标题中提取 0 到 3 个关键实体或话题词" +
+		"（人名、公司、产品、地名、事件等），使用标题原文中的写法，同一实体在不同标题中使用相同的写法，" +
+		"不要提取「发布」「最新」之类的泛泛词语。" +
+		"只输出 JSON：{\"编号\": [\"关键词\"]}，不要输出其他内容。"
+
+	var content strings.Builder
+	for i, item := range items {
+		content.WriteString(fmt.Sprintf("%d. %s\n", i, item.Title))
+	}
+
+	reqBody := ChatRequest{
+		Model:   aiConfig.GetModel(),
+		Purpose: "trending",
+		Messages: []ChatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: content.String()},
+		},
+		Temperature: aiConfig.GetTemperature(),
+		// 条目较多时输出较长，不受分类用的 maxTokens 限制
+		MaxTokens: 4000,
+	}
+	jsonMode := aiConfig.GetJSONMode()
+	maybeEnableJSONObjectResponseFormat(&reqBody, jsonMode, prompt)
+
+	client := &http.Client{
+		Timeout: 4 * time.Duration(aiConfig.GetTimeout()) * time.Second,
+	}
+	chatResp, err := sendChatCompletion(client, aiConfig, jsonMode, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed map[string]json.RawMessage
+	responseContent := extractJSON(stripCodeFences(chatResp.Choices[0].Message.Content))
+	if err := json.Unmarshal([]byte(responseContent), &parsed); err != nil {
+		return nil, fmt.Errorf("解析关键词失败: %w", err)
+	}
+
+	result := make(map[string][]string, len(parsed))
+	for id, raw := range parsed {
+		index, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil || index < 0 || index >= len(items) {
+			continue
+		}
+		// 关键词可能以数组或单个字符串返回
+		var terms []string
+		if err := json.Unmarshal(raw, &terms); err != nil {
+			var single string
+			if json.Unmarshal(raw, &single) != nil {
+				continue
+			}
+			terms = []string{single}
+		}
+		seen := make(map[string]bool, len(terms))
+		for _, term := range terms {
+			term = strings.TrimSpace(term)
+			key := strings.ToLower(term)
+			if term != "" && !seen[key] {
+				seen[key] = true
+				result[items[index].Link] = append(result[items[index].Link], term)
+			}
+		}
+	}
+	return result, nil
+}
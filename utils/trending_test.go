@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"feedora/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractTitleTerms(t *testing.T) {
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"OpenAI releases GPT-5, the new model", []string{"openai", "releases", "openai releases", "gpt-5", "releases gpt-5", "model"}},
+		{"Show HN: Node.js 22 is out", []string{"node.js"}},
+		{"苹果的新品", []string{"苹果", "新品"}},
+	}
+	for _, tt := range tests {
+		if got := extractTitleTerms(tt.title); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractTitleTerms(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestRankTrendingTerms(t *testing.T) {
+	entry := func(link, source, title string) trendingEntry {
+		return trendingEntry{item: models.Item{Link: link, Title: title}, source: source, name: source}
+	}
+	recent := []trendingEntry{
+		entry("https://a.example.com/1", "a", "大模型价格战开打"),
+		entry("https://b.example.com/1", "b", "国产大模型降价"),
+		entry("https://c.example.com/1", "c", "Rust 1.80 released"),
+		entry("https://c.example.com/2", "c", "Why Rust compiles slowly"),
+		entry("https://d.example.com/1", "d", "Kubernetes 1.31 released"),
+	}
+	previous := []trendingEntry{
+		entry("https://d.example.com/0", "d", "Go 1.23 released"),
+		entry("https://e.example.com/0", "e", "Python 3.13 released"),
+		entry("https://e.example.com/1", "e", "Ruby 3.4 released"),
+	}
+	config := models.TrendingConfig{StopWords: []string{"降价"}}
+	extract := func(item models.Item) []string { return extractTitleTerms(item.Title) }
+	topics := rankTrendingTerms(recent, previous, extract, config, 6*time.Hour, 24*time.Hour)
+
+	var terms []string
+	for _, topic := range topics {
+		terms = append(terms, topic.Term)
+	}
+	// 「模型」被「大模型」包含且提及条目相同，rust 只出现在一个源中
+	if want := []string{"大模型", "released"}; !reflect.DeepEqual(terms, want) {
+		t.Fatalf("terms = %q, want %q", terms, want)
+	}
+	if topics[0].Sources != 2 || topics[0].Baseline != 0 || topics[0].Growth != 3 || topics[0].Score != 6 {
+		t.Errorf("大模型 = %+v", topics[0])
+	}
+	if topics[1].Items != 2 || topics[1].Baseline != 3 || len(topics[1].Examples) != 2 {
+		t.Errorf("released = %+v", topics[1])
+	}
+}
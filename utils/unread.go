@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+)
+
+// UnreadCounts 各订阅源、文件夹与分组的未读条目数
+type UnreadCounts struct {
+	// map[源URL] -> 未读数
+	Sources map[string]int `json:"sources"`
+	// map[文件夹ID] -> 未读数（与文件夹卡片展示的条目一致）
+	Folders map[string]int `json:"folders"`
+	// map[分组ID] -> 未读数（分组内各卡片条目去重后计数）
+	Groups map[string]int `json:"groups"`
+	// 所有订阅源的未读数（去重）
+	Total int `json:"total"`
+}
+
+// GetUnreadCounts 按已读状态统计各订阅源、文件夹与分组的未读条目数，同一链接只计一次
+func GetUnreadCounts() UnreadCounts {
+	counts := UnreadCounts{
+		Sources: make(map[string]int),
+		Folders: make(map[string]int),
+		Groups:  make(map[string]int),
+	}
+
+	// 各源与各文件夹当前展示的条目链接
+	sourceLinks := make(map[string][]string)
+	globals.Lock.RLock()
+	for _, source := range globals.RssUrls.Sources {
+		if feed, ok := globals.DbMap[source.URL]; ok {
+			sourceLinks[source.URL] = itemLinks(feed.Items)
+		}
+	}
+	globals.Lock.RUnlock()
+
+	folderLinks := make(map[string][]string)
+	for _, folder := range globals.RssUrls.Folders {
+		if feed := buildFolderFeed(folder, "", models.DisplayFlags{}); feed != nil {
+			folderLinks[folder.ID] = itemLinks(feed.Items)
+		}
+	}
+
+	globals.ReadStateLock.RLock()
+	defer globals.ReadStateLock.RUnlock()
+
+	all := make(map[string]bool)
+	for url, links := range sourceLinks {
+		unread := unreadLinks(links)
+		counts.Sources[url] = len(unread)
+		for link := range unread {
+			all[link] = true
+		}
+	}
+	counts.Total = len(all)
+
+	for id, links := range folderLinks {
+		counts.Folders[id] = len(unreadLinks(links))
+	}
+
+	for _, group := range globals.RssUrls.LayoutGroups {
+		var links []string
+		for _, item := range group.Items {
+			switch item.Type {
+			case "source":
+				links = append(links, sourceLinks[item.SourceURL]...)
+			case "folder":
+				links = append(links, folderLinks[item.FolderID]...)
+			}
+		}
+		counts.Groups[group.ID] = len(unreadLinks(links))
+	}
+	return counts
+}
+
+// excludeReadItems 移除已读条目（用于设置了不显示已读条目的源与文件夹）
+func excludeReadItems(items []models.Item) []models.Item {
+	globals.ReadStateLock.RLock()
+	defer globals.ReadStateLock.RUnlock()
+
+	unread := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		if _, read := globals.ReadState[item.Link]; !read {
+			unread = append(unread, item)
+		}
+	}
+	return unread
+}
+
+// itemLinks 获取条目的链接列表
+func itemLinks(items []models.Item) []string {
+	links := make([]string, 0, len(items))
+	for _, item := range items {
+		links = append(links, item.Link)
+	}
+	return links
+}
+
+// unreadLinks 返回去重后的未读链接（调用方需持有 ReadStateLock）
+func unreadLinks(links []string) map[string]bool {
+	unread := make(map[string]bool)
+	for _, link := range links {
+		if _, read := globals.ReadState[link]; !read && link != "" {
+			unread[link] = true
+		}
+	}
+	return unread
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"encoding/json"
+	"feedora/globals"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReleaseManifest 静态发布清单
+type ReleaseManifest struct {
+	// 最新版本号
+	Version string `json:"version"`
+	// 发布页地址
+	URL string `json:"url,omitempty"`
+	// 更新说明
+	Notes string `json:"notes,omitempty"`
+	// 是否包含安全修复
+	Security bool `json:"security,omitempty"`
+}
+
+// UpdateStatus 更新检查结果
+type UpdateStatus struct {
+	CurrentVersion  string `json:"currentVersion"`
+	LatestVersion   string `json:"latestVersion,omitempty"`
+	UpdateAvailable bool   `json:"updateAvailable"`
+	Security        bool   `json:"security,omitempty"`
+	ReleaseURL      string `json:"releaseUrl,omitempty"`
+	Notes           string `json:"notes,omitempty"`
+	CheckedAt       string `json:"checkedAt,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+var (
+	updateStatus     UpdateStatus
+	updateStatusLock sync.RWMutex
+	// 已发送过通知的版本，避免重复通知
+	notifiedVersion string
+)
+
+// GetUpdateStatus 获取最近一次更新检查的结果
+func GetUpdateStatus() UpdateStatus {
+	updateStatusLock.RLock()
+	defer updateStatusLock.RUnlock()
+	status := updateStatus
+	status.CurrentVersion = globals.Version
+	return status
+}
+
+// UpdateCheckLoop 定期检查新版本（仅在配置启用时请求清单地址）
+func UpdateCheckLoop() {
+	// 启动后稍作延迟，避免与首次抓取争抢资源
+	time.Sleep(1 * time.Minute)
+	for {
+		config := globals.RssUrls.UpdateCheck
+		if config.Enabled && config.ManifestURL != "" {
+			CheckForUpdate()
+		}
+		time.Sleep(time.Duration(globals.RssUrls.UpdateCheck.GetIntervalHours()) * time.Hour)
+	}
+}
+
+// CheckForUpdate 请求发布清单并更新检查结果
+func CheckForUpdate() UpdateStatus {
+	config := globals.RssUrls.UpdateCheck
+	status := UpdateStatus{
+		CurrentVersion: globals.Version,
+		CheckedAt:      time.Now().Format(time.RFC3339),
+	}
+
+	manifest, err := fetchReleaseManifest(config.ManifestURL)
+	if err != nil {
+		status.Error = err.Error()
+		log.Printf("[更新检查] 获取发布清单失败: %v", err)
+	} else {
+		status.LatestVersion = manifest.Version
+		status.ReleaseURL = manifest.URL
+		status.Notes = manifest.Notes
+		status.Security = manifest.Security
+		status.UpdateAvailable = compareVersions(manifest.Version, globals.Version) > 0
+	}
+
+	updateStatusLock.Lock()
+	updateStatus = status
+	shouldNotify := status.UpdateAvailable && config.Notify && notifiedVersion != status.LatestVersion
+	if shouldNotify {
+		notifiedVersion = status.LatestVersion
+	}
+	updateStatusLock.Unlock()
+
+	if status.UpdateAvailable {
+		log.Printf("[更新检查] 发现新版本: %s (当前: %s)", status.LatestVersion, status.CurrentVersion)
+	}
+
+	if shouldNotify {
+		title := fmt.Sprintf("Feedora 新版本 %s 可用", status.LatestVersion)
+		if status.Security {
+			title += "（包含安全修复）"
+		}
+		message := fmt.Sprintf("当前版本: %s", status.CurrentVersion)
+		if status.Notes != "" {
+			message += "\n" + status.Notes
+		}
+		if status.ReleaseURL != "" {
+			message += "\n" + status.ReleaseURL
+		}
+		SendNotification(title, message)
+	}
+
+	return status
+}
+
+// fetchReleaseManifest 获取静态发布清单（普通 GET 请求，不附带任何实例信息）
+func fetchReleaseManifest(manifestURL string) (*ReleaseManifest, error) {
+	client := &http.Client{
+		Timeout: 15 * time.Second,
+	}
+	resp, err := client.Get(manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ReleaseManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析发布清单失败: %w", err)
+	}
+	if manifest.Version == "" {
+		return nil, fmt.Errorf("发布清单缺少 version 字段")
+	}
+	return &manifest, nil
+}
+
+// compareVersions 比较两个语义化版本号，a > b 返回 1，a < b 返回 -1，相等返回 0
+// 当前版本为 dev 等非数字版本时视为无法比较，返回 0
+func compareVersions(a, b string) int {
+	partsA, okA := parseVersion(a)
+	partsB, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0
+	}
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var x, y int
+		if i < len(partsA) {
+			x = partsA[i]
+		}
+		if i < len(partsB) {
+			y = partsB[i]
+		}
+		if x > y {
+			return 1
+		}
+		if x < y {
+			return -1
+		}
+	}
+	return 0
+}
+
+// parseVersion 解析形如 v1.2.3 的版本号（忽略 -rc 等后缀）
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if idx := strings.IndexAny(v, "-+"); idx >= 0 {
+		v = v[:idx]
+	}
+	if v == "" {
+		return nil, false
+	}
+	parts := strings.Split(v, ".")
+	result := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		result = append(result, n)
+	}
+	return result, true
+}
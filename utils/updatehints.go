@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"feedora/globals"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+	"github.com/mmcdole/gofeed/rss"
+)
+
+// 订阅源声明的更新提示：RSS 的 <ttl>（缓存分钟数）、Syndication 模块的 sy:updatePeriod / sy:updateFrequency（发布频率），
+// 以及 <skipHours> / <skipDays>（不需要抓取的时段）。开启 fetch.updateHints 后，前两者作为抓取间隔的下限
+// （最多延长到 fetch.maxHintInterval），跳过的时段内暂停定时抓取
+
+// gofeed.Feed.Custom 中保存 RSS 频道级提示使用的键（通用 Feed 结构不含这些字段）
+const (
+	customKeyTTL       = "ttl"
+	customKeySkipHours = "skipHours"
+	customKeySkipDays  = "skipDays"
+)
+
+// syUpdatePeriods sy:updatePeriod 对应的分钟数
+var syUpdatePeriods = map[string]int{
+	"hourly":  60,
+	"daily":   24 * 60,
+	"weekly":  7 * 24 * 60,
+	"monthly": 30 * 24 * 60,
+	"yearly":  365 * 24 * 60,
+}
+
+// feedUpdateHints 订阅源声明的更新提示
+type feedUpdateHints struct {
+	// 建议的最短抓取间隔（分钟），取 ttl 与 sy:updatePeriod / sy:updateFrequency 中较大者
+	MinInterval int
+	// 不需要抓取的小时（UTC，0-23）与星期
+	SkipHours map[int]bool
+	SkipDays  map[time.Weekday]bool
+}
+
+var (
+	// 各源最近一次抓取到的更新提示: map[RSS URL] -> 提示
+	updateHints     = make(map[string]feedUpdateHints)
+	updateHintsLock sync.RWMutex
+)
+
+func init() {
+	globals.Fp.RSSTranslator = &hintRSSTranslator{}
+}
+
+// hintRSSTranslator 在默认转换的基础上将 ttl、skipHours、skipDays 保存到 Feed.Custom
+type hintRSSTranslator struct {
+	gofeed.DefaultRSSTranslator
+}
+
+func (t *hintRSSTranslator) Translate(feed interface{}) (*gofeed.Feed, error) {
+	result, err := t.DefaultRSSTranslator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+	channel, ok := feed.(*rss.Feed)
+	if !ok {
+		return result, nil
+	}
+	set := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if result.Custom == nil {
+			result.Custom = make(map[string]string)
+		}
+		result.Custom[key] = value
+	}
+	set(customKeyTTL, strings.TrimSpace(channel.TTL))
+	set(customKeySkipHours, strings.Join(channel.SkipHours, ","))
+	set(customKeySkipDays, strings.Join(channel.SkipDays, ","))
+	return result, nil
+}
+
+// parseUpdateHints 读取 Feed 中的更新提示
+func parseUpdateHints(feed *gofeed.Feed) feedUpdateHints {
+	var hints feedUpdateHints
+	if ttl, err := strconv.Atoi(feed.Custom[customKeyTTL]); err == nil && ttl > 0 {
+		hints.MinInterval = ttl
+	}
+	if sy, ok := feed.Extensions["sy"]; ok {
+		if period, ok := syUpdatePeriods[strings.ToLower(strings.TrimSpace(firstExtensionValue(sy["updatePeriod"])))]; ok {
+			frequency, err := strconv.Atoi(strings.TrimSpace(firstExtensionValue(sy["updateFrequency"])))
+			if err != nil || frequency <= 0 {
+				frequency = 1
+			}
+			if minutes := period / frequency; minutes > hints.MinInterval {
+				hints.MinInterval = minutes
+			}
+		}
+	}
+
+	for _, raw := range strings.Split(feed.Custom[customKeySkipHours], ",") {
+		hour, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || hour < 0 || hour > 24 {
+			continue
+		}
+		if hints.SkipHours == nil {
+			hints.SkipHours = make(map[int]bool)
+		}
+		// 部分源使用 1-24 表示，24 即 0 点
+		hints.SkipHours[hour%24] = true
+	}
+	for _, raw := range strings.Split(feed.Custom[customKeySkipDays], ",") {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(strings.TrimSpace(raw), day.String()) {
+				if hints.SkipDays == nil {
+					hints.SkipDays = make(map[time.Weekday]bool)
+				}
+				hints.SkipDays[day] = true
+			}
+		}
+	}
+	// 跳过全部时段的声明视为无效，避免源永远不再抓取
+	if len(hints.SkipHours) == 24 {
+		hints.SkipHours = nil
+	}
+	if len(hints.SkipDays) == 7 {
+		hints.SkipDays = nil
+	}
+	return hints
+}
+
+// firstExtensionValue 返回扩展元素中第一个的文本
+func firstExtensionValue(values []ext.Extension) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0].Value
+}
+
+// rememberUpdateHints 保存源本次抓取到的更新提示
+func rememberUpdateHints(rssURL string, feed *gofeed.Feed) {
+	hints := parseUpdateHints(feed)
+	updateHintsLock.Lock()
+	defer updateHintsLock.Unlock()
+	if hints.MinInterval == 0 && hints.SkipHours == nil && hints.SkipDays == nil {
+		delete(updateHints, rssURL)
+		return
+	}
+	updateHints[rssURL] = hints
+}
+
+// applyUpdateHints 按源的更新提示调整时段规则计算出的抓取间隔，返回调整后的间隔与说明（未调整时说明为空）
+// 处于源声明跳过的时段时返回 0，本轮不抓取
+func applyUpdateHints(rssURL string, interval int, now time.Time) (int, string) {
+	if interval <= 0 || !globals.RssUrls.Fetch.UpdateHints {
+		return interval, ""
+	}
+	if source := globals.RssUrls.GetSourceByURL(rssURL); source != nil && source.IgnoreUpdateHints {
+		return interval, ""
+	}
+	updateHintsLock.RLock()
+	hints, ok := updateHints[rssURL]
+	updateHintsLock.RUnlock()
+	if !ok {
+		return interval, ""
+	}
+
+	utc := now.UTC()
+	if hints.SkipHours[utc.Hour()] || hints.SkipDays[utc.Weekday()] {
+		return 0, "源声明跳过当前时段"
+	}
+	floor := hints.MinInterval
+	if ceiling := globals.RssUrls.Fetch.GetMaxHintInterval(); floor > ceiling {
+		floor = ceiling
+	}
+	if floor > interval {
+		return floor, fmt.Sprintf("源建议间隔 %d 分钟", hints.MinInterval)
+	}
+	return interval, ""
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateHints(t *testing.T) {
+	saved := globals.RssUrls
+	defer func() { globals.RssUrls = saved }()
+	globals.RssUrls = models.Config{Fetch: models.FetchConfig{UpdateHints: true, MaxHintInterval: 180}}
+
+	const data = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+<channel>
+<title>hints</title>
+<ttl>30</ttl>
+<sy:updatePeriod>daily</sy:updatePeriod>
+<sy:updateFrequency>12</sy:updateFrequency>
+<skipHours><hour>2</hour><hour>24</hour></skipHours>
+<skipDays><day>Sunday</day></skipDays>
+</channel>
+</rss>`
+	feed, err := globals.Fp.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	hints := parseUpdateHints(feed)
+	if hints.MinInterval != 120 || !hints.SkipHours[2] || !hints.SkipHours[0] || !hints.SkipDays[time.Sunday] {
+		t.Fatalf("parseUpdateHints() = %+v", hints)
+	}
+
+	const url = "https://example.com/feed.xml"
+	rememberUpdateHints(url, feed)
+	defer delete(updateHints, url)
+
+	monday := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	if got, _ := applyUpdateHints(url, 15, monday); got != 120 {
+		t.Errorf("applyUpdateHints() = %d, want floor 120", got)
+	}
+	if got, _ := applyUpdateHints(url, 240, monday); got != 240 {
+		t.Errorf("applyUpdateHints() = %d, want unchanged 240", got)
+	}
+	if got, _ := applyUpdateHints(url, 15, monday.Add(-8*time.Hour)); got != 0 {
+		t.Errorf("applyUpdateHints() in skipHours = %d, want 0", got)
+	}
+	if got, _ := applyUpdateHints(url, 15, monday.AddDate(0, 0, -1)); got != 0 {
+		t.Errorf("applyUpdateHints() in skipDays = %d, want 0", got)
+	}
+
+	// 提示间隔不超过 maxHintInterval
+	hints.MinInterval = 1440
+	updateHints[url] = hints
+	if got, _ := applyUpdateHints(url, 15, monday); got != 180 {
+		t.Errorf("applyUpdateHints() = %d, want ceiling 180", got)
+	}
+}
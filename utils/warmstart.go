@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"feedora/globals"
+	"log"
+	"sync"
+	"time"
+)
+
+// 预热启动：重启后缓存中的内容都标记为“已加载缓存”，第一轮调度会同时派发所有源，抓取顺序不确定。
+// 第一轮先抓取默认分组（首页）中直接放置的源与文件夹内的源，等待其完成后再调度其余的源，使首页最先刷新
+
+// warmStartTimeout 等待默认分组抓取完成的最长时间，超时后不再等待较慢的源
+const warmStartTimeout = 60 * time.Second
+
+// defaultGroupSourceURLs 返回默认分组中的源 URL（按布局顺序，文件夹展开为其中的源）
+func defaultGroupSourceURLs() []string {
+	group := globals.RssUrls.GetDefaultLayoutGroup()
+	if group == nil {
+		return nil
+	}
+	urls := make([]string, 0, len(group.Items))
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	for _, item := range group.Items {
+		switch item.Type {
+		case "source":
+			add(item.SourceURL)
+		case "folder":
+			if folder := globals.RssUrls.GetFolderByID(item.FolderID); folder != nil {
+				for _, u := range getFolderSourceURLs(*folder) {
+					add(u)
+				}
+			}
+		}
+	}
+	return urls
+}
+
+// warmStartDefaultGroup 调度默认分组中需要抓取的源并等待其完成（最多 warmStartTimeout）
+func warmStartDefaultGroup(formattedTime string, now time.Time, nextGlobalUpdate *time.Time) {
+	urls := defaultGroupSourceURLs()
+	if len(urls) == 0 {
+		return
+	}
+	var done sync.WaitGroup
+	for _, u := range urls {
+		source := globals.RssUrls.GetSourceByURL(u)
+		if source == nil || source.GetType() == "webhook" {
+			continue
+		}
+		processFeedUpdate(source.URL, source.RefreshCount, formattedTime, now, nextGlobalUpdate, &done)
+	}
+
+	start := time.Now()
+	finished := make(chan struct{})
+	go func() {
+		done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		log.Printf("[预热启动] 默认分组的源已抓取完成，用时 %v", time.Since(start).Round(time.Millisecond))
+	case <-time.After(warmStartTimeout):
+		log.Printf("[预热启动] 默认分组的源在 %v 内未全部完成，继续调度其余的源", warmStartTimeout)
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"feedora/globals"
+	"feedora/models"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultGroupSourceURLs(t *testing.T) {
+	saved := globals.RssUrls
+	defer func() { globals.RssUrls = saved }()
+	globals.RssUrls = models.Config{
+		Sources: []models.Source{{URL: "https://a.example/feed"}, {URL: "https://b.example/feed"}, {URL: "https://c.example/feed"}},
+		Folders: []models.Folder{{ID: "f1", Entries: []models.FolderEntry{{SourceURL: "https://c.example/feed"}, {SourceURL: "https://a.example/feed"}}}},
+		LayoutGroups: []models.LayoutGroup{
+			{ID: "g1", Items: []models.LayoutItem{{Type: "source", SourceURL: "https://b.example/feed"}}},
+			{ID: "g2", Items: []models.LayoutItem{
+				{Type: "source", SourceURL: "https://a.example/feed"},
+				{Type: "folder", FolderID: "f1"},
+				{Type: "folder", FolderID: "missing"},
+			}},
+		},
+		DefaultGroup: "g2",
+	}
+
+	want := []string{"https://a.example/feed", "https://c.example/feed"}
+	if got := defaultGroupSourceURLs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("defaultGroupSourceURLs() = %v, want %v", got, want)
+	}
+
+	// 默认分组不存在时使用第一个分组
+	globals.RssUrls.DefaultGroup = "gone"
+	want = []string{"https://b.example/feed"}
+	if got := defaultGroupSourceURLs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("defaultGroupSourceURLs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"encoding/json"
+	"feedora/models"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// defaultWebhookCacheItems 推送源未设置 cacheItems 时默认保留的条目数
+const defaultWebhookCacheItems = 100
+
+// webhookIngestLock 串行处理推送，避免同一源并发合并缓存时互相覆盖
+var webhookIngestLock sync.Mutex
+
+// ParseIngestItems 解析推送请求体，支持单个 JSON 对象、JSON 数组或 JSON Lines
+func ParseIngestItems(body []byte) ([]models.Item, error) {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "{") {
+		var item models.Item
+		if err := json.Unmarshal([]byte(trimmed), &item); err == nil {
+			return []models.Item{item}, nil
+		}
+	}
+	return parseScriptItemsOutput(body)
+}
+
+// fetchWebhookFeed 推送源没有可抓取的地址，条目仅通过 /api/ingest 写入
+func fetchWebhookFeed(source models.Source) (*gofeed.Feed, error) {
+	return nil, fmt.Errorf("推送源 %s 不支持抓取，请通过 /api/ingest 推送条目", source.GetWebhookID())
+}
+
+// IngestWebhookItems 将推送的条目写入推送源，复用常规的分类、后处理与缓存合并流程
+// 返回实际接收（包含标题和链接）的条目数
+func IngestWebhookItems(source models.Source, items []models.Item) (int, error) {
+	if source.GetType() != "webhook" {
+		return 0, fmt.Errorf("源 %s 不是推送源", source.URL)
+	}
+
+	title := source.Name
+	if title == "" {
+		title = source.GetWebhookID()
+	}
+	feed := buildFeedFromItems(title, source.URL, items)
+	if len(feed.Items) == 0 {
+		return 0, fmt.Errorf("没有有效条目（title 和 link 为必填）")
+	}
+
+	webhookIngestLock.Lock()
+	defer webhookIngestLock.Unlock()
+
+	log.Printf("[推送接收] 源: %s | 条目数: %d", title, len(feed.Items))
+
+	dropBlockedItems(source.URL, feed)
+	sanitizeFeed(source, feed)
+	formattedTime := time.Now().Format(time.RFC3339)
+	if err := processFeedResult(source.URL, feed, formattedTime, "[推送接收]", true, false); err != nil {
+		return 0, err
+	}
+	return len(feed.Items), nil
+}
@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"feedora/globals"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// webSubRenewMargin 租约到期前多久恢复轮询并续订
+	webSubRenewMargin = 1 * time.Hour
+	// webSubPendingTimeout 等待 Hub 验证回调的时间，超时后允许重新订阅
+	webSubPendingTimeout = 10 * time.Minute
+	// webSubRetryDelay 未发现 Hub、订阅失败或被拒绝后的重试间隔
+	webSubRetryDelay = 24 * time.Hour
+)
+
+// webSubSubscription 单个订阅源的 WebSub 订阅状态
+type webSubSubscription struct {
+	SourceURL    string
+	Hub          string
+	Topic        string
+	Secret       string
+	Pending      bool
+	LeaseExpires time.Time
+	RetryAfter   time.Time
+}
+
+var (
+	// WebSub 订阅状态: map[回调ID] -> 订阅
+	webSubs     = make(map[string]*webSubSubscription)
+	webSubsLock sync.Mutex
+
+	webSubLinkTagPattern = regexp.MustCompile(`(?i)<(?:atom:)?link\b[^>]*>`)
+	webSubRelPattern     = regexp.MustCompile(`(?i)\brel=["']([^"']+)["']`)
+	webSubHrefPattern    = regexp.MustCompile(`(?i)\bhref=["']([^"']+)["']`)
+	webSubLinkHeader     = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?([^";]+)"?`)
+)
+
+// webSubID 根据订阅源 URL 生成回调ID
+func webSubID(rssURL string) string {
+	sum := sha1.Sum([]byte(rssURL))
+	return hex.EncodeToString(sum[:8])
+}
+
+// webSubEnabled 判断订阅源是否启用了 WebSub（需配置回调地址，仅支持 RSS/Atom 源）
+func webSubEnabled(rssURL string) bool {
+	if globals.RssUrls.WebSub.CallbackURL == "" {
+		return false
+	}
+	source := globals.RssUrls.GetSourceByURL(rssURL)
+	return source != nil && source.WebSub && source.GetType() == "rss"
+}
+
+// IsWebSubActive 判断订阅源当前是否处于有效的 WebSub 租约中（租约有效期间暂停轮询）
+func IsWebSubActive(rssURL string) bool {
+	if !webSubEnabled(rssURL) {
+		return false
+	}
+	webSubsLock.Lock()
+	defer webSubsLock.Unlock()
+	sub, ok := webSubs[webSubID(rssURL)]
+	return ok && !sub.Pending && time.Now().Before(sub.LeaseExpires.Add(-webSubRenewMargin))
+}
+
+// ensureWebSubSubscription 抓取成功后检查订阅状态，必要时发现 Hub 并发起（续）订阅
+func ensureWebSubSubscription(rssURL string) {
+	if !webSubEnabled(rssURL) {
+		return
+	}
+
+	id := webSubID(rssURL)
+	now := time.Now()
+	webSubsLock.Lock()
+	if sub, ok := webSubs[id]; ok {
+		if now.Before(sub.RetryAfter) || now.Before(sub.LeaseExpires.Add(-webSubRenewMargin)) {
+			webSubsLock.Unlock()
+			return
+		}
+	}
+	// 先占位，避免并发抓取重复发起订阅
+	webSubs[id] = &webSubSubscription{SourceURL: rssURL, Pending: true, RetryAfter: now.Add(webSubPendingTimeout)}
+	webSubsLock.Unlock()
+
+	go func() {
+		if err := subscribeWebSub(id, rssURL); err != nil {
+			log.Printf("[WebSub] 订阅失败 | 源: %s | 错误: %v", rssURL, err)
+			webSubsLock.Lock()
+			webSubs[id] = &webSubSubscription{SourceURL: rssURL, RetryAfter: time.Now().Add(webSubRetryDelay)}
+			webSubsLock.Unlock()
+		}
+	}()
+}
+
+// subscribeWebSub 发现 Hub 并向其发送订阅请求
+func subscribeWebSub(id, rssURL string) error {
+	hub, topic, err := discoverWebSubHub(rssURL)
+	if err != nil {
+		return err
+	}
+
+	secretBytes := make([]byte, 16)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return err
+	}
+	secret := hex.EncodeToString(secretBytes)
+	callback := strings.TrimSuffix(globals.RssUrls.WebSub.CallbackURL, "/") + "/api/websub/" + id
+
+	// 先记录订阅信息，Hub 可能在响应返回前就发起验证回调
+	webSubsLock.Lock()
+	webSubs[id] = &webSubSubscription{
+		SourceURL:  rssURL,
+		Hub:        hub,
+		Topic:      topic,
+		Secret:     secret,
+		Pending:    true,
+		RetryAfter: time.Now().Add(webSubPendingTimeout),
+	}
+	webSubsLock.Unlock()
+
+	form := url.Values{}
+	form.Set("hub.mode", "subscribe")
+	form.Set("hub.topic", topic)
+	form.Set("hub.callback", callback)
+	form.Set("hub.secret", secret)
+	form.Set("hub.lease_seconds", strconv.Itoa(globals.RssUrls.WebSub.GetLeaseSeconds()))
+
+	resp, err := globals.Fp.Client.PostForm(hub, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Hub 返回 %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	log.Printf("[WebSub] 已发送订阅请求 | 源: %s | Hub: %s", rssURL, hub)
+	return nil
+}
+
+// discoverWebSubHub 从 HTTP Link 头或 Feed 中的 <link rel="hub"> 发现 Hub 与 Topic
+func discoverWebSubHub(rssURL string) (string, string, error) {
+	resp, err := globals.Fp.Client.Get(rssURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	var hub, topic string
+	for _, header := range resp.Header.Values("Link") {
+		for _, match := range webSubLinkHeader.FindAllStringSubmatch(header, -1) {
+			for _, rel := range strings.Fields(match[2]) {
+				if rel == "hub" && hub == "" {
+					hub = match[1]
+				} else if rel == "self" && topic == "" {
+					topic = match[1]
+				}
+			}
+		}
+	}
+
+	if hub == "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return "", "", err
+		}
+		for _, tag := range webSubLinkTagPattern.FindAllString(string(body), -1) {
+			rel := webSubRelPattern.FindStringSubmatch(tag)
+			href := webSubHrefPattern.FindStringSubmatch(tag)
+			if rel == nil || href == nil {
+				continue
+			}
+			if rel[1] == "hub" && hub == "" {
+				hub = href[1]
+			} else if rel[1] == "self" && topic == "" {
+				topic = href[1]
+			}
+		}
+	}
+
+	if hub == "" {
+		return "", "", fmt.Errorf("未发现 WebSub Hub")
+	}
+	if topic == "" {
+		topic = rssURL
+	}
+	return hub, topic, nil
+}
+
+// VerifyWebSubIntent 处理 Hub 的订阅验证回调，验证通过时返回需要回显的 challenge
+func VerifyWebSubIntent(id string, query url.Values) (string, error) {
+	webSubsLock.Lock()
+	defer webSubsLock.Unlock()
+
+	sub, ok := webSubs[id]
+	if !ok || sub.Hub == "" {
+		return "", fmt.Errorf("未知的订阅")
+	}
+	if query.Get("hub.topic") != sub.Topic {
+		return "", fmt.Errorf("topic 不匹配")
+	}
+
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		lease, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || lease <= 0 {
+			lease = globals.RssUrls.WebSub.GetLeaseSeconds()
+		}
+		sub.Pending = false
+		sub.LeaseExpires = time.Now().Add(time.Duration(lease) * time.Second)
+		sub.RetryAfter = time.Time{}
+		log.Printf("[WebSub] 订阅已生效 | 源: %s | 租约: %d 秒", sub.SourceURL, lease)
+		return query.Get("hub.challenge"), nil
+	case "unsubscribe":
+		delete(webSubs, id)
+		return query.Get("hub.challenge"), nil
+	case "denied":
+		log.Printf("[WebSub] 订阅被拒绝 | 源: %s | 原因: %s", sub.SourceURL, query.Get("hub.reason"))
+		webSubs[id] = &webSubSubscription{SourceURL: sub.SourceURL, RetryAfter: time.Now().Add(webSubRetryDelay)}
+		return "", nil
+	default:
+		return "", fmt.Errorf("未知的 hub.mode")
+	}
+}
+
+// HandleWebSubNotification 处理 Hub 推送的内容更新：校验签名后立即抓取该源
+// 签名无效时按规范忽略消息（调用方仍应返回 2xx），返回 false
+func HandleWebSubNotification(id string, body []byte, signature string) (bool, error) {
+	webSubsLock.Lock()
+	sub, ok := webSubs[id]
+	var rssURL, secret string
+	if ok {
+		rssURL, secret = sub.SourceURL, sub.Secret
+	}
+	webSubsLock.Unlock()
+	if !ok || secret == "" {
+		return false, fmt.Errorf("未知的订阅")
+	}
+
+	if !verifyWebSubSignature(secret, body, signature) {
+		log.Printf("[WebSub] 推送签名无效，已忽略 | 源: %s", rssURL)
+		return false, nil
+	}
+
+	go func() {
+		if err := UpdateFeedWithOptions(rssURL, time.Now().Format(time.RFC3339), false, false); err != nil {
+			log.Printf("[WebSub] 推送触发更新失败 | 源: %s | 错误: %v", rssURL, err)
+		}
+	}()
+	return true, nil
+}
+
+// verifyWebSubSignature 校验 X-Hub-Signature（形如 sha256=hex）
+func verifyWebSubSignature(secret string, body []byte, signature string) bool {
+	parts := strings.SplitN(signature, "=", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch parts[0] {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"context"
+	"feedora/globals"
+	"feedora/models"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// customKeyDuration gofeed.Item.Custom 中保存视频时长（秒）使用的键
+const customKeyDuration = "duration"
+
+var (
+	// 频道地址解析结果缓存: map[源地址] -> XML Feed 地址
+	youtubeFeedURLCache     = make(map[string]string)
+	youtubeFeedURLCacheLock sync.Mutex
+
+	// Shorts 判断结果缓存: map[视频ID] -> 是否为 Shorts
+	youtubeShortsCache     = make(map[string]bool)
+	youtubeShortsCacheLock sync.Mutex
+
+	youtubeChannelIDPattern = regexp.MustCompile(`(?:"externalId"|"channelId"|channel/)"?:?"?(UC[\w-]{22})`)
+	youtubeDurationPattern  = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+)
+
+// ResolveYouTubeFeedURL 将频道地址、@handle 或播放列表地址解析为 YouTube XML Feed 地址
+// 支持: https://www.youtube.com/channel/UC... / https://www.youtube.com/@handle / @handle /
+// https://www.youtube.com/c/name / https://www.youtube.com/user/name / https://www.youtube.com/playlist?list=PL...
+func ResolveYouTubeFeedURL(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("YouTube 地址为空")
+	}
+
+	youtubeFeedURLCacheLock.Lock()
+	cached, ok := youtubeFeedURLCache[input]
+	youtubeFeedURLCacheLock.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	raw := input
+	if strings.HasPrefix(raw, "@") {
+		raw = "https://www.youtube.com/" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("无效的 YouTube 地址: %s", input)
+	}
+
+	const feedBase = "https://www.youtube.com/feeds/videos.xml"
+	var feedURL string
+	path := strings.Trim(u.Path, "/")
+	switch {
+	case strings.HasSuffix(u.Path, "/feeds/videos.xml"):
+		feedURL = raw
+	case u.Query().Get("list") != "":
+		feedURL = feedBase + "?playlist_id=" + url.QueryEscape(u.Query().Get("list"))
+	case strings.HasPrefix(path, "channel/"):
+		channelID := strings.SplitN(strings.TrimPrefix(path, "channel/"), "/", 2)[0]
+		feedURL = feedBase + "?channel_id=" + url.QueryEscape(channelID)
+	case strings.HasPrefix(path, "@"), strings.HasPrefix(path, "c/"), strings.HasPrefix(path, "user/"):
+		channelID, err := lookupYouTubeChannelID(u.Scheme + "://" + u.Host + "/" + path)
+		if err != nil {
+			return "", err
+		}
+		feedURL = feedBase + "?channel_id=" + channelID
+	default:
+		return "", fmt.Errorf("无法识别的 YouTube 地址: %s", input)
+	}
+
+	youtubeFeedURLCacheLock.Lock()
+	youtubeFeedURLCache[input] = feedURL
+	youtubeFeedURLCacheLock.Unlock()
+	return feedURL, nil
+}
+
+// lookupYouTubeChannelID 从频道页面中提取频道 ID
+func lookupYouTubeChannelID(pageURL string) (string, error) {
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html")
+	// 跳过欧盟地区的 Cookie 同意页
+	req.Header.Set("Cookie", "CONSENT=YES+1")
+
+	resp, err := globals.Fp.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("获取频道页面失败: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", err
+	}
+	match := youtubeChannelIDPattern.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("未能在页面中找到频道 ID: %s", pageURL)
+	}
+	return string(match[1]), nil
+}
+
+// fetchYouTubeFeed 抓取 YouTube 频道/播放列表，补充缩略图与时长，并按配置跳过 Shorts
+func fetchYouTubeFeed(source models.Source) (*gofeed.Feed, error) {
+	feedURL, err := ResolveYouTubeFeedURL(source.URL)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := fetchFeedURL(context.Background(), feedURL)
+	if err != nil {
+		return nil, err
+	}
+	options := source.GetYouTubeOptions()
+
+	ids := make([]string, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		if thumbnail := youtubeThumbnail(item); thumbnail != "" {
+			item.Image = &gofeed.Image{URL: thumbnail}
+		}
+		if description := youtubeMediaValue(item, "description"); description != "" && item.Description == "" {
+			item.Description = description
+		}
+		if id := youtubeVideoID(item); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	// 通过 Data API 获取时长（需要配置 API Key）
+	durations := make(map[string]int)
+	if options.APIKey != "" && len(ids) > 0 {
+		durations, err = fetchYouTubeDurations(ids, options.APIKey)
+		if err != nil {
+			log.Printf("[YouTube] 获取视频时长失败 | 源: %s | 错误: %v", source.URL, err)
+		}
+	}
+
+	items := make([]*gofeed.Item, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		id := youtubeVideoID(item)
+		if options.SkipShorts && id != "" && isYouTubeShort(id) {
+			continue
+		}
+		if duration, ok := durations[id]; ok {
+			if item.Custom == nil {
+				item.Custom = make(map[string]string)
+			}
+			item.Custom[customKeyDuration] = strconv.Itoa(duration)
+		}
+		items = append(items, item)
+	}
+	feed.Items = items
+	return feed, nil
+}
+
+// youtubeVideoID 获取视频 ID（优先使用 yt:videoId 扩展字段）
+func youtubeVideoID(item *gofeed.Item) string {
+	if values := item.Extensions["yt"]["videoId"]; len(values) > 0 && values[0].Value != "" {
+		return values[0].Value
+	}
+	if u, err := url.Parse(item.Link); err == nil {
+		return u.Query().Get("v")
+	}
+	return ""
+}
+
+// youtubeMediaChild 获取 media:group 下的子元素
+func youtubeMediaChild(item *gofeed.Item, name string) (ext.Extension, bool) {
+	groups := item.Extensions["media"]["group"]
+	if len(groups) == 0 {
+		return ext.Extension{}, false
+	}
+	children := groups[0].Children[name]
+	if len(children) == 0 {
+		return ext.Extension{}, false
+	}
+	return children[0], true
+}
+
+// youtubeThumbnail 获取 media:group/media:thumbnail 的地址
+func youtubeThumbnail(item *gofeed.Item) string {
+	if child, ok := youtubeMediaChild(item, "thumbnail"); ok {
+		return child.Attrs["url"]
+	}
+	return ""
+}
+
+// youtubeMediaValue 获取 media:group 下子元素的文本
+func youtubeMediaValue(item *gofeed.Item, name string) string {
+	if child, ok := youtubeMediaChild(item, name); ok {
+		return child.Value
+	}
+	return ""
+}
+
+// isYouTubeShort 判断视频是否为 Shorts：/shorts/{id} 对普通视频会重定向到 /watch
+func isYouTubeShort(videoID string) bool {
+	youtubeShortsCacheLock.Lock()
+	cached, ok := youtubeShortsCache[videoID]
+	youtubeShortsCacheLock.Unlock()
+	if ok {
+		return cached
+	}
+
+	client := &http.Client{
+		Transport: globals.Fp.Client.Transport,
+		Timeout:   10 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequest("HEAD", "https://www.youtube.com/shorts/"+url.PathEscape(videoID), nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Cookie", "CONSENT=YES+1")
+	resp, err := client.Do(req)
+	if err != nil {
+		// 判断失败时不缓存，下次抓取时重试
+		return false
+	}
+	resp.Body.Close()
+
+	isShort := resp.StatusCode == http.StatusOK
+	youtubeShortsCacheLock.Lock()
+	youtubeShortsCache[videoID] = isShort
+	youtubeShortsCacheLock.Unlock()
+	return isShort
+}
+
+// fetchYouTubeDurations 通过 YouTube Data API 批量获取视频时长（秒）
+func fetchYouTubeDurations(ids []string, apiKey string) (map[string]int, error) {
+	durations := make(map[string]int)
+	for start := 0; start < len(ids); start += 50 {
+		end := start + 50
+		if end > len(ids) {
+			end = len(ids)
+		}
+		apiURL := fmt.Sprintf("https://www.googleapis.com/youtube/v3/videos?part=contentDetails&id=%s&key=%s",
+			url.QueryEscape(strings.Join(ids[start:end], ",")), url.QueryEscape(apiKey))
+
+		var response struct {
+			Items []struct {
+				ID             string `json:"id"`
+				ContentDetails struct {
+					Duration string `json:"duration"`
+				} `json:"contentDetails"`
+			} `json:"items"`
+		}
+		if err := fetchJSON(apiURL, nil, &response); err != nil {
+			return durations, err
+		}
+		for _, item := range response.Items {
+			if seconds, ok := parseISO8601Duration(item.ContentDetails.Duration); ok {
+				durations[item.ID] = seconds
+			}
+		}
+	}
+	return durations, nil
+}
+
+// parseISO8601Duration 解析 ISO 8601 时长（如 PT1H2M3S），返回秒数
+func parseISO8601Duration(value string) (int, bool) {
+	match := youtubeDurationPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+	multipliers := []int{86400, 3600, 60, 1}
+	total := 0
+	for i, m := range multipliers {
+		if match[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return 0, false
+		}
+		total += n * m
+	}
+	return total, true
+}
+
+// parseItemMedia 从 gofeed.Item 中读取缩略图与时长（秒）
+func parseItemMedia(item *gofeed.Item) (string, int) {
+	if item == nil {
+		return "", 0
+	}
+	thumbnail := extractThumbnail(item)
+	duration := 0
+	if item.Custom != nil {
+		duration, _ = strconv.Atoi(item.Custom[customKeyDuration])
+	}
+	return thumbnail, duration
+}